@@ -0,0 +1,79 @@
+package bombard
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// arrival is the process requests are sent according to, when their
+// rate is limited.
+type arrival int
+
+const (
+	// uniformArrival spaces requests evenly
+	uniformArrival arrival = iota
+	// poissonArrival draws intervals between requests from
+	// exponential distribution, so that requests arrive in bursts
+	// the way independent clients make them
+	poissonArrival
+)
+
+type unknownArrivalError struct {
+	name string
+}
+
+func (u *unknownArrivalError) Error() string {
+	return fmt.Sprintf("Unknown arrival process: %q", u.name)
+}
+
+func parseArrival(s string) (arrival, error) {
+	switch s {
+	case "uniform":
+		return uniformArrival, nil
+	case "poisson":
+		return poissonArrival, nil
+	default:
+		return uniformArrival, &unknownArrivalError{s}
+	}
+}
+
+func (a arrival) String() string {
+	if a == poissonArrival {
+		return "poisson"
+	}
+	return "uniform"
+}
+
+// interval returns time until the next request, given the mean rate
+// of requests per second. rng is only used by Poisson process.
+func (a arrival) interval(rate float64, rng *rand.Rand) time.Duration {
+	interval := float64(time.Second) / rate
+	if a == poissonArrival {
+		interval *= rng.ExpFloat64()
+	}
+	return time.Duration(interval)
+}
+
+// newRateLimiter limits requests to the given rate, following the
+// arrival process.
+func newRateLimiter(rate uint64, arr arrival, rng *rand.Rand) limiter {
+	if arr == uniformArrival {
+		return newBucketLimiter(rate)
+	}
+	return newProfileLimiter(&constantProfile{rate}, arr, rng)
+}
+
+// constantProfile keeps the rate the same for the whole test, so that
+// a fixed rate can be followed by any arrival process.
+type constantProfile struct {
+	rps uint64
+}
+
+func (c *constantProfile) rate(time.Duration) float64 {
+	return float64(c.rps)
+}
+
+func (c *constantProfile) String() string {
+	return fmt.Sprintf("constant:rate=%v", c.rps)
+}
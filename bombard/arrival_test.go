@@ -0,0 +1,88 @@
+package bombard
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestParseArrival(t *testing.T) {
+	expectations := []struct {
+		in  string
+		out arrival
+		err error
+	}{
+		{"uniform", uniformArrival, nil},
+		{"poisson", poissonArrival, nil},
+		{"", uniformArrival, &unknownArrivalError{""}},
+		{"exponential", uniformArrival, &unknownArrivalError{"exponential"}},
+	}
+	for _, e := range expectations {
+		out, err := parseArrival(e.in)
+		if out != e.out {
+			t.Errorf("%q: expected %v, but got %v", e.in, e.out, out)
+		}
+		if (err == nil) != (e.err == nil) ||
+			(err != nil && err.Error() != e.err.Error()) {
+			t.Errorf("%q: expected error %v, but got %v", e.in, e.err, err)
+		}
+		if err == nil && out.String() != e.in {
+			t.Errorf("Expected %q, but got %q", e.in, out.String())
+		}
+	}
+}
+
+func TestArrivalInterval(t *testing.T) {
+	if got := uniformArrival.interval(100, nil); got != 10*time.Millisecond {
+		t.Errorf("Expected uniform interval of 10ms, but got %v", got)
+	}
+	rng := rand.New(rand.NewSource(42))
+	const n = 100000
+	var sum, sumSq float64
+	distinct := make(map[time.Duration]bool)
+	for i := 0; i < n; i++ {
+		d := poissonArrival.interval(100, rng)
+		if d < 0 {
+			t.Fatalf("Negative interval %v", d)
+		}
+		distinct[d] = true
+		sum += float64(d)
+		sumSq += float64(d) * float64(d)
+	}
+	// Exponential distribution has standard deviation equal to mean
+	mean := sum / n
+	stddev := math.Sqrt(sumSq/n - mean*mean)
+	exp := float64(10 * time.Millisecond)
+	if math.Abs(mean-exp)/exp > 0.02 {
+		t.Errorf("Expected mean interval of %v, but got %v",
+			time.Duration(exp), time.Duration(mean))
+	}
+	if math.Abs(stddev-exp)/exp > 0.05 {
+		t.Errorf("Expected standard deviation of %v, but got %v",
+			time.Duration(exp), time.Duration(stddev))
+	}
+	if len(distinct) < n/2 {
+		t.Errorf("Expected intervals to vary, but got only %v distinct",
+			len(distinct))
+	}
+}
+
+func TestPoissonRateLimiter(t *testing.T) {
+	done := make(chan struct{})
+	lim := newRateLimiter(1000, poissonArrival, rand.New(rand.NewSource(1)))
+	const n = 200
+	start := time.Now()
+	for i := 0; i < n; i++ {
+		if lim.pace(done) != cont {
+			t.Fatal("Expected limiter to let request through")
+		}
+	}
+	// The mean rate is 1000 per second, so n requests take n ms on
+	// average
+	if taken := time.Since(start); taken < 100*time.Millisecond {
+		t.Errorf("Expected %v requests to take about %vms, but took %v",
+			n, n, taken)
+	}
+	close(done)
+}
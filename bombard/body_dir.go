@@ -0,0 +1,110 @@
+package bombard
+
+import (
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	"github.com/kostyay/bombardier/internal"
+)
+
+// largeBodyDirSize is the total size of files read from body directory
+// past which a warning is printed.
+const largeBodyDirSize = 256 * 1024 * 1024
+
+// bodyDir holds contents of the files of a directory, one of which is
+// picked at random as the body of every request. Picks of every
+// connection are drawn from its own RNG.
+type bodyDir struct {
+	path   string
+	names  []string
+	bodies []string
+	// size is the total size of the files, as read from the disk
+	size int64
+	rngs []*rand.Rand
+	// sent holds how many times every file was sent
+	sent []uint64
+}
+
+// readBodyDir reads all the regular files of the directory into
+// memory, hidden ones and subdirectories are skipped.
+func readBodyDir(path string, rngs []*rand.Rand) (*bodyDir, error) {
+	infos, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	d := &bodyDir{path: path, rngs: rngs}
+	for _, info := range infos {
+		if strings.HasPrefix(info.Name(), ".") {
+			continue
+		}
+		name := filepath.Join(path, info.Name())
+		// Symlinks are followed
+		if info, err = os.Stat(name); err != nil {
+			return nil, err
+		}
+		if !info.Mode().IsRegular() {
+			continue
+		}
+		body, err := ioutil.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		d.names = append(d.names, info.Name())
+		d.bodies = append(d.bodies, string(body))
+		d.size += int64(len(body))
+	}
+	if len(d.bodies) == 0 {
+		return nil, errEmptyBodyDir
+	}
+	d.sent = make([]uint64, len(d.bodies))
+	return d, nil
+}
+
+// compress compresses all the bodies once, so that requests reuse
+// the results.
+func (d *bodyDir) compress(encoding string) error {
+	for i, body := range d.bodies {
+		compressed, err := compressBody(body, encoding)
+		if err != nil {
+			return err
+		}
+		d.bodies[i] = compressed
+	}
+	return nil
+}
+
+// large tells whether the files take up enough memory to warn about.
+func (d *bodyDir) large() bool {
+	return d.size > largeBodyDirSize
+}
+
+// pick returns the body of the next request of the connection.
+func (d *bodyDir) pick(connID uint64) string {
+	i := d.rngs[connID].Intn(len(d.bodies))
+	atomic.AddUint64(&d.sent[i], 1)
+	return d.bodies[i]
+}
+
+func (d *bodyDir) reset() {
+	for i := range d.sent {
+		atomic.StoreUint64(&d.sent[i], 0)
+	}
+}
+
+// counts returns how many times every file that was sent at least
+// once was sent, sorted by name.
+func (d *bodyDir) counts() []internal.BodyFileCount {
+	var counts []internal.BodyFileCount
+	for i, name := range d.names {
+		if n := atomic.LoadUint64(&d.sent[i]); n > 0 {
+			counts = append(counts, internal.BodyFileCount{
+				Name: name, Count: n,
+			})
+		}
+	}
+	return counts
+}
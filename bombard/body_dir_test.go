@@ -0,0 +1,83 @@
+package bombard
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/kostyay/bombardier/internal"
+)
+
+func writeBodyDir(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "bombardier-bodies")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestReadBodyDir(t *testing.T) {
+	dir := writeBodyDir(t, map[string]string{
+		"b.json": `{"b":2}`, "a.json": `{"a":1}`, ".hidden": "x",
+	})
+	defer os.RemoveAll(dir)
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	d, err := readBodyDir(dir, newConnRNGs(42, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := []string{"a.json", "b.json"}; !reflect.DeepEqual(d.names, exp) {
+		t.Errorf("Expected files %v, but got %v", exp, d.names)
+	}
+	if exp := []string{`{"a":1}`, `{"b":2}`}; !reflect.DeepEqual(
+		d.bodies, exp) {
+		t.Errorf("Expected bodies %v, but got %v", exp, d.bodies)
+	}
+	if d.size != 14 || d.large() {
+		t.Errorf("Unexpected size: %v", d.size)
+	}
+	if d.counts() != nil {
+		t.Errorf("Expected no files to be sent yet, but got %v", d.counts())
+	}
+
+	sent := map[string]uint64{}
+	for i := 0; i < 100; i++ {
+		sent[d.pick(uint64(i%2))]++
+	}
+	if sent[`{"a":1}`] < 25 || sent[`{"b":2}`] < 25 {
+		t.Errorf("Expected both files to be sent ~50 times, but got %v",
+			sent)
+	}
+	exp := []internal.BodyFileCount{
+		{Name: "a.json", Count: sent[`{"a":1}`]},
+		{Name: "b.json", Count: sent[`{"b":2}`]},
+	}
+	if counts := d.counts(); !reflect.DeepEqual(counts, exp) {
+		t.Errorf("Expected counts %v, but got %v", exp, counts)
+	}
+	d.reset()
+	if d.counts() != nil {
+		t.Errorf("Expected counts to be reset, but got %v", d.counts())
+	}
+}
+
+func TestReadBodyDirFailures(t *testing.T) {
+	empty := writeBodyDir(t, map[string]string{".gitkeep": ""})
+	defer os.RemoveAll(empty)
+	if _, err := readBodyDir(empty, nil); err != errEmptyBodyDir {
+		t.Errorf("Expected %v, but got %v", errEmptyBodyDir, err)
+	}
+	if _, err := readBodyDir(filepath.Join(empty, "missing"), nil); err == nil {
+		t.Error("Expected missing directory to be rejected")
+	}
+}
@@ -0,0 +1,12 @@
+package bombard
+
+import "io"
+
+// generatedBodyLength returns length of generated body, if it's known
+// without reading it, and -1 otherwise.
+func generatedBodyLength(body io.Reader) int64 {
+	if l, ok := body.(interface{ Len() int }); ok {
+		return int64(l.Len())
+	}
+	return -1
+}
@@ -0,0 +1,156 @@
+package bombard
+
+import (
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// bodyPoolSize is the number of bodies generated at startup, when
+// their sizes follow a distribution.
+const bodyPoolSize = 1024
+
+// bodyAlphabet is what generated bodies are made of, so that servers
+// that expect text accept them.
+const bodyAlphabet = "abcdefghijklmnopqrstuvwxyz" +
+	"ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+type bodySizeDistribution int
+
+const (
+	// uniformBodySize draws sizes evenly from the range
+	uniformBodySize bodySizeDistribution = iota
+	// lognormalBodySize draws sizes from log-normal distribution
+	// with the median at geometric mean of the bounds, which are
+	// three standard deviations away from it
+	lognormalBodySize
+)
+
+func (d bodySizeDistribution) String() string {
+	if d == lognormalBodySize {
+		return "lognormal"
+	}
+	return "uniform"
+}
+
+// bodySize is the range sizes of generated request bodies are drawn
+// from and the distribution they follow.
+type bodySize struct {
+	min, max uint64
+	dist     bodySizeDistribution
+}
+
+// parseBodySize parses either a single number of bytes (i.e. "4KB") or
+// a range of them (i.e. "1KB-1MB"), optionally followed by
+// the distribution (i.e. "1KB-1MB:lognormal").
+func parseBodySize(s string) (*bodySize, error) {
+	res := &bodySize{}
+	if i := strings.Index(s, ":"); i >= 0 {
+		switch s[i+1:] {
+		case "uniform":
+			res.dist = uniformBodySize
+		case "lognormal":
+			res.dist = lognormalBodySize
+		default:
+			return nil, errInvalidBodySize
+		}
+		s = s[:i]
+	}
+	bounds := strings.SplitN(s, "-", 2)
+	var min nullableBytes
+	if err := min.Set(bounds[0]); err != nil {
+		return nil, errInvalidBodySize
+	}
+	res.min, res.max = *min.val, *min.val
+	if len(bounds) == 2 {
+		var max nullableBytes
+		if err := max.Set(bounds[1]); err != nil {
+			return nil, errInvalidBodySize
+		}
+		res.max = *max.val
+	}
+	if res.max < res.min {
+		return nil, errInvalidBodySize
+	}
+	return res, nil
+}
+
+func (b *bodySize) String() string {
+	if b.min == b.max {
+		return formatBytesExact(b.min)
+	}
+	return formatBytesExact(b.min) + "-" + formatBytesExact(b.max) +
+		":" + b.dist.String()
+}
+
+// next draws size of a body.
+func (b *bodySize) next(rng *rand.Rand) uint64 {
+	if b.min == b.max {
+		return b.min
+	}
+	if b.dist == uniformBodySize {
+		return b.min + uint64(rng.Int63n(int64(b.max-b.min)+1))
+	}
+	// Logarithm of zero is undefined, so empty bodies are only
+	// produced by clamping
+	lmin, lmax := math.Log(math.Max(float64(b.min), 1)),
+		math.Log(float64(b.max))
+	mu, sigma := (lmin+lmax)/2, (lmax-lmin)/6
+	size := math.Exp(mu + sigma*rng.NormFloat64())
+	return uint64(math.Max(float64(b.min), math.Min(size, float64(b.max))))
+}
+
+// formatBytesExact formats the number of bytes with the largest binary
+// unit it's a multiple of, so that parsing the result gives it back.
+func formatBytesExact(n uint64) string {
+	unit := "B"
+	for _, u := range binaryUnits.units {
+		if n == 0 || n%binaryUnits.scale != 0 {
+			break
+		}
+		n /= binaryUnits.scale
+		unit = u
+	}
+	return strconv.FormatUint(n, 10) + unit
+}
+
+// bodyPool holds bodies of random sizes generated at startup, one of
+// which is picked at random for every request, so that requests
+// don't allocate them. All the bodies are parts of a single random
+// string as long as the largest of them. Picks of every connection
+// are drawn from its own RNG.
+type bodyPool struct {
+	size   *bodySize
+	bodies []string
+	rngs   []*rand.Rand
+}
+
+func newBodyPool(size *bodySize, rng *rand.Rand,
+	rngs []*rand.Rand) *bodyPool {
+	p := &bodyPool{size: size, rngs: rngs}
+	sizes := make([]uint64, bodyPoolSize)
+	var longest uint64
+	for i := range sizes {
+		sizes[i] = size.next(rng)
+		if sizes[i] > longest {
+			longest = sizes[i]
+		}
+	}
+	buf := make([]byte, longest)
+	for i := range buf {
+		buf[i] = bodyAlphabet[rng.Intn(len(bodyAlphabet))]
+	}
+	data := string(buf)
+	p.bodies = make([]string, len(sizes))
+	for i, n := range sizes {
+		start := uint64(rng.Int63n(int64(longest-n) + 1))
+		p.bodies[i] = data[start : start+n]
+	}
+	return p
+}
+
+// pick returns the body of the next request of the connection.
+func (p *bodyPool) pick(connID uint64) string {
+	return p.bodies[p.rngs[connID].Intn(len(p.bodies))]
+}
@@ -0,0 +1,117 @@
+package bombard
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestParseBodySize(t *testing.T) {
+	expectations := []struct {
+		in  string
+		out *bodySize
+		err error
+	}{
+		{"4KB", &bodySize{4096, 4096, uniformBodySize}, nil},
+		{"100", &bodySize{100, 100, uniformBodySize}, nil},
+		{"0", &bodySize{0, 0, uniformBodySize}, nil},
+		{"1KB-1MB", &bodySize{1 << 10, 1 << 20, uniformBodySize}, nil},
+		{"1KB-1MB:uniform", &bodySize{1 << 10, 1 << 20, uniformBodySize}, nil},
+		{"1kb-1mb:lognormal", &bodySize{1 << 10, 1 << 20, lognormalBodySize}, nil},
+		{"", nil, errInvalidBodySize},
+		{"big", nil, errInvalidBodySize},
+		{"-1KB", nil, errInvalidBodySize},
+		{"1MB-1KB", nil, errInvalidBodySize},
+		{"1KB-", nil, errInvalidBodySize},
+		{"1KB-1MB:normal", nil, errInvalidBodySize},
+		{"1KB-1MB:", nil, errInvalidBodySize},
+	}
+	for _, e := range expectations {
+		act, err := parseBodySize(e.in)
+		if err != e.err {
+			t.Errorf("%q: expected error %v, but got %v", e.in, e.err, err)
+			continue
+		}
+		if e.out != nil && *act != *e.out {
+			t.Errorf("%q: expected %v, but got %v", e.in, e.out, act)
+		}
+	}
+}
+
+func TestBodySizeString(t *testing.T) {
+	for _, s := range []string{
+		"4KB", "0B", "1500B", "1KB-1MB:lognormal", "100B-3GB:uniform",
+	} {
+		b, err := parseBodySize(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if act := b.String(); act != s {
+			t.Errorf("Expected %q, but got %q", s, act)
+		}
+	}
+}
+
+func TestBodySizeNext(t *testing.T) {
+	rng := newConnRNGs(42, 1)[0]
+	for _, dist := range []bodySizeDistribution{
+		uniformBodySize, lognormalBodySize,
+	} {
+		b := &bodySize{1 << 10, 1 << 20, dist}
+		sizes := make([]uint64, 10000)
+		for i := range sizes {
+			sizes[i] = b.next(rng)
+			if sizes[i] < b.min || sizes[i] > b.max {
+				t.Fatalf("%v: expected size within %v, but got %v",
+					dist, b, sizes[i])
+			}
+		}
+		sort.Slice(sizes, func(i, j int) bool { return sizes[i] < sizes[j] })
+		median := sizes[len(sizes)/2]
+		// Geometric mean of the bounds is 32KB and the mean is ~512KB
+		if dist == lognormalBodySize && (median < 28<<10 || median > 36<<10) {
+			t.Errorf("Expected median of log-normal sizes near 32KB, "+
+				"but got %v", median)
+		}
+		if dist == uniformBodySize && (median < 480<<10 || median > 544<<10) {
+			t.Errorf("Expected median of uniform sizes near 512KB, "+
+				"but got %v", median)
+		}
+	}
+	fixed := &bodySize{4096, 4096, lognormalBodySize}
+	if size := fixed.next(rng); size != 4096 {
+		t.Errorf("Expected fixed size, but got %v", size)
+	}
+	fromZero := &bodySize{0, 100, lognormalBodySize}
+	for i := 0; i < 1000; i++ {
+		if size := fromZero.next(rng); size > 100 {
+			t.Fatalf("Expected size within %v, but got %v", fromZero, size)
+		}
+	}
+}
+
+func TestBodyPool(t *testing.T) {
+	size := &bodySize{10, 20, uniformBodySize}
+	rngs := newConnRNGs(42, 2)
+	p := newBodyPool(size, rand.New(rand.NewSource(1)), rngs)
+	if len(p.bodies) != bodyPoolSize {
+		t.Fatalf("Expected %v bodies, but got %v",
+			bodyPoolSize, len(p.bodies))
+	}
+	for _, connID := range []uint64{0, 1} {
+		body := p.pick(connID)
+		if len(body) < 10 || len(body) > 20 {
+			t.Errorf("Expected body of 10 to 20 bytes, but got %q", body)
+		}
+		for _, c := range body {
+			if c > 127 {
+				t.Errorf("Expected alphanumeric body, but got %q", body)
+				break
+			}
+		}
+	}
+	empty := newBodyPool(&bodySize{}, rand.New(rand.NewSource(1)), rngs)
+	if body := empty.pick(0); body != "" {
+		t.Errorf("Expected empty body, but got %q", body)
+	}
+}
@@ -0,0 +1,132 @@
+package bombard
+
+import (
+	"bytes"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"text/template"
+
+	"github.com/satori/go.uuid"
+)
+
+// templateData is what gets passed to body and header templates on
+// every request.
+type templateData struct {
+	// RequestNum is the sequential number of the request, starting
+	// from 1.
+	RequestNum uint64
+	// ConnID is the number of connection the request is sent
+	// through, starting from 0.
+	ConnID uint64
+
+	// vars holds values captured from earlier responses of the
+	// connection, nil if nothing is captured
+	vars map[string]string
+}
+
+// value returns what templates are executed with. Captured variables,
+// if any, are available alongside RequestNum and ConnID.
+func (d templateData) value() interface{} {
+	if d.vars == nil {
+		return d
+	}
+	m := make(map[string]interface{}, len(d.vars)+2)
+	for k, v := range d.vars {
+		m[k] = v
+	}
+	m["RequestNum"], m["ConnID"] = d.RequestNum, d.ConnID
+	return m
+}
+
+// requestCounter numbers requests, so that all the templates of the
+// same request get the same RequestNum.
+type requestCounter struct {
+	n uint64
+}
+
+func (rc *requestCounter) next(connID uint64) templateData {
+	return templateData{
+		RequestNum: atomic.AddUint64(&rc.n, 1),
+		ConnID:     connID,
+	}
+}
+
+// templateFuncs returns functions available to templates, random ones
+// draw from the given RNG.
+func templateFuncs(rng *rand.Rand) template.FuncMap {
+	return template.FuncMap{
+		"uuid": func() string {
+			return randomUUID(rng)
+		},
+	}
+}
+
+// randomUUID generates version 4 UUID using the given RNG instead of
+// crypto/rand, so that generated UUIDs are reproducible with --seed.
+func randomUUID(rng *rand.Rand) string {
+	var u uuid.UUID
+	// Read of *rand.Rand never fails
+	_, _ = rng.Read(u[:])
+	u.SetVersion(uuid.V4)
+	u.SetVariant(uuid.VariantRFC4122)
+	return u.String()
+}
+
+// parseConnTemplates parses the template and returns its clone for
+// every connection, with functions drawing from the connection's RNG.
+// Clones share the parsed tree, so these are cheap.
+func parseConnTemplates(
+	name, text string, rngs []*rand.Rand,
+) ([]*template.Template, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs(rngs[0])).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	tmpls := make([]*template.Template, len(rngs))
+	for i, rng := range rngs {
+		clone, err := tmpl.Clone()
+		if err != nil {
+			return nil, err
+		}
+		tmpls[i] = clone.Funcs(templateFuncs(rng))
+	}
+	return tmpls, nil
+}
+
+type bodyTemplate struct {
+	// tmpls holds a template for every connection
+	tmpls   []*template.Template
+	buffers sync.Pool
+}
+
+func newBodyTemplate(body string, rngs []*rand.Rand) (*bodyTemplate, error) {
+	tmpls, err := parseConnTemplates("body", body, rngs)
+	if err != nil {
+		return nil, err
+	}
+	return &bodyTemplate{
+		tmpls: tmpls,
+		buffers: sync.Pool{
+			New: func() interface{} {
+				return new(bytes.Buffer)
+			},
+		},
+	}, nil
+}
+
+// render executes the template for the request. Buffer should
+// be returned with release once it's no longer in use.
+func (bt *bodyTemplate) render(data templateData) (*bytes.Buffer, error) {
+	buf := bt.buffers.Get().(*bytes.Buffer)
+	buf.Reset()
+	if err := bt.tmpls[data.ConnID].Execute(buf, data.value()); err != nil {
+		bt.release(buf)
+		return nil, err
+	}
+	return buf, nil
+}
+
+func (bt *bodyTemplate) release(buf *bytes.Buffer) {
+	bt.buffers.Put(buf)
+}
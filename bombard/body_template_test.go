@@ -0,0 +1,90 @@
+package bombard
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/satori/go.uuid"
+)
+
+func TestBodyTemplateRendersVariables(t *testing.T) {
+	bt, err := newBodyTemplate(
+		"{{ .RequestNum }}-{{ .ConnID }}", newConnRNGs(42, 8),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc := new(requestCounter)
+	for i, exp := range []string{"1-7", "2-7", "3-7"} {
+		buf, err := bt.render(rc.next(7))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if act := buf.String(); act != exp {
+			t.Errorf("%v: expected %q, but got %q", i, exp, act)
+		}
+		bt.release(buf)
+	}
+}
+
+func TestBodyTemplateUUID(t *testing.T) {
+	bt, err := newBodyTemplate("{{ uuid }}", newConnRNGs(42, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := bt.render(templateData{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := first.String()
+	bt.release(first)
+	second, err := bt.render(templateData{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := second.String()
+	if len(a) != 36 || a == b {
+		t.Errorf("Expected two distinct UUIDs, but got %q and %q", a, b)
+	}
+}
+
+func TestBodyTemplateParseError(t *testing.T) {
+	if _, err := newBodyTemplate(
+		"{{ .RequestNum ", newConnRNGs(42, 1),
+	); err == nil {
+		t.Error("invalid template parsed successfully")
+	}
+}
+
+func TestBodyTemplateUUIDIsReproducible(t *testing.T) {
+	render := func(seed int64) []string {
+		bt, err := newBodyTemplate("{{ uuid }}", newConnRNGs(seed, 2))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var uuids []string
+		for _, connID := range []uint64{0, 1, 0} {
+			buf, err := bt.render(templateData{ConnID: connID})
+			if err != nil {
+				t.Fatal(err)
+			}
+			uuids = append(uuids, buf.String())
+			bt.release(buf)
+		}
+		return uuids
+	}
+	first, second := render(42), render(42)
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("Expected the same UUIDs, but got %v and %v", first, second)
+	}
+	if first[0] == first[1] || first[0] == first[2] {
+		t.Errorf("Expected distinct UUIDs, but got %v", first)
+	}
+	u, err := uuid.FromString(first[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Version() != uuid.V4 || u.Variant() != uuid.VariantRFC4122 {
+		t.Errorf("Expected RFC 4122 version 4 UUID, but got %v", u)
+	}
+}
@@ -0,0 +1,1877 @@
+package bombard
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
+
+	"github.com/kostyay/bombardier/internal"
+
+	"github.com/cheggaaa/pb"
+	fhist "github.com/codesenberg/concurrent/float64/histogram"
+	uhist "github.com/codesenberg/concurrent/uint64/histogram"
+
+	"github.com/satori/go.uuid"
+)
+
+type bombardier struct {
+	bytesRead, bytesWritten int64
+	// Bytes read as status lines and headers of successful responses
+	// received after warmup and as their bodies, unlike bytesRead
+	// these aren't counted on connections
+	headerBytesRead, bodyBytesRead int64
+	// Connections established, whether requests over them succeeded
+	// or not
+	connsOpened uint64
+
+	// HTTP codes
+	req1xx uint64
+	req2xx uint64
+	req3xx uint64
+	req4xx uint64
+	req5xx uint64
+	req502 uint64
+	others uint64
+
+	statusCodesMutex sync.Mutex
+	statusCodes      map[int]uint64
+
+	conf        config
+	barrier     completionBarrier
+	ratelimiter limiter
+	workers     sync.WaitGroup
+	activeConns int64
+
+	// Only used if rate is limited per connection
+	connLimiters []limiter
+
+	timeTaken time.Duration
+	latencies *uhist.Histogram
+	ttfb      *uhist.Histogram
+	requests  *fhist.Histogram
+	// Sizes of response bodies
+	responseSizes *uhist.Histogram
+	// Sizes of request bodies, nil unless they are picked from the
+	// pool
+	requestSizes *uhist.Histogram
+	// Durations of TLS handshakes
+	handshakes *uhist.Histogram
+	// Processing times reported by server and the number of
+	// responses they couldn't be read from, nil unless requested
+	serverTimes      *uhist.Histogram
+	serverTimeErrors uint64
+	// Gaps between completions of requests and when the last one
+	// completed (in nanoseconds since epoch), nil unless requested
+	interArrivals  *uhist.Histogram
+	lastCompletion int64
+
+	// Latencies by status class, nil unless requested
+	latenciesByClass map[int]*uhist.Histogram
+	// Results for every second, nil unless requested
+	timeSeries *timeSeries
+
+	clients  []client
+	selector urlSelector
+	doneChan chan struct{}
+
+	// Per-URL statistics, only gathered if there is more than one URL
+	urlStats []*urlStats
+	// Per-connection statistics, only gathered if requested
+	connStats []connStats
+
+	// RPS metrics
+	rpl   sync.Mutex
+	reqs  int64
+	start time.Time
+
+	// Warmup, latencies and request rates aren't recorded while
+	// warmingUp is non-zero
+	warmingUp    int32
+	warmupDone   chan struct{}
+	measureBegin time.Time
+	// How long priming of connections took, it precedes measurements
+	primingTime time.Duration
+
+	// Set to non-zero once the test is aborted because of too high
+	// rate of 5xx responses
+	aborted int32
+	// Set to non-zero once the test is stopped because of reaching
+	// the cap on bytes read and written
+	bytesCapped int32
+
+	// interrupted is closed once the test is interrupted by user or
+	// the context it's run with is done
+	interrupted   chan struct{}
+	interruptOnce sync.Once
+	// gracePeriod is how long in-flight requests are waited for
+	// after interruption
+	gracePeriod time.Duration
+	// workersDone is closed once all the workers are finished
+	workersDone chan struct{}
+	// resultsMu is held for reading while results of a request are
+	// recorded, abandoned is set once requests still in flight after
+	// the grace period can no longer affect the results
+	resultsMu sync.RWMutex
+	abandoned bool
+
+	// Request generation is suspended while paused, time spent
+	// paused before measurement began is excluded from the total
+	pauser              pauser
+	pausedBeforeMeasure time.Duration
+
+	// Errors
+	errors *errorMap
+
+	// Responses with status codes outside of this set are treated
+	// as errors, nil means that any status code is expected
+	expectedStatuses map[int]bool
+	unexpected       uint64
+
+	// Number of times requests were retried
+	retries uint64
+	// Number of redirects followed
+	redirects uint64
+
+	// Step of the load profile during which knee latency was
+	// exceeded, nil if it wasn't (yet)
+	knee *internal.KneeStep
+
+	// Number of completed requests sent over new and reused
+	// connections
+	newConns, reusedConns uint64
+
+	// Requests sent, but not completed yet
+	inFlight inFlight
+	// Number of completed requests and time elapsed as of the
+	// previous tick, only accessed by the goroutine that ticks
+	tickCompleted uint64
+	tickElapsed   time.Duration
+
+	// Progress bar
+	bar *pb.ProgressBar
+	// Dashboard replaces the progress bar, nil if not used
+	dashboard *dashboard
+
+	// Output, errOut is where errors and hints are written
+	out, errOut io.Writer
+	template    *template.Template
+
+	// Live metrics
+	metrics *metricsServer
+
+	// Per-request samples
+	samples *samplesWriter
+	// Dumps of failed requests, nil if they aren't dumped
+	dumper *errorDumper
+
+	// OAuth2 tokens, nil if not used
+	tokens *tokenSource
+	// resolver records remote addresses of connections
+	resolver *resolver
+	// hostConns limits connections to every host, nil if they
+	// aren't limited
+	hostConns *hostConns
+	// bodyDir holds bodies requests are sent with, nil unless they
+	// are picked from a directory
+	bodyDir *bodyDir
+	// bodyPool holds bodies of random sizes requests are sent with,
+	// nil unless their sizes are given
+	bodyPool *bodyPool
+	// resolution latencies are recorded with, nil if they are
+	// recorded exactly
+	resolution *latencyResolution
+
+	// All the random choices of connection are drawn from its RNG,
+	// RNGs are derived from the seed
+	seed uint64
+	rngs []*rand.Rand
+}
+
+func newBombardier(c config) (*bombardier, error) {
+	if err := c.checkArgs(); err != nil {
+		return nil, err
+	}
+	b := new(bombardier)
+	b.conf = c
+	b.latencies = uhist.Default()
+	b.resolution = newLatencyResolution(c.latencyDigits, c.latencyCeiling)
+	b.ttfb = uhist.Default()
+	b.requests = fhist.Default()
+	b.responseSizes = uhist.Default()
+	b.handshakes = uhist.Default()
+	if c.bodySize != nil {
+		b.requestSizes = uhist.Default()
+	}
+	if c.serverTimeHeader != "" {
+		b.serverTimes = uhist.Default()
+	}
+	if c.interArrival {
+		b.interArrivals = uhist.Default()
+	}
+	if c.latenciesByStatus {
+		b.latenciesByClass = make(map[int]*uhist.Histogram)
+		for class := 0; class <= 5; class++ {
+			b.latenciesByClass[class] = uhist.Default()
+		}
+	}
+	if c.connStats {
+		b.connStats = make([]connStats, c.numConns)
+	}
+	b.statusCodes = make(map[int]uint64)
+	b.inFlight.interval = inFlightSampleInterval
+	if c.timeSeries {
+		b.timeSeries = new(timeSeries)
+	}
+	b.interrupted = make(chan struct{})
+	b.gracePeriod = interruptGracePeriod
+	b.seed = uint64(time.Now().UnixNano())
+	if c.seed != nil {
+		b.seed = *c.seed
+	}
+	b.rngs = newConnRNGs(int64(b.seed), c.numConns)
+
+	switch b.conf.testType() {
+	case counted:
+		b.bar = pb.New64(int64(*b.conf.numReqs))
+		b.bar.ShowSpeed = true
+	case timed:
+		b.bar = pb.New64(b.conf.duration.Nanoseconds() / 1e9)
+		b.bar.ShowCounters = false
+		b.bar.ShowPercent = false
+	case timedOrCounted:
+		// Progress is whichever of the two is closer to completion,
+		// so only percents make sense
+		b.bar = pb.New64(100)
+		b.bar.ShowCounters = false
+	}
+	b.bar.ManualUpdate = true
+
+	switch b.conf.testType() {
+	case counted:
+		b.barrier = newCountingCompletionBarrier(*b.conf.numReqs)
+	case timedOrCounted:
+		b.barrier = newCombinedCompletionBarrier(
+			*b.conf.numReqs, *b.conf.duration,
+		)
+	default:
+		b.barrier = newTimedCompletionBarrier(*b.conf.duration)
+	}
+
+	// Shared limiter has RNG of its own, derived as if it was one
+	// more connection
+	arrivalRNG := rand.New(rand.NewSource(
+		connSeed(int64(b.seed), c.numConns),
+	))
+	if c.correctLatency && c.replay == nil {
+		// Schedule is kept, so that it's known when requests were
+		// meant to be sent
+		var profile loadProfile = b.conf.loadProfile
+		if b.conf.rate != nil {
+			profile = &constantProfile{*b.conf.rate}
+		}
+		b.ratelimiter = newScheduler(
+			profile, b.conf.arrival, arrivalRNG, b.pauser.pausedFor,
+		)
+	} else if b.conf.rate != nil && !b.conf.ratePerConn {
+		b.ratelimiter = newRateLimiter(
+			*b.conf.rate, b.conf.arrival, arrivalRNG,
+		)
+	} else if b.conf.loadProfile != nil {
+		b.ratelimiter = newProfileLimiter(
+			b.conf.loadProfile, b.conf.arrival, arrivalRNG,
+		)
+	} else {
+		b.ratelimiter = &nooplimiter{}
+	}
+	if b.conf.rate != nil && b.conf.ratePerConn {
+		b.connLimiters = make([]limiter, b.conf.numConns)
+		for i := range b.connLimiters {
+			// Only the worker of connection uses its limiter
+			if c.correctLatency {
+				b.connLimiters[i] = newScheduler(
+					&constantProfile{*b.conf.rate}, b.conf.arrival,
+					b.rngs[i], b.pauser.pausedFor,
+				)
+			} else {
+				b.connLimiters[i] = newRateLimiter(
+					*b.conf.rate, b.conf.arrival, b.rngs[i],
+				)
+			}
+		}
+	}
+	if c.replay != nil {
+		// Connections take requests from the log in turns, the test
+		// ends early once there are none left
+		c.replay.prepare(c.numConns, b.barrier.cancel, b.pauser.pausedFor)
+		b.connLimiters = make([]limiter, c.numConns)
+		for i := range b.connLimiters {
+			b.connLimiters[i] = c.replay.limiter(uint64(i))
+		}
+	}
+
+	b.out, b.errOut = os.Stdout, os.Stdout
+	if c.quiet {
+		b.errOut = os.Stderr
+	}
+
+	tlsConfig, err := generateTLSConfig(c)
+	if err != nil {
+		return nil, err
+	}
+	if c.oauth2 != nil {
+		// Token is obtained right away, so that problems with it
+		// surface before the test starts
+		// SNI and ALPN overrides are meant for the target, not
+		// token endpoint
+		tokenTLSConfig := tlsConfig.Clone()
+		tokenTLSConfig.ServerName = ""
+		tokenTLSConfig.NextProtos = nil
+		b.tokens = newTokenSource(*c.oauth2, tokenTLSConfig, c.timeout)
+		if err = b.tokens.fetch(); err != nil {
+			return nil, err
+		}
+	}
+	var signer *sigV4Signer
+	if c.sigV4 != nil {
+		signer = newSigV4Signer(*c.sigV4)
+	}
+
+	var (
+		pbody *string
+		bsp   bodyStreamProducer
+		btmpl *bodyTemplate
+		bgen  internal.BodyGenerator
+
+		formContentType string
+	)
+	body, bodyFilePath := c.body, c.bodyFilePath
+	if bodyFilePath == stdinBodyPath {
+		// Body from stdin is read only once and then used just
+		// like the one specified with --body
+		var bodyBytes []byte
+		bodyBytes, err = readBodyFromStdin()
+		if err != nil {
+			return nil, err
+		}
+		body, bodyFilePath = string(bodyBytes), ""
+	}
+	if c.bodyTemplate {
+		if bodyFilePath != "" {
+			var bodyBytes []byte
+			bodyBytes, err = ioutil.ReadFile(bodyFilePath)
+			if err != nil {
+				return nil, err
+			}
+			body = string(bodyBytes)
+		}
+		btmpl, err = newBodyTemplate(body, b.rngs)
+		if err != nil {
+			return nil, err
+		}
+	} else if c.stream {
+		if bodyFilePath != "" {
+			bsp = func() (io.ReadCloser, error) {
+				return os.Open(bodyFilePath)
+			}
+		} else {
+			bsp = func() (io.ReadCloser, error) {
+				return ioutil.NopCloser(
+					proxyReader{strings.NewReader(body)},
+				), nil
+			}
+		}
+	} else if c.bodyGenerator != nil {
+		bgen = *c.bodyGenerator
+	} else if c.bodySize != nil {
+		// Pool has RNG of its own, derived as if it was the second
+		// connection past the last one, after the shared limiter
+		b.bodyPool = newBodyPool(c.bodySize, rand.New(rand.NewSource(
+			connSeed(int64(b.seed), c.numConns+1),
+		)), b.rngs)
+	} else if c.bodyDir != "" {
+		b.bodyDir, err = readBodyDir(c.bodyDir, b.rngs)
+		if err != nil {
+			return nil, err
+		}
+		if c.compressBody != "" {
+			if err = b.bodyDir.compress(c.compressBody); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		pbody = &body
+		if c.form != nil {
+			var form string
+			form, formContentType, err = buildForm(*c.form)
+			if err != nil {
+				return nil, err
+			}
+			pbody = &form
+		} else if bodyFilePath != "" {
+			var bodyBytes []byte
+			bodyBytes, err = ioutil.ReadFile(bodyFilePath)
+			if err != nil {
+				return nil, err
+			}
+			sbody := string(bodyBytes)
+			pbody = &sbody
+		}
+		if c.compressBody != "" {
+			// Compressed only once, every request reuses the result
+			var compressed string
+			compressed, err = compressBody(*pbody, c.compressBody)
+			if err != nil {
+				return nil, err
+			}
+			pbody = &compressed
+		}
+	}
+	var implied headersList
+	if c.compressBody != "" {
+		implied = append(implied,
+			header{"Content-Encoding", c.compressBody})
+	}
+	if formContentType != "" {
+		implied = append(implied, header{"Content-Type", formContentType})
+	}
+	if contentType := c.autoContentType(); contentType != "" {
+		implied = append(implied, header{"Content-Type", contentType})
+	}
+	if c.basicAuth != "" {
+		implied = append(implied, header{"Authorization", "Basic " +
+			base64.StdEncoding.EncodeToString([]byte(c.basicAuth))})
+	}
+	headers := c.headers
+	if len(implied) > 0 {
+		all := append(append(headersList{}, *c.headers...), implied...)
+		headers = &all
+	}
+
+	var htmpls headerTemplates
+	if c.headerTemplates != nil {
+		htmpls, err = newHeaderTemplates(*c.headerTemplates, b.rngs)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var rheaders *randomHeaders
+	if c.randomHeaders != nil {
+		// Shared by all targets, since decisions are made per
+		// connection
+		rheaders = newRandomHeaders(*c.randomHeaders, b.rngs)
+	}
+	var counter *requestCounter
+	if btmpl != nil || htmpls != nil || bgen != nil ||
+		(c.scenario != nil && c.scenario.templated()) {
+		// Shared by all targets, so that requests are numbered
+		// across all of them
+		counter = new(requestCounter)
+	}
+	var cookies cookieJars
+	if c.cookies {
+		// Jars are shared between targets, so that cookies are sent
+		// to all the URLs they are applicable to
+		cookies = newCookieJars(c.numConns)
+	}
+	var grpcRequest []byte
+	if c.grpc != nil {
+		// Encoded only once, every call reuses the result
+		if grpcRequest, err = c.grpc.encodeRequest(*pbody); err != nil {
+			return nil, err
+		}
+	}
+	var overrides resolveOverridesList
+	if c.resolve != nil {
+		overrides = *c.resolve
+	}
+	b.resolver = newResolver(overrides, c.pinDNS, c.unixSocket)
+	if c.localAddrs != nil {
+		b.resolver.bind(*c.localAddrs)
+	}
+	var px *proxy
+	if c.proxy != "" {
+		px = newProxy(c.proxy, c.timeout)
+	}
+	targets := c.targets()
+	if err = b.resolver.pinURLs(context.Background(), targets); err != nil {
+		return nil, err
+	}
+	if c.maxConnsPerHost > 0 {
+		b.hostConns = newHostConns(c.maxConnsPerHost)
+	}
+	if c.dumpErrors > 0 {
+		b.dumper, err = newErrorDumper(c.dumpErrorsPath, c.dumpErrors)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var vars connVars
+	if c.scenario != nil {
+		if names := c.scenario.variables(); len(names) > 0 {
+			vars = newConnVars(c.numConns, names)
+		}
+	}
+	for i, url := range targets {
+		cc := &clientOpts{
+			HTTP2:     false,
+			maxConns:  c.numConns,
+			timeout:   c.timeout,
+			tlsConfig: tlsConfig,
+			alpn:      tlsConfig.NextProtos,
+
+			connectTimeout: c.connectTimeout,
+
+			headers:      headers,
+			url:          url,
+			method:       c.method,
+			body:         pbody,
+			bodProd:      bsp,
+			bodyTmpl:     btmpl,
+			bodyDir:      b.bodyDir,
+			bodyGen:      bgen,
+			bodyPool:     b.bodyPool,
+			headerTmpls:  htmpls,
+			reqCounter:   counter,
+			randHeaders:  rheaders,
+			cookies:      cookies,
+			tokens:       b.tokens,
+			signer:       signer,
+			resolver:     b.resolver,
+			hostConns:    b.hostConns,
+			proxy:        px,
+			dumper:       b.dumper,
+			replay:       c.replay,
+			bytesRead:    &b.bytesRead,
+			bytesWritten: &b.bytesWritten,
+			connsOpened:  &b.connsOpened,
+
+			disableKeepAlive: c.disableKeepAlive,
+			maxRedirects:     c.maxRedirects,
+			finalHopLatency:  c.finalHopLatency,
+			serverTimeHeader: c.serverTimeHeader,
+
+			vars: vars,
+		}
+		if c.scenario != nil {
+			if err = c.scenario.steps[i].apply(cc, b.rngs); err != nil {
+				return nil, err
+			}
+		}
+		if c.grpc != nil {
+			var gc *grpcClient
+			if gc, err = newGRPCClient(cc, c.grpc, grpcRequest); err != nil {
+				return nil, err
+			}
+			b.clients = append(b.clients, gc)
+		} else {
+			b.clients = append(b.clients, makeHTTPClient(c.clientType, cc))
+		}
+	}
+	if c.weights != nil {
+		b.selector = newWeightedSelector(*c.weights, b.rngs)
+	} else if c.scenario != nil {
+		b.selector = newSequenceSelector(len(targets), c.numConns)
+	} else {
+		b.selector = newRoundRobinSelector(len(targets))
+	}
+	if c.urls != nil {
+		for i, url := range targets {
+			us := newURLStats(url)
+			if c.scenario != nil {
+				us.name = c.scenario.steps[i].Name
+			}
+			b.urlStats = append(b.urlStats, us)
+		}
+	}
+
+	if !b.conf.printProgress {
+		b.bar.Output = ioutil.Discard
+		b.bar.NotPrint = true
+	} else if c.dashboard && isTerminal(os.Stdout) {
+		// Otherwise the progress bar is used as usual
+		b.dashboard = newDashboard(b)
+		b.bar.Output = ioutil.Discard
+		b.bar.NotPrint = true
+	}
+
+	b.template, err = b.prepareTemplate()
+	if err != nil {
+		return nil, err
+	}
+
+	if c.expectedStatuses != nil {
+		b.expectedStatuses = make(map[int]bool)
+		for _, code := range *c.expectedStatuses {
+			b.expectedStatuses[code] = true
+		}
+	}
+
+	b.workers.Add(int(c.numConns))
+	b.errors = newErrorMap()
+	b.doneChan = make(chan struct{}, 2)
+
+	if c.samplesPath != "" {
+		b.samples, err = newSamplesWriter(c.samplesPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if c.prometheusAddr != "" {
+		b.metrics, err = newMetricsServer(c.prometheusAddr, b)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+func makeHTTPClient(clientType clientTyp, cc *clientOpts) client {
+	var cl client
+	switch clientType {
+	case nhttp1:
+		cl = newHTTPClient(cc)
+	case nhttp2:
+		cc.HTTP2 = true
+		cl = newHTTPClient(cc)
+	case fhttp:
+		fallthrough
+	default:
+		cl = newFastHTTPClient(cc)
+	}
+	return cl
+}
+
+func (b *bombardier) prepareTemplate() (*template.Template, error) {
+	var (
+		templateBytes []byte
+		err           error
+	)
+	switch f := b.conf.format.(type) {
+	case knownFormat:
+		templateBytes = f.template()
+	case userDefinedTemplate:
+		templateBytes, err = ioutil.ReadFile(string(f))
+		if err != nil {
+			return nil, err
+		}
+	default:
+		panic("format can't be nil at this point, this is a bug")
+	}
+	outputTemplate, err := template.New("output-template").
+		Funcs(template.FuncMap{
+			"WithLatencies": func() bool {
+				return b.conf.printLatencies
+			},
+			"FormatBinary": formatBinary,
+			"FormatBinaryUint64": func(n uint64) string {
+				return formatBinary(float64(n))
+			},
+			"FormatBinaryInt64": func(n int64) string {
+				return formatBinary(float64(n))
+			},
+			"FormatTimeUs": formatTimeUs,
+			"FormatTimeUsUint64": func(us uint64) string {
+				return formatTimeUs(float64(us))
+			},
+			"FormatDuration": func(d time.Duration) string {
+				return formatTimeUs(float64(d / time.Microsecond))
+			},
+			"FloatsToArray": func(ps ...float64) []float64 {
+				return ps
+			},
+			"Multiply": func(num, coeff float64) float64 {
+				return num * coeff
+			},
+			"StringToBytes": func(s string) []byte {
+				return []byte(s)
+			},
+			"FormatPercentile":  formatPercentile,
+			"FormatStatusClass": formatStatusClass,
+			"FormatGRPCCode":    formatGRPCCode,
+			"JoinInts": func(is []int) string {
+				ss := make([]string, len(is))
+				for i, v := range is {
+					ss[i] = strconv.Itoa(v)
+				}
+				return strings.Join(ss, ", ")
+			},
+			"JoinStrings": func(ss []string) string {
+				return strings.Join(ss, ", ")
+			},
+			"EscapeMarkdown": escapeMarkdown,
+			"Uint64HistogramJSON": func(
+				h internal.ReadonlyUint64Histogram,
+			) string {
+				return string(internal.MarshalUint64Histogram(h))
+			},
+			"Float64HistogramJSON": func(
+				h internal.ReadonlyFloat64Histogram,
+			) string {
+				return string(internal.MarshalFloat64Histogram(h))
+			},
+			"UUIDV1": uuid.NewV1,
+			"UUIDV2": uuid.NewV2,
+			"UUIDV3": uuid.NewV3,
+			"UUIDV4": uuid.NewV4,
+			"UUIDV5": uuid.NewV5,
+		}).Parse(string(templateBytes))
+
+	if err != nil {
+		return nil, err
+	}
+	return outputTemplate, nil
+}
+
+// writeStatistics counts response with the given status code, its
+// latency is only recorded if the results are being measured, i.e.
+// the warmup is over.
+func (b *bombardier) writeStatistics(
+	code int, msTaken uint64, measuring bool,
+) {
+	if measuring {
+		b.latencies.Increment(msTaken)
+		if b.latenciesByClass != nil {
+			b.latenciesByClass[statusClass(code)].Increment(msTaken)
+		}
+	}
+	b.rpl.Lock()
+	b.reqs++
+	b.rpl.Unlock()
+	var counter *uint64
+	switch code / 100 {
+	case 1:
+		counter = &b.req1xx
+	case 2:
+		counter = &b.req2xx
+	case 3:
+		counter = &b.req3xx
+	case 4:
+		counter = &b.req4xx
+	case 5:
+		counter = &b.req5xx
+	default:
+		counter = &b.others
+	}
+
+	if code == 502 {
+		atomic.AddUint64(&b.req502, 1)
+	}
+
+	b.statusCodesMutex.Lock()
+
+	_, exists := b.statusCodes[code]
+	if !exists {
+		b.statusCodes[code] = 1
+	} else {
+		b.statusCodes[code] += 1
+	}
+
+	b.statusCodesMutex.Unlock()
+
+	atomic.AddUint64(counter, 1)
+}
+
+// statusClass returns the hundreds digit of status code, or 0 if
+// it's not a valid one (which includes failed requests).
+func statusClass(code int) int {
+	class := code / 100
+	if class < 1 || class > 5 {
+		return 0
+	}
+	return class
+}
+
+// performSingleRequest sends request and records its results. If
+// scheduled isn't zero, latency is measured from that time, rather
+// than from the time request was actually sent.
+func (b *bombardier) performSingleRequest(
+	connID uint64, scheduled time.Time,
+) {
+	target := b.selector.next(connID)
+	var delay uint64
+	if !scheduled.IsZero() {
+		if d := time.Since(scheduled); d > 0 {
+			delay = uint64(d.Nanoseconds() / 1000)
+		}
+	}
+	b.inFlight.inc()
+	res := b.clients[target].do(connID)
+	total := res.msTaken
+	retries := uint64(0)
+	for ; retries < b.conf.retries && shouldRetry(res); retries++ {
+		res = b.clients[target].do(connID)
+		total += res.msTaken
+	}
+	b.inFlight.dec()
+	b.resultsMu.RLock()
+	defer b.resultsMu.RUnlock()
+	if b.abandoned {
+		return
+	}
+	// Warmup may end halfway through recording, so that the request
+	// would only be partially counted if it were checked every time
+	measuring := atomic.LoadInt32(&b.warmingUp) == 0
+	if retries > 0 {
+		atomic.AddUint64(&b.retries, retries)
+	}
+	if res.redirects > 0 {
+		atomic.AddUint64(&b.redirects, res.redirects)
+	}
+	if b.conf.latencyWithRetries {
+		res.msTaken = total
+	}
+	res.msTaken += delay
+	if b.resolution != nil {
+		res.msTaken = b.resolution.apply(res.msTaken)
+	}
+	if res.err == nil {
+		if res.reused {
+			atomic.AddUint64(&b.reusedConns, 1)
+		} else {
+			atomic.AddUint64(&b.newConns, 1)
+		}
+		if measuring {
+			b.ttfb.Increment(res.ttfb)
+			b.responseSizes.Increment(uint64(res.bodySize))
+			atomic.AddInt64(&b.headerBytesRead, res.headerSize)
+			atomic.AddInt64(&b.bodyBytesRead, res.bodySize)
+			if res.handshake > 0 {
+				b.handshakes.Increment(res.handshake)
+			}
+			if b.requestSizes != nil {
+				b.requestSizes.Increment(uint64(res.sentBodySize))
+			}
+			if b.serverTimes != nil {
+				b.recordServerTime(res.serverTime)
+			}
+		}
+	}
+	if res.err == nil && b.expectedStatuses != nil &&
+		!b.expectedStatuses[res.code] {
+		atomic.AddUint64(&b.unexpected, 1)
+		res.err = &unexpectedStatusError{res.code}
+	}
+	if res.err == nil && res.captureErr != nil {
+		res.err = res.captureErr
+	}
+	if res.err != nil {
+		b.errors.add(res.err)
+	}
+	b.writeStatistics(res.code, res.msTaken, measuring)
+	if b.interArrivals != nil && measuring {
+		b.recordInterArrival(time.Now())
+	}
+	if b.timeSeries != nil && measuring {
+		b.timeSeries.record(res.msTaken, res.err != nil)
+	}
+	if b.urlStats != nil && measuring {
+		b.urlStats[target].record(res.code, res.msTaken, res.err)
+	}
+	if b.connStats != nil && measuring {
+		b.connStats[connID].record(res.msTaken, res.err)
+	}
+	if b.samples != nil {
+		b.recordSample(connID, res)
+	}
+	if b.conf.onRequest != nil {
+		(*b.conf.onRequest)(
+			time.Duration(res.msTaken)*time.Microsecond, res.code, res.err,
+		)
+	}
+}
+
+// shouldRetry tells whether request with such result is worth
+// retrying.
+func shouldRetry(res requestResult) bool {
+	return res.err != nil || res.code/100 == 5
+}
+
+func (b *bombardier) recordSample(connID uint64, res requestResult) {
+	s := sample{
+		Timestamp:  time.Now(),
+		LatencyUs:  res.msTaken,
+		StatusCode: res.code,
+		BytesRead:  res.bodySize,
+		ConnID:     connID,
+	}
+	if res.err != nil {
+		s.Error = res.err.Error()
+	}
+	b.samples.add(s)
+}
+
+func (b *bombardier) worker(connID uint64) {
+	done := b.barrier.done()
+	ratelimiter := b.ratelimiter
+	if b.connLimiters != nil {
+		ratelimiter = b.connLimiters[connID]
+	}
+	first := true
+	for b.barrier.tryGrabWork() {
+		if !first && b.conf.thinkTime != nil &&
+			b.think(connID, done) == brk {
+			break
+		}
+		first = false
+		if b.pauser.wait(done) == brk {
+			break
+		}
+		var scheduled time.Time
+		if sched, ok := ratelimiter.(scheduler); ok && b.conf.correctLatency {
+			var res token
+			if res, scheduled = sched.schedule(done); res == brk {
+				break
+			}
+		} else if ratelimiter.pace(done) == brk {
+			break
+		}
+		if b.conf.jitter > 0 {
+			delay, res := b.delayByJitter(connID, done)
+			if res == brk {
+				break
+			}
+			if !scheduled.IsZero() {
+				// Jitter is deliberate, so it isn't counted as
+				// the time request waited to be sent
+				scheduled = scheduled.Add(delay)
+			}
+		}
+		b.performSingleRequest(connID, scheduled)
+		b.barrier.jobDone()
+	}
+}
+
+// think pauses the connection for its think time, unless the test
+// is over sooner.
+func (b *bombardier) think(connID uint64, done <-chan struct{}) token {
+	timer := time.NewTimer(b.conf.thinkTime.next(b.rngs[connID]))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return cont
+	case <-done:
+		return brk
+	}
+}
+
+// delayByJitter pauses the connection for a random fraction of
+// jitter, unless the test is over sooner. It returns the pause.
+func (b *bombardier) delayByJitter(
+	connID uint64, done <-chan struct{},
+) (time.Duration, token) {
+	delay := time.Duration(
+		b.rngs[connID].Int63n(int64(b.conf.jitter) + 1),
+	)
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return delay, cont
+	case <-done:
+		return delay, brk
+	}
+}
+
+// spawnWorkers starts all the workers, if ramp-up period is specified
+// their starts are evenly distributed over it.
+func (b *bombardier) spawnWorkers() {
+	interval := time.Duration(0)
+	if b.conf.rampUp > 0 {
+		interval = b.conf.rampUp / time.Duration(b.conf.numConns)
+	}
+	done := b.barrier.done()
+	for i := uint64(0); i < b.conf.numConns; i++ {
+		if i > 0 && interval > 0 {
+			timer := time.NewTimer(interval)
+			select {
+			case <-timer.C:
+			case <-done:
+				// Remaining workers will exit right away
+				timer.Stop()
+			}
+		}
+		go func(connID uint64) {
+			defer b.workers.Done()
+			atomic.AddInt64(&b.activeConns, 1)
+			defer atomic.AddInt64(&b.activeConns, -1)
+			b.worker(connID)
+		}(i)
+	}
+}
+
+func (b *bombardier) barUpdater() {
+	done := b.barrier.done()
+	for {
+		select {
+		case <-done:
+			if b.dashboard != nil {
+				b.dashboard.stop()
+			}
+			if b.conf.printProgress {
+				b.bar.Set64(b.bar.Total)
+				b.bar.Update()
+				b.bar.Finish()
+				fmt.Fprintln(b.out, "Done!")
+			}
+			b.doneChan <- struct{}{}
+			return
+		default:
+			if b.errorRateExceeded() {
+				atomic.StoreInt32(&b.aborted, 1)
+				b.barrier.cancel()
+			}
+			if b.bytesCapReached() {
+				atomic.StoreInt32(&b.bytesCapped, 1)
+				b.barrier.cancel()
+			}
+			if b.dashboard != nil {
+				b.dashboard.update()
+				time.Sleep(dashboardRefreshRate)
+				continue
+			}
+			if !b.conf.printProgress {
+				// Nothing to show, the test is only checked on
+				time.Sleep(b.bar.RefreshRate)
+				continue
+			}
+			current := int64(b.barrier.completed() * float64(b.bar.Total))
+			postfix := ""
+			if b.conf.rampUp > 0 {
+				postfix = fmt.Sprintf(" %v/%v conns",
+					atomic.LoadInt64(&b.activeConns), b.conf.numConns)
+			}
+			if b.pauser.paused() {
+				postfix += " (paused)"
+			}
+			b.bar.Postfix(postfix)
+			b.bar.Set64(current)
+			b.bar.Update()
+			time.Sleep(b.bar.RefreshRate)
+		}
+	}
+}
+
+// errorRateExceeded tells whether the fraction of 5xx responses among
+// completed requests is above the threshold the test is aborted at.
+func (b *bombardier) errorRateExceeded() bool {
+	if b.conf.abortErrorRate == nil {
+		return false
+	}
+	req5xx := atomic.LoadUint64(&b.req5xx)
+	total := b.completedRequests()
+	if total < minRequestsToAbort {
+		return false
+	}
+	return float64(req5xx)/float64(total) > *b.conf.abortErrorRate
+}
+
+// bytesCapReached tells whether the number of bytes read and written
+// so far is at least the cap the test is stopped at.
+func (b *bombardier) bytesCapReached() bool {
+	if b.conf.maxBytes == nil {
+		return false
+	}
+	transferred := atomic.LoadInt64(&b.bytesRead) +
+		atomic.LoadInt64(&b.bytesWritten)
+	return uint64(transferred) >= *b.conf.maxBytes
+}
+
+func (b *bombardier) rateMeter() {
+	requestsInterval := 10 * time.Millisecond
+	if b.conf.rate != nil {
+		requestsInterval, _ = estimate(*b.conf.rate, rateLimitInterval)
+	}
+	requestsInterval += 10 * time.Millisecond
+	ticker := time.NewTicker(requestsInterval)
+	defer ticker.Stop()
+	tick := ticker.C
+	inFlightTicker := time.NewTicker(b.inFlight.interval)
+	defer inFlightTicker.Stop()
+	done := b.barrier.done()
+	for {
+		select {
+		case <-tick:
+			b.recordRps()
+			continue
+		case <-inFlightTicker.C:
+			if atomic.LoadInt32(&b.warmingUp) == 0 {
+				elapsed := time.Since(b.measureBegin)
+				b.inFlight.sample(elapsed)
+				if b.timeSeries != nil {
+					b.timeSeries.point(elapsed)
+				}
+				if b.conf.onTick != nil {
+					b.tick(elapsed)
+				}
+			}
+			continue
+		case <-done:
+			b.waitForWorkers()
+			b.recordRps()
+			elapsed := time.Since(b.measureBegin)
+			if b.timeSeries != nil {
+				b.timeSeries.point(elapsed)
+			}
+			if b.conf.onTick != nil && atomic.LoadInt32(&b.warmingUp) == 0 {
+				b.tick(elapsed)
+			}
+			b.doneChan <- struct{}{}
+			return
+		}
+	}
+}
+
+func (b *bombardier) recordRps() {
+	b.rpl.Lock()
+	duration := time.Since(b.start)
+	reqs := b.reqs
+	b.reqs = 0
+	b.start = time.Now()
+	b.rpl.Unlock()
+	if atomic.LoadInt32(&b.warmingUp) != 0 || b.pauser.paused() {
+		return
+	}
+
+	reqsf := float64(reqs) / duration.Seconds()
+	b.requests.Increment(reqsf)
+}
+
+func (b *bombardier) bombard() {
+	if b.conf.printIntro {
+		b.printIntro()
+	}
+	if b.metrics != nil {
+		go b.metrics.serve()
+	}
+	if b.tokens != nil {
+		go b.tokens.refreshUntil(b.barrier.done(), b.errOut)
+	}
+	if b.conf.primeConns && !b.isInterrupted() {
+		b.primeConnections()
+	}
+	// Smoke and priming requests, if there were any, are done by now
+	b.barrier.start()
+	b.bar.Start()
+	b.measureBegin = time.Now()
+	if b.dashboard != nil {
+		b.dashboard.start()
+	}
+	b.start = time.Now()
+	var warmupTimer *time.Timer
+	if b.conf.warmup > 0 {
+		atomic.StoreInt32(&b.warmingUp, 1)
+		b.warmupDone = make(chan struct{})
+		warmupTimer = time.AfterFunc(b.conf.warmup, b.endWarmup)
+	}
+	b.workersDone = make(chan struct{})
+	go func() {
+		b.workers.Wait()
+		close(b.workersDone)
+	}()
+	go b.spawnWorkers()
+	go b.rateMeter()
+	go b.barUpdater()
+	var kneeDone chan struct{}
+	if b.conf.kneeLatency != nil {
+		kneeDone = make(chan struct{})
+		go b.detectKnee(kneeDone)
+	}
+	b.waitForWorkers()
+	if warmupTimer != nil && !warmupTimer.Stop() {
+		<-b.warmupDone
+	}
+	b.timeTaken = time.Since(b.measureBegin) -
+		(b.pauser.pausedFor() - b.pausedBeforeMeasure)
+	if b.samples != nil {
+		if err := b.samples.close(); err != nil {
+			fmt.Fprintln(b.errOut, err)
+		}
+	}
+	for _, cl := range b.clients {
+		if gc, ok := cl.(*grpcClient); ok {
+			if err := gc.Close(); err != nil {
+				fmt.Fprintln(b.errOut, err)
+			}
+		}
+	}
+	if b.dumper != nil {
+		if err := b.dumper.close(); err != nil {
+			fmt.Fprintln(b.errOut, err)
+		}
+	}
+	<-b.doneChan
+	<-b.doneChan
+	if kneeDone != nil {
+		<-kneeDone
+	}
+	if b.metrics != nil {
+		if err := b.metrics.shutdown(); err != nil {
+			fmt.Fprintln(b.errOut, err)
+		}
+	}
+}
+
+// waitForWorkers waits for all the workers to finish, unless the test
+// is interrupted, in which case in-flight requests are only given the
+// grace period to complete.
+func (b *bombardier) waitForWorkers() {
+	select {
+	case <-b.workersDone:
+		return
+	case <-b.interrupted:
+	}
+	timer := time.NewTimer(b.gracePeriod)
+	defer timer.Stop()
+	select {
+	case <-b.workersDone:
+	case <-timer.C:
+		b.resultsMu.Lock()
+		b.abandoned = true
+		b.resultsMu.Unlock()
+	}
+}
+
+// interrupt stops sending new requests, results gathered so far are
+// reported as partial ones.
+func (b *bombardier) interrupt() {
+	b.interruptOnce.Do(func() {
+		close(b.interrupted)
+		b.barrier.cancel()
+	})
+}
+
+func (b *bombardier) isInterrupted() bool {
+	select {
+	case <-b.interrupted:
+		return true
+	default:
+		return false
+	}
+}
+
+// endWarmup starts recording of latencies and request rates and
+// resets the counters, if requested to.
+func (b *bombardier) endWarmup() {
+	defer close(b.warmupDone)
+	if b.conf.resetAfterWarmup {
+		for _, counter := range []*uint64{
+			&b.req1xx, &b.req2xx, &b.req3xx, &b.req4xx, &b.req5xx,
+			&b.req502, &b.others, &b.newConns, &b.reusedConns,
+			&b.connsOpened, &b.redirects,
+		} {
+			atomic.StoreUint64(counter, 0)
+		}
+		atomic.StoreInt64(&b.bytesRead, 0)
+		atomic.StoreInt64(&b.bytesWritten, 0)
+		b.statusCodesMutex.Lock()
+		b.statusCodes = make(map[int]uint64)
+		b.statusCodesMutex.Unlock()
+		if b.bodyDir != nil {
+			b.bodyDir.reset()
+		}
+		b.measureBegin = time.Now()
+		b.pausedBeforeMeasure = b.pauser.pausedFor()
+	}
+	b.rpl.Lock()
+	b.reqs = 0
+	b.start = time.Now()
+	b.rpl.Unlock()
+	b.inFlight.resetMax()
+	if b.timeSeries != nil {
+		b.timeSeries.reset(time.Since(b.measureBegin))
+	}
+	atomic.StoreInt32(&b.warmingUp, 0)
+}
+
+func (b *bombardier) printIntro() {
+	target := strings.Join(b.conf.targets(), ", ")
+	switch b.conf.testType() {
+	case counted:
+		fmt.Fprintf(b.out,
+			"Bombarding %v with %v request(s) using %v connection(s)\n",
+			target, *b.conf.numReqs, b.conf.numConns)
+	case timed:
+		fmt.Fprintf(b.out, "Bombarding %v for %v using %v connection(s)\n",
+			target, *b.conf.duration, b.conf.numConns)
+	case timedOrCounted:
+		fmt.Fprintf(b.out,
+			"Bombarding %v with %v request(s) or for %v, whichever "+
+				"comes first, using %v connection(s)\n",
+			target, *b.conf.numReqs, *b.conf.duration, b.conf.numConns)
+	}
+	if g := b.conf.grpc; g != nil {
+		fmt.Fprintf(b.out, "Calling gRPC method %v described in %v\n",
+			g, g.protoSet)
+	}
+	if w := b.conf.bodyWarning(); w != "" {
+		fmt.Fprintln(b.out, w)
+	}
+	if b.conf.scenario != nil {
+		fmt.Fprintf(b.out, "Following scenario %v: %v\n",
+			b.conf.scenario.path,
+			strings.Join(b.conf.scenario.names(), " -> "))
+	}
+	if r := b.conf.replay; r != nil {
+		fmt.Fprintf(b.out, "Replaying %v request(s) from %v at %vx speed\n",
+			len(r.entries), r.path, r.speed)
+	}
+	if p := b.bodyPool; p != nil {
+		fmt.Fprintf(b.out, "Picking bodies from %v generated ones of %v\n",
+			len(p.bodies), p.size)
+	}
+	if d := b.bodyDir; d != nil {
+		fmt.Fprintf(b.out, "Picking bodies from %v file(s) in %v\n",
+			len(d.bodies), d.path)
+		if d.large() {
+			fmt.Fprintf(b.out, "Warning: bodies take up %v of memory\n",
+				formatBinary(float64(d.size)))
+		}
+	}
+	if b.conf.unixSocket != "" {
+		fmt.Fprintf(b.out, "Connecting to %v\n", b.conf.unixSocket)
+	}
+	if s := b.conf.sigV4; s != nil {
+		fmt.Fprintf(b.out, "Signing requests with AWS SigV4 for %v "+
+			"in %v\n", s.service, s.region)
+	}
+	if b.conf.proxy != "" {
+		fmt.Fprintf(b.out, "Connecting through proxy %v\n",
+			redactedProxy(b.conf.proxy))
+	}
+	if b.conf.localAddrs != nil {
+		fmt.Fprintf(b.out, "Connecting from %v\n",
+			strings.Join(*b.conf.localAddrs, ", "))
+	}
+	if b.conf.disableKeepAlive {
+		fmt.Fprintln(b.out, "Keep-alive disabled, every request "+
+			"is sent over a new connection")
+	}
+	if b.dumper != nil {
+		fmt.Fprintf(b.out, "Dumping the first %v failed request(s) "+
+			"into %v\n", b.conf.dumpErrors, b.conf.dumpErrorsPath)
+	}
+	if b.conf.rampUp > 0 {
+		fmt.Fprintf(b.out, "Ramping up connections over %v\n", b.conf.rampUp)
+	}
+	if b.conf.warmup > 0 {
+		fmt.Fprintf(b.out, "Warming up for %v\n", b.conf.warmup)
+	}
+	if r := b.resolution; r != nil && r.digits > 0 {
+		fmt.Fprintf(b.out, "Latencies are recorded with %v significant "+
+			"digit(s)\n", r.digits)
+	}
+	if c := b.conf.latencyCeiling; c > 0 {
+		if b.conf.timeout == 0 || c < b.conf.timeout ||
+			b.conf.correctLatency {
+			fmt.Fprintf(b.out, "Warning: latencies above %v are clamped "+
+				"to it, while requests may take longer\n", c)
+		}
+	}
+	if b.conf.rate != nil {
+		scope := "in total"
+		if b.conf.ratePerConn {
+			scope = "per connection"
+		}
+		fmt.Fprintf(b.out, "Rate limited to %v request(s) per second %v\n",
+			*b.conf.rate, scope)
+	}
+	if b.conf.loadProfile != nil {
+		fmt.Fprintf(b.out, "Rate follows load profile %v\n",
+			b.conf.loadProfile)
+	}
+	if b.conf.arrival == poissonArrival {
+		fmt.Fprintln(b.out, "Requests arrive as a Poisson process")
+	}
+	if b.conf.correctLatency {
+		fmt.Fprintln(b.out, "Latency is measured from the time "+
+			"requests were scheduled for")
+	}
+	if b.conf.thinkTime != nil {
+		fmt.Fprintf(b.out, "Connections pause for %v between requests\n",
+			b.conf.thinkTime)
+	}
+	if b.conf.jitter > 0 {
+		fmt.Fprintf(b.out, "Requests are delayed by up to %v at random\n",
+			b.conf.jitter)
+	}
+	if b.conf.serverTimeHeader != "" {
+		fmt.Fprintf(b.out, "Server processing time is read from %v "+
+			"header\n", b.conf.serverTimeHeader)
+	}
+	if b.conf.alpn != nil {
+		fmt.Fprintf(b.out, "Advertising %v with ALPN\n",
+			strings.Join(*b.conf.alpn, ", "))
+	}
+	if b.conf.maxConnsPerHost > 0 {
+		fmt.Fprintf(b.out, "Limited to %v connection(s) per host\n",
+			b.conf.maxConnsPerHost)
+	}
+}
+
+func (b *bombardier) gatherInfo() internal.TestInfo {
+	info := internal.TestInfo{
+		Spec: internal.Spec{
+			NumberOfConnections: b.conf.numConns,
+
+			Method: b.conf.method,
+			URL:    b.conf.url,
+
+			Body:         b.conf.body,
+			BodyFilePath: b.conf.bodyFilePath,
+			BodyDir:      b.conf.bodyDir,
+			CompressBody: b.conf.compressBody,
+
+			CertPath: b.conf.certPath,
+			KeyPath:  b.conf.keyPath,
+
+			CertFromMemory: b.conf.certPEM != "" || b.conf.keyPEM != "",
+			CACertPath:     b.conf.caCertPath,
+			SNI:            b.conf.sni,
+			Insecure:       b.conf.insecure,
+
+			BodyTemplate: b.conf.bodyTemplate,
+			Cookies:      b.conf.cookies,
+			PinDNS:       b.conf.pinDNS,
+			UnixSocket:   b.conf.unixSocket,
+
+			Stream:     b.conf.stream,
+			Timeout:    b.conf.timeout,
+			ClientType: internal.ClientType(b.conf.clientType),
+			RampUp:     b.conf.rampUp,
+			Warmup:     b.conf.warmup,
+
+			ConnectTimeout: b.conf.connectTimeout,
+
+			ResetAfterWarmup: b.conf.resetAfterWarmup,
+			PrimeConnections: b.conf.primeConns,
+
+			Percentiles: b.conf.percentilesOrDefault(),
+
+			Rate:        b.conf.rate,
+			RatePerConn: b.conf.ratePerConn,
+
+			AbortErrorRate: b.conf.abortErrorRate,
+			MaxBytes:       b.conf.maxBytes,
+
+			Seed: b.seed,
+		},
+		Result: internal.Results{
+			BytesRead:    atomic.LoadInt64(&b.bytesRead),
+			BytesWritten: atomic.LoadInt64(&b.bytesWritten),
+
+			HeaderBytesRead: atomic.LoadInt64(&b.headerBytesRead),
+			BodyBytesRead:   atomic.LoadInt64(&b.bodyBytesRead),
+			TimeTaken:       b.timeTaken,
+
+			Req1XX:      b.req1xx,
+			Req2XX:      b.req2xx,
+			Req3XX:      b.req3xx,
+			Req4XX:      b.req4xx,
+			Req5XX:      b.req5xx,
+			Req502:      b.req502,
+			Others:      b.others,
+			StatusCodes: b.statusCodes,
+
+			Retries:   b.retries,
+			Redirects: b.redirects,
+			Aborted:   atomic.LoadInt32(&b.aborted) != 0,
+
+			BytesCapReached: atomic.LoadInt32(&b.bytesCapped) != 0,
+
+			Interrupted: b.isInterrupted(),
+
+			NewConns:    b.newConns,
+			ReusedConns: b.reusedConns,
+			ConnsOpened: atomic.LoadUint64(&b.connsOpened),
+			PrimingTime: b.primingTime,
+
+			MaxInFlight: b.inFlight.maximum(),
+			InFlight:    b.inFlight.samples,
+
+			Latencies: b.latencies,
+			TTFB:      b.ttfb,
+			Requests:  b.requests,
+
+			ResponseSizes: b.responseSizes,
+			Handshakes:    b.handshakes,
+		},
+	}
+	if b.requestSizes != nil {
+		info.Spec.BodySize = b.conf.bodySize.String()
+		info.Result.RequestSizes = b.requestSizes
+	}
+	if b.interArrivals != nil {
+		info.Spec.InterArrival = true
+		info.Result.InterArrivals = b.interArrivals
+	}
+	if b.serverTimes != nil {
+		info.Spec.ServerTimeHeader = b.conf.serverTimeHeader
+		info.Result.ServerTimes = b.serverTimes
+		info.Result.ServerTimeErrors = atomic.LoadUint64(&b.serverTimeErrors)
+	}
+
+	testType := b.conf.testType()
+	info.Spec.TestType = internal.TestType(testType)
+	if testType.limitsTime() {
+		info.Spec.TestDuration = *b.conf.duration
+	}
+	if testType.limitsRequests() {
+		info.Spec.NumberOfRequests = *b.conf.numReqs
+	}
+	if c, ok := b.barrier.(*combinedCompletionBarrier); ok {
+		info.Result.StoppedBy = internal.TestType(c.stoppedBy())
+	}
+
+	if b.conf.urls != nil {
+		info.Spec.URLs = *b.conf.urls
+	}
+	if g := b.conf.grpc; g != nil {
+		info.Spec.GRPC = &internal.GRPC{
+			Method:   g.String(),
+			ProtoSet: g.protoSet,
+		}
+	}
+	if b.conf.scenario != nil {
+		info.Spec.Scenario = b.conf.scenario.path
+	}
+	if r := b.conf.replay; r != nil {
+		info.Spec.Replay = &internal.Replay{
+			Path:   r.path,
+			Format: r.format,
+			Speed:  r.speed,
+		}
+	}
+	if b.resolver != nil {
+		info.Result.RemoteAddresses = b.resolver.addresses()
+	}
+	if b.conf.proxy != "" {
+		info.Spec.Proxy = redactedProxy(b.conf.proxy)
+	}
+	if b.conf.localAddrs != nil {
+		info.Spec.LocalAddrs = *b.conf.localAddrs
+	}
+	if b.hostConns != nil {
+		info.Spec.MaxConnsPerHost = b.conf.maxConnsPerHost
+		info.Result.HostConns = b.hostConns.peaks()
+	}
+	if b.bodyDir != nil {
+		info.Result.BodyFiles = b.bodyDir.counts()
+	}
+	info.Spec.BodyGenerated = b.conf.bodyGenerator != nil
+	if b.conf.loadProfile != nil {
+		info.Spec.LoadProfile = b.conf.loadProfile.String()
+	}
+	if b.conf.thinkTime != nil {
+		info.Spec.ThinkTime = b.conf.thinkTime.String()
+	}
+	info.Spec.Jitter = b.conf.jitter
+	if b.conf.arrival != uniformArrival {
+		info.Spec.Arrival = b.conf.arrival.String()
+	}
+	info.Spec.CorrectedLatency = b.conf.correctLatency
+	info.Spec.DisableKeepAlive = b.conf.disableKeepAlive
+	if b.conf.kneeLatency != nil {
+		info.Spec.KneeLatency = b.conf.kneeLatency.String()
+		info.Result.Knee = b.knee
+	}
+	if b.latenciesByClass != nil {
+		info.Result.LatenciesByStatusClass =
+			make(map[int]internal.ReadonlyUint64Histogram)
+		for class, h := range b.latenciesByClass {
+			info.Result.LatenciesByStatusClass[class] = h
+		}
+	}
+	if b.conf.expectedStatuses != nil {
+		info.Spec.ExpectedStatusCodes = *b.conf.expectedStatuses
+	}
+	if b.conf.retries > 0 {
+		info.Spec.Retries = b.conf.retries
+		info.Spec.LatencyWithRetries = b.conf.latencyWithRetries
+	}
+	if b.conf.alpn != nil {
+		info.Spec.ALPN = *b.conf.alpn
+	}
+	if b.conf.maxRedirects > 0 {
+		info.Spec.MaxRedirects = b.conf.maxRedirects
+		info.Spec.FinalHopLatency = b.conf.finalHopLatency
+	}
+
+	if b.conf.weights != nil {
+		for i, w := range *b.conf.weights {
+			info.Spec.WeightedURLs = append(info.Spec.WeightedURLs,
+				internal.WeightedURL{
+					URL:    info.Spec.URLs[i],
+					Weight: w,
+				})
+		}
+	}
+
+	if b.conf.headers != nil {
+		for _, h := range *b.conf.headers {
+			info.Spec.Headers = append(info.Spec.Headers,
+				internal.Header{
+					Key:   h.key,
+					Value: h.value,
+				})
+		}
+	}
+	if b.conf.resolve != nil {
+		for _, o := range *b.conf.resolve {
+			info.Spec.Resolve = append(info.Spec.Resolve, o.String())
+		}
+	}
+	if b.conf.form != nil {
+		for _, f := range *b.conf.form {
+			info.Spec.Form = append(info.Spec.Form,
+				internal.FormField{
+					Name:   f.name,
+					Value:  f.value,
+					IsFile: f.isFile,
+				})
+		}
+	}
+	if o := b.conf.oauth2; o != nil {
+		info.Spec.OAuth2 = &internal.OAuth2{
+			TokenURL: o.tokenURL,
+			ClientID: o.clientID,
+			Scope:    o.scope,
+		}
+	}
+	if b.conf.basicAuth != "" {
+		info.Spec.BasicAuthUser = strings.SplitN(b.conf.basicAuth, ":", 2)[0]
+	}
+	if s := b.conf.sigV4; s != nil {
+		info.Spec.SigV4 = &internal.SigV4{
+			AccessKey: s.accessKey,
+			Region:    s.region,
+			Service:   s.service,
+		}
+	}
+	if b.conf.headerTemplates != nil {
+		for _, h := range *b.conf.headerTemplates {
+			info.Spec.Headers = append(info.Spec.Headers,
+				internal.Header{
+					Key:        h.key,
+					Value:      h.value,
+					IsTemplate: true,
+				})
+		}
+	}
+	if b.conf.randomHeaders != nil {
+		for _, h := range *b.conf.randomHeaders {
+			info.Spec.Headers = append(info.Spec.Headers,
+				internal.Header{
+					Key:         h.key,
+					Value:       h.value,
+					Probability: h.probability,
+				})
+		}
+	}
+
+	for _, us := range b.urlStats {
+		res := us.results()
+		res.InterpolatePercentiles = b.conf.interpolatePercentiles
+		info.Result.URLs = append(info.Result.URLs, res)
+	}
+	if b.connStats != nil {
+		info.Spec.ConnStats = true
+		info.Result.Conns = make([]internal.ConnResults, len(b.connStats))
+		for i := range b.connStats {
+			info.Result.Conns[i] = b.connStats[i].results(uint64(i))
+		}
+	}
+	info.Result.InterpolatePercentiles = b.conf.interpolatePercentiles
+	info.Spec.LatencyDigits = b.conf.latencyDigits
+	info.Spec.LatencyCeiling = b.conf.latencyCeiling
+	if b.resolution != nil {
+		info.Result.ClampedLatencies = b.resolution.clampedCount()
+	}
+
+	for _, ewc := range b.errors.byFrequency() {
+		info.Result.Errors = append(info.Result.Errors,
+			internal.ErrorWithCount{
+				Error: ewc.error,
+				Count: ewc.count,
+			})
+	}
+	info.Result.ErrorsByCategory = b.errors.byCategory()
+	if b.timeSeries != nil {
+		info.Result.TimeSeries = b.timeSeries.points
+	}
+
+	return info
+}
+
+func (b *bombardier) printStats() {
+	info := b.gatherInfo()
+	err := b.template.Execute(b.out, info)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+}
+
+func (b *bombardier) writeCSV() error {
+	f, err := os.OpenFile(
+		b.conf.csvPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644,
+	)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	p := internal.NewCSVPrinter(b.conf.percentilesOrDefault())
+	if fi.Size() == 0 {
+		if err = p.WriteHeader(f); err != nil {
+			return err
+		}
+	}
+	return p.WriteRow(f, b.gatherInfo())
+}
+
+func (b *bombardier) writeHdr() error {
+	f, err := os.Create(b.conf.hdrPath)
+	if err != nil {
+		return err
+	}
+	err = internal.WriteHdrHistogramLog(
+		f, b.gatherInfo().Result, b.measureBegin,
+	)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func (b *bombardier) redirectOutputTo(out io.Writer) {
+	b.bar.Output = out
+	b.out, b.errOut = out, out
+}
+
+func (b *bombardier) disableOutput() {
+	b.redirectOutputTo(ioutil.Discard)
+	b.bar.NotPrint = true
+}
+
+// Main runs bombardier command line utility with arguments from
+// os.Args and exits with its exit code, it's all bombardier binary
+// does. v is the version the utility reports.
+func Main(v string) {
+	version = v
+	if len(os.Args) > 1 && os.Args[1] == compareCommand {
+		regressed, err := runCompare(os.Args[2:], os.Stdout)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(exitFailure)
+		}
+		if regressed {
+			os.Exit(exitFailure)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == mergeCommand {
+		if err := runMerge(os.Args[2:], os.Stdout); err != nil {
+			fmt.Println(err)
+			os.Exit(exitFailure)
+		}
+		return
+	}
+	cfg, err := parser.parse(os.Args)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(exitFailure)
+	}
+	bombardier, err := newBombardier(cfg)
+	if err != nil {
+		if cfg.quiet {
+			fmt.Fprintln(os.Stderr, err)
+		} else {
+			fmt.Println(err)
+		}
+		os.Exit(exitFailure)
+	}
+	if cfg.dryRun {
+		if err := bombardier.dryRun(); err != nil {
+			fmt.Fprintln(bombardier.errOut, err)
+			os.Exit(exitFailure)
+		}
+		return
+	}
+	if cfg.smoke {
+		if err := bombardier.smoke(); err != nil {
+			fmt.Fprintln(bombardier.errOut, err)
+			os.Exit(exitFailure)
+		}
+	}
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt)
+	go func() {
+		<-c
+		bombardier.interrupt()
+		// Impatient users get out right away
+		<-c
+		os.Exit(exitInterrupted)
+	}()
+	listenForPauseSignals(&bombardier.pauser)
+	bombardier.bombard()
+	if bombardier.conf.printResult {
+		bombardier.printStats()
+	}
+	bombardier.printPortsExhaustedHint()
+	if bombardier.conf.csvPath != "" {
+		if err := bombardier.writeCSV(); err != nil {
+			fmt.Fprintln(bombardier.errOut, err)
+			os.Exit(exitFailure)
+		}
+	}
+	if bombardier.conf.hdrPath != "" {
+		if err := bombardier.writeHdr(); err != nil {
+			fmt.Fprintln(bombardier.errOut, err)
+			os.Exit(exitFailure)
+		}
+	}
+	if bombardier.conf.junitPath != "" {
+		if err := bombardier.writeJUnit(); err != nil {
+			fmt.Fprintln(bombardier.errOut, err)
+			os.Exit(exitFailure)
+		}
+	}
+	if bombardier.conf.influxURL != "" {
+		if err := bombardier.pushInflux(); err != nil {
+			fmt.Fprintln(bombardier.errOut,
+				"Failed to push results to InfluxDB:", err)
+		}
+	}
+	if bombardier.conf.syslog {
+		if err := bombardier.writeSyslog(os.Stderr); err != nil {
+			fmt.Fprintln(bombardier.errOut, "Failed to write results "+
+				"to syslog, written to stderr instead:", err)
+		}
+	}
+	failed := atomic.LoadUint64(&bombardier.unexpected) > 0 ||
+		atomic.LoadInt32(&bombardier.aborted) != 0
+	if breaches := bombardier.checkLatencyAssertions(); len(breaches) > 0 {
+		bombardier.printLatencyBreaches(breaches)
+		failed = true
+	}
+	if bombardier.isInterrupted() {
+		os.Exit(exitInterrupted)
+	}
+	if failed {
+		os.Exit(exitFailure)
+	}
+}
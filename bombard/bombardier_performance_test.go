@@ -1,4 +1,4 @@
-package main
+package bombard
 
 import (
 	"flag"
@@ -63,7 +63,7 @@ func benchmarkFireRequest(c config, bm *testing.B) {
 		done := b.barrier.done()
 		for pb.Next() {
 			b.ratelimiter.pace(done)
-			b.performSingleRequest()
+			b.performSingleRequest(0, time.Time{})
 		}
 	})
 }
@@ -0,0 +1,4305 @@
+package bombard
+
+import (
+	"bytes"
+	"compress/gzip"
+	"container/ring"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kostyay/bombardier/internal"
+	"github.com/valyala/fasthttp"
+)
+
+func TestBombardierShouldFireSpecifiedNumberOfRequests(t *testing.T) {
+	testAllClients(t, testBombardierShouldFireSpecifiedNumberOfRequests)
+}
+
+func testBombardierShouldFireSpecifiedNumberOfRequests(
+	clientType clientTyp, t *testing.T,
+) {
+	reqsReceived := uint64(0)
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			atomic.AddUint64(&reqsReceived, 1)
+		}),
+	)
+	defer s.Close()
+	numReqs := uint64(100)
+	noHeaders := new(headersList)
+	b, e := newBombardier(config{
+		numConns:   defaultNumberOfConns,
+		numReqs:    &numReqs,
+		url:        s.URL,
+		headers:    noHeaders,
+		timeout:    defaultTimeout,
+		method:     "GET",
+		body:       "",
+		clientType: clientType,
+		format:     knownFormat("plain-text"),
+	})
+	if e != nil {
+		t.Error(e)
+	}
+	b.disableOutput()
+	b.bombard()
+	if reqsReceived != numReqs {
+		t.Fail()
+	}
+}
+
+func TestBombardierStopsAtWhicheverLimitComesFirst(t *testing.T) {
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/slow" {
+				time.Sleep(100 * time.Millisecond)
+			}
+		}),
+	)
+	defer s.Close()
+	numReqs := uint64(100)
+	duration := time.Second
+	expectations := []struct {
+		path      string
+		stoppedBy internal.TestType
+		plain     string
+		json      string
+	}{
+		{
+			"/", internal.ByNumberOfReqs,
+			"Stopped: all 100 requests completed",
+			`"stoppedBy":"number-of-requests"`,
+		},
+		{
+			"/slow", internal.ByTime,
+			"Stopped: 1s elapsed before all requests completed",
+			`"stoppedBy":"timed"`,
+		},
+	}
+	for _, e := range expectations {
+		b, err := newBombardier(config{
+			numConns:    2,
+			numReqs:     &numReqs,
+			duration:    &duration,
+			url:         s.URL + e.path,
+			headers:     new(headersList),
+			timeout:     defaultTimeout,
+			method:      "GET",
+			clientType:  fhttp,
+			printResult: true,
+			format:      knownFormat("plain-text"),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.disableOutput()
+		b.bombard()
+		info := b.gatherInfo()
+		if !info.Spec.IsTestByTimeOrNumberOfReqs() {
+			t.Errorf("%v: unexpected test type %v", e.path, info.Spec.TestType)
+		}
+		if info.Result.StoppedBy != e.stoppedBy {
+			t.Errorf("%v: expected to be stopped by %v, but got %v",
+				e.path, e.stoppedBy, info.Result.StoppedBy)
+		}
+		completed := info.Result.NumberOfRequests()
+		if e.stoppedBy == internal.ByNumberOfReqs && completed != numReqs ||
+			e.stoppedBy == internal.ByTime && completed >= numReqs {
+			t.Errorf("%v: unexpected number of requests %v", e.path, completed)
+		}
+		for format, exp := range map[string]string{
+			"plain-text": e.plain,
+			"json":       e.json,
+		} {
+			b.conf.format = knownFormat(format)
+			if b.template, err = b.prepareTemplate(); err != nil {
+				t.Fatal(err)
+			}
+			out := new(bytes.Buffer)
+			b.redirectOutputTo(out)
+			b.printStats()
+			if !strings.Contains(out.String(), exp) {
+				t.Errorf("%v: expected %q in %q", e.path, exp, out)
+			}
+		}
+	}
+}
+
+func TestBombardierShouldFinish(t *testing.T) {
+	testAllClients(t, testBombardierShouldFinish)
+}
+
+func testBombardierShouldFinish(clientType clientTyp, t *testing.T) {
+	reqsReceived := uint64(0)
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			atomic.AddUint64(&reqsReceived, 1)
+		}),
+	)
+	defer s.Close()
+	noHeaders := new(headersList)
+	desiredTestDuration := 1 * time.Second
+	b, e := newBombardier(config{
+		numConns:   defaultNumberOfConns,
+		duration:   &desiredTestDuration,
+		url:        s.URL,
+		headers:    noHeaders,
+		timeout:    defaultTimeout,
+		method:     "GET",
+		body:       "",
+		clientType: clientType,
+		format:     knownFormat("plain-text"),
+	})
+	if e != nil {
+		t.Error(e)
+	}
+	b.disableOutput()
+	waitCh := make(chan struct{})
+	go func() {
+		b.bombard()
+		waitCh <- struct{}{}
+	}()
+	select {
+	case <-waitCh:
+	// Do nothing here
+	case <-time.After(desiredTestDuration + 5*time.Second):
+		t.Fail()
+	}
+	if reqsReceived == 0 {
+		t.Fail()
+	}
+}
+
+func TestBombardierShouldSendHeaders(t *testing.T) {
+	testAllClients(t, testBombardierShouldSendHeaders)
+}
+
+func testBombardierShouldSendHeaders(clientType clientTyp, t *testing.T) {
+	requestHeaders := headersList([]header{
+		{"Header1", "Value1"},
+		{"Header-Two", "value-two"},
+	})
+
+	// It's a bit hacky, but FastHTTP can't send Host header correctly
+	// as of now
+	if clientType != fhttp {
+		requestHeaders = append(requestHeaders, header{"Host", "web"})
+	}
+
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			for _, h := range requestHeaders {
+				av := r.Header.Get(h.key)
+				if h.key == "Host" {
+					av = r.Host
+				}
+				if av != h.value {
+					t.Logf("%q <-> %q", av, h.value)
+					t.Fail()
+				}
+			}
+		}),
+	)
+	defer s.Close()
+	numReqs := uint64(1)
+	b, e := newBombardier(config{
+		numConns:   defaultNumberOfConns,
+		numReqs:    &numReqs,
+		url:        s.URL,
+		headers:    &requestHeaders,
+		timeout:    defaultTimeout,
+		method:     "GET",
+		body:       "",
+		clientType: clientType,
+		format:     knownFormat("plain-text"),
+	})
+	if e != nil {
+		t.Error(e)
+	}
+	b.disableOutput()
+	b.bombard()
+}
+
+func TestBombardierHTTPCodeRecording(t *testing.T) {
+	testAllClients(t, testBombardierHTTPCodeRecording)
+}
+
+func testBombardierHTTPCodeRecording(clientType clientTyp, t *testing.T) {
+	cs := []int{200, 302, 404, 505, 606, 707}
+	codes := ring.New(len(cs))
+	for _, v := range cs {
+		codes.Value = v
+		codes = codes.Next()
+	}
+	codes = codes.Next()
+	var m sync.Mutex
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			m.Lock()
+			nextCode := codes.Value.(int)
+			codes = codes.Next()
+			m.Unlock()
+			if nextCode/100 == 3 {
+				rw.Header().Set("Location", "http://localhost:666")
+			}
+			rw.WriteHeader(nextCode)
+		}),
+	)
+	defer s.Close()
+	eachCodeCount := uint64(10)
+	numReqs := uint64(len(cs)) * eachCodeCount
+	b, e := newBombardier(config{
+		numConns:   defaultNumberOfConns,
+		numReqs:    &numReqs,
+		url:        s.URL,
+		headers:    new(headersList),
+		timeout:    defaultTimeout,
+		method:     "GET",
+		body:       "",
+		clientType: clientType,
+		format:     knownFormat("plain-text"),
+	})
+	if e != nil {
+		t.Error(e)
+	}
+	b.disableOutput()
+	b.bombard()
+	expectation := []struct {
+		name     string
+		reqsGot  uint64
+		expected uint64
+	}{
+		{"errored", b.others, eachCodeCount * 2},
+		{"2xx", b.req2xx, eachCodeCount},
+		{"3xx", b.req3xx, eachCodeCount},
+		{"4xx", b.req4xx, eachCodeCount},
+		{"5xx", b.req5xx, eachCodeCount},
+	}
+	for _, e := range expectation {
+		if e.reqsGot != e.expected {
+			t.Error(e.name, e.reqsGot, e.expected)
+		}
+	}
+	t.Logf("%+v", b.errors.byFrequency())
+}
+
+func TestBombardierTimeoutRecoding(t *testing.T) {
+	testAllClients(t, testBombardierTimeoutRecoding)
+}
+
+func testBombardierTimeoutRecoding(clientType clientTyp, t *testing.T) {
+	shortTimeout := 10 * time.Millisecond
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			time.Sleep(shortTimeout * 2)
+		}),
+	)
+	defer s.Close()
+	numReqs := uint64(10)
+	b, e := newBombardier(config{
+		numConns:   defaultNumberOfConns,
+		numReqs:    &numReqs,
+		duration:   nil,
+		url:        s.URL,
+		headers:    new(headersList),
+		timeout:    shortTimeout,
+		method:     "GET",
+		body:       "",
+		clientType: clientType,
+		format:     knownFormat("plain-text"),
+	})
+	if e != nil {
+		t.Error(e)
+	}
+	b.disableOutput()
+	b.bombard()
+	if b.errors.sum() != numReqs {
+		t.Fail()
+	}
+}
+
+func TestBombardierThroughputRecording(t *testing.T) {
+	testAllClients(t, testBombardierThroughputRecording)
+}
+
+func testBombardierThroughputRecording(clientType clientTyp, t *testing.T) {
+	responseSize := 1024
+	response := bytes.Repeat([]byte{'a'}, responseSize)
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			_, err := rw.Write(response)
+			if err != nil {
+				t.Error(err)
+			}
+		}),
+	)
+	defer s.Close()
+	numReqs := uint64(10)
+	b, e := newBombardier(config{
+		numConns:   defaultNumberOfConns,
+		numReqs:    &numReqs,
+		url:        s.URL,
+		headers:    new(headersList),
+		timeout:    defaultTimeout,
+		method:     "GET",
+		body:       "",
+		clientType: clientType,
+		format:     knownFormat("plain-text"),
+	})
+	if e != nil {
+		t.Error(e)
+	}
+	b.disableOutput()
+	b.bombard()
+	if b.bytesRead == 0 || b.bytesWritten == 0 {
+		t.Error(b.bytesRead, b.bytesWritten)
+	}
+}
+
+func TestBombardierStatsPrinting(t *testing.T) {
+	responseSize := 1024
+	response := bytes.Repeat([]byte{'a'}, responseSize)
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			_, err := rw.Write(response)
+			if err != nil {
+				t.Error(err)
+			}
+		}),
+	)
+	defer s.Close()
+	numReqs := uint64(10)
+	b, e := newBombardier(config{
+		numConns:       defaultNumberOfConns,
+		numReqs:        &numReqs,
+		url:            s.URL,
+		headers:        new(headersList),
+		timeout:        defaultTimeout,
+		method:         "GET",
+		body:           "",
+		printLatencies: true,
+		printIntro:     true,
+		printProgress:  true,
+		printResult:    true,
+		format:         knownFormat("plain-text"),
+	})
+	if e != nil {
+		t.Error(e)
+		return
+	}
+	dummy := errors.New("dummy error")
+	b.errors.add(dummy)
+
+	out := new(bytes.Buffer)
+	b.redirectOutputTo(out)
+	b.bombard()
+
+	b.printStats()
+	l := out.Len()
+	// Here we only test if anything is written
+	if l == 0 {
+		t.Fail()
+	}
+}
+
+func TestBombardierErrorIfFailToReadClientCert(t *testing.T) {
+	numReqs := uint64(10)
+	_, e := newBombardier(config{
+		numConns:       defaultNumberOfConns,
+		numReqs:        &numReqs,
+		url:            "http://localhost",
+		headers:        new(headersList),
+		timeout:        defaultTimeout,
+		method:         "GET",
+		body:           "",
+		printLatencies: true,
+		certPath:       "certPath",
+		keyPath:        "keyPath",
+		format:         knownFormat("plain-text"),
+	})
+	if e == nil {
+		t.Fail()
+	}
+}
+
+func TestBombardierClientCerts(t *testing.T) {
+	testAllClients(t, testBombardierClientCerts)
+}
+
+func testBombardierClientCerts(clientType clientTyp, t *testing.T) {
+	clientCert, err := tls.LoadX509KeyPair("testclient.cert", "testclient.key")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	x509Cert, err := x509.ParseCertificate(clientCert.Certificate[0])
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	server := fasthttp.Server{
+		DisableKeepalive: true,
+		Handler: func(ctx *fasthttp.RequestCtx) {
+			certs := ctx.TLSConnectionState().PeerCertificates
+			if numCerts := len(certs); numCerts != 1 {
+				t.Errorf("expected 1 cert, but got %v", numCerts)
+				ctx.Error("invalid number of certs", http.StatusBadRequest)
+				return
+			}
+
+			cert := certs[0]
+			if !cert.Equal(x509Cert) {
+				t.Error("certificates don't match")
+				ctx.Error("wrong cert", http.StatusBadRequest)
+				return
+			}
+
+			ctx.Success("text/plain; charset=utf-8", []byte("OK"))
+		},
+	}
+
+	ln, err := net.Listen("tcp", "localhost:8080")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	go func() {
+		serr := server.ServeTLS(ln, "testserver.cert", "testserver.key")
+		if serr != nil {
+			t.Error(err)
+		}
+	}()
+
+	numReqs := uint64(1)
+	b, e := newBombardier(config{
+		numConns:       defaultNumberOfConns,
+		numReqs:        &numReqs,
+		url:            "https://localhost:8080/",
+		headers:        new(headersList),
+		timeout:        defaultTimeout,
+		method:         "GET",
+		body:           "",
+		printLatencies: true,
+		certPath:       "testclient.cert",
+		keyPath:        "testclient.key",
+		insecure:       true,
+		clientType:     clientType,
+		format:         knownFormat("plain-text"),
+	})
+	if e != nil {
+		t.Error(e)
+		return
+	}
+	b.disableOutput()
+
+	b.bombard()
+	if b.req2xx != 1 {
+		t.Error("no requests succeeded")
+	}
+
+	err = ln.Close()
+	if err != nil {
+		t.Error(err)
+	}
+	// TODO(codesenberg): remove. Another hacky attempt to fix Travis CI's
+	// slowness
+	time.Sleep(100 * time.Millisecond)
+}
+
+func TestBombardierRateLimiting(t *testing.T) {
+	testAllClients(t, testBombardierRateLimiting)
+}
+
+func testBombardierRateLimiting(clientType clientTyp, t *testing.T) {
+	responseSize := 1024
+	response := bytes.Repeat([]byte{'a'}, responseSize)
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			_, err := rw.Write(response)
+			if err != nil {
+				t.Error(err)
+			}
+		}),
+	)
+	defer s.Close()
+	rate := uint64(5000)
+	testDuration := 1 * time.Second
+	b, e := newBombardier(config{
+		numConns:   defaultNumberOfConns,
+		duration:   &testDuration,
+		url:        s.URL,
+		headers:    new(headersList),
+		timeout:    defaultTimeout,
+		method:     "GET",
+		body:       "",
+		rate:       &rate,
+		clientType: clientType,
+		format:     knownFormat("plain-text"),
+	})
+	if e != nil {
+		t.Error(e)
+		return
+	}
+	b.disableOutput()
+	b.bombard()
+	if float64(b.req2xx) < float64(rate)*0.75 ||
+		float64(b.req2xx) > float64(rate)*1.25 {
+		t.Error(rate, b.req2xx)
+	}
+}
+
+func testAllClients(parent *testing.T, testFun func(clientTyp, *testing.T)) {
+	clients := []clientTyp{fhttp, nhttp1, nhttp2}
+	for _, ct := range clients {
+		parent.Run(ct.String(), func(t *testing.T) {
+			testFun(ct, t)
+		})
+	}
+}
+
+func TestBombardierSendsBody(t *testing.T) {
+	testAllClients(t, testBombardierSendsBody)
+}
+
+func testBombardierSendsBody(clientType clientTyp, t *testing.T) {
+	response := []byte("OK")
+	requestBody := "abracadabra"
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if string(body) != requestBody {
+				t.Errorf("Expected %v, but got %v", requestBody, string(body))
+			}
+			_, err = rw.Write(response)
+			if err != nil {
+				t.Error(err)
+			}
+		}),
+	)
+	defer s.Close()
+	one := uint64(1)
+	b, e := newBombardier(config{
+		numConns:   defaultNumberOfConns,
+		numReqs:    &one,
+		url:        s.URL,
+		headers:    new(headersList),
+		timeout:    defaultTimeout,
+		method:     "POST",
+		body:       requestBody,
+		clientType: clientType,
+		format:     knownFormat("plain-text"),
+	})
+	if e != nil {
+		t.Error(e)
+		return
+	}
+	b.disableOutput()
+	b.bombard()
+}
+
+func TestBombardierSendsBodyFromFile(t *testing.T) {
+	testAllClients(t, testBombardierSendsBodyFromFile)
+}
+
+func testBombardierSendsBodyFromFile(clientType clientTyp, t *testing.T) {
+	response := []byte("OK")
+	bodyPath := "testbody.txt"
+	requestBody, err := ioutil.ReadFile(bodyPath)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if string(body) != string(requestBody) {
+				t.Errorf("Expected %v, but got %v", string(requestBody), string(body))
+			}
+			_, err = rw.Write(response)
+			if err != nil {
+				t.Error(err)
+			}
+		}),
+	)
+	defer s.Close()
+	one := uint64(1)
+	b, e := newBombardier(config{
+		numConns:     defaultNumberOfConns,
+		numReqs:      &one,
+		url:          s.URL,
+		headers:      new(headersList),
+		timeout:      defaultTimeout,
+		method:       "POST",
+		bodyFilePath: bodyPath,
+		clientType:   clientType,
+		format:       knownFormat("plain-text"),
+	})
+	if e != nil {
+		t.Error(e)
+		return
+	}
+	b.disableOutput()
+	b.bombard()
+}
+
+func TestBombardierFileDoesntExist(t *testing.T) {
+	bodyPath := "/does/not/exist.forreal"
+	_, e := newBombardier(config{
+		numConns:     defaultNumberOfConns,
+		url:          "http://example.com",
+		headers:      new(headersList),
+		timeout:      defaultTimeout,
+		method:       "POST",
+		bodyFilePath: bodyPath,
+		format:       knownFormat("plain-text"),
+	})
+	_, ok := e.(*os.PathError)
+	if !ok {
+		t.Errorf("Expected to get PathError, but got %v", e)
+	}
+}
+
+func TestBombardierStreamsBody(t *testing.T) {
+	testAllClients(t, testBombardierStreamsBody)
+}
+
+func testBombardierStreamsBody(clientType clientTyp, t *testing.T) {
+	response := []byte("OK")
+	requestBody := "abracadabra"
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			if te := r.TransferEncoding; !reflect.DeepEqual(te, []string{"chunked"}) {
+				t.Errorf("Expected chunked transfer encoding, but got %v", te)
+			}
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if string(body) != requestBody {
+				t.Errorf("Expected %v, but got %v", requestBody, string(body))
+			}
+			_, err = rw.Write(response)
+			if err != nil {
+				t.Error(err)
+			}
+		}),
+	)
+	defer s.Close()
+	one := uint64(1)
+	b, e := newBombardier(config{
+		numConns:   defaultNumberOfConns,
+		numReqs:    &one,
+		url:        s.URL,
+		headers:    new(headersList),
+		timeout:    defaultTimeout,
+		method:     "POST",
+		body:       requestBody,
+		stream:     true,
+		clientType: clientType,
+		format:     knownFormat("plain-text"),
+	})
+	if e != nil {
+		t.Error(e)
+		return
+	}
+	b.disableOutput()
+	b.bombard()
+}
+
+func TestBombardierStreamsBodyFromFile(t *testing.T) {
+	testAllClients(t, testBombardierStreamsBodyFromFile)
+}
+
+func testBombardierStreamsBodyFromFile(clientType clientTyp, t *testing.T) {
+	response := []byte("OK")
+	bodyPath := "testbody.txt"
+	requestBody, err := ioutil.ReadFile(bodyPath)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			if te := r.TransferEncoding; !reflect.DeepEqual(te, []string{"chunked"}) {
+				t.Errorf("Expected chunked transfer encoding, but got %v", te)
+			}
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if string(body) != string(requestBody) {
+				t.Errorf("Expected %v, but got %v", string(requestBody), string(body))
+			}
+			_, err = rw.Write(response)
+			if err != nil {
+				t.Error(err)
+			}
+		}),
+	)
+	defer s.Close()
+	one := uint64(1)
+	b, e := newBombardier(config{
+		numConns:     defaultNumberOfConns,
+		numReqs:      &one,
+		url:          s.URL,
+		headers:      new(headersList),
+		timeout:      defaultTimeout,
+		method:       "POST",
+		bodyFilePath: bodyPath,
+		stream:       true,
+		clientType:   clientType,
+		format:       knownFormat("plain-text"),
+	})
+	if e != nil {
+		t.Error(e)
+		return
+	}
+	b.disableOutput()
+	b.bombard()
+}
+
+func TestBombardierShouldSendCustomHostHeader(t *testing.T) {
+	testAllClients(t, testBombardierShouldSendCustomHostHeader)
+}
+
+func testBombardierShouldSendCustomHostHeader(
+	clientType clientTyp, t *testing.T,
+) {
+	host := "custom-host"
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			if r.Host != host {
+				t.Errorf("Host must be %q, but it's %q", host, r.Host)
+			}
+		}),
+	)
+	defer s.Close()
+	numReqs := uint64(100)
+	headers := headersList([]header{
+		{"Host", host},
+	})
+	b, e := newBombardier(config{
+		numConns:   defaultNumberOfConns,
+		numReqs:    &numReqs,
+		url:        s.URL,
+		headers:    &headers,
+		timeout:    defaultTimeout,
+		method:     "GET",
+		body:       "",
+		clientType: clientType,
+		format:     knownFormat("plain-text"),
+	})
+	if e != nil {
+		t.Error(e)
+	}
+	b.disableOutput()
+	b.bombard()
+}
+
+func TestBombardierRecordsConnStats(t *testing.T) {
+	testAllClients(t, testBombardierRecordsConnStats)
+}
+
+func testBombardierRecordsConnStats(clientType clientTyp, t *testing.T) {
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {}),
+	)
+	defer s.Close()
+	numReqs := uint64(100)
+	for _, enabled := range []bool{false, true} {
+		b, e := newBombardier(config{
+			numConns:    4,
+			numReqs:     &numReqs,
+			url:         s.URL,
+			headers:     new(headersList),
+			timeout:     defaultTimeout,
+			method:      "GET",
+			clientType:  clientType,
+			printResult: true,
+			format:      knownFormat("plain-text"),
+			connStats:   enabled,
+		})
+		if e != nil {
+			t.Fatal(e)
+		}
+		b.disableOutput()
+		b.bombard()
+		info := b.gatherInfo()
+		if !enabled {
+			if info.Spec.ConnStats || info.Result.Conns != nil {
+				t.Errorf("Unexpected connection stats: %v",
+					info.Result.Conns)
+			}
+			continue
+		}
+		if !info.Spec.ConnStats || len(info.Result.Conns) != 4 {
+			t.Fatalf("Expected stats of 4 connections, but got %v",
+				info.Result.Conns)
+		}
+		total := uint64(0)
+		for i, c := range info.Result.Conns {
+			if c.ConnID != uint64(i) {
+				t.Errorf("Expected connection %v, but got %v", i, c.ConnID)
+			}
+			if c.Errors != 0 || c.Requests > 0 && c.MeanLatency <= 0 {
+				t.Errorf("Unexpected results of connection: %+v", c)
+			}
+			total += c.Requests
+		}
+		if total != numReqs {
+			t.Errorf("Expected %v requests, but got %v", numReqs, total)
+		}
+		out := new(bytes.Buffer)
+		b.redirectOutputTo(out)
+		b.printStats()
+		if exp := "Per connection:\n    #0 "; !strings.Contains(
+			out.String(), exp,
+		) {
+			t.Errorf("Expected %q in %q", exp, out)
+		}
+	}
+}
+
+func TestBombardierDistributesRequestsAmongURLs(t *testing.T) {
+	testAllClients(t, testBombardierDistributesRequestsAmongURLs)
+}
+
+func testBombardierDistributesRequestsAmongURLs(
+	clientType clientTyp, t *testing.T,
+) {
+	var reqsToA, reqsToB uint64
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/a":
+				atomic.AddUint64(&reqsToA, 1)
+			case "/b":
+				atomic.AddUint64(&reqsToB, 1)
+				rw.WriteHeader(http.StatusNotFound)
+			}
+		}),
+	)
+	defer s.Close()
+	numReqs := uint64(100)
+	urls := []string{s.URL + "/a", s.URL + "/b"}
+	b, e := newBombardier(config{
+		numConns:   defaultNumberOfConns,
+		numReqs:    &numReqs,
+		url:        urls[0],
+		urls:       &urls,
+		headers:    new(headersList),
+		timeout:    defaultTimeout,
+		method:     "GET",
+		clientType: clientType,
+		format:     knownFormat("plain-text"),
+	})
+	if e != nil {
+		t.Error(e)
+		return
+	}
+	b.disableOutput()
+	b.bombard()
+	if reqsToA != numReqs/2 || reqsToB != numReqs/2 {
+		t.Errorf("Expected %v requests to each URL, but got %v and %v",
+			numReqs/2, reqsToA, reqsToB)
+	}
+	res := b.gatherInfo().Result.URLs
+	if len(res) != len(urls) {
+		t.Fatalf("Expected results for %v URLs, but got %v", len(urls), len(res))
+	}
+	if res[0].Req2XX != numReqs/2 || res[1].Req4XX != numReqs/2 {
+		t.Errorf("Unexpected per-URL results: %+v", res)
+	}
+}
+
+func TestBombardierSendsTemplatedBody(t *testing.T) {
+	testAllClients(t, testBombardierSendsTemplatedBody)
+}
+
+func testBombardierSendsTemplatedBody(clientType clientTyp, t *testing.T) {
+	var (
+		mu     sync.Mutex
+		bodies = make(map[string]bool)
+	)
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			bodies[string(body)] = true
+			mu.Unlock()
+		}),
+	)
+	defer s.Close()
+	numReqs := uint64(10)
+	b, e := newBombardier(config{
+		numConns:     2,
+		numReqs:      &numReqs,
+		url:          s.URL,
+		headers:      new(headersList),
+		timeout:      defaultTimeout,
+		method:       "POST",
+		body:         "req-{{ .RequestNum }}",
+		bodyTemplate: true,
+		clientType:   clientType,
+		format:       knownFormat("plain-text"),
+	})
+	if e != nil {
+		t.Error(e)
+		return
+	}
+	b.disableOutput()
+	b.bombard()
+	for i := uint64(1); i <= numReqs; i++ {
+		if exp := fmt.Sprintf("req-%v", i); !bodies[exp] {
+			t.Errorf("Body %q wasn't received", exp)
+		}
+	}
+}
+
+func TestBombardierFailsOnInvalidBodyTemplate(t *testing.T) {
+	numReqs := uint64(1)
+	_, e := newBombardier(config{
+		numConns:     defaultNumberOfConns,
+		numReqs:      &numReqs,
+		url:          "http://localhost:8080",
+		headers:      new(headersList),
+		timeout:      defaultTimeout,
+		method:       "POST",
+		body:         "{{ .RequestNum",
+		bodyTemplate: true,
+		format:       knownFormat("plain-text"),
+	})
+	if e == nil {
+		t.Error("invalid body template should fail fast")
+	}
+}
+
+func TestBombardierCountsUnexpectedStatusesAsErrors(t *testing.T) {
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/missing" {
+				rw.WriteHeader(http.StatusNotFound)
+			}
+		}),
+	)
+	defer s.Close()
+	numReqs := uint64(10)
+	urls := []string{s.URL + "/ok", s.URL + "/missing"}
+	b, e := newBombardier(config{
+		numConns:         defaultNumberOfConns,
+		numReqs:          &numReqs,
+		url:              urls[0],
+		urls:             &urls,
+		headers:          new(headersList),
+		timeout:          defaultTimeout,
+		method:           "GET",
+		format:           knownFormat("plain-text"),
+		expectedStatuses: &[]int{200, 201},
+	})
+	if e != nil {
+		t.Error(e)
+		return
+	}
+	b.disableOutput()
+	b.bombard()
+	if b.unexpected != numReqs/2 {
+		t.Errorf("Expected %v unexpected statuses, but got %v",
+			numReqs/2, b.unexpected)
+	}
+	if c := b.errors.get(&unexpectedStatusError{404}); c != numReqs/2 {
+		t.Errorf("Expected %v errors, but got %v", numReqs/2, c)
+	}
+	if b.req2xx != numReqs/2 {
+		t.Errorf("Expected %v 2xx responses, but got %v", numReqs/2, b.req2xx)
+	}
+}
+
+func TestBombardierRateLimitingPerConnection(t *testing.T) {
+	testAllClients(t, testBombardierRateLimitingPerConnection)
+}
+
+func testBombardierRateLimitingPerConnection(
+	clientType clientTyp, t *testing.T,
+) {
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {}),
+	)
+	defer s.Close()
+	rate := uint64(500)
+	numConns := uint64(10)
+	testDuration := 1 * time.Second
+	b, e := newBombardier(config{
+		numConns:    numConns,
+		duration:    &testDuration,
+		url:         s.URL,
+		headers:     new(headersList),
+		timeout:     defaultTimeout,
+		method:      "GET",
+		rate:        &rate,
+		ratePerConn: true,
+		clientType:  clientType,
+		format:      knownFormat("plain-text"),
+	})
+	if e != nil {
+		t.Error(e)
+		return
+	}
+	b.disableOutput()
+	b.bombard()
+	total := float64(rate * numConns)
+	if float64(b.req2xx) < total*0.75 || float64(b.req2xx) > total*1.25 {
+		t.Error(total, b.req2xx)
+	}
+}
+
+func TestBombardierRampsUpConnections(t *testing.T) {
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {}),
+	)
+	defer s.Close()
+	testDuration := 2 * time.Second
+	b, e := newBombardier(config{
+		numConns:   10,
+		duration:   &testDuration,
+		rampUp:     time.Second,
+		url:        s.URL,
+		headers:    new(headersList),
+		timeout:    defaultTimeout,
+		method:     "GET",
+		clientType: fhttp,
+		format:     knownFormat("plain-text"),
+	})
+	if e != nil {
+		t.Error(e)
+		return
+	}
+	b.disableOutput()
+	waitCh := make(chan struct{})
+	go func() {
+		b.bombard()
+		close(waitCh)
+	}()
+	time.Sleep(250 * time.Millisecond)
+	if active := atomic.LoadInt64(&b.activeConns); active < 1 || active > 5 {
+		t.Errorf("Expected 1 to 5 active connections, but got %v", active)
+	}
+	time.Sleep(time.Second)
+	if active := atomic.LoadInt64(&b.activeConns); active != 10 {
+		t.Errorf("Expected all connections to be active, but got %v", active)
+	}
+	<-waitCh
+	if active := atomic.LoadInt64(&b.activeConns); active != 0 {
+		t.Errorf("Expected no active connections, but got %v", active)
+	}
+}
+
+func TestBombardierExcludesWarmupFromStatistics(t *testing.T) {
+	reqsReceived := uint64(0)
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			atomic.AddUint64(&reqsReceived, 1)
+		}),
+	)
+	defer s.Close()
+	testDuration := time.Second
+	for _, reset := range []bool{false, true} {
+		atomic.StoreUint64(&reqsReceived, 0)
+		b, e := newBombardier(config{
+			numConns:         defaultNumberOfConns,
+			duration:         &testDuration,
+			warmup:           500 * time.Millisecond,
+			resetAfterWarmup: reset,
+			url:              s.URL,
+			headers:          new(headersList),
+			timeout:          defaultTimeout,
+			method:           "GET",
+			clientType:       fhttp,
+			format:           knownFormat("plain-text"),
+		})
+		if e != nil {
+			t.Error(e)
+			return
+		}
+		b.disableOutput()
+		b.bombard()
+
+		received := atomic.LoadUint64(&reqsReceived)
+		recorded := uint64(0)
+		b.latencies.VisitAll(func(_ uint64, c uint64) bool {
+			recorded += c
+			return true
+		})
+		if recorded == 0 || recorded >= received {
+			t.Errorf("Expected only part of %v requests to be recorded, "+
+				"but got %v", received, recorded)
+		}
+		if reset && b.req2xx >= received {
+			t.Errorf("Expected 2xx counter to be reset, but got %v of %v",
+				b.req2xx, received)
+		}
+		if !reset && b.req2xx != received {
+			t.Errorf("Expected %v 2xx responses, but got %v",
+				received, b.req2xx)
+		}
+		if reset && b.timeTaken >= testDuration {
+			t.Errorf("Expected warmup to be excluded from time taken, "+
+				"but got %v", b.timeTaken)
+		}
+	}
+}
+
+func TestBombardierSendsTemplatedHeaders(t *testing.T) {
+	testAllClients(t, testBombardierSendsTemplatedHeaders)
+}
+
+func testBombardierSendsTemplatedHeaders(clientType clientTyp, t *testing.T) {
+	var (
+		mu   sync.Mutex
+		nums = make(map[string]bool)
+	)
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if r.Header.Get("X-Static") != "static" {
+				t.Errorf("Static header is missing: %v", r.Header)
+			}
+			// Body and headers of the same request are rendered
+			// with the same request number
+			num := r.Header.Get("X-Request-Num")
+			if string(body) != num {
+				t.Errorf("Expected body %q to match header %q", body, num)
+			}
+			mu.Lock()
+			nums[num] = true
+			mu.Unlock()
+		}),
+	)
+	defer s.Close()
+	numReqs := uint64(10)
+	headers := new(headersList)
+	if err := headers.Set("X-Static: static"); err != nil {
+		t.Fatal(err)
+	}
+	b, e := newBombardier(config{
+		numConns:        2,
+		numReqs:         &numReqs,
+		url:             s.URL,
+		headers:         headers,
+		headerTemplates: &headersList{{"X-Request-Num", "{{ .RequestNum }}"}},
+		timeout:         defaultTimeout,
+		method:          "POST",
+		body:            "{{ .RequestNum }}",
+		bodyTemplate:    true,
+		clientType:      clientType,
+		format:          knownFormat("plain-text"),
+	})
+	if e != nil {
+		t.Error(e)
+		return
+	}
+	b.disableOutput()
+	b.bombard()
+	for i := uint64(1); i <= numReqs; i++ {
+		if exp := fmt.Sprint(i); !nums[exp] {
+			t.Errorf("Request number %q wasn't received", exp)
+		}
+	}
+}
+
+func TestBombardierFailsOnInvalidHeaderTemplate(t *testing.T) {
+	numReqs := uint64(1)
+	_, e := newBombardier(config{
+		numConns:        defaultNumberOfConns,
+		numReqs:         &numReqs,
+		url:             "http://localhost:8080",
+		headers:         new(headersList),
+		headerTemplates: &headersList{{"X-Bad", "{{ .ConnID"}},
+		timeout:         defaultTimeout,
+		method:          "GET",
+		format:          knownFormat("plain-text"),
+	})
+	if e == nil {
+		t.Error("invalid header template should fail fast")
+	}
+}
+
+func TestBombardierSendsOAuth2Token(t *testing.T) {
+	testAllClients(t, testBombardierSendsOAuth2Token)
+}
+
+func testBombardierSendsOAuth2Token(clientType clientTyp, t *testing.T) {
+	issued := uint64(0)
+	ts := newTokenServer(t, 3600, &issued)
+	defer ts.Close()
+	authorized := uint64(0)
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") == "Bearer token-1" {
+				atomic.AddUint64(&authorized, 1)
+			}
+		}),
+	)
+	defer s.Close()
+	numReqs := uint64(10)
+	b, e := newBombardier(config{
+		numConns: 2,
+		numReqs:  &numReqs,
+		url:      s.URL,
+		headers:  new(headersList),
+		timeout:  defaultTimeout,
+		method:   "GET",
+		oauth2: &oauth2Config{
+			tokenURL:     ts.URL,
+			clientID:     "client",
+			clientSecret: "s3cr3t",
+			scope:        "read write",
+		},
+		clientType: clientType,
+		format:     knownFormat("plain-text"),
+	})
+	if e != nil {
+		t.Error(e)
+		return
+	}
+	b.disableOutput()
+	b.bombard()
+	if act := atomic.LoadUint64(&authorized); act != numReqs {
+		t.Errorf("Expected %v authorized requests, but got %v",
+			numReqs, act)
+	}
+}
+
+func TestBombardierSendsBasicAuth(t *testing.T) {
+	testAllClients(t, testBombardierSendsBasicAuth)
+}
+
+func testBombardierSendsBasicAuth(clientType clientTyp, t *testing.T) {
+	authorized := uint64(0)
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			user, pass, ok := r.BasicAuth()
+			if ok && user == "user" && pass == "pa:ss" {
+				atomic.AddUint64(&authorized, 1)
+			}
+		}),
+	)
+	defer s.Close()
+	numReqs := uint64(10)
+	b, e := newBombardier(config{
+		numConns:   2,
+		numReqs:    &numReqs,
+		url:        s.URL,
+		headers:    new(headersList),
+		timeout:    defaultTimeout,
+		method:     "GET",
+		basicAuth:  "user:pa:ss",
+		clientType: clientType,
+		format:     knownFormat("json"),
+	})
+	if e != nil {
+		t.Error(e)
+		return
+	}
+	out := new(bytes.Buffer)
+	b.disableOutput()
+	b.redirectOutputTo(out)
+	b.bombard()
+	if act := atomic.LoadUint64(&authorized); act != numReqs {
+		t.Errorf("Expected %v authorized requests, but got %v",
+			numReqs, act)
+	}
+	b.printStats()
+	if s := out.String(); !strings.Contains(s, `"basicAuthUser":"user"`) ||
+		strings.Contains(s, "pa:ss") {
+		t.Errorf("Expected user, but not password in %q", s)
+	}
+}
+
+func TestBombardierSignsRequests(t *testing.T) {
+	testAllClients(t, testBombardierSignsRequests)
+}
+
+func testBombardierSignsRequests(clientType clientTyp, t *testing.T) {
+	creds := sigV4Config{
+		accessKey:    "id",
+		secretKey:    "secret",
+		sessionToken: "token",
+		region:       "eu-west-1",
+		service:      "execute-api",
+	}
+	verified := uint64(0)
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				return
+			}
+			signedAt, err := time.Parse(sigV4TimeFormat,
+				r.Header.Get("X-Amz-Date"))
+			if err != nil {
+				return
+			}
+			signer := &sigV4Signer{
+				creds, func() time.Time { return signedAt },
+			}
+			var exp string
+			signer.sign(r.Method, r.Host, r.URL, body,
+				func(k, v string) {
+					if k == "Authorization" {
+						exp = v
+					}
+				})
+			if r.Header.Get("Authorization") == exp &&
+				r.Header.Get("X-Amz-Security-Token") == "token" {
+				atomic.AddUint64(&verified, 1)
+			}
+		}),
+	)
+	defer s.Close()
+	numReqs := uint64(10)
+	body := "some body"
+	b, e := newBombardier(config{
+		numConns:   2,
+		numReqs:    &numReqs,
+		url:        s.URL + "/some%20path?b=2&a=1",
+		headers:    new(headersList),
+		timeout:    defaultTimeout,
+		method:     "POST",
+		body:       body,
+		sigV4:      &creds,
+		clientType: clientType,
+		format:     knownFormat("json"),
+	})
+	if e != nil {
+		t.Error(e)
+		return
+	}
+	out := new(bytes.Buffer)
+	b.disableOutput()
+	b.redirectOutputTo(out)
+	b.bombard()
+	if act := atomic.LoadUint64(&verified); act != numReqs {
+		t.Errorf("Expected %v correctly signed requests, but got %v",
+			numReqs, act)
+	}
+	b.printStats()
+	exp := `"sigV4":{"accessKey":"id","region":"eu-west-1",` +
+		`"service":"execute-api"}`
+	if s := out.String(); !strings.Contains(s, exp) ||
+		strings.Contains(s, "secret") || strings.Contains(s, "token") {
+		t.Errorf("Expected %v, but no secrets in %q", exp, s)
+	}
+}
+
+func TestBombardierFailsOnTokenEndpointError(t *testing.T) {
+	issued := uint64(0)
+	ts := newTokenServer(t, 3600, &issued)
+	defer ts.Close()
+	numReqs := uint64(1)
+	_, e := newBombardier(config{
+		numConns: defaultNumberOfConns,
+		numReqs:  &numReqs,
+		url:      "http://localhost:8080",
+		headers:  new(headersList),
+		timeout:  defaultTimeout,
+		method:   "GET",
+		oauth2: &oauth2Config{
+			tokenURL: ts.URL,
+			clientID: "unknown",
+		},
+		format: knownFormat("plain-text"),
+	})
+	if _, ok := e.(*tokenRequestError); !ok {
+		t.Errorf("Expected token request error, but got %v", e)
+	}
+}
+
+func TestBombardierRetriesFailedRequests(t *testing.T) {
+	testAllClients(t, testBombardierRetriesFailedRequests)
+}
+
+func testBombardierRetriesFailedRequests(clientType clientTyp, t *testing.T) {
+	var attempts uint64
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			// Every first attempt fails, every retry succeeds
+			if atomic.AddUint64(&attempts, 1)%2 == 1 {
+				rw.WriteHeader(http.StatusInternalServerError)
+			}
+		}),
+	)
+	defer s.Close()
+	numReqs := uint64(10)
+	b, e := newBombardier(config{
+		numConns:   1,
+		numReqs:    &numReqs,
+		url:        s.URL,
+		headers:    new(headersList),
+		timeout:    defaultTimeout,
+		method:     "GET",
+		clientType: clientType,
+		format:     knownFormat("plain-text"),
+		retries:    2,
+	})
+	if e != nil {
+		t.Error(e)
+		return
+	}
+	b.disableOutput()
+	b.bombard()
+	if b.req2xx != numReqs {
+		t.Errorf("Expected %v 2xx responses, but got %v", numReqs, b.req2xx)
+	}
+	if b.req5xx != 0 {
+		t.Errorf("Expected no 5xx responses, but got %v", b.req5xx)
+	}
+	if b.retries != numReqs {
+		t.Errorf("Expected %v retries, but got %v", numReqs, b.retries)
+	}
+	if attempts != 2*numReqs {
+		t.Errorf("Expected %v attempts, but got %v", 2*numReqs, attempts)
+	}
+}
+
+func TestBombardierFollowsRedirects(t *testing.T) {
+	testAllClients(t, testBombardierFollowsRedirects)
+}
+
+func testBombardierFollowsRedirects(clientType clientTyp, t *testing.T) {
+	var final uint64
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			// 307 keeps method and body, while 302 turns POST into
+			// GET without body
+			switch {
+			case r.URL.Path == "/start" && r.Method == "POST" &&
+				string(body) == "BODY":
+				http.Redirect(rw, r, "/middle?q=1",
+					http.StatusTemporaryRedirect)
+			case r.URL.Path == "/middle" && r.Method == "POST" &&
+				string(body) == "BODY" && r.URL.Query().Get("q") == "1":
+				http.Redirect(rw, r, "final", http.StatusFound)
+			case r.URL.Path == "/final" && r.Method == "GET" &&
+				len(body) == 0:
+				atomic.AddUint64(&final, 1)
+			default:
+				rw.WriteHeader(http.StatusBadRequest)
+			}
+		}),
+	)
+	defer s.Close()
+	for _, maxRedirects := range []uint64{0, 1, 2, 3} {
+		atomic.StoreUint64(&final, 0)
+		numReqs := uint64(10)
+		b, e := newBombardier(config{
+			numConns:     2,
+			numReqs:      &numReqs,
+			url:          s.URL + "/start",
+			headers:      new(headersList),
+			timeout:      defaultTimeout,
+			method:       "POST",
+			body:         "BODY",
+			clientType:   clientType,
+			format:       knownFormat("plain-text"),
+			maxRedirects: maxRedirects,
+		})
+		if e != nil {
+			t.Error(e)
+			return
+		}
+		b.disableOutput()
+		b.bombard()
+		expRedirects, expFinal := maxRedirects*numReqs, numReqs
+		if maxRedirects < 2 {
+			expFinal = 0
+		} else {
+			expRedirects = 2 * numReqs
+		}
+		if b.redirects != expRedirects {
+			t.Errorf("%v: expected %v redirects, but got %v",
+				maxRedirects, expRedirects, b.redirects)
+		}
+		if act := atomic.LoadUint64(&final); act != expFinal {
+			t.Errorf("%v: expected %v requests to final location, "+
+				"but got %v", maxRedirects, expFinal, act)
+		}
+		if b.req3xx+b.req2xx != numReqs || b.req2xx != expFinal {
+			t.Errorf("%v: expected %v 2xx and the rest of %v 3xx, "+
+				"but got %v and %v", maxRedirects, expFinal, numReqs,
+				b.req2xx, b.req3xx)
+		}
+	}
+}
+
+func TestBombardierMeasuresFinalHop(t *testing.T) {
+	testAllClients(t, testBombardierMeasuresFinalHop)
+}
+
+func testBombardierMeasuresFinalHop(clientType clientTyp, t *testing.T) {
+	delay := 100 * time.Millisecond
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/slow" {
+				time.Sleep(delay)
+				http.Redirect(rw, r, "/fast", http.StatusFound)
+			}
+		}),
+	)
+	defer s.Close()
+	for _, finalHop := range []bool{false, true} {
+		numReqs := uint64(4)
+		b, e := newBombardier(config{
+			numConns:        1,
+			numReqs:         &numReqs,
+			url:             s.URL + "/slow",
+			headers:         new(headersList),
+			timeout:         defaultTimeout,
+			method:          "GET",
+			clientType:      clientType,
+			format:          knownFormat("plain-text"),
+			maxRedirects:    1,
+			finalHopLatency: finalHop,
+		})
+		if e != nil {
+			t.Error(e)
+			return
+		}
+		b.disableOutput()
+		b.bombard()
+		max := uint64(0)
+		b.latencies.VisitAll(func(us uint64, _ uint64) bool {
+			if us > max {
+				max = us
+			}
+			return true
+		})
+		slow := max >= uint64(delay.Nanoseconds()/1000)
+		if slow == finalHop {
+			t.Errorf("final hop %v: unexpected max latency %vus",
+				finalHop, max)
+		}
+		if b.req2xx != numReqs {
+			t.Errorf("Expected %v 2xx responses, but got %v",
+				numReqs, b.req2xx)
+		}
+	}
+}
+
+func TestBombardierFastHTTPRedirectToAnotherHost(t *testing.T) {
+	other := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {}),
+	)
+	defer other.Close()
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			http.Redirect(rw, r, other.URL, http.StatusFound)
+		}),
+	)
+	defer s.Close()
+	numReqs := uint64(5)
+	b, e := newBombardier(config{
+		numConns:     1,
+		numReqs:      &numReqs,
+		url:          s.URL,
+		headers:      new(headersList),
+		timeout:      defaultTimeout,
+		method:       "GET",
+		clientType:   fhttp,
+		format:       knownFormat("plain-text"),
+		maxRedirects: 1,
+	})
+	if e != nil {
+		t.Fatal(e)
+	}
+	b.disableOutput()
+	b.bombard()
+	if act := b.errors.sum(); act != numReqs {
+		t.Errorf("Expected %v errors, but got %v", numReqs, act)
+	}
+	for _, e := range b.errors.byFrequency() {
+		if !strings.HasPrefix(e.error, "redirect to another host") {
+			t.Errorf("Unexpected error: %v", e.error)
+		}
+	}
+}
+
+func TestBombardierSendsBodyOnGet(t *testing.T) {
+	testAllClients(t, testBombardierSendsBodyOnGet)
+}
+
+func testBombardierSendsBodyOnGet(clientType clientTyp, t *testing.T) {
+	for _, allow := range []bool{false, true} {
+		var received uint64
+		s := httptest.NewServer(
+			http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+				body, err := ioutil.ReadAll(r.Body)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if r.Method == "GET" && string(body) == "BODY" {
+					atomic.AddUint64(&received, 1)
+				}
+			}),
+		)
+		numReqs := uint64(5)
+		b, e := newBombardier(config{
+			numConns:       1,
+			numReqs:        &numReqs,
+			url:            s.URL,
+			headers:        new(headersList),
+			timeout:        defaultTimeout,
+			method:         "GET",
+			body:           "BODY",
+			clientType:     clientType,
+			format:         knownFormat("plain-text"),
+			printIntro:     true,
+			allowBodyOnGet: allow,
+		})
+		if e != nil {
+			s.Close()
+			t.Fatal(e)
+		}
+		out := new(bytes.Buffer)
+		b.disableOutput()
+		b.redirectOutputTo(out)
+		b.printIntro()
+		b.bombard()
+		s.Close()
+		if received != numReqs {
+			t.Errorf("Expected %v requests with body, but got %v",
+				numReqs, received)
+		}
+		warned := bytes.Contains(out.Bytes(), []byte("Warning: GET requests"))
+		if warned == allow {
+			t.Errorf("Expected warning to be printed: %v, but got %q",
+				!allow, out)
+		}
+	}
+}
+
+func TestBombardierAbortsOnErrorRate(t *testing.T) {
+	testAllClients(t, testBombardierAbortsOnErrorRate)
+}
+
+func testBombardierAbortsOnErrorRate(clientType clientTyp, t *testing.T) {
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+		}),
+	)
+	defer s.Close()
+	numReqs := uint64(10000000)
+	errorRate := 0.5
+	b, e := newBombardier(config{
+		numConns:       4,
+		numReqs:        &numReqs,
+		url:            s.URL,
+		headers:        new(headersList),
+		timeout:        defaultTimeout,
+		method:         "GET",
+		clientType:     clientType,
+		format:         knownFormat("plain-text"),
+		abortErrorRate: &errorRate,
+	})
+	if e != nil {
+		t.Error(e)
+		return
+	}
+	b.disableOutput()
+	b.bombard()
+	if !b.gatherInfo().Result.Aborted {
+		t.Error("Expected test to be aborted")
+	}
+	if b.req5xx < minRequestsToAbort || b.req5xx >= numReqs {
+		t.Errorf("Expected test to stop early, but got %v responses",
+			b.req5xx)
+	}
+}
+
+func TestBombardierErrorRateExceeded(t *testing.T) {
+	errorRate := 0.1
+	b := &bombardier{conf: config{abortErrorRate: &errorRate}}
+	expectations := []struct {
+		req2xx, req5xx uint64
+		errors         int
+		out            bool
+	}{
+		{0, 0, 0, false},
+		{0, minRequestsToAbort - 1, 0, false},
+		{90, 10, 0, false},
+		{89, 11, 0, true},
+		{89, 11, 10, false},
+	}
+	for _, e := range expectations {
+		b.req2xx, b.req5xx = e.req2xx, e.req5xx
+		b.errors = newErrorMap()
+		for i := 0; i < e.errors; i++ {
+			b.errors.add(errors.New("error"))
+		}
+		if act := b.errorRateExceeded(); act != e.out {
+			t.Errorf("%+v: expected %v, but got %v", e, e.out, act)
+		}
+	}
+}
+
+func TestBombardierStopsAtMaxBytes(t *testing.T) {
+	testAllClients(t, testBombardierStopsAtMaxBytes)
+}
+
+func testBombardierStopsAtMaxBytes(clientType clientTyp, t *testing.T) {
+	response := bytes.Repeat([]byte{'a'}, 1024)
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			_, _ = rw.Write(response)
+		}),
+	)
+	defer s.Close()
+	numReqs := uint64(10000000)
+	maxBytes := uint64(1 << 20)
+	b, e := newBombardier(config{
+		numConns:    4,
+		numReqs:     &numReqs,
+		url:         s.URL,
+		headers:     new(headersList),
+		timeout:     defaultTimeout,
+		method:      "GET",
+		clientType:  clientType,
+		printResult: true,
+		format:      knownFormat("plain-text"),
+		maxBytes:    &maxBytes,
+	})
+	if e != nil {
+		t.Fatal(e)
+	}
+	b.disableOutput()
+	b.bombard()
+	info := b.gatherInfo()
+	if !info.Result.BytesCapReached || info.Result.Aborted {
+		t.Error("Expected test to be stopped by the byte cap")
+	}
+	transferred := info.Result.BytesRead + info.Result.BytesWritten
+	if uint64(transferred) < maxBytes {
+		t.Errorf("Expected at least %v bytes, but got %v",
+			maxBytes, transferred)
+	}
+	if b.req2xx >= numReqs {
+		t.Errorf("Expected test to stop early, but got %v responses",
+			b.req2xx)
+	}
+	out := new(bytes.Buffer)
+	b.redirectOutputTo(out)
+	b.printStats()
+	if exp := "Stopped: reached byte cap of 1.00MB"; !strings.Contains(
+		out.String(), exp,
+	) {
+		t.Errorf("Expected %q in %q", exp, out)
+	}
+}
+
+func TestBombardierBytesCapReached(t *testing.T) {
+	maxBytes := uint64(1000)
+	b := &bombardier{conf: config{maxBytes: &maxBytes}}
+	expectations := []struct {
+		read, written int64
+		out           bool
+	}{
+		{0, 0, false},
+		{999, 0, false},
+		{500, 499, false},
+		{500, 500, true},
+		{0, 1000, true},
+		{2000, 0, true},
+	}
+	for _, e := range expectations {
+		b.bytesRead, b.bytesWritten = e.read, e.written
+		if act := b.bytesCapReached(); act != e.out {
+			t.Errorf("%+v: expected %v, but got %v", e, e.out, act)
+		}
+	}
+	b.conf.maxBytes = nil
+	if b.bytesCapReached() {
+		t.Error("Test without byte cap shouldn't be stopped")
+	}
+}
+
+func TestBombardierRecordsTimeToFirstByte(t *testing.T) {
+	testAllClients(t, testBombardierRecordsTimeToFirstByte)
+}
+
+func testBombardierRecordsTimeToFirstByte(clientType clientTyp, t *testing.T) {
+	bodyDelay := 50 * time.Millisecond
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			rw.WriteHeader(http.StatusOK)
+			rw.(http.Flusher).Flush()
+			time.Sleep(bodyDelay)
+			_, _ = rw.Write([]byte("body"))
+		}),
+	)
+	defer s.Close()
+	numReqs := uint64(4)
+	b, e := newBombardier(config{
+		numConns:   2,
+		numReqs:    &numReqs,
+		url:        s.URL,
+		headers:    new(headersList),
+		timeout:    defaultTimeout,
+		method:     "GET",
+		clientType: clientType,
+		format:     knownFormat("plain-text"),
+	})
+	if e != nil {
+		t.Error(e)
+		return
+	}
+	b.disableOutput()
+	b.bombard()
+	result := b.gatherInfo().Result
+	ttfb := result.TTFBStats(nil)
+	latencies := result.LatenciesStats(nil)
+	if ttfb == nil || latencies == nil {
+		t.Fatal("Expected both TTFB and latencies to be recorded")
+	}
+	bodyDelayUs := float64(bodyDelay / time.Microsecond)
+	if ttfb.Max >= bodyDelayUs {
+		t.Errorf("Expected TTFB (max %v) to exclude body delay of %v",
+			ttfb.Max, bodyDelay)
+	}
+	if latencies.Min < bodyDelayUs {
+		t.Errorf("Expected latency (min %v) to include body delay of %v",
+			latencies.Min, bodyDelay)
+	}
+}
+
+func TestBombardierCountsConnectionReuse(t *testing.T) {
+	testAllClients(t, testBombardierCountsConnectionReuse)
+}
+
+func testBombardierCountsConnectionReuse(clientType clientTyp, t *testing.T) {
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("close") != "" {
+				rw.Header().Set("Connection", "close")
+			}
+		}),
+	)
+	defer s.Close()
+	numReqs := uint64(20)
+	expectations := []struct {
+		url                   string
+		newConns, reusedConns uint64
+	}{
+		{s.URL + "?close=true", numReqs, 0},
+		{s.URL, 1, numReqs - 1},
+	}
+	for _, e := range expectations {
+		b, err := newBombardier(config{
+			numConns:   1,
+			numReqs:    &numReqs,
+			url:        e.url,
+			headers:    new(headersList),
+			timeout:    defaultTimeout,
+			method:     "GET",
+			clientType: clientType,
+			format:     knownFormat("plain-text"),
+		})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		b.disableOutput()
+		b.bombard()
+		result := b.gatherInfo().Result
+		if result.NewConns != e.newConns ||
+			result.ReusedConns != e.reusedConns {
+			t.Errorf("%v: expected %v new and %v reused connections, "+
+				"but got %v and %v", e.url, e.newConns, e.reusedConns,
+				result.NewConns, result.ReusedConns)
+		}
+	}
+}
+
+func TestBombardierSendsRandomHeaders(t *testing.T) {
+	testAllClients(t, testBombardierSendsRandomHeaders)
+}
+
+func testBombardierSendsRandomHeaders(clientType clientTyp, t *testing.T) {
+	var withHeader uint64
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-Debug") != "" {
+				atomic.AddUint64(&withHeader, 1)
+			}
+		}),
+	)
+	defer s.Close()
+	numReqs := uint64(500)
+	seed := uint64(42)
+	b, e := newBombardier(config{
+		numConns:      4,
+		numReqs:       &numReqs,
+		url:           s.URL,
+		headers:       new(headersList),
+		timeout:       defaultTimeout,
+		method:        "GET",
+		clientType:    clientType,
+		format:        knownFormat("plain-text"),
+		randomHeaders: &randomHeadersList{{header{"X-Debug", "1"}, 0.3}},
+		seed:          &seed,
+	})
+	if e != nil {
+		t.Error(e)
+		return
+	}
+	b.disableOutput()
+	b.bombard()
+	if withHeader < 100 || withHeader > 200 {
+		t.Errorf("Expected ~150 requests with X-Debug header, but got %v",
+			withHeader)
+	}
+}
+
+func TestBombardierIsReproducibleWithSeed(t *testing.T) {
+	run := func(seed uint64) []string {
+		var (
+			mu     sync.Mutex
+			bodies []string
+		)
+		s := httptest.NewServer(
+			http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+				body, err := ioutil.ReadAll(r.Body)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				mu.Lock()
+				bodies = append(bodies, string(body))
+				mu.Unlock()
+			}),
+		)
+		defer s.Close()
+		numReqs := uint64(5)
+		b, e := newBombardier(config{
+			numConns:     1,
+			numReqs:      &numReqs,
+			url:          s.URL,
+			headers:      new(headersList),
+			timeout:      defaultTimeout,
+			method:       "POST",
+			body:         "{{ uuid }}",
+			bodyTemplate: true,
+			format:       knownFormat("plain-text"),
+			seed:         &seed,
+		})
+		if e != nil {
+			t.Fatal(e)
+		}
+		b.disableOutput()
+		b.bombard()
+		if act := b.gatherInfo().Spec.Seed; act != seed {
+			t.Errorf("Expected seed %v in spec, but got %v", seed, act)
+		}
+		return bodies
+	}
+	first, second := run(7), run(7)
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("Expected the same bodies, but got %v and %v", first, second)
+	}
+	if reflect.DeepEqual(first, run(8)) {
+		t.Error("Expected different seeds to produce different bodies")
+	}
+}
+
+func TestBombardierRecordsLatenciesByStatusClass(t *testing.T) {
+	testAllClients(t, testBombardierRecordsLatenciesByStatusClass)
+}
+
+func testBombardierRecordsLatenciesByStatusClass(
+	clientType clientTyp, t *testing.T,
+) {
+	const delay = 20 * time.Millisecond
+	reqs := uint64(0)
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			if atomic.AddUint64(&reqs, 1)%2 == 0 {
+				rw.WriteHeader(http.StatusNotFound)
+				return
+			}
+			time.Sleep(delay)
+		}),
+	)
+	defer s.Close()
+	numReqs := uint64(10)
+	b, e := newBombardier(config{
+		numConns:          1,
+		numReqs:           &numReqs,
+		url:               s.URL,
+		headers:           new(headersList),
+		timeout:           defaultTimeout,
+		method:            "GET",
+		clientType:        clientType,
+		printLatencies:    true,
+		format:            knownFormat("json"),
+		latenciesByStatus: true,
+	})
+	if e != nil {
+		t.Error(e)
+		return
+	}
+	out := new(bytes.Buffer)
+	b.redirectOutputTo(out)
+	b.bombard()
+	stats := b.gatherInfo().Result.LatenciesStatsByStatusClass([]float64{0.99})
+	if len(stats) != 2 || stats[2] == nil || stats[4] == nil {
+		t.Fatalf("Expected stats for 2xx and 4xx only, but got %+v", stats)
+	}
+	if min := stats[2].Min; min < float64(delay/time.Microsecond) {
+		t.Errorf("Expected 2xx latencies to include delay, but min is %v",
+			min)
+	}
+	if stats[4].Mean >= stats[2].Mean {
+		t.Errorf("Expected 4xx to be faster than 2xx, but got %v and %v",
+			stats[4].Mean, stats[2].Mean)
+	}
+
+	out.Reset()
+	b.printStats()
+	var res struct {
+		Result struct {
+			LatencyByStatusClass map[string]struct {
+				Percentiles map[string]uint64
+			}
+		}
+	}
+	if err := json.Unmarshal(out.Bytes(), &res); err != nil {
+		t.Fatalf("%v: %s", err, out.Bytes())
+	}
+	byClass := res.Result.LatencyByStatusClass
+	if len(byClass) != 2 || len(byClass["2xx"].Percentiles) == 0 ||
+		len(byClass["4xx"].Percentiles) == 0 {
+		t.Errorf("Unexpected latencies by status class: %s", out.Bytes())
+	}
+}
+
+func TestBombardierFollowsLoadProfile(t *testing.T) {
+	reqs := uint64(0)
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			atomic.AddUint64(&reqs, 1)
+		}),
+	)
+	defer s.Close()
+	duration := time.Second
+	b, e := newBombardier(config{
+		numConns:    defaultNumberOfConns,
+		duration:    &duration,
+		url:         s.URL,
+		headers:     new(headersList),
+		timeout:     defaultTimeout,
+		method:      "GET",
+		format:      knownFormat("plain-text"),
+		loadProfile: &sineProfile{50, 150, 2 * time.Second},
+	})
+	if e != nil {
+		t.Fatal(e)
+	}
+	b.disableOutput()
+	b.bombard()
+	// Rate grows from 50 to 150 over the first second, averaging 100
+	if reqs < 90 || reqs > 115 {
+		t.Errorf("Expected about 100 requests, but got %v", reqs)
+	}
+	if p := b.gatherInfo().Spec.LoadProfile; p != "sine:min=50,max=150,period=2s" {
+		t.Errorf("Unexpected load profile in spec: %q", p)
+	}
+}
+
+func TestBombardierDetectsKnee(t *testing.T) {
+	// Server gets slow once the rate grows past the first step
+	reqs := uint64(0)
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			if atomic.AddUint64(&reqs, 1) > 70 {
+				time.Sleep(30 * time.Millisecond)
+			}
+		}),
+	)
+	defer s.Close()
+	duration := 1500 * time.Millisecond
+	b, e := newBombardier(config{
+		numConns:    defaultNumberOfConns,
+		duration:    &duration,
+		url:         s.URL,
+		headers:     new(headersList),
+		timeout:     defaultTimeout,
+		method:      "GET",
+		format:      knownFormat("json"),
+		loadProfile: &stepProfile{100, 100, 500 * time.Millisecond},
+		kneeLatency: &latencyAssertion{0.5, 20 * time.Millisecond},
+	})
+	if e != nil {
+		t.Fatal(e)
+	}
+	out := new(bytes.Buffer)
+	b.redirectOutputTo(out)
+	b.bombard()
+	info := b.gatherInfo()
+	if info.Spec.KneeLatency != "p50:20ms" {
+		t.Errorf("Unexpected knee latency in spec: %q", info.Spec.KneeLatency)
+	}
+	knee := info.Result.Knee
+	if knee == nil {
+		t.Fatal("Expected knee to be detected")
+	}
+	if knee.Step != 2 || knee.Rate != 200 || knee.Latency < 20000 {
+		t.Errorf("Unexpected knee: %+v", knee)
+	}
+	out.Reset()
+	b.printStats()
+	var res struct {
+		Result struct {
+			Knee struct {
+				Step uint64
+			}
+		}
+	}
+	if err := json.Unmarshal(out.Bytes(), &res); err != nil {
+		t.Fatalf("%v: %s", err, out.Bytes())
+	}
+	if res.Result.Knee.Step != 2 {
+		t.Errorf("Unexpected knee in output: %s", out.Bytes())
+	}
+}
+
+func TestBombardierRecordsResponseSizes(t *testing.T) {
+	testAllClients(t, testBombardierRecordsResponseSizes)
+}
+
+func testBombardierRecordsResponseSizes(clientType clientTyp, t *testing.T) {
+	reqs := uint64(0)
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			size := 100
+			if atomic.AddUint64(&reqs, 1)%2 == 0 {
+				size = 3000
+			}
+			if _, err := rw.Write(bytes.Repeat([]byte{'a'}, size)); err != nil {
+				t.Error(err)
+			}
+		}),
+	)
+	defer s.Close()
+	numReqs := uint64(10)
+	b, e := newBombardier(config{
+		numConns:   1,
+		numReqs:    &numReqs,
+		url:        s.URL,
+		headers:    new(headersList),
+		timeout:    defaultTimeout,
+		method:     "GET",
+		clientType: clientType,
+		format:     knownFormat("plain-text"),
+	})
+	if e != nil {
+		t.Error(e)
+		return
+	}
+	b.disableOutput()
+	b.bombard()
+	stats := b.gatherInfo().Result.ResponseSizeStats([]float64{1})
+	if stats == nil {
+		t.Fatal("Expected response sizes to be recorded")
+	}
+	if stats.Min != 100 || stats.Max != 3000 || stats.Mean != 1550 {
+		t.Errorf("Unexpected response size stats: %+v", stats)
+	}
+}
+
+func TestBombardierCompressesBody(t *testing.T) {
+	testAllClients(t, testBombardierCompressesBody)
+}
+
+func testBombardierCompressesBody(clientType clientTyp, t *testing.T) {
+	body := strings.Repeat("compressible ", 100)
+	var received uint64
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			if ce := r.Header.Get("Content-Encoding"); ce != "gzip" {
+				t.Errorf("Unexpected Content-Encoding: %q", ce)
+				return
+			}
+			zr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			decompressed, err := ioutil.ReadAll(zr)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if string(decompressed) == body {
+				atomic.AddUint64(&received, 1)
+			}
+		}),
+	)
+	defer s.Close()
+	numReqs := uint64(5)
+	b, e := newBombardier(config{
+		numConns:     1,
+		numReqs:      &numReqs,
+		url:          s.URL,
+		headers:      new(headersList),
+		timeout:      defaultTimeout,
+		method:       "POST",
+		body:         body,
+		clientType:   clientType,
+		format:       knownFormat("plain-text"),
+		compressBody: "gzip",
+	})
+	if e != nil {
+		t.Error(e)
+		return
+	}
+	b.disableOutput()
+	b.bombard()
+	if received != numReqs {
+		t.Errorf("Expected %v compressed bodies, but got %v",
+			numReqs, received)
+	}
+	if written := b.gatherInfo().Result.BytesWritten; written >=
+		int64(len(body))*int64(numReqs) {
+		t.Errorf("Expected compressed bodies to be written, but %v bytes "+
+			"were written", written)
+	}
+}
+
+func TestBombardierSetsContentTypeOfBodyFile(t *testing.T) {
+	testAllClients(t, testBombardierSetsContentTypeOfBodyFile)
+}
+
+func testBombardierSetsContentTypeOfBodyFile(
+	clientType clientTyp, t *testing.T,
+) {
+	f, err := ioutil.TempFile("", "bombardier-body-*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	if _, err = f.WriteString(`{"a":1}`); err != nil {
+		t.Fatal(err)
+	}
+	if err = f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	var received uint64
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			if ct := r.Header.Get("Content-Type"); ct == "application/json" {
+				atomic.AddUint64(&received, 1)
+			}
+		}),
+	)
+	defer s.Close()
+	numReqs := uint64(5)
+	b, e := newBombardier(config{
+		numConns:     1,
+		numReqs:      &numReqs,
+		url:          s.URL,
+		headers:      new(headersList),
+		timeout:      defaultTimeout,
+		method:       "POST",
+		bodyFilePath: f.Name(),
+		clientType:   clientType,
+		format:       knownFormat("plain-text"),
+	})
+	if e != nil {
+		t.Error(e)
+		return
+	}
+	b.disableOutput()
+	b.bombard()
+	if received != numReqs {
+		t.Errorf("Expected %v requests with JSON Content-Type, but got %v",
+			numReqs, received)
+	}
+}
+
+func TestBombardierSendsForm(t *testing.T) {
+	testAllClients(t, testBombardierSendsForm)
+}
+
+func testBombardierSendsForm(clientType clientTyp, t *testing.T) {
+	f, err := ioutil.TempFile("", "bombardier-upload-*.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	if _, err = f.WriteString("content"); err != nil {
+		t.Fatal(err)
+	}
+	if err = f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	var received uint64
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			if err := r.ParseMultipartForm(1 << 20); err != nil {
+				t.Error(err)
+				return
+			}
+			defer r.MultipartForm.RemoveAll()
+			if r.FormValue("title") != "cat" {
+				t.Errorf("Unexpected title: %q", r.FormValue("title"))
+				return
+			}
+			if fhs := r.MultipartForm.File["upload"]; len(fhs) == 1 &&
+				fhs[0].Size == int64(len("content")) {
+				atomic.AddUint64(&received, 1)
+			}
+		}),
+	)
+	defer s.Close()
+	numReqs := uint64(5)
+	b, e := newBombardier(config{
+		numConns: 1,
+		numReqs:  &numReqs,
+		url:      s.URL,
+		headers:  new(headersList),
+		timeout:  defaultTimeout,
+		method:   "POST",
+		form: &formFieldsList{
+			{name: "title", value: "cat"},
+			{name: "upload", value: f.Name(), isFile: true},
+		},
+		clientType: clientType,
+		format:     knownFormat("plain-text"),
+	})
+	if e != nil {
+		t.Error(e)
+		return
+	}
+	b.disableOutput()
+	b.bombard()
+	if received != numReqs {
+		t.Errorf("Expected %v requests with form, but got %v",
+			numReqs, received)
+	}
+}
+
+func TestBombardierResolveOverride(t *testing.T) {
+	testAllClients(t, testBombardierResolveOverride)
+}
+
+func testBombardierResolveOverride(clientType clientTyp, t *testing.T) {
+	var received uint64
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			atomic.AddUint64(&received, 1)
+		}),
+	)
+	defer s.Close()
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	numReqs := uint64(5)
+	b, e := newBombardier(config{
+		numConns: 1,
+		numReqs:  &numReqs,
+		url:      "http://bombardier.invalid:" + u.Port(),
+		headers:  new(headersList),
+		timeout:  defaultTimeout,
+		method:   "GET",
+		resolve: &resolveOverridesList{
+			{"bombardier.invalid", u.Port(), "127.0.0.1"},
+		},
+		clientType: clientType,
+		format:     knownFormat("plain-text"),
+	})
+	if e != nil {
+		t.Error(e)
+		return
+	}
+	b.disableOutput()
+	b.bombard()
+	if received != numReqs {
+		t.Errorf("Expected %v requests, but got %v", numReqs, received)
+	}
+	exp := []string{u.Host}
+	if act := b.gatherInfo().Result.RemoteAddresses; !reflect.DeepEqual(act, exp) {
+		t.Errorf("Expected remote addresses %v, but got %v", exp, act)
+	}
+}
+
+func TestBombardierUnixSocket(t *testing.T) {
+	testAllClients(t, testBombardierUnixSocket)
+}
+
+func testBombardierUnixSocket(clientType clientTyp, t *testing.T) {
+	dir, err := ioutil.TempDir("", "bombardier-socket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	socketPath := filepath.Join(dir, "app.sock")
+	l, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var received uint64
+	s := httptest.NewUnstartedServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			if r.Host == "app.local:8080" {
+				atomic.AddUint64(&received, 1)
+			}
+		}),
+	)
+	s.Listener = l
+	s.Start()
+	defer s.Close()
+	numReqs := uint64(5)
+	b, e := newBombardier(config{
+		numConns:   1,
+		numReqs:    &numReqs,
+		url:        "http://app.local:8080",
+		headers:    new(headersList),
+		timeout:    defaultTimeout,
+		method:     "GET",
+		unixSocket: socketPath,
+		clientType: clientType,
+		format:     knownFormat("plain-text"),
+	})
+	if e != nil {
+		t.Error(e)
+		return
+	}
+	b.disableOutput()
+	b.bombard()
+	if received != numReqs {
+		t.Errorf("Expected %v requests with original Host, but got %v",
+			numReqs, received)
+	}
+	exp := []string{socketPath}
+	if act := b.gatherInfo().Result.RemoteAddresses; !reflect.DeepEqual(act, exp) {
+		t.Errorf("Expected remote addresses %v, but got %v", exp, act)
+	}
+}
+
+func TestBombardierCACert(t *testing.T) {
+	testAllClients(t, testBombardierCACert)
+}
+
+func testBombardierCACert(clientType clientTyp, t *testing.T) {
+	s := httptest.NewTLSServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {}),
+	)
+	defer s.Close()
+	f, err := ioutil.TempFile("", "bombardier-ca-*.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	err = pem.Encode(f, &pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: s.Certificate().Raw,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for _, caCertPath := range []string{f.Name(), ""} {
+		numReqs := uint64(3)
+		b, e := newBombardier(config{
+			numConns:   1,
+			numReqs:    &numReqs,
+			url:        s.URL,
+			headers:    new(headersList),
+			timeout:    defaultTimeout,
+			method:     "GET",
+			caCertPath: caCertPath,
+			clientType: clientType,
+			format:     knownFormat("plain-text"),
+		})
+		if e != nil {
+			t.Error(e)
+			return
+		}
+		b.disableOutput()
+		b.bombard()
+		// Test server's certificate isn't trusted by the system
+		exp := uint64(0)
+		if caCertPath != "" {
+			exp = numReqs
+		}
+		if b.req2xx != exp {
+			t.Errorf("CA bundle %q: expected %v successful requests, "+
+				"but got %v", caCertPath, exp, b.req2xx)
+		}
+	}
+}
+
+func TestBombardierSNI(t *testing.T) {
+	testAllClients(t, testBombardierSNI)
+}
+
+func testBombardierSNI(clientType clientTyp, t *testing.T) {
+	var received uint64
+	s := httptest.NewTLSServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			if r.TLS.ServerName == "example.com" &&
+				r.Host == "backend.local" {
+				atomic.AddUint64(&received, 1)
+			}
+		}),
+	)
+	defer s.Close()
+	f, err := ioutil.TempFile("", "bombardier-ca-*.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	err = pem.Encode(f, &pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: s.Certificate().Raw,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	numReqs := uint64(3)
+	// Certificate of the test server is valid for example.com, so
+	// it's only verified successfully if SNI is used for that
+	b, e := newBombardier(config{
+		numConns:   1,
+		numReqs:    &numReqs,
+		url:        s.URL,
+		headers:    &headersList{{"Host", "backend.local"}},
+		timeout:    defaultTimeout,
+		method:     "GET",
+		caCertPath: f.Name(),
+		sni:        "example.com",
+		clientType: clientType,
+		format:     knownFormat("plain-text"),
+	})
+	if e != nil {
+		t.Error(e)
+		return
+	}
+	b.disableOutput()
+	b.bombard()
+	if received != numReqs {
+		t.Errorf("Expected %v requests with SNI and Host header, "+
+			"but got %v (errors: %v)", numReqs, received,
+			b.errors.byFrequency())
+	}
+}
+
+func TestBombardierRecordsHandshakes(t *testing.T) {
+	testAllClients(t, testBombardierRecordsHandshakes)
+}
+
+func testBombardierRecordsHandshakes(clientType clientTyp, t *testing.T) {
+	tlsServer := httptest.NewTLSServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {}),
+	)
+	defer tlsServer.Close()
+	plainServer := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {}),
+	)
+	defer plainServer.Close()
+	for _, s := range []*httptest.Server{tlsServer, plainServer} {
+		numReqs := uint64(10)
+		b, e := newBombardier(config{
+			numConns:   2,
+			numReqs:    &numReqs,
+			url:        s.URL,
+			headers:    new(headersList),
+			timeout:    defaultTimeout,
+			method:     "GET",
+			insecure:   true,
+			clientType: clientType,
+			format:     knownFormat("plain-text"),
+		})
+		if e != nil {
+			t.Error(e)
+			return
+		}
+		b.disableOutput()
+		b.bombard()
+		if b.req2xx != numReqs {
+			t.Errorf("%v: expected %v successful requests, but got %v",
+				s.URL, numReqs, b.req2xx)
+		}
+		count := b.handshakes.Count()
+		if s == plainServer && count != 0 {
+			t.Errorf("Expected no handshakes, but got %v", count)
+		}
+		if s == tlsServer && (count == 0 || count > b.newConns) {
+			t.Errorf("Expected between 1 and %v handshakes, but got %v",
+				b.newConns, count)
+		}
+	}
+}
+
+func TestBombardierInterrupt(t *testing.T) {
+	testAllClients(t, testBombardierInterrupt)
+}
+
+func testBombardierInterrupt(clientType clientTyp, t *testing.T) {
+	release := make(chan struct{})
+	var received uint64
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			// The second request hangs until the end of the test
+			if atomic.AddUint64(&received, 1) == 2 {
+				<-release
+			}
+		}),
+	)
+	defer s.Close()
+	defer close(release)
+	duration := 10 * time.Second
+	b, e := newBombardier(config{
+		numConns:   1,
+		duration:   &duration,
+		url:        s.URL,
+		headers:    new(headersList),
+		timeout:    duration,
+		method:     "GET",
+		clientType: clientType,
+		format:     knownFormat("plain-text"),
+	})
+	if e != nil {
+		t.Error(e)
+		return
+	}
+	b.disableOutput()
+	b.gracePeriod = 100 * time.Millisecond
+	time.AfterFunc(100*time.Millisecond, b.interrupt)
+	start := time.Now()
+	b.bombard()
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected hanging request to be abandoned, but test "+
+			"took %v", elapsed)
+	}
+	info := b.gatherInfo()
+	if !info.Result.Interrupted {
+		t.Error("Expected results to be marked as interrupted")
+	}
+	if info.Result.Req2XX != 1 {
+		t.Errorf("Expected 1 completed request, but got %v",
+			info.Result.Req2XX)
+	}
+}
+
+func TestBombardierPause(t *testing.T) {
+	testAllClients(t, testBombardierPause)
+}
+
+func testBombardierPause(clientType clientTyp, t *testing.T) {
+	var received uint64
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			atomic.AddUint64(&received, 1)
+		}),
+	)
+	defer s.Close()
+	numReqs := uint64(10)
+	b, e := newBombardier(config{
+		numConns:   2,
+		numReqs:    &numReqs,
+		url:        s.URL,
+		headers:    new(headersList),
+		timeout:    defaultTimeout,
+		method:     "GET",
+		clientType: clientType,
+		format:     knownFormat("plain-text"),
+	})
+	if e != nil {
+		t.Error(e)
+		return
+	}
+	b.disableOutput()
+	pause := 300 * time.Millisecond
+	b.pauser.pause()
+	var receivedWhilePaused uint64
+	time.AfterFunc(pause, func() {
+		receivedWhilePaused = atomic.LoadUint64(&received)
+		b.pauser.resume()
+	})
+	b.bombard()
+	if receivedWhilePaused != 0 {
+		t.Errorf("Expected no requests while paused, but got %v",
+			receivedWhilePaused)
+	}
+	if received != numReqs {
+		t.Errorf("Expected %v requests, but got %v", numReqs, received)
+	}
+	if b.timeTaken >= pause {
+		t.Errorf("Expected time spent paused to be excluded, but "+
+			"test took %v", b.timeTaken)
+	}
+}
+
+func TestBombardierFollowsScenario(t *testing.T) {
+	testAllClients(t, testBombardierFollowsScenario)
+}
+
+func testBombardierFollowsScenario(clientType clientTyp, t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received []string
+	)
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			mu.Lock()
+			received = append(received, fmt.Sprintf("%v %v %s %v",
+				r.Method, r.URL.Path, body, r.Header.Get("X-Req")))
+			mu.Unlock()
+		}),
+	)
+	defer s.Close()
+	sc := &scenario{
+		path: "scenario.json",
+		steps: []scenarioStep{
+			{
+				Name: "login", Method: "POST", URL: s.URL + "/login",
+				Body: "creds",
+			},
+			{
+				Method: "GET", URL: s.URL + "/items",
+				Headers:  map[string]string{"X-Req": "{{ .RequestNum }}"},
+				Template: true,
+			},
+		},
+	}
+	numReqs := uint64(4)
+	b, e := newBombardier(config{
+		numConns:   1,
+		numReqs:    &numReqs,
+		url:        sc.steps[0].URL,
+		urls:       &[]string{sc.steps[0].URL, sc.steps[1].URL},
+		scenario:   sc,
+		headers:    new(headersList),
+		timeout:    defaultTimeout,
+		method:     "GET",
+		clientType: clientType,
+		format:     knownFormat("plain-text"),
+	})
+	if e != nil {
+		t.Error(e)
+		return
+	}
+	b.disableOutput()
+	b.bombard()
+	exp := []string{
+		"POST /login creds ", "GET /items  2",
+		"POST /login creds ", "GET /items  4",
+	}
+	if !reflect.DeepEqual(received, exp) {
+		t.Errorf("Expected requests %q, but got %q", exp, received)
+	}
+	info := b.gatherInfo()
+	if info.Spec.Scenario != "scenario.json" {
+		t.Errorf("Expected scenario in spec, but got %q",
+			info.Spec.Scenario)
+	}
+	if len(info.Result.URLs) != 2 || info.Result.URLs[0].Name != "login" ||
+		info.Result.URLs[0].Req2XX != 2 || info.Result.URLs[1].Req2XX != 2 {
+		t.Errorf("Unexpected per-step results: %+v", info.Result.URLs)
+	}
+}
+
+func TestBombardierReplaysAccessLog(t *testing.T) {
+	testAllClients(t, testBombardierReplaysAccessLog)
+}
+
+func testBombardierReplaysAccessLog(clientType clientTyp, t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received []string
+	)
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			received = append(received, r.Method+" "+r.URL.RequestURI())
+			mu.Unlock()
+		}),
+	)
+	defer s.Close()
+	path := writeAccessLog(t, `
+- - - [10/Oct/2000:13:55:36 +0000] "POST /a?x=1 HTTP/1.1" 200 1
+- - - [10/Oct/2000:13:55:37 +0000] "GET /b HTTP/1.1" 200 1
+- - - [10/Oct/2000:13:55:38 +0000] "DELETE /c HTTP/1.1" 200 1
+`)
+	defer os.RemoveAll(filepath.Dir(path))
+	r, err := readReplay(path, "common", 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, e := newBombardier(config{
+		numConns:   2,
+		url:        s.URL + "/ignored",
+		replay:     r,
+		headers:    new(headersList),
+		timeout:    defaultTimeout,
+		method:     "GET",
+		clientType: clientType,
+		format:     knownFormat("plain-text"),
+	})
+	if e != nil {
+		t.Error(e)
+		return
+	}
+	b.disableOutput()
+	start := time.Now()
+	b.bombard()
+	if d := time.Since(start); d < 90*time.Millisecond {
+		t.Errorf("Expected replay to take at least 100ms, but took %v", d)
+	}
+	exp := []string{"POST /a?x=1", "GET /b", "DELETE /c"}
+	if !reflect.DeepEqual(received, exp) {
+		t.Errorf("Expected requests %q, but got %q", exp, received)
+	}
+	info := b.gatherInfo()
+	if rs := info.Spec.Replay; rs == nil || rs.Path != path ||
+		rs.Format != "common" || rs.Speed != 20 {
+		t.Errorf("Expected replay in spec, but got %+v", rs)
+	}
+}
+
+func TestBombardierPicksBodiesFromDir(t *testing.T) {
+	testAllClients(t, testBombardierPicksBodiesFromDir)
+}
+
+func testBombardierPicksBodiesFromDir(clientType clientTyp, t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received = map[string]uint64{}
+	)
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			mu.Lock()
+			received[string(body)]++
+			mu.Unlock()
+		}),
+	)
+	defer s.Close()
+	dir := writeBodyDir(t, map[string]string{"a": "first", "b": "second"})
+	defer os.RemoveAll(dir)
+	numReqs := uint64(40)
+	b, e := newBombardier(config{
+		numConns:   2,
+		numReqs:    &numReqs,
+		url:        s.URL,
+		bodyDir:    dir,
+		headers:    new(headersList),
+		timeout:    defaultTimeout,
+		method:     "POST",
+		clientType: clientType,
+		format:     knownFormat("plain-text"),
+	})
+	if e != nil {
+		t.Error(e)
+		return
+	}
+	b.disableOutput()
+	b.bombard()
+	if len(received) != 2 || received["first"]+received["second"] != 40 {
+		t.Fatalf("Expected bodies from both files, but got %v", received)
+	}
+	info := b.gatherInfo()
+	exp := []internal.BodyFileCount{
+		{Name: "a", Count: received["first"]},
+		{Name: "b", Count: received["second"]},
+	}
+	if !reflect.DeepEqual(info.Result.BodyFiles, exp) {
+		t.Errorf("Expected %v, but got %v", exp, info.Result.BodyFiles)
+	}
+	if info.Spec.BodyDir != dir {
+		t.Errorf("Expected body dir in spec, but got %q", info.Spec.BodyDir)
+	}
+}
+
+type closeCountingReader struct {
+	io.Reader
+	closed *uint64
+}
+
+func (c *closeCountingReader) Close() error {
+	atomic.AddUint64(c.closed, 1)
+	return nil
+}
+
+func TestBombardierGeneratesBodies(t *testing.T) {
+	testAllClients(t, testBombardierGeneratesBodies)
+}
+
+func testBombardierGeneratesBodies(clientType clientTyp, t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received []string
+		chunked  int
+	)
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			mu.Lock()
+			received = append(received, string(body))
+			if r.ContentLength < 0 {
+				chunked++
+			}
+			mu.Unlock()
+		}),
+	)
+	defer s.Close()
+	var closed uint64
+	gen := internal.BodyGenerator(func(reqNum uint64) io.Reader {
+		body := strings.NewReader(fmt.Sprint(reqNum))
+		if reqNum%2 == 0 {
+			// Length of such body isn't known in advance
+			return &closeCountingReader{body, &closed}
+		}
+		return body
+	})
+	numReqs := uint64(10)
+	b, e := newBombardier(config{
+		numConns:      2,
+		numReqs:       &numReqs,
+		url:           s.URL,
+		bodyGenerator: &gen,
+		headers:       new(headersList),
+		timeout:       defaultTimeout,
+		method:        "POST",
+		clientType:    clientType,
+		format:        knownFormat("plain-text"),
+	})
+	if e != nil {
+		t.Error(e)
+		return
+	}
+	b.disableOutput()
+	b.bombard()
+	sort.Slice(received, func(i, j int) bool {
+		return len(received[i]) < len(received[j]) ||
+			len(received[i]) == len(received[j]) && received[i] < received[j]
+	})
+	exp := []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10"}
+	if !reflect.DeepEqual(received, exp) {
+		t.Errorf("Expected bodies %q, but got %q", exp, received)
+	}
+	if chunked != 5 || atomic.LoadUint64(&closed) != 5 {
+		t.Errorf("Expected 5 bodies to be sent chunked and closed, "+
+			"but got %v and %v", chunked, closed)
+	}
+	if !b.gatherInfo().Spec.BodyGenerated {
+		t.Error("Expected body generator in spec")
+	}
+}
+
+func TestBombardierCapturesValues(t *testing.T) {
+	testAllClients(t, testBombardierCapturesValues)
+}
+
+func testBombardierCapturesValues(clientType clientTyp, t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received []string
+		logins   uint64
+	)
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/login" {
+				n := atomic.AddUint64(&logins, 1)
+				if n == 2 {
+					rw.Write([]byte(`{"error":"try later"}`))
+					return
+				}
+				rw.Header().Set("X-Session", fmt.Sprint("s", n))
+				fmt.Fprintf(rw, `{"user":{"token":"t%v"}}`, n)
+				return
+			}
+			body, _ := ioutil.ReadAll(r.Body)
+			mu.Lock()
+			received = append(received,
+				r.Header.Get("Authorization")+" "+string(body))
+			mu.Unlock()
+		}),
+	)
+	defer s.Close()
+	captures, err := parseCaptures(map[string]string{
+		"token":   "$.user.token",
+		"session": "header:X-Session",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc := &scenario{
+		path: "scenario.json",
+		steps: []scenarioStep{
+			{Method: "POST", URL: s.URL + "/login", captures: captures},
+			{
+				Method: "POST", URL: s.URL + "/items",
+				Headers: map[string]string{
+					"Authorization": "Bearer {{ .token }}",
+				},
+				Body:     "{{ .session }}",
+				Template: true,
+			},
+		},
+	}
+	numReqs := uint64(6)
+	b, e := newBombardier(config{
+		numConns:   1,
+		numReqs:    &numReqs,
+		url:        sc.steps[0].URL,
+		urls:       &[]string{sc.steps[0].URL, sc.steps[1].URL},
+		scenario:   sc,
+		headers:    new(headersList),
+		timeout:    defaultTimeout,
+		method:     "GET",
+		clientType: clientType,
+		format:     knownFormat("plain-text"),
+	})
+	if e != nil {
+		t.Error(e)
+		return
+	}
+	b.disableOutput()
+	b.bombard()
+	// Failed capture leaves previous values in place
+	exp := []string{"Bearer t1 s1", "Bearer t1 s1", "Bearer t3 s3"}
+	if !reflect.DeepEqual(received, exp) {
+		t.Errorf("Expected requests %q, but got %q", exp, received)
+	}
+	captureErr := &captureError{"session", "no X-Session header"}
+	if act := b.errors.get(captureErr); act != 1 {
+		t.Errorf("Expected 1 capture error, but got %v", act)
+	}
+}
+
+func TestBombardierThinkTime(t *testing.T) {
+	testAllClients(t, testBombardierThinkTime)
+}
+
+func testBombardierThinkTime(clientType clientTyp, t *testing.T) {
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {}),
+	)
+	defer s.Close()
+	numReqs := uint64(4)
+	think := 50 * time.Millisecond
+	b, e := newBombardier(config{
+		numConns:   1,
+		numReqs:    &numReqs,
+		url:        s.URL,
+		headers:    new(headersList),
+		timeout:    defaultTimeout,
+		method:     "GET",
+		clientType: clientType,
+		format:     knownFormat("plain-text"),
+		thinkTime:  &thinkTime{think, think},
+	})
+	if e != nil {
+		t.Error(e)
+		return
+	}
+	b.disableOutput()
+	b.bombard()
+	// Only pauses between requests count, not the one after the last
+	if min := 3 * think; b.timeTaken < min {
+		t.Errorf("Expected test to take at least %v, but it took %v",
+			min, b.timeTaken)
+	}
+	info := b.gatherInfo()
+	stats := info.Result.LatenciesStats(nil)
+	if stats.Max >= float64(think/time.Microsecond) {
+		t.Errorf("Expected think time not to be included in latencies, "+
+			"but max is %vus", stats.Max)
+	}
+	if info.Spec.ThinkTime != "50ms" {
+		t.Errorf("Expected think time in spec, but got %q",
+			info.Spec.ThinkTime)
+	}
+}
+
+func TestBombardierJitter(t *testing.T) {
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {}),
+	)
+	defer s.Close()
+	numReqs := uint64(20)
+	rate := uint64(200)
+	jitter := 50 * time.Millisecond
+	b, e := newBombardier(config{
+		numConns:       4,
+		numReqs:        &numReqs,
+		url:            s.URL,
+		headers:        new(headersList),
+		timeout:        defaultTimeout,
+		method:         "GET",
+		clientType:     fhttp,
+		format:         knownFormat("plain-text"),
+		rate:           &rate,
+		correctLatency: true,
+		jitter:         jitter,
+	})
+	if e != nil {
+		t.Fatal(e)
+	}
+	b.disableOutput()
+	b.bombard()
+	info := b.gatherInfo()
+	if info.Result.Req2XX != numReqs {
+		t.Errorf("Expected %v 2xx responses, but got %v",
+			numReqs, info.Result.Req2XX)
+	}
+	stats := info.Result.LatenciesStats(nil)
+	if stats.Max >= float64(jitter/time.Microsecond) {
+		t.Errorf("Expected jitter not to be included in latencies, "+
+			"but max is %vus", stats.Max)
+	}
+	if info.Spec.Jitter != jitter {
+		t.Errorf("Expected jitter %v in spec, but got %v",
+			jitter, info.Spec.Jitter)
+	}
+}
+
+func TestDelayByJitter(t *testing.T) {
+	jitter := 10 * time.Millisecond
+	b := &bombardier{
+		conf: config{jitter: jitter},
+		rngs: newConnRNGs(1, 1),
+	}
+	done := make(chan struct{})
+	for i := 0; i < 10; i++ {
+		start := time.Now()
+		delay, res := b.delayByJitter(0, done)
+		if res != cont {
+			t.Fatalf("Expected delay to complete")
+		}
+		if delay < 0 || delay > jitter {
+			t.Errorf("Expected delay within [0, %v], but got %v",
+				jitter, delay)
+		}
+		if elapsed := time.Since(start); elapsed < delay {
+			t.Errorf("Expected to wait for %v, but waited %v",
+				delay, elapsed)
+		}
+	}
+	b.conf.jitter = time.Hour
+	close(done)
+	if _, res := b.delayByJitter(0, done); res != brk {
+		t.Errorf("Expected delay to be cut short once test is done")
+	}
+}
+
+func TestBombardierReadsServerTime(t *testing.T) {
+	testAllClients(t, testBombardierReadsServerTime)
+}
+
+func testBombardierReadsServerTime(clientType clientTyp, t *testing.T) {
+	var reqs uint64
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			// Every fifth response has malformed header
+			if atomic.AddUint64(&reqs, 1)%5 == 0 {
+				rw.Header().Set("X-Response-Time", "soon")
+			} else {
+				rw.Header().Set("X-Response-Time", "2.5ms")
+			}
+		}),
+	)
+	defer s.Close()
+	numReqs := uint64(20)
+	b, e := newBombardier(config{
+		numConns:         2,
+		numReqs:          &numReqs,
+		url:              s.URL,
+		headers:          new(headersList),
+		timeout:          defaultTimeout,
+		method:           "GET",
+		clientType:       clientType,
+		format:           knownFormat("plain-text"),
+		printLatencies:   true,
+		serverTimeHeader: "x-response-time",
+	})
+	if e != nil {
+		t.Error(e)
+		return
+	}
+	out := new(bytes.Buffer)
+	b.disableOutput()
+	b.redirectOutputTo(out)
+	b.bombard()
+	info := b.gatherInfo()
+	if act := info.Result.ServerTimeErrors; act != numReqs/5 {
+		t.Errorf("Expected %v server time errors, but got %v",
+			numReqs/5, act)
+	}
+	stats := info.Result.ServerTimeStats([]float64{0.5})
+	if stats == nil || stats.Min != 2500 || stats.Max != 2500 {
+		t.Errorf("Expected all server times to be 2.5ms, but got %+v",
+			stats)
+	}
+	if info.Result.Req2XX != numReqs || info.Result.Errors != nil {
+		t.Errorf("Expected server time errors not to fail requests, "+
+			"but got %v 2xx and %v", info.Result.Req2XX,
+			info.Result.Errors)
+	}
+	b.printStats()
+	for _, exp := range []string{
+		"Server Time vs. Latency Distribution",
+		"     50%     2.50ms",
+		"missing or malformed x-response-time header in 4 response(s)",
+	} {
+		if !strings.Contains(out.String(), exp) {
+			t.Errorf("Expected %q in %q", exp, out.String())
+		}
+	}
+}
+
+func TestBombardierSendsBodiesOfRandomSizes(t *testing.T) {
+	testAllClients(t, testBombardierSendsBodiesOfRandomSizes)
+}
+
+func testBombardierSendsBodiesOfRandomSizes(
+	clientType clientTyp, t *testing.T,
+) {
+	var mu sync.Mutex
+	var sizes []int64
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil || int64(len(body)) != r.ContentLength {
+				rw.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			mu.Lock()
+			sizes = append(sizes, r.ContentLength)
+			mu.Unlock()
+		}),
+	)
+	defer s.Close()
+	numReqs := uint64(50)
+	size := &bodySize{100, 2000, lognormalBodySize}
+	b, e := newBombardier(config{
+		numConns:   2,
+		numReqs:    &numReqs,
+		url:        s.URL,
+		headers:    new(headersList),
+		timeout:    defaultTimeout,
+		method:     "POST",
+		clientType: clientType,
+		format:     knownFormat("json"),
+		bodySize:   size,
+	})
+	if e != nil {
+		t.Error(e)
+		return
+	}
+	out := new(bytes.Buffer)
+	b.disableOutput()
+	b.redirectOutputTo(out)
+	b.bombard()
+	if b.req2xx != numReqs {
+		t.Errorf("Expected %v 2xx responses, but got %v", numReqs, b.req2xx)
+	}
+	var min, max int64 = math.MaxInt64, 0
+	for _, n := range sizes {
+		if n < min {
+			min = n
+		}
+		if n > max {
+			max = n
+		}
+	}
+	if min < 100 || max > 2000 || min == max {
+		t.Errorf("Expected bodies of various sizes within %v, but got "+
+			"%v to %v", size, min, max)
+	}
+	info := b.gatherInfo()
+	stats := info.Result.RequestSizeStats([]float64{0.5})
+	if stats == nil || stats.Min != float64(min) ||
+		stats.Max != float64(max) {
+		t.Errorf("Expected recorded sizes from %v to %v, but got %+v",
+			min, max, stats)
+	}
+	b.printStats()
+	for _, exp := range []string{
+		`"bodySize":"100B-2000B:lognormal"`, `"requestSize":{"mean":`,
+	} {
+		if !strings.Contains(out.String(), exp) {
+			t.Errorf("Expected %q in %q", exp, out.String())
+		}
+	}
+}
+
+func TestBombardierRecordsInterArrivals(t *testing.T) {
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {}),
+	)
+	defer s.Close()
+	numReqs, rate := uint64(30), uint64(100)
+	b, e := newBombardier(config{
+		numConns:       1,
+		numReqs:        &numReqs,
+		rate:           &rate,
+		url:            s.URL,
+		headers:        new(headersList),
+		timeout:        defaultTimeout,
+		method:         "GET",
+		clientType:     fhttp,
+		format:         knownFormat("plain-text"),
+		printLatencies: true,
+		interArrival:   true,
+	})
+	if e != nil {
+		t.Fatal(e)
+	}
+	out := new(bytes.Buffer)
+	b.disableOutput()
+	b.redirectOutputTo(out)
+	b.bombard()
+	info := b.gatherInfo()
+	// The first completion only marks the time
+	gaps := uint64(0)
+	info.Result.InterArrivals.VisitAll(func(_, count uint64) bool {
+		gaps += count
+		return true
+	})
+	if gaps != numReqs-1 {
+		t.Errorf("Expected %v gaps, but got %v", numReqs-1, gaps)
+	}
+	stats := info.Result.InterArrivalStats([]float64{0.5})
+	if stats == nil || stats.Mean < 5000 || stats.Mean > 20000 {
+		t.Errorf("Expected mean gap of about 10ms, but got %+v", stats)
+	}
+	b.printStats()
+	if exp := "Inter-arrival Gap Distribution"; !strings.Contains(
+		out.String(), exp) {
+		t.Errorf("Expected %q in %q", exp, out.String())
+	}
+}
+
+func TestBombardierSplitsBytesRead(t *testing.T) {
+	testAllClients(t, testBombardierSplitsBytesRead)
+}
+
+func testBombardierSplitsBytesRead(clientType clientTyp, t *testing.T) {
+	body := strings.Repeat("a", 100)
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			rw.Header().Set("Content-Length", strconv.Itoa(len(body)))
+			rw.Header().Set("X-Padding", strings.Repeat("b", 200))
+			rw.Write([]byte(body))
+		}),
+	)
+	defer s.Close()
+	numReqs := uint64(10)
+	b, e := newBombardier(config{
+		numConns:   1,
+		numReqs:    &numReqs,
+		url:        s.URL,
+		headers:    new(headersList),
+		timeout:    defaultTimeout,
+		method:     "GET",
+		clientType: clientType,
+		format:     knownFormat("plain-text"),
+	})
+	if e != nil {
+		t.Error(e)
+		return
+	}
+	out := new(bytes.Buffer)
+	b.disableOutput()
+	b.redirectOutputTo(out)
+	b.bombard()
+	res := b.gatherInfo().Result
+	if res.BodyBytesRead != int64(numReqs)*int64(len(body)) {
+		t.Errorf("Expected %v body bytes, but got %v",
+			int64(numReqs)*int64(len(body)), res.BodyBytesRead)
+	}
+	// Plain HTTP/1.1 has no overhead for the split to miss
+	if res.HeaderBytesRead+res.BodyBytesRead != res.BytesRead {
+		t.Errorf("Expected %v header and %v body bytes to add up to %v",
+			res.HeaderBytesRead, res.BodyBytesRead, res.BytesRead)
+	}
+	b.printStats()
+	if exp := "Read:      headers - "; !strings.Contains(out.String(), exp) {
+		t.Errorf("Expected %q in %q", exp, out.String())
+	}
+}
+
+func TestBombardierALPN(t *testing.T) {
+	var proto int64
+	s := httptest.NewUnstartedServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			atomic.StoreInt64(&proto, int64(r.ProtoMajor))
+		}),
+	)
+	s.EnableHTTP2 = true
+	s.StartTLS()
+	defer s.Close()
+	expectations := []struct {
+		clientType clientTyp
+		alpn       []string
+		proto      int64
+	}{
+		{nhttp2, nil, 2},
+		{nhttp2, []string{"h2"}, 2},
+		{nhttp2, []string{"http/1.1"}, 1},
+		{nhttp1, []string{"http/1.1"}, 1},
+		{fhttp, []string{"http/1.1"}, 1},
+	}
+	for _, e := range expectations {
+		atomic.StoreInt64(&proto, 0)
+		numReqs := uint64(2)
+		c := config{
+			numConns:   1,
+			numReqs:    &numReqs,
+			url:        s.URL,
+			headers:    new(headersList),
+			timeout:    defaultTimeout,
+			method:     "GET",
+			clientType: e.clientType,
+			format:     knownFormat("json"),
+			insecure:   true,
+		}
+		if e.alpn != nil {
+			c.alpn = &e.alpn
+		}
+		b, err := newBombardier(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := new(bytes.Buffer)
+		b.disableOutput()
+		b.redirectOutputTo(out)
+		b.bombard()
+		if b.req2xx != numReqs {
+			t.Errorf("%v, %v: expected %v 2xx responses, but got %v",
+				e.clientType, e.alpn, numReqs, b.req2xx)
+		}
+		if act := atomic.LoadInt64(&proto); act != e.proto {
+			t.Errorf("%v, %v: expected HTTP/%v, but got HTTP/%v",
+				e.clientType, e.alpn, e.proto, act)
+		}
+		b.printStats()
+		if e.alpn != nil &&
+			!strings.Contains(out.String(), `"alpn":["`+e.alpn[0]+`"]`) {
+			t.Errorf("Expected ALPN protocols in %q", out.String())
+		}
+	}
+}
+
+func TestBombardierMaxConnsPerHost(t *testing.T) {
+	testAllClients(t, testBombardierMaxConnsPerHost)
+}
+
+func testBombardierMaxConnsPerHost(clientType clientTyp, t *testing.T) {
+	var (
+		mu         sync.Mutex
+		open, peak int
+	)
+	s := httptest.NewUnstartedServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			time.Sleep(5 * time.Millisecond)
+		}),
+	)
+	s.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch state {
+		case http.StateNew:
+			open++
+			if open > peak {
+				peak = open
+			}
+		case http.StateClosed, http.StateHijacked:
+			open--
+		}
+	}
+	s.Start()
+	defer s.Close()
+	numReqs := uint64(40)
+	urls := []string{s.URL + "/a", s.URL + "/b"}
+	b, e := newBombardier(config{
+		numConns:        8,
+		numReqs:         &numReqs,
+		url:             urls[0],
+		urls:            &urls,
+		headers:         new(headersList),
+		timeout:         defaultTimeout,
+		method:          "GET",
+		clientType:      clientType,
+		format:          knownFormat("plain-text"),
+		maxConnsPerHost: 2,
+	})
+	if e != nil {
+		t.Error(e)
+		return
+	}
+	b.disableOutput()
+	b.bombard()
+	if b.req2xx != numReqs {
+		t.Errorf("Expected %v successful requests, but got %v",
+			numReqs, b.req2xx)
+	}
+	mu.Lock()
+	if peak > 2 {
+		t.Errorf("Expected at most 2 connections at once, but got %v",
+			peak)
+	}
+	mu.Unlock()
+	info := b.gatherInfo()
+	if info.Spec.MaxConnsPerHost != 2 {
+		t.Errorf("Expected limit of 2 in spec, but got %v",
+			info.Spec.MaxConnsPerHost)
+	}
+	hcs := info.Result.HostConns
+	if len(hcs) != 1 || hcs[0].MaxOpen == 0 || hcs[0].MaxOpen > 2 {
+		t.Errorf("Expected a single host with at most 2 connections, "+
+			"but got %+v", hcs)
+	}
+}
+
+func TestBombardierInFlight(t *testing.T) {
+	testAllClients(t, testBombardierInFlight)
+}
+
+func testBombardierInFlight(clientType clientTyp, t *testing.T) {
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			time.Sleep(20 * time.Millisecond)
+		}),
+	)
+	defer s.Close()
+	numReqs := uint64(40)
+	numConns := uint64(4)
+	b, e := newBombardier(config{
+		numConns:   numConns,
+		numReqs:    &numReqs,
+		url:        s.URL,
+		headers:    new(headersList),
+		timeout:    defaultTimeout,
+		method:     "GET",
+		clientType: clientType,
+		format:     knownFormat("plain-text"),
+	})
+	if e != nil {
+		t.Error(e)
+		return
+	}
+	b.inFlight.interval = 10 * time.Millisecond
+	b.disableOutput()
+	b.bombard()
+	info := b.gatherInfo()
+	if max := info.Result.MaxInFlight; max == 0 || max > numConns {
+		t.Errorf("Expected between 1 and %v requests in flight, but got %v",
+			numConns, max)
+	}
+	if len(info.Result.InFlight) == 0 {
+		t.Fatal("Expected number of requests in flight to be sampled")
+	}
+	prev := time.Duration(0)
+	for _, sample := range info.Result.InFlight {
+		if sample.Requests > numConns || sample.Elapsed < prev {
+			t.Errorf("Unexpected samples: %+v", info.Result.InFlight)
+			break
+		}
+		prev = sample.Elapsed
+	}
+	if b.inFlight.current() != 0 {
+		t.Errorf("Expected no requests in flight after the test, "+
+			"but got %v", b.inFlight.current())
+	}
+}
+
+func TestBombardierCorrectsLatency(t *testing.T) {
+	testAllClients(t, testBombardierCorrectsLatency)
+}
+
+func testBombardierCorrectsLatency(clientType clientTyp, t *testing.T) {
+	const stall = 200 * time.Millisecond
+	for _, correct := range []bool{false, true} {
+		var served int32
+		s := httptest.NewServer(
+			http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&served, 1) == 1 {
+					time.Sleep(stall)
+				}
+			}),
+		)
+		numReqs := uint64(10)
+		rate := uint64(100)
+		b, e := newBombardier(config{
+			numConns:       1,
+			numReqs:        &numReqs,
+			url:            s.URL,
+			headers:        new(headersList),
+			timeout:        defaultTimeout,
+			method:         "GET",
+			clientType:     clientType,
+			format:         knownFormat("plain-text"),
+			rate:           &rate,
+			correctLatency: correct,
+		})
+		if e != nil {
+			t.Error(e)
+			s.Close()
+			return
+		}
+		b.disableOutput()
+		b.bombard()
+		s.Close()
+		// Requests scheduled during the stall were sent late, only
+		// the stalled one is slow unless that is corrected for
+		slow := uint64(0)
+		b.latencies.VisitAll(func(us uint64, count uint64) bool {
+			if us >= uint64(stall/time.Microsecond)/2 {
+				slow += count
+			}
+			return true
+		})
+		if !correct && slow != 1 {
+			t.Errorf("Expected a single slow request, but got %v", slow)
+		}
+		if correct && slow < 5 {
+			t.Errorf("Expected at least 5 slow requests once corrected, "+
+				"but got %v", slow)
+		}
+		if info := b.gatherInfo(); info.Spec.CorrectedLatency != correct {
+			t.Errorf("Expected corrected latency in spec to be %v", correct)
+		}
+	}
+}
+
+func TestBombardierDisableKeepAlive(t *testing.T) {
+	testAllClients(t, testBombardierDisableKeepAlive)
+}
+
+func testBombardierDisableKeepAlive(clientType clientTyp, t *testing.T) {
+	var conns, closing uint64
+	s := httptest.NewUnstartedServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			if r.Close {
+				atomic.AddUint64(&closing, 1)
+			}
+		}),
+	)
+	s.Config.ConnState = func(c net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddUint64(&conns, 1)
+		}
+	}
+	s.Start()
+	defer s.Close()
+	numReqs := uint64(20)
+	b, e := newBombardier(config{
+		numConns:         2,
+		numReqs:          &numReqs,
+		url:              s.URL,
+		headers:          new(headersList),
+		timeout:          defaultTimeout,
+		method:           "GET",
+		clientType:       clientType,
+		format:           knownFormat("plain-text"),
+		disableKeepAlive: true,
+	})
+	if e != nil {
+		t.Error(e)
+		return
+	}
+	b.disableOutput()
+	b.bombard()
+	if got := atomic.LoadUint64(&conns); got != numReqs {
+		t.Errorf("Expected %v connections, but server got %v", numReqs, got)
+	}
+	if got := atomic.LoadUint64(&closing); got != numReqs {
+		t.Errorf("Expected %v requests with Connection: close, but got %v",
+			numReqs, got)
+	}
+	info := b.gatherInfo()
+	if info.Result.ConnsOpened != numReqs || info.Result.ReusedConns != 0 {
+		t.Errorf("Expected %v connections opened and none reused, "+
+			"but got %v and %v", numReqs,
+			info.Result.ConnsOpened, info.Result.ReusedConns)
+	}
+	if !info.Spec.DisableKeepAlive {
+		t.Error("Expected disabled keep-alive in spec")
+	}
+}
+
+func TestBombardierLocalAddrs(t *testing.T) {
+	testAllClients(t, testBombardierLocalAddrs)
+}
+
+func testBombardierLocalAddrs(clientType clientTyp, t *testing.T) {
+	var (
+		mu    sync.Mutex
+		hosts = make(map[string]uint64)
+	)
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			host, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			hosts[host]++
+			mu.Unlock()
+		}),
+	)
+	defer s.Close()
+	numReqs := uint64(10)
+	locals := localAddrsList{"127.0.0.2", "127.0.0.3"}
+	b, e := newBombardier(config{
+		numConns:         2,
+		numReqs:          &numReqs,
+		url:              s.URL,
+		headers:          new(headersList),
+		timeout:          defaultTimeout,
+		method:           "GET",
+		clientType:       clientType,
+		format:           knownFormat("plain-text"),
+		disableKeepAlive: true,
+		localAddrs:       &locals,
+	})
+	if e != nil {
+		t.Error(e)
+		return
+	}
+	b.disableOutput()
+	b.bombard()
+	exp := map[string]uint64{"127.0.0.2": 5, "127.0.0.3": 5}
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(hosts, exp) {
+		t.Errorf("Expected requests from %v, but got %v", exp, hosts)
+	}
+	if spec := b.gatherInfo().Spec; !reflect.DeepEqual(
+		spec.LocalAddrs, []string(locals),
+	) {
+		t.Errorf("Expected local addresses %v in spec, but got %v",
+			locals, spec.LocalAddrs)
+	}
+}
+
+func TestBombardierJSONSchemaVersion(t *testing.T) {
+	reqs := uint64(0)
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			codes := []int{200, 201, 404, 500}
+			n := atomic.AddUint64(&reqs, 1)
+			rw.WriteHeader(codes[n%uint64(len(codes))])
+		}),
+	)
+	defer s.Close()
+	numReqs := uint64(20)
+	b, e := newBombardier(config{
+		numConns:   2,
+		numReqs:    &numReqs,
+		url:        s.URL,
+		headers:    new(headersList),
+		timeout:    defaultTimeout,
+		method:     "GET",
+		format:     knownFormat("json"),
+		timeSeries: true,
+	})
+	if e != nil {
+		t.Fatal(e)
+	}
+	out := new(bytes.Buffer)
+	b.redirectOutputTo(out)
+	b.bombard()
+	out.Reset()
+	b.printStats()
+	first := out.String()
+	prefix := `{"schemaVersion":` + jsonSchemaVersion + `,"spec":{`
+	if !strings.HasPrefix(first, prefix) {
+		t.Errorf("Expected output to start with %q, but got %q",
+			prefix, first)
+	}
+	var res struct {
+		SchemaVersion int
+		Spec          struct {
+			NumberOfConnections uint64
+		}
+	}
+	if err := json.Unmarshal([]byte(first), &res); err != nil {
+		t.Fatalf("%v: %s", err, first)
+	}
+	if res.SchemaVersion != 1 || res.Spec.NumberOfConnections != 2 {
+		t.Errorf("Unexpected output: %s", first)
+	}
+	for i := 0; i < 10; i++ {
+		out.Reset()
+		b.printStats()
+		if out.String() != first {
+			t.Fatalf("Expected the same output every time, but got "+
+				"%s and %s", first, out.String())
+		}
+	}
+}
+
+func TestBombardierQuietOutput(t *testing.T) {
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {}),
+	)
+	defer s.Close()
+	numReqs := uint64(50)
+	b, e := newBombardier(config{
+		numConns:    2,
+		numReqs:     &numReqs,
+		url:         s.URL,
+		headers:     new(headersList),
+		timeout:     defaultTimeout,
+		method:      "GET",
+		clientType:  fhttp,
+		printResult: true,
+		quiet:       true,
+		format:      knownFormat("json"),
+		latencyAssertions: &latencyAssertionsList{
+			{percentile: 0.5, max: time.Nanosecond},
+		},
+	})
+	if e != nil {
+		t.Fatal(e)
+	}
+	if b.errOut != os.Stderr {
+		t.Error("Expected errors to be written to stderr")
+	}
+	out, errOut := new(bytes.Buffer), new(bytes.Buffer)
+	b.disableOutput()
+	b.out, b.errOut = out, errOut
+	b.bombard()
+	if current := b.bar.Get(); current != 0 {
+		t.Errorf("Expected progress not to be tracked, but got %v", current)
+	}
+	if out.Len() != 0 {
+		t.Errorf("Expected nothing to be output during the test, got %q", out)
+	}
+	b.printStats()
+	b.printLatencyBreaches(b.checkLatencyAssertions())
+	if !json.Valid(out.Bytes()) {
+		t.Errorf("Expected only valid JSON, but got %q", out)
+	}
+	if !strings.Contains(errOut.String(), "Latency assertions failed") {
+		t.Errorf("Expected breaches among errors, but got %q", errOut)
+	}
+}
+
+func TestBombardierUserDefinedTemplate(t *testing.T) {
+	f, err := ioutil.TempFile("", "bombardier-*.tmpl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(
+		`{{ .Result.NumberOfRequests }} in {{ FormatDuration .Spec.TestDuration }}`,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {}),
+	)
+	defer s.Close()
+	numReqs := uint64(10)
+	duration := 90 * time.Second
+	b, e := newBombardier(config{
+		numConns:    1,
+		numReqs:     &numReqs,
+		duration:    &duration,
+		url:         s.URL,
+		headers:     new(headersList),
+		timeout:     defaultTimeout,
+		method:      "GET",
+		clientType:  fhttp,
+		printResult: true,
+		format:      userDefinedTemplate(f.Name()),
+	})
+	if e != nil {
+		t.Fatal(e)
+	}
+	out := new(bytes.Buffer)
+	b.disableOutput()
+	b.redirectOutputTo(out)
+	b.bombard()
+	b.printStats()
+	if exp := "10 in 1.50m"; out.String() != exp {
+		t.Errorf("Expected %q, but got %q", exp, out)
+	}
+}
+
+func TestBombardierMarkdownOutput(t *testing.T) {
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/fail" {
+				rw.WriteHeader(http.StatusInternalServerError)
+			}
+		}),
+	)
+	defer s.Close()
+	numReqs := uint64(20)
+	pcs := []float64{0.5, 0.99}
+	b, e := newBombardier(config{
+		numConns:    2,
+		numReqs:     &numReqs,
+		url:         s.URL,
+		urls:        &[]string{s.URL, s.URL + "/fail"},
+		headers:     new(headersList),
+		timeout:     defaultTimeout,
+		method:      "GET",
+		clientType:  fhttp,
+		printResult: true,
+		percentiles: &pcs,
+		format:      knownFormat("markdown"),
+	})
+	if e != nil {
+		t.Fatal(e)
+	}
+	out := new(bytes.Buffer)
+	b.disableOutput()
+	b.redirectOutputTo(out)
+	b.bombard()
+	b.printStats()
+	tables := strings.Split(strings.TrimSpace(out.String()), "\n\n")
+	if len(tables) != 4 {
+		t.Fatalf("Expected 4 tables, but got %v in %q", len(tables), out)
+	}
+	for _, table := range tables {
+		rows := strings.Split(table, "\n")
+		if len(rows) < 3 {
+			t.Errorf("Expected header, separator and data in %q", table)
+			continue
+		}
+		cols := strings.Count(rows[0], "|")
+		for _, row := range rows {
+			if !strings.HasPrefix(row, "| ") || !strings.HasSuffix(row, " |") ||
+				strings.Count(row, "|") != cols {
+				t.Errorf("Malformed row %q in table %q", row, table)
+			}
+		}
+		for _, cell := range strings.Split(strings.Trim(rows[1], "| "), " | ") {
+			if cell != "---" && cell != "---:" {
+				t.Errorf("Malformed separator %q in table %q", rows[1], table)
+			}
+		}
+	}
+	for _, exp := range []string{
+		"| 50% | ", "| 99% | ", "| 2xx | 10 |", "| 5xx | 10 |",
+	} {
+		if !strings.Contains(out.String(), exp) {
+			t.Errorf("Expected %q in %q", exp, out)
+		}
+	}
+}
+
+func TestBombardierPrimesConnections(t *testing.T) {
+	var received, newConns uint64
+	s := httptest.NewUnstartedServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			atomic.AddUint64(&received, 1)
+			rw.Write([]byte("primed"))
+		}),
+	)
+	s.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddUint64(&newConns, 1)
+		}
+	}
+	s.Start()
+	defer s.Close()
+	numConns, numReqs := uint64(4), uint64(20)
+	b, e := newBombardier(config{
+		numConns:   numConns,
+		numReqs:    &numReqs,
+		url:        s.URL,
+		headers:    new(headersList),
+		timeout:    defaultTimeout,
+		method:     "GET",
+		clientType: fhttp,
+		primeConns: true,
+		printIntro: true,
+		format:     knownFormat("plain-text"),
+	})
+	if e != nil {
+		t.Fatal(e)
+	}
+	out := new(bytes.Buffer)
+	b.disableOutput()
+	b.redirectOutputTo(out)
+	b.bombard()
+	if exp, act := numConns+numReqs, atomic.LoadUint64(&received); exp != act {
+		t.Errorf("Expected %v requests to be received, but got %v", exp, act)
+	}
+	if act := atomic.LoadUint64(&newConns); act > numConns {
+		t.Errorf("Expected at most %v connections, but got %v", numConns, act)
+	}
+	info := b.gatherInfo()
+	if act := info.Result.NumberOfRequests(); act != numReqs {
+		t.Errorf("Expected %v requests to be recorded, but got %v",
+			numReqs, act)
+	}
+	if info.Result.PrimingTime <= 0 {
+		t.Error("Expected priming time to be recorded")
+	}
+	if !strings.Contains(out.String(), "Primed 4 connection(s) in ") {
+		t.Errorf("Expected priming to be reported, but got %q", out)
+	}
+	b.redirectOutputTo(out)
+	b.printStats()
+	if !strings.Contains(out.String(), "Priming:   took ") {
+		t.Errorf("Expected priming time in results, but got %q", out)
+	}
+}
+
+func TestBombardierPrimingDoesntShortenTimedTest(t *testing.T) {
+	var reqs uint64
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			if atomic.AddUint64(&reqs, 1) == 1 {
+				time.Sleep(600 * time.Millisecond)
+			}
+		}),
+	)
+	defer s.Close()
+	duration := time.Second
+	b, e := newBombardier(config{
+		numConns:   1,
+		duration:   &duration,
+		url:        s.URL,
+		headers:    new(headersList),
+		timeout:    defaultTimeout,
+		method:     "GET",
+		clientType: nhttp1,
+		primeConns: true,
+		format:     knownFormat("plain-text"),
+	})
+	if e != nil {
+		t.Fatal(e)
+	}
+	b.disableOutput()
+	b.bombard()
+	if b.primingTime < 600*time.Millisecond {
+		t.Errorf("Expected priming to take at least 600ms, but it took %v",
+			b.primingTime)
+	}
+	if b.timeTaken < duration-50*time.Millisecond {
+		t.Errorf("Expected test to last %v, but it took %v",
+			duration, b.timeTaken)
+	}
+}
+
+func TestBombardierPrintsSummaryWhenAllRequestsFail(t *testing.T) {
+	testAllClients(t, testBombardierPrintsSummaryWhenAllRequestsFail)
+}
+
+func testBombardierPrintsSummaryWhenAllRequestsFail(
+	clientType clientTyp, t *testing.T,
+) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Nothing listens on the address anymore, connections are refused
+	addr := l.Addr().String()
+	l.Close()
+	numReqs := uint64(10)
+	for _, format := range []string{"plain-text", "json"} {
+		b, e := newBombardier(config{
+			numConns:       2,
+			numReqs:        &numReqs,
+			url:            "http://" + addr,
+			headers:        new(headersList),
+			timeout:        defaultTimeout,
+			method:         "GET",
+			clientType:     clientType,
+			printLatencies: true,
+			printResult:    true,
+			format:         knownFormat(format),
+		})
+		if e != nil {
+			t.Fatal(e)
+		}
+		b.disableOutput()
+		b.bombard()
+		out := new(bytes.Buffer)
+		b.redirectOutputTo(out)
+		b.printStats()
+		if format == "json" {
+			if !json.Valid(out.Bytes()) {
+				t.Errorf("Expected valid JSON, but got %q", out)
+			}
+			continue
+		}
+		for _, exp := range []string{
+			"No successful requests, all 10 of them failed.",
+			"  Errors:",
+			"connection refused - 10",
+		} {
+			if !strings.Contains(out.String(), exp) {
+				t.Errorf("Expected %q in the summary, but got:\n%v", exp, out)
+			}
+		}
+	}
+}
+
+func TestBombardierPrintsSummaryWithoutRequests(t *testing.T) {
+	for _, format := range []string{"plain-text", "json"} {
+		numReqs := uint64(10)
+		b, e := newBombardier(config{
+			numConns:       2,
+			numReqs:        &numReqs,
+			url:            "http://localhost",
+			headers:        new(headersList),
+			timeout:        defaultTimeout,
+			method:         "GET",
+			printLatencies: true,
+			printResult:    true,
+			format:         knownFormat(format),
+		})
+		if e != nil {
+			t.Fatal(e)
+		}
+		// Test is never performed, so that no requests complete
+		out := new(bytes.Buffer)
+		b.redirectOutputTo(out)
+		b.printStats()
+		if format == "json" {
+			if !json.Valid(out.Bytes()) {
+				t.Errorf("Expected valid JSON, but got %q", out)
+			}
+			continue
+		}
+		for _, exp := range []string{
+			"There wasn't enough data to compute statistics for requests.",
+			"There wasn't enough data to compute statistics for latencies.",
+			"  HTTP codes:",
+		} {
+			if !strings.Contains(out.String(), exp) {
+				t.Errorf("Expected %q in the summary, but got:\n%v", exp, out)
+			}
+		}
+		if strings.Contains(out.String(), "No successful requests") {
+			t.Errorf("Requests that were never sent can't fail:\n%v", out)
+		}
+	}
+}
+
+func TestBombardierReportsAchievedRate(t *testing.T) {
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			time.Sleep(50 * time.Millisecond)
+		}),
+	)
+	defer s.Close()
+	// Single connection can't do more than 20 requests per second
+	rate := uint64(100)
+	testDuration := 1 * time.Second
+	for _, format := range []string{"plain-text", "json"} {
+		b, e := newBombardier(config{
+			numConns:    1,
+			duration:    &testDuration,
+			url:         s.URL,
+			headers:     new(headersList),
+			timeout:     defaultTimeout,
+			method:      "GET",
+			rate:        &rate,
+			clientType:  fhttp,
+			printResult: true,
+			format:      knownFormat(format),
+		})
+		if e != nil {
+			t.Fatal(e)
+		}
+		b.disableOutput()
+		b.bombard()
+		out := new(bytes.Buffer)
+		b.redirectOutputTo(out)
+		b.printStats()
+		if format == "json" {
+			var res struct {
+				Result struct {
+					AchievedRate float64 `json:"achievedRate"`
+				} `json:"result"`
+			}
+			if err := json.Unmarshal(out.Bytes(), &res); err != nil {
+				t.Fatal(err, out)
+			}
+			if ar := res.Result.AchievedRate; ar <= 0 || ar > 50 {
+				t.Errorf("Unexpected achieved rate: %v", ar)
+			}
+			continue
+		}
+		for _, exp := range []string{
+			"Rate:        requested 100.00/s, achieved ",
+			"% short)",
+		} {
+			if !strings.Contains(out.String(), exp) {
+				t.Errorf("Expected %q in %q", exp, out)
+			}
+		}
+	}
+}
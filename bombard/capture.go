@@ -0,0 +1,206 @@
+package bombard
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const captureHeaderPrefix = "header:"
+
+// capture extracts a value from response into a variable available
+// to templates of subsequent requests of the connection.
+type capture struct {
+	name, source string
+	// header is the name of header value is taken from, empty if
+	// it's taken from JSON body
+	header string
+	path   jsonPath
+}
+
+type captures []capture
+
+// parseCaptures parses sources, which are either JSON paths (i.e.
+// "$.data.token") or header names prefixed with "header:", of the
+// variables. Captures are sorted by name.
+func parseCaptures(sources map[string]string) (captures, error) {
+	cs := make(captures, 0, len(sources))
+	for name, source := range sources {
+		if name == "RequestNum" || name == "ConnID" {
+			return nil, fmt.Errorf("variable name %v is reserved", name)
+		}
+		c := capture{name: name, source: source}
+		if strings.HasPrefix(source, captureHeaderPrefix) {
+			c.header = strings.TrimPrefix(source, captureHeaderPrefix)
+			if c.header == "" {
+				return nil, fmt.Errorf("no header name to capture %v from",
+					name)
+			}
+		} else {
+			path, err := parseJSONPath(source)
+			if err != nil {
+				return nil, fmt.Errorf("capture of %v: %v", name, err)
+			}
+			c.path = path
+		}
+		cs = append(cs, c)
+	}
+	sort.Slice(cs, func(i, j int) bool {
+		return cs[i].name < cs[j].name
+	})
+	return cs, nil
+}
+
+// needBody tells whether any of the values are taken from body.
+func (cs captures) needBody() bool {
+	for _, c := range cs {
+		if c.header == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// extract stores captured values into vars. Values are only stored
+// if all of them were extracted successfully.
+func (cs captures) extract(
+	header func(string) string, body []byte, vars map[string]string,
+) error {
+	var (
+		doc    interface{}
+		docErr error
+		parsed bool
+	)
+	values := make([]string, len(cs))
+	for i, c := range cs {
+		if c.header != "" {
+			v := header(c.header)
+			if v == "" {
+				return &captureError{c.name, "no " + c.header + " header"}
+			}
+			values[i] = v
+			continue
+		}
+		if !parsed {
+			d := json.NewDecoder(bytes.NewReader(body))
+			d.UseNumber()
+			docErr = d.Decode(&doc)
+			parsed = true
+		}
+		if docErr != nil {
+			return &captureError{c.name, "response body isn't valid JSON"}
+		}
+		v, ok := c.path.lookup(doc)
+		if !ok {
+			return &captureError{c.name, "nothing at " + c.source}
+		}
+		values[i] = jsonValueString(v)
+	}
+	for i, c := range cs {
+		vars[c.name] = values[i]
+	}
+	return nil
+}
+
+type captureError struct {
+	name, reason string
+}
+
+func (c *captureError) Error() string {
+	return fmt.Sprintf("capture failed: %v: %v", c.name, c.reason)
+}
+
+// jsonPath is a sequence of object keys (strings) and array indices
+// (ints) leading to a value in JSON document.
+type jsonPath []interface{}
+
+// parseJSONPath parses paths like "$.items[0].id".
+func parseJSONPath(s string) (jsonPath, error) {
+	if !strings.HasPrefix(s, "$") {
+		return nil, fmt.Errorf("JSON path %q must start with $", s)
+	}
+	var path jsonPath
+	rest := s[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			key := rest[1 : end+1]
+			if key == "" {
+				return nil, fmt.Errorf("empty key in JSON path %q", s)
+			}
+			path = append(path, key)
+			rest = rest[end+1:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed [ in JSON path %q", s)
+			}
+			i, err := strconv.Atoi(rest[1:end])
+			if err != nil || i < 0 {
+				return nil, fmt.Errorf("invalid index in JSON path %q", s)
+			}
+			path = append(path, i)
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("invalid JSON path %q", s)
+		}
+	}
+	return path, nil
+}
+
+func (p jsonPath) lookup(doc interface{}) (interface{}, bool) {
+	for _, seg := range p {
+		switch s := seg.(type) {
+		case string:
+			obj, ok := doc.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			if doc, ok = obj[s]; !ok {
+				return nil, false
+			}
+		case int:
+			arr, ok := doc.([]interface{})
+			if !ok || s >= len(arr) {
+				return nil, false
+			}
+			doc = arr[s]
+		}
+	}
+	return doc, true
+}
+
+// jsonValueString returns strings as they are and other values in
+// JSON encoding.
+func jsonValueString(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	// Values decoded from JSON can always be encoded back
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// connVars holds variables captured by every connection, each of them
+// is only accessed by the connection's worker.
+type connVars []map[string]string
+
+// newConnVars creates variables for every connection, all of them are
+// initially empty.
+func newConnVars(numConns uint64, names []string) connVars {
+	vars := make(connVars, numConns)
+	for i := range vars {
+		vars[i] = make(map[string]string, len(names))
+		for _, name := range names {
+			vars[i][name] = ""
+		}
+	}
+	return vars
+}
@@ -0,0 +1,127 @@
+package bombard
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestParseJSONPath(t *testing.T) {
+	expectations := []struct {
+		in  string
+		out jsonPath
+		err bool
+	}{
+		{"$", nil, false},
+		{"$.token", jsonPath{"token"}, false},
+		{"$.data.items[2].id", jsonPath{"data", "items", 2, "id"}, false},
+		{"$[0][1]", jsonPath{0, 1}, false},
+		{"token", nil, true},
+		{"$..token", nil, true},
+		{"$.items[", nil, true},
+		{"$.items[-1]", nil, true},
+		{"$.items[a]", nil, true},
+		{"$token", nil, true},
+	}
+	for _, e := range expectations {
+		act, err := parseJSONPath(e.in)
+		if e.err != (err != nil) {
+			t.Errorf("%v: unexpected error %v", e.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(act, e.out) {
+			t.Errorf("%v: expected %v, but got %v", e.in, e.out, act)
+		}
+	}
+}
+
+func TestParseCaptures(t *testing.T) {
+	cs, err := parseCaptures(map[string]string{
+		"token": "$.token",
+		"etag":  "header:ETag",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := captures{
+		{name: "etag", source: "header:ETag", header: "ETag"},
+		{name: "token", source: "$.token", path: jsonPath{"token"}},
+	}
+	if !reflect.DeepEqual(cs, exp) {
+		t.Errorf("Expected %+v, but got %+v", exp, cs)
+	}
+	for _, invalid := range []map[string]string{
+		{"token": "token"},
+		{"etag": "header:"},
+		{"ConnID": "$.id"},
+	} {
+		if _, err := parseCaptures(invalid); err == nil {
+			t.Errorf("%v: expected an error", invalid)
+		}
+	}
+}
+
+func TestCapturesExtract(t *testing.T) {
+	cs, err := parseCaptures(map[string]string{
+		"token":   "$.token",
+		"id":      "$.items[1].id",
+		"items":   "$.items[0]",
+		"session": "header:X-Session",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := http.Header{"X-Session": {"s1"}}
+	vars := map[string]string{}
+	err = cs.extract(header.Get,
+		[]byte(`{"token":"abc","items":[{"a":true},{"id":12345678901234}]}`),
+		vars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := map[string]string{
+		"token": "abc", "id": "12345678901234", "items": `{"a":true}`,
+		"session": "s1",
+	}
+	if !reflect.DeepEqual(vars, exp) {
+		t.Errorf("Expected %v, but got %v", exp, vars)
+	}
+
+	expectations := []struct {
+		header http.Header
+		body   string
+		err    error
+	}{
+		{http.Header{}, `{"token":"a","items":[1,{"id":1}]}`,
+			&captureError{"session", "no X-Session header"}},
+		{header, `{"items":[1,{"id":1}]}`,
+			&captureError{"token", "nothing at $.token"}},
+		{header, `<html>`,
+			&captureError{"id", "response body isn't valid JSON"}},
+	}
+	for _, e := range expectations {
+		vars := map[string]string{"token": "old"}
+		err := cs.extract(e.header.Get, []byte(e.body), vars)
+		if !reflect.DeepEqual(err, e.err) {
+			t.Errorf("%v: expected %v, but got %v", e.body, e.err, err)
+		}
+		if vars["token"] != "old" {
+			t.Errorf("%v: expected variables to be left as is, but got %v",
+				e.body, vars)
+		}
+	}
+}
+
+func TestTemplateDataValue(t *testing.T) {
+	d := templateData{RequestNum: 3, ConnID: 1}
+	if v := d.value(); !reflect.DeepEqual(v, d) {
+		t.Errorf("Expected data itself, but got %v", d.value())
+	}
+	d.vars = map[string]string{"token": "abc"}
+	exp := map[string]interface{}{
+		"RequestNum": uint64(3), "ConnID": uint64(1), "token": "abc",
+	}
+	if v := d.value(); !reflect.DeepEqual(v, exp) {
+		t.Errorf("Expected %v, but got %v", exp, v)
+	}
+}
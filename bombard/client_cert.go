@@ -0,0 +1,84 @@
+package bombard
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+)
+
+// readClientCert - helper function to read client certificate
+// from pem formatted strings, falling back to certPath and keyPath
+// files for the parts that weren't supplied in memory
+func readClientCert(c config) ([]tls.Certificate, error) {
+	certPEM, err := pemOrFile(c.certPEM, c.certPath)
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := pemOrFile(c.keyPEM, c.keyPath)
+	if err != nil {
+		return nil, err
+	}
+	if certPEM != nil && keyPEM != nil {
+		// load keypair
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, err
+		}
+
+		return []tls.Certificate{cert}, nil
+	}
+	return nil, nil
+}
+
+func pemOrFile(pem, path string) ([]byte, error) {
+	if pem != "" {
+		return []byte(pem), nil
+	}
+	if path != "" {
+		return ioutil.ReadFile(path)
+	}
+	return nil, nil
+}
+
+// generateTLSConfig - helper function to generate a TLS configuration based on
+// config
+func generateTLSConfig(c config) (*tls.Config, error) {
+	certs, err := readClientCert(c)
+	if err != nil {
+		return nil, err
+	}
+	// Disable gas warning, because InsecureSkipVerify may be set to true
+	// for the purpose of testing
+	/* #nosec */
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: c.insecure,
+		Certificates:       certs,
+		// Server certificate is verified against this name too, empty
+		// means that host from URL is used
+		ServerName: c.sni,
+	}
+	if c.alpn != nil {
+		tlsConfig.NextProtos = *c.alpn
+	}
+	if c.caCertPath != "" {
+		tlsConfig.RootCAs, err = readCACerts(c.caCertPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return tlsConfig, nil
+}
+
+// readCACerts - helper function to read pem formatted CA bundle, that
+// replaces system roots for verification of server certificates
+func readCACerts(path string) (*x509.CertPool, error) {
+	bundle, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(bundle) {
+		return nil, &noCACertsError{path}
+	}
+	return pool, nil
+}
@@ -0,0 +1,94 @@
+package bombard
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestGenerateTLSConfig(t *testing.T) {
+	expectations := []struct {
+		certPath string
+		keyPath  string
+		errIsNil bool
+	}{
+		{
+			certPath: "testclient.cert",
+			keyPath:  "testclient.key",
+			errIsNil: true,
+		},
+		{
+			certPath: "doesnotexist.pem",
+			keyPath:  "doesnotexist.pem",
+			errIsNil: false,
+		},
+		{
+			certPath: "",
+			keyPath:  "",
+			errIsNil: true,
+		},
+	}
+	for _, e := range expectations {
+		_, r := generateTLSConfig(
+			config{
+				url:      "https://doesnt.exist.com",
+				certPath: e.certPath,
+				keyPath:  e.keyPath,
+			},
+		)
+		if (r == nil) != e.errIsNil {
+			t.Error(e.certPath, e.keyPath, r)
+		}
+	}
+}
+
+func TestGenerateTLSConfigFromPEM(t *testing.T) {
+	certPEM, err := ioutil.ReadFile("testclient.cert")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPEM, err := ioutil.ReadFile("testclient.key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectations := []struct {
+		in       config
+		errIsNil bool
+	}{
+		{config{certPEM: string(certPEM), keyPEM: string(keyPEM)}, true},
+		{config{certPEM: string(certPEM), keyPath: "testclient.key"}, true},
+		{config{certPath: "testclient.cert", keyPEM: string(keyPEM)}, true},
+		{config{certPEM: "garbage", keyPEM: string(keyPEM)}, false},
+		{config{certPEM: string(keyPEM), keyPEM: string(certPEM)}, false},
+	}
+	for _, e := range expectations {
+		tlsConfig, err := generateTLSConfig(e.in)
+		if (err == nil) != e.errIsNil {
+			t.Errorf("%+v: unexpected error %v", e.in, err)
+			continue
+		}
+		if err == nil && len(tlsConfig.Certificates) != 1 {
+			t.Errorf("Expected 1 certificate, but got %v",
+				len(tlsConfig.Certificates))
+		}
+	}
+}
+
+func TestGenerateTLSConfigCACert(t *testing.T) {
+	tlsConfig, err := generateTLSConfig(config{caCertPath: "testserver.cert"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tlsConfig.RootCAs == nil {
+		t.Error("Expected root CAs to be set")
+	}
+	_, err = generateTLSConfig(config{caCertPath: "testbody.txt"})
+	if !reflect.DeepEqual(err, &noCACertsError{"testbody.txt"}) {
+		t.Errorf("Expected error about missing certificates, but got %v", err)
+	}
+	_, err = generateTLSConfig(config{caCertPath: "doesnotexist.pem"})
+	if !os.IsNotExist(err) {
+		t.Errorf("Expected file not found error, but got %v", err)
+	}
+}
@@ -0,0 +1,849 @@
+package bombard
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptrace"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/kostyay/bombardier/internal"
+	"github.com/valyala/fasthttp"
+	"golang.org/x/net/http2"
+)
+
+type client interface {
+	do(connID uint64) requestResult
+	// dump sends a request just like do does and also returns the
+	// whole response: status line, headers and body
+	dump(connID uint64) (requestResult, []byte)
+}
+
+// requestResult describes the outcome of a single request.
+type requestResult struct {
+	code    int
+	msTaken uint64
+	// ttfb is the time to first byte of the response (in
+	// microseconds), only set if the request didn't fail
+	ttfb uint64
+	// reused tells whether the request was sent over a connection
+	// that served other requests before
+	reused bool
+	// handshake is the duration of TLS handshake (in microseconds)
+	// of the new connection request was sent over, zero if there
+	// was none
+	handshake uint64
+	// bodySize is the number of bytes in the response body
+	bodySize int64
+	// headerSize is the number of bytes in the response status line
+	// and headers
+	headerSize int64
+	// sentBodySize is the number of bytes in the request body, it's
+	// only set if bodies are picked from the pool
+	sentBodySize int64
+	// redirects is the number of redirects followed to get the
+	// response
+	redirects uint64
+	err       error
+	// captureErr is set if values couldn't be captured from
+	// otherwise successful response
+	captureErr error
+	// serverTime is the value of the header server reports its
+	// processing time in, it's only read if the header is given
+	serverTime string
+}
+
+type bodyStreamProducer func() (io.ReadCloser, error)
+
+type clientOpts struct {
+	HTTP2 bool
+
+	maxConns  uint64
+	timeout   time.Duration
+	tlsConfig *tls.Config
+	// alpn is nil unless protocols advertised with ALPN are given
+	// explicitly, in which case they are in tlsConfig too
+	alpn []string
+	// connectTimeout is zero if connections are bounded by timeout
+	// only
+	connectTimeout time.Duration
+
+	headers     *headersList
+	url, method string
+
+	body     *string
+	bodProd  bodyStreamProducer
+	bodyTmpl *bodyTemplate
+	// nil unless bodies are picked from a directory
+	bodyDir *bodyDir
+	// nil unless bodies are generated for every request
+	bodyGen internal.BodyGenerator
+	// nil unless bodies of random sizes are picked from the pool
+	bodyPool *bodyPool
+
+	// Only set if there are templated headers or body, nil otherwise
+	headerTmpls headerTemplates
+	reqCounter  *requestCounter
+	// nil if there are no headers sent with only some requests
+	randHeaders *randomHeaders
+
+	// nil if cookies are disabled
+	cookies cookieJars
+	// nil if Authorization header isn't obtained with OAuth2
+	tokens *tokenSource
+	// nil unless requests are signed with AWS Signature Version 4
+	signer *sigV4Signer
+	// maxRedirects is zero if redirects aren't followed,
+	// finalHopLatency tells whether only the request to the last
+	// location is measured
+	maxRedirects    uint64
+	finalHopLatency bool
+	// serverTimeHeader is empty unless server processing time is read
+	// from the response
+	serverTimeHeader string
+	// nil if addresses are dialed as is and aren't recorded
+	resolver *resolver
+	// nil unless connections per host are limited
+	hostConns *hostConns
+	// nil unless connections go through proxy
+	proxy *proxy
+	// nil unless failed requests are dumped
+	dumper *errorDumper
+	// nil unless requests are replayed from access log, their
+	// methods and paths override the given ones then
+	replay *replay
+
+	// nil if no values are captured from responses
+	captures captures
+	vars     connVars
+
+	bytesRead, bytesWritten *int64
+	connsOpened             *uint64
+
+	// disableKeepAlive makes clients send every request over a new
+	// connection
+	disableKeepAlive bool
+}
+
+type fasthttpClient struct {
+	client *fasthttp.HostClient
+	// slots limit requests in flight to the host, nil if they aren't
+	slots chan struct{}
+
+	headers                  *fasthttp.RequestHeader
+	host, requestURI, method string
+
+	body     *string
+	bodProd  bodyStreamProducer
+	bodyTmpl *bodyTemplate
+	bodyDir  *bodyDir
+	bodyGen  internal.BodyGenerator
+	bodyPool *bodyPool
+
+	headerTmpls headerTemplates
+	reqCounter  *requestCounter
+	randHeaders *randomHeaders
+
+	url     *url.URL
+	cookies cookieJars
+	tokens  *tokenSource
+	signer  *sigV4Signer
+
+	captures captures
+	vars     connVars
+
+	dumper *errorDumper
+	replay *replay
+
+	connClose bool
+
+	maxRedirects     uint64
+	finalHopLatency  bool
+	serverTimeHeader string
+}
+
+func newFastHTTPClient(opts *clientOpts) client {
+	c := new(fasthttpClient)
+	u, err := url.Parse(opts.url)
+	if err != nil {
+		// opts.url guaranteed to be valid at this point
+		panic(err)
+	}
+	c.url = u
+	c.host = u.Host
+	c.requestURI = u.RequestURI()
+	dial := fasthttpDialFunc(
+		opts.bytesRead, opts.bytesWritten, opts.connsOpened,
+		opts.resolver, opts.proxy, opts.connectTimeout,
+	)
+	if u.Scheme == "https" {
+		// TLS is handled by the dialer instead of fasthttp, so that
+		// durations of handshakes are known
+		dial = fasthttpTLSDialFunc(
+			dial, fasthttpTLSConfig(opts.tlsConfig, u), opts.timeout,
+		)
+	}
+	create := func() *fasthttp.HostClient {
+		return &fasthttp.HostClient{
+			Addr:                          u.Host,
+			MaxConns:                      int(opts.maxConns),
+			ReadTimeout:                   opts.timeout,
+			WriteTimeout:                  opts.timeout,
+			DisableHeaderNamesNormalizing: true,
+			Dial:                          dial,
+		}
+	}
+	if opts.hostConns != nil {
+		address, err := urlAddress(opts.url)
+		if err != nil {
+			// opts.url guaranteed to be valid at this point
+			panic(err)
+		}
+		c.client, c.slots = opts.hostConns.fasthttpClient(
+			u.Scheme, address, create,
+		)
+	} else {
+		c.client = create()
+	}
+	c.headers = headersToFastHTTPHeaders(opts.headers)
+	c.method, c.body = opts.method, opts.body
+	c.bodProd, c.bodyTmpl = opts.bodProd, opts.bodyTmpl
+	c.bodyDir, c.bodyGen = opts.bodyDir, opts.bodyGen
+	c.bodyPool = opts.bodyPool
+	c.headerTmpls, c.reqCounter = opts.headerTmpls, opts.reqCounter
+	c.randHeaders = opts.randHeaders
+	c.cookies, c.tokens = opts.cookies, opts.tokens
+	c.signer = opts.signer
+	c.captures, c.vars = opts.captures, opts.vars
+	c.dumper, c.replay = opts.dumper, opts.replay
+	c.connClose = opts.disableKeepAlive
+	c.maxRedirects = opts.maxRedirects
+	c.finalHopLatency = opts.finalHopLatency
+	c.serverTimeHeader = opts.serverTimeHeader
+	return client(c)
+}
+
+// fasthttpTLSConfig returns TLS config the same as fasthttp would use
+// for connections to u.
+func fasthttpTLSConfig(tlsConfig *tls.Config, u *url.URL) *tls.Config {
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{}
+	} else {
+		tlsConfig = tlsConfig.Clone()
+	}
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = u.Hostname()
+	}
+	if tlsConfig.ClientSessionCache == nil {
+		tlsConfig.ClientSessionCache = tls.NewLRUClientSessionCache(0)
+	}
+	return tlsConfig
+}
+
+func (c *fasthttpClient) do(connID uint64) requestResult {
+	return c.perform(connID, nil)
+}
+
+func (c *fasthttpClient) dump(connID uint64) (requestResult, []byte) {
+	var dump []byte
+	res := c.perform(connID, &dump)
+	return res, dump
+}
+
+// perform sends a request, the response is stored into dump unless
+// it's nil.
+func (c *fasthttpClient) perform(
+	connID uint64, dump *[]byte,
+) (res requestResult) {
+	// prepare the request
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+	if c.headers != nil {
+		c.headers.CopyTo(&req.Header)
+	}
+	var data templateData
+	if c.reqCounter != nil {
+		data = c.reqCounter.next(connID)
+	}
+	if c.vars != nil {
+		data.vars = c.vars[connID]
+	}
+	if c.headerTmpls != nil {
+		err := c.headerTmpls.render(data, req.Header.Set)
+		if err != nil {
+			fasthttp.ReleaseRequest(req)
+			fasthttp.ReleaseResponse(resp)
+			return requestResult{err: err}
+		}
+	}
+	if c.randHeaders != nil {
+		c.randHeaders.add(connID, req.Header.Set)
+	}
+	if c.tokens != nil {
+		req.Header.Set("Authorization", c.tokens.header())
+	}
+	if len(req.Header.Host()) == 0 {
+		req.Header.SetHost(c.host)
+	}
+	if c.replay != nil {
+		e := c.replay.entry(connID)
+		req.Header.SetMethod(e.method)
+		req.SetRequestURI(e.uri.RequestURI())
+	} else {
+		req.Header.SetMethod(c.method)
+		req.SetRequestURI(c.requestURI)
+	}
+	if c.connClose {
+		req.SetConnectionClose()
+	}
+	if c.cookies != nil {
+		c.cookies.addToFastHTTPRequest(connID, c.url, req)
+	}
+	// streamed bodies can't be resent to the location request is
+	// redirected to
+	streamed := false
+	if c.bodyTmpl != nil {
+		buf, terr := c.bodyTmpl.render(data)
+		if terr != nil {
+			fasthttp.ReleaseRequest(req)
+			fasthttp.ReleaseResponse(resp)
+			return requestResult{err: terr}
+		}
+		req.SetBody(buf.Bytes())
+		c.bodyTmpl.release(buf)
+	} else if c.bodyDir != nil {
+		req.SetBodyString(c.bodyDir.pick(connID))
+	} else if c.bodyPool != nil {
+		body := c.bodyPool.pick(connID)
+		req.SetBodyString(body)
+		res.sentBodySize = int64(len(body))
+	} else if c.bodyGen != nil {
+		body := c.bodyGen(data.RequestNum)
+		req.SetBodyStream(body, int(generatedBodyLength(body)))
+		streamed = true
+	} else if c.body != nil {
+		req.SetBodyString(*c.body)
+	} else {
+		bs, bserr := c.bodProd()
+		if bserr != nil {
+			fasthttp.ReleaseRequest(req)
+			fasthttp.ReleaseResponse(resp)
+			return requestResult{err: bserr}
+		}
+		req.SetBodyStream(bs, -1)
+		streamed = true
+	}
+	if c.signer != nil {
+		u, err := url.ParseRequestURI(string(req.RequestURI()))
+		if err != nil {
+			fasthttp.ReleaseRequest(req)
+			fasthttp.ReleaseResponse(resp)
+			return requestResult{err: err}
+		}
+		c.signer.sign(string(req.Header.Method()), string(req.Header.Host()),
+			u, req.Body(), req.Header.Set)
+	}
+
+	// fire the request
+	start := time.Now()
+	hopStart := start
+	for {
+		if c.slots != nil {
+			c.slots <- struct{}{}
+		}
+		res.err = c.client.Do(req, resp)
+		if c.slots != nil {
+			<-c.slots
+		}
+		if res.err != nil || res.redirects >= c.maxRedirects {
+			break
+		}
+		var follow bool
+		follow, res.err = c.followRedirect(req, resp, streamed)
+		if !follow {
+			break
+		}
+		res.redirects++
+		hopStart = time.Now()
+	}
+	if res.err == fasthttp.ErrConnectionClosed && c.client.ReadTimeout > 0 &&
+		time.Since(hopStart) >= c.client.ReadTimeout {
+		// fasthttp reports any failure to read the first byte of
+		// response as closed connection, timeouts included
+		res.err = &timeoutError{res.err}
+	}
+	if c.finalHopLatency {
+		start = hopStart
+	}
+	if res.err != nil {
+		res.code = -1
+	} else {
+		res.code = resp.StatusCode()
+		res.bodySize = int64(len(resp.Body()))
+		res.headerSize = int64(len(resp.Header.Header()))
+		if c.serverTimeHeader != "" {
+			res.serverTime = string(
+				peekResponseHeader(&resp.Header, c.serverTimeHeader),
+			)
+		}
+		if dump != nil {
+			*dump = []byte(resp.String())
+		}
+		if c.cookies != nil {
+			c.cookies.storeFromFastHTTPResponse(connID, c.url, resp)
+		}
+		if c.captures != nil && res.code/100 == 2 {
+			res.captureErr = c.captures.extract(func(key string) string {
+				return string(resp.Header.Peek(key))
+			}, resp.Body(), c.vars[connID])
+		}
+	}
+	res.msTaken = uint64(time.Since(start).Nanoseconds() / 1000)
+	if res.err == nil {
+		res.ttfb = res.msTaken
+		if ta, ok := resp.LocalAddr().(*tracingAddr); ok {
+			if ttfb := ta.since(start); ttfb > 0 {
+				res.ttfb = uint64(ttfb.Nanoseconds() / 1000)
+			}
+			res.reused = ta.reused()
+			if !res.reused {
+				res.handshake = uint64(ta.handshake().Nanoseconds() / 1000)
+			}
+		}
+	}
+
+	if c.dumper != nil && failed(res) && c.dumper.reserve() {
+		request := append([]byte(req.Header.String()),
+			truncatedBody(req.Body())...)
+		var response []byte
+		if res.err == nil {
+			response = append([]byte(resp.Header.String()),
+				truncatedBody(resp.Body())...)
+		}
+		c.dumper.dump(connID, request, response, res.err)
+	}
+
+	// release resources
+	fasthttp.ReleaseRequest(req)
+	fasthttp.ReleaseResponse(resp)
+
+	return
+}
+
+type httpClient struct {
+	client *http.Client
+
+	headers http.Header
+	url     *url.URL
+	method  string
+
+	body     *string
+	bodProd  bodyStreamProducer
+	bodyTmpl *bodyTemplate
+	bodyDir  *bodyDir
+	bodyGen  internal.BodyGenerator
+	bodyPool *bodyPool
+
+	headerTmpls headerTemplates
+	reqCounter  *requestCounter
+	randHeaders *randomHeaders
+
+	cookies cookieJars
+	tokens  *tokenSource
+	signer  *sigV4Signer
+
+	captures captures
+	vars     connVars
+
+	dumper *errorDumper
+	replay *replay
+
+	maxRedirects     uint64
+	finalHopLatency  bool
+	serverTimeHeader string
+}
+
+func newHTTPClient(opts *clientOpts) client {
+	c := new(httpClient)
+	create := func() *http.Transport {
+		tr := &http.Transport{
+			TLSClientConfig:     opts.tlsConfig,
+			MaxIdleConnsPerHost: int(opts.maxConns),
+		}
+		tr.DialContext = httpDialContextFunc(
+			opts.bytesRead, opts.bytesWritten, opts.connsOpened,
+			opts.resolver, opts.proxy, opts.connectTimeout,
+		)
+		tr.DisableKeepAlives = opts.disableKeepAlive
+		if opts.HTTP2 {
+			if opts.alpn != nil {
+				// Shared config mustn't be altered below
+				tr.TLSClientConfig = tr.TLSClientConfig.Clone()
+			}
+			_ = http2.ConfigureTransport(tr)
+			if opts.alpn != nil {
+				// ConfigureTransport always advertises both h2
+				// and http/1.1
+				tr.TLSClientConfig.NextProtos = opts.alpn
+			}
+		} else {
+			tr.TLSNextProto = make(map[string]func(
+				authority string, c *tls.Conn) http.RoundTripper,
+			)
+		}
+		return tr
+	}
+	var tr *http.Transport
+	if opts.hostConns != nil {
+		tr = opts.hostConns.transport(create)
+	} else {
+		tr = create()
+	}
+
+	cl := &http.Client{
+		Transport:     tr,
+		Timeout:       opts.timeout,
+		CheckRedirect: checkRedirect(opts.maxRedirects),
+	}
+	c.client = cl
+	c.maxRedirects = opts.maxRedirects
+	c.finalHopLatency = opts.finalHopLatency
+	c.serverTimeHeader = opts.serverTimeHeader
+
+	c.headers = headersToHTTPHeaders(opts.headers)
+	c.method, c.body, c.bodProd = opts.method, opts.body, opts.bodProd
+	c.bodyTmpl, c.bodyDir = opts.bodyTmpl, opts.bodyDir
+	c.bodyGen, c.bodyPool = opts.bodyGen, opts.bodyPool
+	c.headerTmpls, c.reqCounter = opts.headerTmpls, opts.reqCounter
+	c.randHeaders = opts.randHeaders
+	c.cookies, c.tokens = opts.cookies, opts.tokens
+	c.signer = opts.signer
+	c.captures, c.vars = opts.captures, opts.vars
+	c.dumper, c.replay = opts.dumper, opts.replay
+	var err error
+	c.url, err = url.Parse(opts.url)
+	if err != nil {
+		// opts.url guaranteed to be valid at this point
+		panic(err)
+	}
+
+	return client(c)
+}
+
+func (c *httpClient) do(connID uint64) requestResult {
+	return c.perform(connID, nil)
+}
+
+func (c *httpClient) dump(connID uint64) (requestResult, []byte) {
+	var dump []byte
+	res := c.perform(connID, &dump)
+	return res, dump
+}
+
+// perform sends a request, the response is stored into dump unless
+// it's nil.
+func (c *httpClient) perform(
+	connID uint64, dump *[]byte,
+) (res requestResult) {
+	req := &http.Request{}
+
+	req.Header = c.headers
+	req.Method = c.method
+	req.URL = c.url
+	if c.replay != nil {
+		e := c.replay.entry(connID)
+		u := *c.url
+		u.Path, u.RawPath, u.RawQuery = e.uri.Path, e.uri.RawPath,
+			e.uri.RawQuery
+		req.Method, req.URL = e.method, &u
+	}
+
+	if c.headerTmpls != nil || c.randHeaders != nil || c.cookies != nil ||
+		c.tokens != nil || c.signer != nil {
+		// Headers are shared between requests, so they must be
+		// copied before adding per-request ones
+		req.Header = cloneHTTPHeaders(c.headers)
+	}
+	var data templateData
+	if c.reqCounter != nil {
+		data = c.reqCounter.next(connID)
+	}
+	if c.vars != nil {
+		data.vars = c.vars[connID]
+	}
+	if c.headerTmpls != nil {
+		if err := c.headerTmpls.render(data, req.Header.Set); err != nil {
+			return requestResult{err: err}
+		}
+	}
+	if c.randHeaders != nil {
+		c.randHeaders.add(connID, req.Header.Set)
+	}
+	if c.tokens != nil {
+		req.Header.Set("Authorization", c.tokens.header())
+	}
+	if host := req.Header.Get("Host"); host != "" {
+		req.Host = host
+	}
+	if c.cookies != nil {
+		c.cookies.addToHTTPRequest(connID, req)
+	}
+
+	var (
+		tbuf *bytes.Buffer
+		// payload is only set if the request is signed or may be
+		// redirected
+		payload     []byte
+		keepPayload = c.signer != nil || c.maxRedirects > 0
+	)
+	if c.bodyTmpl != nil {
+		var terr error
+		tbuf, terr = c.bodyTmpl.render(data)
+		if terr != nil {
+			return requestResult{err: terr}
+		}
+		req.ContentLength = int64(tbuf.Len())
+		req.Body = ioutil.NopCloser(bytes.NewReader(tbuf.Bytes()))
+		payload = tbuf.Bytes()
+	} else if c.bodyDir != nil {
+		body := c.bodyDir.pick(connID)
+		req.ContentLength = int64(len(body))
+		req.Body = ioutil.NopCloser(strings.NewReader(body))
+		if keepPayload {
+			payload = []byte(body)
+		}
+	} else if c.bodyPool != nil {
+		body := c.bodyPool.pick(connID)
+		req.ContentLength = int64(len(body))
+		req.Body = ioutil.NopCloser(strings.NewReader(body))
+		if keepPayload {
+			payload = []byte(body)
+		}
+		res.sentBodySize = req.ContentLength
+	} else if c.bodyGen != nil {
+		body := c.bodyGen(data.RequestNum)
+		if l := generatedBodyLength(body); l >= 0 {
+			req.ContentLength = l
+		}
+		if rc, ok := body.(io.ReadCloser); ok {
+			req.Body = rc
+		} else {
+			req.Body = ioutil.NopCloser(body)
+		}
+	} else if c.body != nil {
+		br := strings.NewReader(*c.body)
+		req.ContentLength = int64(len(*c.body))
+		req.Body = ioutil.NopCloser(br)
+		if keepPayload {
+			payload = []byte(*c.body)
+		}
+	} else {
+		bs, bserr := c.bodProd()
+		if bserr != nil {
+			return requestResult{err: bserr}
+		}
+		req.Body = bs
+	}
+	if c.signer != nil {
+		host := req.Host
+		if host == "" {
+			host = req.URL.Host
+		}
+		c.signer.sign(req.Method, host, req.URL, payload, req.Header.Set)
+	}
+	if c.maxRedirects > 0 && len(payload) > 0 {
+		// Lets net/http resend the body on 307 and 308 redirects
+		req.GetBody = func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(payload)), nil
+		}
+	}
+
+	var (
+		firstByte, handshakeStart time.Time
+		// Connection might be dialed on behalf of the request and
+		// end up serving some other one, so handshake callbacks may
+		// run after the request is done
+		handshake int64
+	)
+	hops := new(redirectHops)
+	ctx := context.Background()
+	if c.maxRedirects > 0 {
+		ctx = context.WithValue(ctx, redirectHopsKey{}, hops)
+	}
+	req = req.WithContext(httptrace.WithClientTrace(
+		ctx, &httptrace.ClientTrace{
+			GotConn: func(info httptrace.GotConnInfo) {
+				res.reused = info.Reused
+			},
+			GotFirstResponseByte: func() {
+				firstByte = time.Now()
+			},
+			TLSHandshakeStart: func() {
+				handshakeStart = time.Now()
+			},
+			TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+				if err == nil {
+					atomic.StoreInt64(&handshake,
+						int64(time.Since(handshakeStart)))
+				}
+			},
+		},
+	))
+
+	var (
+		// dumping tells whether the failure is dumped, dumped
+		// holds the response to dump, if there is one
+		dumping bool
+		dumped  []byte
+	)
+	start := time.Now()
+	resp, err := c.client.Do(req)
+	res.redirects = hops.count
+	if c.finalHopLatency && hops.count > 0 {
+		start = hops.last
+	}
+	if err != nil {
+		res.code = -1
+		if uerr, ok := err.(*url.Error); ok {
+			if perr, ok := uerr.Err.(*proxyError); ok {
+				// Reported the same way fasthttp client does
+				err = perr
+			}
+		}
+	} else {
+		res.ttfb = uint64(firstByte.Sub(start).Nanoseconds() / 1000)
+		if !res.reused {
+			res.handshake = uint64(atomic.LoadInt64(&handshake) / 1000)
+		}
+		res.code = resp.StatusCode
+		res.headerSize = httpResponseHeaderSize(resp)
+		if c.serverTimeHeader != "" {
+			res.serverTime = resp.Header.Get(c.serverTimeHeader)
+		}
+		if c.cookies != nil {
+			c.cookies.storeFromHTTPResponse(connID, c.url, resp)
+		}
+
+		var berr error
+		if dump != nil {
+			// Body is read into memory and replaced with its copy
+			*dump, berr = httputil.DumpResponse(resp, true)
+		}
+		capture := c.captures != nil && res.code/100 == 2
+		dumping = c.dumper != nil && failed(res) && c.dumper.reserve()
+		var (
+			n    int64
+			cerr error
+			body []byte
+		)
+		if (capture && c.captures.needBody()) || dumping {
+			body, cerr = ioutil.ReadAll(resp.Body)
+			n = int64(len(body))
+		} else {
+			n, cerr = io.Copy(ioutil.Discard, resp.Body)
+		}
+		if berr == nil {
+			berr = cerr
+		}
+		if dumping {
+			dumped, _ = httputil.DumpResponse(resp, false)
+			dumped = append(dumped, truncatedBody(body)...)
+		}
+		if berr != nil {
+			err = berr
+		} else if capture {
+			res.captureErr = c.captures.extract(
+				resp.Header.Get, body, c.vars[connID],
+			)
+		}
+		res.bodySize = n
+
+		if cerr := resp.Body.Close(); cerr != nil {
+			err = cerr
+		}
+	}
+	res.msTaken = uint64(time.Since(start).Nanoseconds() / 1000)
+	res.err = err
+
+	if resp == nil && c.dumper != nil {
+		dumping = c.dumper.reserve()
+	}
+	if dumping {
+		var reqBody []byte
+		if tbuf != nil {
+			reqBody = tbuf.Bytes()
+		} else if c.body != nil {
+			reqBody = []byte(*c.body)
+		}
+		c.dumper.dump(connID, dumpHTTPRequest(req, reqBody), dumped, err)
+	}
+
+	// Transport might still be using request body if the request
+	// failed, so buffer is only reused once the response is closed.
+	if tbuf != nil && resp != nil {
+		c.bodyTmpl.release(tbuf)
+	}
+
+	return
+}
+
+// failed tells whether request failed with an error or got 4xx or
+// 5xx response.
+func failed(res requestResult) bool {
+	return res.err != nil || res.code >= 400
+}
+
+// dumpHTTPRequest formats request the way it's sent, body is left out
+// if it's nil.
+func dumpHTTPRequest(req *http.Request, body []byte) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%v %v HTTP/1.1\r\n", req.Method, req.URL.RequestURI())
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	fmt.Fprintf(&buf, "Host: %v\r\n", host)
+	_ = req.Header.WriteSubset(&buf, map[string]bool{"Host": true})
+	buf.WriteString("\r\n")
+	buf.Write(truncatedBody(body))
+	return buf.Bytes()
+}
+
+func headersToFastHTTPHeaders(h *headersList) *fasthttp.RequestHeader {
+	if len(*h) == 0 {
+		return nil
+	}
+	res := new(fasthttp.RequestHeader)
+	for _, header := range *h {
+		res.Set(header.key, header.value)
+	}
+	return res
+}
+
+func headersToHTTPHeaders(h *headersList) http.Header {
+	if len(*h) == 0 {
+		return http.Header{}
+	}
+	headers := http.Header{}
+
+	for _, header := range *h {
+		headers[header.key] = []string{header.value}
+	}
+	return headers
+}
+
+func cloneHTTPHeaders(h http.Header) http.Header {
+	clone := make(http.Header, len(h))
+	for k, vs := range h {
+		clone[k] = append([]string(nil), vs...)
+	}
+	return clone
+}
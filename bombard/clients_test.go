@@ -1,10 +1,11 @@
-package main
+package bombard
 
 import (
 	"bytes"
 	"crypto/tls"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -88,6 +89,7 @@ func TestHTTP2Client(t *testing.T) {
 	// TODO(codesenberg): this should be fixed later
 	time.Sleep(100 * time.Millisecond)
 	bytesRead, bytesWritten := int64(0), int64(0)
+	connsOpened := uint64(0)
 	c := newHTTPClient(&clientOpts{
 		HTTP2: true,
 
@@ -102,14 +104,20 @@ func TestHTTP2Client(t *testing.T) {
 
 		bytesRead:    &bytesRead,
 		bytesWritten: &bytesWritten,
+		connsOpened:  &connsOpened,
 	})
-	code, _, err := c.do()
+	res := c.do(0)
+	err := res.err
 	if err != nil {
 		t.Error(err)
 		return
 	}
-	if code != http.StatusOK {
-		t.Errorf("invalid response code: %v", code)
+	if res.code != http.StatusOK {
+		t.Errorf("invalid response code: %v", res.code)
+	}
+	if res.bodySize != int64(responseSize) {
+		t.Errorf("Expected body size to be %v, but got %v",
+			responseSize, res.bodySize)
 	}
 	if atomic.LoadInt64(&bytesRead) == 0 {
 		t.Errorf("invalid response size: %v", bytesRead)
@@ -145,6 +153,7 @@ func TestHTTP1Clients(t *testing.T) {
 	defer s.Close()
 
 	bytesRead, bytesWritten := int64(0), int64(0)
+	connsOpened := uint64(0)
 	cc := &clientOpts{
 		HTTP2: false,
 
@@ -156,6 +165,7 @@ func TestHTTP1Clients(t *testing.T) {
 
 		bytesRead:    &bytesRead,
 		bytesWritten: &bytesWritten,
+		connsOpened:  &connsOpened,
 	}
 	clients := []client{
 		newHTTPClient(cc),
@@ -163,13 +173,17 @@ func TestHTTP1Clients(t *testing.T) {
 	}
 	for _, c := range clients {
 		bytesRead, bytesWritten = 0, 0
-		code, _, err := c.do()
-		if err != nil {
-			t.Error(err)
+		res := c.do(0)
+		if res.err != nil {
+			t.Error(res.err)
 			return
 		}
-		if code != http.StatusOK {
-			t.Errorf("invalid response code: %v", code)
+		if res.code != http.StatusOK {
+			t.Errorf("invalid response code: %v", res.code)
+		}
+		if res.bodySize != int64(responseSize) {
+			t.Errorf("Expected body size to be %v, but got %v",
+				responseSize, res.bodySize)
 		}
 		if bytesRead == 0 {
 			t.Errorf("invalid response size: %v", bytesRead)
@@ -179,3 +193,53 @@ func TestHTTP1Clients(t *testing.T) {
 		}
 	}
 }
+
+func TestClientsKeepCookiesPerConnection(t *testing.T) {
+	sessions := uint64(0)
+	s := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if c, err := r.Cookie("session"); err == nil {
+				w.Header().Set("X-Session", c.Value)
+				return
+			}
+			id := atomic.AddUint64(&sessions, 1)
+			http.SetCookie(w, &http.Cookie{
+				Name:  "session",
+				Value: strconv.FormatUint(id, 10),
+			})
+		},
+	))
+	defer s.Close()
+
+	bytesRead, bytesWritten := int64(0), int64(0)
+	connsOpened := uint64(0)
+	newOpts := func() *clientOpts {
+		return &clientOpts{
+			headers: new(headersList),
+			url:     s.URL,
+			method:  "GET",
+
+			body:    new(string),
+			cookies: newCookieJars(2),
+
+			bytesRead:    &bytesRead,
+			bytesWritten: &bytesWritten,
+			connsOpened:  &connsOpened,
+		}
+	}
+	clients := []client{
+		newHTTPClient(newOpts()),
+		newFastHTTPClient(newOpts()),
+	}
+	for _, c := range clients {
+		atomic.StoreUint64(&sessions, 0)
+		for _, connID := range []uint64{0, 1, 0, 1, 0} {
+			if res := c.do(connID); res.err != nil {
+				t.Fatal(res.err)
+			}
+		}
+		if act := atomic.LoadUint64(&sessions); act != 2 {
+			t.Errorf("Expected a session per connection, but got %v", act)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package bombard
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+const (
+	decBase = 10
+
+	rateLimitInterval = 10 * time.Millisecond
+	oneSecond         = 1 * time.Second
+	// Number of requests in flight is sampled this often
+	inFlightSampleInterval = time.Second
+
+	exitFailure = 1
+	// Same as shells use for processes killed by SIGINT
+	exitInterrupted = 130
+
+	// In-flight requests are waited for at most this long once the
+	// test is interrupted
+	interruptGracePeriod = 5 * time.Second
+
+	// Error rate isn't checked until at least this many requests
+	// have completed, so that a few early failures don't abort the test
+	minRequestsToAbort = 100
+
+	// Results are printed in this format unless --format is given
+	defaultFormatSpec = "plain-text"
+)
+
+var (
+	version = "unspecified"
+
+	emptyConf = config{}
+	parser    = newKingpinParser()
+
+	defaultTestDuration  = 10 * time.Second
+	defaultNumberOfConns = uint64(125)
+	defaultTimeout       = 2 * time.Second
+	defaultPercentiles   = []float64{0.5, 0.75, 0.9, 0.95, 0.99}
+
+	httpMethods = []string{
+		"GET", "POST", "PUT", "DELETE", "HEAD", "OPTIONS",
+		"PATCH",
+	}
+	cantHaveBody = []string{"GET", "HEAD"}
+
+	errInvalidURL = errors.New(
+		"No hostname or invalid scheme")
+	errNoURL = errors.New(
+		"required argument 'url' not provided")
+	errZeroWeight = errors.New(
+		"Weight of URL can't be less than 1")
+	errWeightsMismatch = errors.New(
+		"Number of weights doesn't match number of URLs")
+	errMixedURLs = errors.New(
+		"Use either weighted or unweighted URLs")
+	errInvalidWeightedURLFormat = errors.New(
+		"Invalid weighted URL format(must be <weight>:<url>)")
+	errInvalidLatencyAssertionFormat = errors.New(
+		"Invalid latency assertion format(must be p<percentile>:<duration>)")
+	errInvalidNumberOfConns = errors.New(
+		"Invalid number of connections(must be > 0)")
+	errInvalidNumberOfRequests = errors.New(
+		"Invalid number of requests(must be > 0)")
+	errInvalidTestDuration = errors.New(
+		"Invalid test duration(must be >= 1s)")
+	errNegativeTimeout = errors.New(
+		"Timeout can't be negative")
+	errNegativeConnectTimeout = errors.New(
+		"Connect timeout can't be negative")
+	errConnectTimeoutTooLong = errors.New(
+		"Connect timeout can't be longer than --timeout")
+	errTooManyLatencyDigits = errors.New(
+		"Latencies can't be recorded with more than 12 significant digits")
+	errInvalidLatencyCeiling = errors.New(
+		"Invalid latency ceiling(must be >= 1us)")
+	errNegativeRampUp = errors.New(
+		"Ramp-up period can't be negative")
+	errNegativeJitter = errors.New(
+		"Jitter can't be negative")
+	errNegativeWarmup = errors.New(
+		"Warmup period can't be negative")
+	errWarmupTooLong = errors.New(
+		"Warmup period must be shorter than test duration")
+	errResetWithoutWarmup = errors.New(
+		"Resetting counters after warmup requires warmup to be specified")
+	errPrimeWithoutKeepAlive = errors.New(
+		"Connections can't be primed with keep-alive disabled")
+	errNoOAuth2TokenURL = errors.New(
+		"OAuth2 token URL is required to obtain tokens")
+	errNoOAuth2ClientID = errors.New(
+		"OAuth2 client ID is required to obtain tokens")
+	errInvalidBasicAuthFormat = errors.New(
+		"Invalid basic auth format(must be <user>:<password>)")
+	errConflictingAuth = errors.New(
+		"Use only one of --basic-auth, OAuth2, AWS SigV4 and " +
+			"Authorization header")
+	errIncompleteSigV4 = errors.New(
+		"AWS access key, secret key, region and service are all " +
+			"required to sign requests")
+	errSigV4WithStream = errors.New(
+		"Requests with streamed or generated bodies can't be signed")
+	errNoAccessToken = errors.New(
+		"Token response doesn't contain access token")
+	errNoPathToCert = errors.New(
+		"No Path to TLS Client Certificate")
+	errNoPathToKey = errors.New(
+		"No Path to TLS Client Certificate Private Key")
+	errCertProvidedTwice = errors.New(
+		"Use either --cert or --cert-pem")
+	errKeyProvidedTwice = errors.New(
+		"Use either --key or --key-pem")
+	errEmptyALPNProtocol = errors.New(
+		"ALPN protocols can't be empty")
+	errALPNH2WithoutHTTP2 = errors.New(
+		"h2 can only be advertised with ALPN by --http2 client")
+	errCACertWithInsecure = errors.New(
+		"CA bundle can't be used when server certificates aren't " +
+			"verified (--insecure)")
+	errZeroRate = errors.New(
+		"Rate can't be less than 1")
+	errRatePerConnWithoutRate = errors.New(
+		"Rate per connection requires rate to be specified")
+	errInvalidAbortErrorRate = errors.New(
+		"Error rate to abort on must be at least 0 and less than 1")
+	errZeroMaxBytes = errors.New(
+		"Byte cap can't be less than 1")
+	errLatencyWithRetriesWithoutRetries = errors.New(
+		"Latency with retries requires retries to be specified")
+	errFinalHopLatencyWithoutRedirects = errors.New(
+		"Final hop latency requires --max-redirects to be specified")
+	errRedirectsWithSigV4 = errors.New(
+		"Requests signed with AWS SigV4 can't follow redirects")
+	errBodyProvidedTwice = errors.New(
+		"Use only one of --body, --body-file or --body-dir")
+	errStreamedBodyTemplate = errors.New(
+		"Body template can't be used with --stream")
+	errBodyFromTerminal = errors.New(
+		"Body can't be read from stdin, since it's a terminal " +
+			"(pipe the body into bombardier instead)")
+	errGRPCIncomplete = errors.New(
+		"gRPC method requires descriptor set it's described in, " +
+			"use both --grpc-method and --proto-set")
+	errGRPCWithBody = errors.New(
+		"gRPC request can only be given in JSON with --body or --body-file")
+	errGRPCWithHTTPOption = errors.New(
+		"gRPC calls can't be made to multiple URLs or with --scenario, " +
+			"--replay, cookies, redirects, AWS SigV4, templated or " +
+			"random headers, --server-time-header or --dump-errors")
+	errCompressionWithoutBody = errors.New(
+		"Body compression requires --body, --body-file, --body-dir " +
+			"or --form")
+	errUncompressibleBody = errors.New(
+		"Body can't be compressed when --body-template or --stream is used")
+	errInvalidResolveFormat = errors.New(
+		"Resolve override must be in the form of host:port:ip")
+	errUnixSocketWithResolve = errors.New(
+		"Unix socket can't be used with --resolve or --pin-dns")
+	errDumpFileWithoutCount = errors.New(
+		"Dump file requires --dump-errors")
+	errInvalidLocalAddr = errors.New(
+		"Local address must be an IP address")
+	errLocalAddrWithUnixSocket = errors.New(
+		"Local address can't be used with --unix-socket")
+	errProxyWithUnixSocket = errors.New(
+		"Proxy can't be used with --unix-socket")
+	errInvalidProxy = errors.New(
+		"Proxy must be in the form of http://host:port or " +
+			"socks5://host:port, optionally with user:password@ " +
+			"before the host")
+	errInvalidFormFieldFormat = errors.New(
+		"Form field must be in the form of name=value or name=@path")
+	errInvalidServerTime = errors.New(
+		"Server time must be a duration or a number of milliseconds")
+	errInvalidThinkTime = errors.New(
+		"Think time must be a duration or a range of them, " +
+			"i.e. 100ms or 50ms-200ms")
+	errScenarioWithURLs = errors.New(
+		"Scenario can't be used alongside URLs")
+	errScenarioWithBody = errors.New(
+		"Scenario can't be used with --body, --body-file, --form, " +
+			"--body-template, --stream or --compress-body")
+	errEmptyScenario = errors.New(
+		"Scenario has no steps")
+	errReplayWithURLs = errors.New(
+		"Replay requires a single URL, requests from the log are sent to")
+	errReplayWithBody = errors.New(
+		"Body can't be sent when access log is replayed")
+	errReplayWithRate = errors.New(
+		"Replay can't be used with --rate or --load-profile")
+	errInvalidReplaySpeed = errors.New(
+		"Replay speed must be positive")
+	errEmptyReplay = errors.New(
+		"Access log has no requests")
+	errBodyDirTemplateOrStream = errors.New(
+		"Bodies from --body-dir can't be used with --body-template " +
+			"or --stream")
+	errEmptyBodyDir = errors.New(
+		"Body directory has no files")
+	errBodyGeneratorWithBody = errors.New(
+		"Body generator can't be used with other sources of body, " +
+			"templates, streaming or compression")
+	errBodySizeWithBody = errors.New(
+		"Body size can't be used with other sources of body, " +
+			"templates, streaming or compression")
+	errInvalidBodySize = errors.New(
+		"Body size must be a number of bytes or a range of them, " +
+			"optionally followed by :uniform or :lognormal, " +
+			"i.e. 4KB or 1KB-1MB:lognormal")
+	errFormWithBody = errors.New(
+		"Form fields can't be used with --body, --body-file, --body-dir, " +
+			"--body-template or --stream")
+	errFormWithContentType = errors.New(
+		"Content-Type header is set automatically when --form is used")
+
+	errInvalidHeaderFormat = errors.New("Invalid header format")
+	errEmptyPrintSpec      = errors.New(
+		"Empty print spec is not a valid print spec")
+	errInvalidRandomHeaderFormat = errors.New(
+		"Random header must be specified as \"P:K: V\", " +
+			"where 0 < P <= 1 is the probability of sending it")
+
+	errInvalidTagFormat = errors.New(
+		"Invalid tag format(must be <key>=<value>)")
+	errTagsWithoutInfluxURL = errors.New(
+		"Tags can only be used when results are pushed to InfluxDB " +
+			"or written to syslog")
+	errQuietWithPrint = errors.New(
+		"Use either --quiet, --print or --no-print")
+	errFormatTemplateWithFormat = errors.New(
+		"Use either --format or --format-template")
+	errSyslogAddrWithoutSyslog = errors.New(
+		"Syslog address can only be used when results are written " +
+			"to syslog")
+
+	errInvalidLoadProfileFormat = errors.New(
+		"Invalid load profile format(must be <name>:<key>=<value>,...)")
+	errInvalidSineProfile = errors.New(
+		"Sine load profile must be specified as " +
+			"sine:min=<rate>,max=<rate>,period=<duration>, " +
+			"where 1 <= min <= max")
+	errInvalidStepProfile = errors.New(
+		"Step load profile must be specified as " +
+			"step:start=<rate>,step=<rate>,interval=<duration>, " +
+			"where start and step are >= 1")
+	errArrivalWithoutRate = errors.New(
+		"Arrival process can only be used with --rate or --load-profile")
+	errCorrectLatencyWithoutRate = errors.New(
+		"Latency correction requires --rate, --load-profile or --replay")
+	errKneeLatencyWithoutSteps = errors.New(
+		"Knee latency can only be used with step load profile")
+	errRateWithLoadProfile = errors.New(
+		"Use either --rate or --load-profile")
+	errNegativeThreshold = errors.New(
+		"Regression threshold can't be negative")
+	errNoHistograms = errors.New(
+		"Histograms are missing, results must be saved in JSON format " +
+			"by the same version of bombardier")
+)
+
+func init() {
+	sort.Strings(httpMethods)
+	sort.Strings(cantHaveBody)
+}
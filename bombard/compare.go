@@ -0,0 +1,271 @@
+package bombard
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/alecthomas/kingpin"
+	"github.com/kostyay/bombardier/internal"
+)
+
+const (
+	compareCommand = "compare"
+	// Changes for the worse by more than this many percents are
+	// reported as regressions by default
+	defaultRegressionThreshold = 10.0
+)
+
+// savedResults holds the parts of results printed in JSON format
+// that can be compared or merged, it's read back from the output.
+type savedResults struct {
+	SchemaVersion json.RawMessage `json:"schemaVersion"`
+	Spec          struct {
+		NumberOfConnections uint64  `json:"numberOfConnections"`
+		TestType            string  `json:"testType"`
+		TestDurationSeconds float64 `json:"testDurationSeconds"`
+		NumberOfRequests    uint64  `json:"numberOfRequests"`
+		Method              string  `json:"method"`
+		URL                 string  `json:"url"`
+		TimeoutSeconds      float64 `json:"timeoutSeconds"`
+		Client              string  `json:"client"`
+		Seed                uint64  `json:"seed"`
+	} `json:"spec"`
+	Result struct {
+		BytesRead        int64    `json:"bytesRead"`
+		BytesWritten     int64    `json:"bytesWritten"`
+		HeaderBytesRead  int64    `json:"headerBytesRead"`
+		BodyBytesRead    int64    `json:"bodyBytesRead"`
+		TimeTakenSeconds float64  `json:"timeTakenSeconds"`
+		Req1XX           uint64   `json:"req1xx"`
+		Req2XX           uint64   `json:"req2xx"`
+		Req3XX           uint64   `json:"req3xx"`
+		Req4XX           uint64   `json:"req4xx"`
+		Req5XX           uint64   `json:"req5xx"`
+		Others           uint64   `json:"others"`
+		Retries          uint64   `json:"retries"`
+		NewConns         uint64   `json:"newConns"`
+		ReusedConns      uint64   `json:"reusedConns"`
+		ConnsOpened      uint64   `json:"connsOpened"`
+		RemoteAddresses  []string `json:"remoteAddresses"`
+		Aborted          bool     `json:"aborted"`
+		BytesCapReached  bool     `json:"bytesCapReached"`
+		Interrupted      bool     `json:"interrupted"`
+		Interpolated     bool     `json:"interpolatePercentiles"`
+		Errors           []struct {
+			Description string `json:"description"`
+			Count       uint64 `json:"count"`
+		} `json:"errors"`
+		ErrorsByCategory map[string]uint64 `json:"errorsByCategory"`
+		Latency          struct {
+			Mean        float64            `json:"mean"`
+			Max         float64            `json:"max"`
+			Percentiles map[string]float64 `json:"percentiles"`
+		} `json:"latency"`
+		Rps struct {
+			Mean        float64            `json:"mean"`
+			Percentiles map[string]float64 `json:"percentiles"`
+		} `json:"rps"`
+		// Histograms are nil if results were saved by an older
+		// version
+		Histograms *struct {
+			Latency  internal.Uint64Histogram  `json:"latency"`
+			Requests internal.Float64Histogram `json:"requests"`
+		} `json:"histograms"`
+	} `json:"result"`
+}
+
+type unsupportedSchemaError struct {
+	path, version string
+}
+
+func (u *unsupportedSchemaError) Error() string {
+	if u.version == "" {
+		return fmt.Sprintf("%v: schema version is missing, results "+
+			"must be saved in JSON format", u.path)
+	}
+	return fmt.Sprintf("%v: unsupported schema version %v (expected %v)",
+		u.path, u.version, jsonSchemaVersion)
+}
+
+// readResults reads results saved in JSON format from the file.
+func readResults(path string) (*savedResults, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := new(savedResults)
+	if err = json.NewDecoder(f).Decode(r); err != nil {
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+	if string(r.SchemaVersion) != jsonSchemaVersion {
+		return nil, &unsupportedSchemaError{path, string(r.SchemaVersion)}
+	}
+	return r, nil
+}
+
+func (s *savedResults) throughput() float64 {
+	r := s.Result
+	if r.TimeTakenSeconds == 0 {
+		return 0
+	}
+	return float64(r.BytesRead+r.BytesWritten) / r.TimeTakenSeconds
+}
+
+func (s *savedResults) errors() float64 {
+	sum := uint64(0)
+	for _, e := range s.Result.Errors {
+		sum += e.Count
+	}
+	return float64(sum)
+}
+
+// metricDelta is the change of a single metric between two runs.
+type metricDelta struct {
+	name     string
+	old, new float64
+	// lowerIsBetter tells which direction of change is a regression
+	lowerIsBetter bool
+	format        func(float64) string
+}
+
+// change returns the change in percents, infinite if metric changed
+// from zero.
+func (d metricDelta) change() float64 {
+	if d.old == d.new {
+		return 0
+	}
+	if d.old == 0 {
+		return math.Inf(int(d.new - d.old))
+	}
+	return (d.new - d.old) / math.Abs(d.old) * 100
+}
+
+// regressed tells whether metric changed for the worse by more than
+// threshold percents.
+func (d metricDelta) regressed(threshold float64) bool {
+	if d.lowerIsBetter {
+		return d.change() > threshold
+	}
+	return d.change() < -threshold
+}
+
+func formatCount(n float64) string {
+	return strconv.FormatFloat(n, 'f', 0, 64)
+}
+
+func formatRate(n float64) string {
+	return strconv.FormatFloat(n, 'f', 2, 64)
+}
+
+func formatThroughput(n float64) string {
+	return formatBinary(n) + "/s"
+}
+
+// compareResults lists changes of the metrics, percentiles are only
+// compared if both runs have them.
+func compareResults(before, after *savedResults) []metricDelta {
+	deltas := []metricDelta{
+		{"Reqs/sec", before.Result.Rps.Mean, after.Result.Rps.Mean,
+			false, formatRate},
+		{"Latency mean", before.Result.Latency.Mean, after.Result.Latency.Mean,
+			true, formatTimeUs},
+		{"Latency max", before.Result.Latency.Max, after.Result.Latency.Max,
+			true, formatTimeUs},
+	}
+	pcs := make([]string, 0, len(after.Result.Latency.Percentiles))
+	for pc := range after.Result.Latency.Percentiles {
+		if _, ok := before.Result.Latency.Percentiles[pc]; ok {
+			pcs = append(pcs, pc)
+		}
+	}
+	sort.Slice(pcs, func(i, j int) bool {
+		a, _ := strconv.ParseFloat(pcs[i], 64)
+		b, _ := strconv.ParseFloat(pcs[j], 64)
+		return a < b
+	})
+	for _, pc := range pcs {
+		deltas = append(deltas, metricDelta{
+			"Latency p" + pc,
+			before.Result.Latency.Percentiles[pc],
+			after.Result.Latency.Percentiles[pc],
+			true, formatTimeUs,
+		})
+	}
+	return append(deltas,
+		metricDelta{"Throughput", before.throughput(), after.throughput(),
+			false, formatThroughput},
+		metricDelta{"Errors", before.errors(), after.errors(),
+			true, formatCount},
+		metricDelta{"4xx", float64(before.Result.Req4XX),
+			float64(after.Result.Req4XX), true, formatCount},
+		metricDelta{"5xx", float64(before.Result.Req5XX),
+			float64(after.Result.Req5XX), true, formatCount},
+	)
+}
+
+// printDeltas prints the table of changes and returns the number of
+// regressions beyond threshold.
+func printDeltas(out io.Writer, deltas []metricDelta, threshold float64) int {
+	regressions := 0
+	fmt.Fprintf(out, "%-16v %14v %14v %10v\n", "Metric", "Old", "New", "Change")
+	for _, d := range deltas {
+		fmt.Fprintf(out, "%-16v %14v %14v %9.2f%%",
+			d.name, d.format(d.old), d.format(d.new), d.change())
+		if d.regressed(threshold) {
+			regressions++
+			fmt.Fprint(out, "  regression")
+		}
+		fmt.Fprintln(out)
+	}
+	if regressions > 0 {
+		fmt.Fprintf(out, "%v regression(s) beyond %v%%\n",
+			regressions, threshold)
+	} else {
+		fmt.Fprintf(out, "No regressions beyond %v%%\n", threshold)
+	}
+	return regressions
+}
+
+// runCompare compares results saved by two runs, given the arguments
+// that follow compare command, and tells whether the second one
+// regressed.
+func runCompare(args []string, out io.Writer) (bool, error) {
+	var (
+		oldPath, newPath string
+		threshold        float64
+	)
+	app := kingpin.New("bombardier "+compareCommand,
+		"Compare results of two runs saved in JSON format")
+	app.Flag("threshold", "Report changes for the worse by more "+
+		"than this many percents as regressions").
+		Default(strconv.FormatFloat(defaultRegressionThreshold, 'f', -1, 64)).
+		PlaceHolder("<pct>").
+		Float64Var(&threshold)
+	app.Arg("old", "Results of the baseline run").
+		Required().
+		StringVar(&oldPath)
+	app.Arg("new", "Results of the run compared to baseline").
+		Required().
+		StringVar(&newPath)
+	if _, err := app.Parse(args); err != nil {
+		return false, err
+	}
+	if threshold < 0 {
+		return false, errNegativeThreshold
+	}
+	old, err := readResults(oldPath)
+	if err != nil {
+		return false, err
+	}
+	cur, err := readResults(newPath)
+	if err != nil {
+		return false, err
+	}
+	return printDeltas(out, compareResults(old, cur), threshold) > 0, nil
+}
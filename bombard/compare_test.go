@@ -0,0 +1,209 @@
+package bombard
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeResults(t *testing.T, results string) string {
+	path := filepath.Join(t.TempDir(), "results.json")
+	if err := ioutil.WriteFile(path, []byte(results), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadResults(t *testing.T) {
+	path := writeResults(t, `{"schemaVersion":1,"spec":{},"result":{
+		"bytesRead":1000,"bytesWritten":1000,"timeTakenSeconds":2,
+		"req4xx":3,"req5xx":4,
+		"errors":[{"description":"a","count":1},{"description":"b","count":2}],
+		"latency":{"mean":100,"max":500,"percentiles":{"50":90,"99.9":450}},
+		"rps":{"mean":1000}}}`)
+	r, err := readResults(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tp := r.throughput(); tp != 1000 {
+		t.Errorf("Expected throughput of 1000, but got %v", tp)
+	}
+	if errs := r.errors(); errs != 3 {
+		t.Errorf("Expected 3 errors, but got %v", errs)
+	}
+	if p := r.Result.Latency.Percentiles["99.9"]; p != 450 {
+		t.Errorf("Expected p99.9 of 450, but got %v", p)
+	}
+}
+
+func TestReadResultsFailures(t *testing.T) {
+	for _, results := range []string{
+		"Bombarding http://localhost with 1 request(s)",
+		`{"spec":{},"result":{}}`,
+		`{"schemaVersion":2,"spec":{},"result":{}}`,
+	} {
+		if _, err := readResults(writeResults(t, results)); err == nil {
+			t.Errorf("%v: expected an error", results)
+		}
+	}
+	missing := filepath.Join(t.TempDir(), "missing.json")
+	if _, err := readResults(missing); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
+
+func TestMetricDelta(t *testing.T) {
+	expectations := []struct {
+		old, new      float64
+		lowerIsBetter bool
+		change        float64
+		regressed     bool
+	}{
+		{100, 100, true, 0, false},
+		{100, 105, true, 5, false},
+		{100, 120, true, 20, true},
+		{100, 80, true, -20, false},
+		{100, 80, false, -20, true},
+		{100, 120, false, 20, false},
+		{0, 5, true, math.Inf(1), true},
+		{0, 0, true, 0, false},
+	}
+	for _, e := range expectations {
+		d := metricDelta{"m", e.old, e.new, e.lowerIsBetter, formatCount}
+		if c := d.change(); c != e.change {
+			t.Errorf("%v -> %v: expected change of %v, but got %v",
+				e.old, e.new, e.change, c)
+		}
+		if r := d.regressed(10); r != e.regressed {
+			t.Errorf("%v -> %v: expected regressed to be %v",
+				e.old, e.new, e.regressed)
+		}
+	}
+}
+
+func TestRunCompare(t *testing.T) {
+	old := writeResults(t, `{"schemaVersion":1,"result":{
+		"bytesRead":1000,"bytesWritten":0,"timeTakenSeconds":1,
+		"latency":{"mean":100,"max":500,
+			"percentiles":{"50":90,"100":500,"99":400,"75":95}},
+		"rps":{"mean":1000}}}`)
+	same := writeResults(t, `{"schemaVersion":1,"result":{
+		"bytesRead":1050,"bytesWritten":0,"timeTakenSeconds":1,
+		"latency":{"mean":105,"max":510,"percentiles":{"50":92,"99":410}},
+		"rps":{"mean":990}}}`)
+	worse := writeResults(t, `{"schemaVersion":1,"result":{
+		"bytesRead":1000,"bytesWritten":0,"timeTakenSeconds":1,"req5xx":2,
+		"latency":{"mean":100,"max":500,"percentiles":{"50":90,"99":800}},
+		"rps":{"mean":1000}}}`)
+
+	out := new(bytes.Buffer)
+	regressed, err := runCompare([]string{old, same}, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if regressed || strings.Contains(out.String(), "  regression") {
+		t.Errorf("Expected no regressions, but got:\n%v", out)
+	}
+	// Percentiles are compared in order, only those both runs have
+	p50 := strings.Index(out.String(), "Latency p50 ")
+	p99 := strings.Index(out.String(), "Latency p99 ")
+	if p50 < 0 || p99 < p50 {
+		t.Errorf("Expected p50 followed by p99, but got:\n%v", out)
+	}
+	if strings.Contains(out.String(), "Latency p75") ||
+		strings.Contains(out.String(), "Latency p100") {
+		t.Errorf("Expected only common percentiles, but got:\n%v", out)
+	}
+
+	out.Reset()
+	regressed, err = runCompare([]string{old, worse}, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !regressed {
+		t.Errorf("Expected regressions, but got:\n%v", out)
+	}
+	for _, line := range strings.Split(out.String(), "\n") {
+		flagged := strings.HasSuffix(line, "  regression")
+		exp := strings.HasPrefix(line, "Latency p99 ") ||
+			strings.HasPrefix(line, "5xx ")
+		if flagged != exp {
+			t.Errorf("Unexpected line: %q", line)
+		}
+	}
+	if !strings.Contains(out.String(), "2 regression(s) beyond 10%") {
+		t.Errorf("Expected a summary, but got:\n%v", out)
+	}
+
+	out.Reset()
+	regressed, err = runCompare([]string{"--threshold=150", old, worse}, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !regressed ||
+		!strings.Contains(out.String(), "1 regression(s) beyond 150%") {
+		t.Errorf("Expected only 5xx to regress, but got:\n%v", out)
+	}
+
+	if _, err := runCompare([]string{"--threshold=-1", old, same},
+		out); err != errNegativeThreshold {
+		t.Errorf("Expected %v, but got %v", errNegativeThreshold, err)
+	}
+	if _, err := runCompare([]string{old}, out); err == nil {
+		t.Error("Expected an error when new results are missing")
+	}
+}
+
+func TestCompareSavedResults(t *testing.T) {
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			_, _ = rw.Write([]byte("hello"))
+		}),
+	)
+	defer s.Close()
+	numReqs := uint64(20)
+	b, e := newBombardier(config{
+		numConns:       2,
+		numReqs:        &numReqs,
+		url:            s.URL,
+		headers:        new(headersList),
+		timeout:        defaultTimeout,
+		method:         "GET",
+		format:         knownFormat("json"),
+		printLatencies: true,
+	})
+	if e != nil {
+		t.Fatal(e)
+	}
+	out := new(bytes.Buffer)
+	b.redirectOutputTo(out)
+	b.bombard()
+	out.Reset()
+	b.printStats()
+	path := writeResults(t, out.String())
+	r, err := readResults(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Result.Rps.Mean <= 0 || r.throughput() <= 0 {
+		t.Errorf("Expected request rate and throughput, but got %+v",
+			r.Result)
+	}
+	if len(r.Result.Latency.Percentiles) == 0 {
+		t.Error("Expected latency percentiles")
+	}
+	cmp := new(bytes.Buffer)
+	regressed, err := runCompare([]string{path, path}, cmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if regressed {
+		t.Errorf("Expected no regressions against itself, but got:\n%v",
+			cmp)
+	}
+}
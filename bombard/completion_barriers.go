@@ -0,0 +1,220 @@
+package bombard
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type completionBarrier interface {
+	// start starts the clock, time doesn't run out until then, so
+	// that whatever happens before the test itself doesn't count
+	start()
+	completed() float64
+	tryGrabWork() bool
+	jobDone()
+	done() <-chan struct{}
+	cancel()
+}
+
+type countingCompletionBarrier struct {
+	numReqs, reqsGrabbed, reqsDone uint64
+	doneChan                       chan struct{}
+	closeOnce                      sync.Once
+}
+
+func newCountingCompletionBarrier(numReqs uint64) completionBarrier {
+	c := new(countingCompletionBarrier)
+	c.reqsDone, c.reqsGrabbed, c.numReqs = 0, 0, numReqs
+	c.doneChan = make(chan struct{})
+	return completionBarrier(c)
+}
+
+func (c *countingCompletionBarrier) start() {
+}
+
+func (c *countingCompletionBarrier) tryGrabWork() bool {
+	select {
+	case <-c.doneChan:
+		return false
+	default:
+		reqsDone := atomic.AddUint64(&c.reqsGrabbed, 1)
+		return reqsDone <= c.numReqs
+	}
+}
+
+func (c *countingCompletionBarrier) jobDone() {
+	reqsDone := atomic.AddUint64(&c.reqsDone, 1)
+	if reqsDone == c.numReqs {
+		c.closeOnce.Do(func() {
+			close(c.doneChan)
+		})
+	}
+}
+
+func (c *countingCompletionBarrier) done() <-chan struct{} {
+	return c.doneChan
+}
+
+func (c *countingCompletionBarrier) cancel() {
+	c.closeOnce.Do(func() {
+		close(c.doneChan)
+	})
+}
+
+func (c *countingCompletionBarrier) completed() float64 {
+	select {
+	case <-c.doneChan:
+		return 1.0
+	default:
+		reqsDone := atomic.LoadUint64(&c.reqsDone)
+		return float64(reqsDone) / float64(c.numReqs)
+	}
+}
+
+// combinedCompletionBarrier is done once either numReqs requests
+// complete or duration elapses.
+type combinedCompletionBarrier struct {
+	numReqs, reqsGrabbed, reqsDone uint64
+	doneChan                       chan struct{}
+	closeOnce                      sync.Once
+	started                        time.Time
+	duration                       time.Duration
+	// by is the condition that made the barrier done, none if it was
+	// cancelled
+	by testTyp
+}
+
+func newCombinedCompletionBarrier(
+	numReqs uint64, duration time.Duration,
+) completionBarrier {
+	if duration < 0 {
+		panic("combinedCompletionBarrier: negative duration")
+	}
+	c := new(combinedCompletionBarrier)
+	c.numReqs = numReqs
+	c.doneChan = make(chan struct{})
+	c.duration = duration
+	return completionBarrier(c)
+}
+
+func (c *combinedCompletionBarrier) start() {
+	c.started = time.Now()
+	time.AfterFunc(c.duration, func() {
+		c.finish(timed)
+	})
+}
+
+func (c *combinedCompletionBarrier) finish(by testTyp) {
+	c.closeOnce.Do(func() {
+		c.by = by
+		close(c.doneChan)
+	})
+}
+
+func (c *combinedCompletionBarrier) tryGrabWork() bool {
+	select {
+	case <-c.doneChan:
+		return false
+	default:
+		reqsGrabbed := atomic.AddUint64(&c.reqsGrabbed, 1)
+		return reqsGrabbed <= c.numReqs
+	}
+}
+
+func (c *combinedCompletionBarrier) jobDone() {
+	if atomic.AddUint64(&c.reqsDone, 1) == c.numReqs {
+		c.finish(counted)
+	}
+}
+
+func (c *combinedCompletionBarrier) done() <-chan struct{} {
+	return c.doneChan
+}
+
+func (c *combinedCompletionBarrier) cancel() {
+	c.finish(none)
+}
+
+func (c *combinedCompletionBarrier) completed() float64 {
+	select {
+	case <-c.doneChan:
+		return 1.0
+	default:
+		reqsDone := atomic.LoadUint64(&c.reqsDone)
+		byReqs := float64(reqsDone) / float64(c.numReqs)
+		byTime := float64(time.Since(c.started).Nanoseconds()) /
+			float64(c.duration.Nanoseconds())
+		return math.Max(byReqs, byTime)
+	}
+}
+
+// stoppedBy returns the condition that made the barrier done, none if
+// it isn't done yet or was cancelled.
+func (c *combinedCompletionBarrier) stoppedBy() testTyp {
+	select {
+	case <-c.doneChan:
+		return c.by
+	default:
+		return none
+	}
+}
+
+type timedCompletionBarrier struct {
+	doneChan  chan struct{}
+	closeOnce sync.Once
+	started   time.Time
+	duration  time.Duration
+}
+
+func newTimedCompletionBarrier(duration time.Duration) completionBarrier {
+	if duration < 0 {
+		panic("timedCompletionBarrier: negative duration")
+	}
+	c := new(timedCompletionBarrier)
+	c.doneChan = make(chan struct{})
+	c.duration = duration
+	return completionBarrier(c)
+}
+
+func (c *timedCompletionBarrier) start() {
+	c.started = time.Now()
+	time.AfterFunc(c.duration, func() {
+		c.closeOnce.Do(func() {
+			close(c.doneChan)
+		})
+	})
+}
+
+func (c *timedCompletionBarrier) tryGrabWork() bool {
+	select {
+	case <-c.doneChan:
+		return false
+	default:
+		return true
+	}
+}
+
+func (c *timedCompletionBarrier) jobDone() {
+}
+
+func (c *timedCompletionBarrier) done() <-chan struct{} {
+	return c.doneChan
+}
+
+func (c *timedCompletionBarrier) cancel() {
+	c.closeOnce.Do(func() {
+		close(c.doneChan)
+	})
+}
+
+func (c *timedCompletionBarrier) completed() float64 {
+	select {
+	case <-c.doneChan:
+		return 1.0
+	default:
+		return float64(time.Since(c.started).Nanoseconds()) /
+			float64(c.duration.Nanoseconds())
+	}
+}
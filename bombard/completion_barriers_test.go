@@ -0,0 +1,239 @@
+package bombard
+
+import (
+	"math"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCouintingCompletionBarrierWait(t *testing.T) {
+	parties := uint64(10)
+	b := newCountingCompletionBarrier(1000)
+	for i := uint64(0); i < parties; i++ {
+		go func() {
+			for b.tryGrabWork() {
+				b.jobDone()
+			}
+		}()
+	}
+	wc := make(chan struct{})
+	go func() {
+		<-b.done()
+		wc <- struct{}{}
+	}()
+	select {
+	case <-wc:
+		return
+	case <-time.After(100 * time.Millisecond):
+		t.Fail()
+	}
+}
+
+func TestTimedCompletionBarrierWait(t *testing.T) {
+	parties := uint64(10)
+	duration := 100 * time.Millisecond
+	timeout := duration * 2
+	err := 15 * time.Millisecond
+	sleepDuration := 2 * time.Millisecond
+	b := newTimedCompletionBarrier(duration)
+	b.start()
+	for i := uint64(0); i < parties; i++ {
+		go func() {
+			for b.tryGrabWork() {
+				time.Sleep(sleepDuration)
+				b.jobDone()
+			}
+		}()
+	}
+	wc := make(chan time.Duration)
+	go func() {
+		start := time.Now()
+		<-b.done()
+		wc <- time.Since(start)
+	}()
+	select {
+	case actual := <-wc:
+		if !approximatelyEqual(duration, actual, sleepDuration+err) {
+			t.Errorf("Expected to run %v, but ran %v instead", duration, actual)
+		}
+	case <-time.After(timeout):
+		t.Error("Barrier hanged")
+	}
+}
+
+func TestTimedCompletionBarrierWaitsForStart(t *testing.T) {
+	duration := 50 * time.Millisecond
+	b := newTimedCompletionBarrier(duration)
+	select {
+	case <-b.done():
+		t.Fatal("Barrier is done before it started")
+	case <-time.After(2 * duration):
+	}
+	b.start()
+	select {
+	case <-b.done():
+	case <-time.After(4 * duration):
+		t.Error("Barrier hanged")
+	}
+}
+
+func TestTimeBarrierCancel(t *testing.T) {
+	b := newTimedCompletionBarrier(9000 * time.Second)
+	b.start()
+	sleepTime := 100 * time.Millisecond
+	go func() {
+		time.Sleep(sleepTime)
+		b.cancel()
+	}()
+	select {
+	case <-b.done():
+		if c := b.completed(); c != 1.0 {
+			t.Error(c)
+		}
+	case <-time.After(sleepTime * 2):
+		t.Fail()
+	}
+}
+
+func TestCountedBarrierCancel(t *testing.T) {
+	parties := uint64(10)
+	b := newCountingCompletionBarrier(math.MaxUint64)
+	sleepTime := 100 * time.Millisecond
+	for i := uint64(0); i < parties; i++ {
+		go func() {
+			for b.tryGrabWork() {
+				b.jobDone()
+			}
+		}()
+	}
+	go func() {
+		time.Sleep(sleepTime)
+		b.cancel()
+	}()
+	select {
+	case <-b.done():
+		if c := b.completed(); c != 1.0 {
+			t.Error(c)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fail()
+	}
+}
+
+func TestTimeBarrierPanicOnBadDuration(t *testing.T) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Error("shouldn't be empty")
+			t.Fail()
+		}
+	}()
+	newTimedCompletionBarrier(-1 * time.Second)
+	t.Error("unreachable")
+	t.Fail()
+}
+
+func TestCombinedCompletionBarrierStopsByRequests(t *testing.T) {
+	parties := uint64(10)
+	b := newCombinedCompletionBarrier(1000, 9000*time.Second)
+	b.start()
+	for i := uint64(0); i < parties; i++ {
+		go func() {
+			for b.tryGrabWork() {
+				b.jobDone()
+			}
+		}()
+	}
+	select {
+	case <-b.done():
+		c := b.(*combinedCompletionBarrier)
+		if by := c.stoppedBy(); by != counted {
+			t.Errorf("Expected to be stopped by requests, but got %v", by)
+		}
+		if reqs := atomic.LoadUint64(&c.reqsDone); reqs != 1000 {
+			t.Errorf("Expected 1000 requests, but got %v", reqs)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("Barrier hanged")
+	}
+}
+
+func TestCombinedCompletionBarrierStopsByTime(t *testing.T) {
+	parties := uint64(10)
+	duration := 100 * time.Millisecond
+	b := newCombinedCompletionBarrier(math.MaxUint64, duration)
+	b.start()
+	for i := uint64(0); i < parties; i++ {
+		go func() {
+			for b.tryGrabWork() {
+				time.Sleep(2 * time.Millisecond)
+				b.jobDone()
+			}
+		}()
+	}
+	select {
+	case <-b.done():
+		c := b.(*combinedCompletionBarrier)
+		if by := c.stoppedBy(); by != timed {
+			t.Errorf("Expected to be stopped by time, but got %v", by)
+		}
+	case <-time.After(duration * 2):
+		t.Error("Barrier hanged")
+	}
+}
+
+func TestCombinedCompletionBarrierCancel(t *testing.T) {
+	b := newCombinedCompletionBarrier(math.MaxUint64, 9000*time.Second)
+	b.start()
+	c := b.(*combinedCompletionBarrier)
+	if by := c.stoppedBy(); by != none {
+		t.Errorf("Running barrier shouldn't be stopped, but got %v", by)
+	}
+	b.cancel()
+	select {
+	case <-b.done():
+		if c := b.completed(); c != 1.0 {
+			t.Error(c)
+		}
+	default:
+		t.Fatal("Cancelled barrier should be done")
+	}
+	if by := c.stoppedBy(); by != none {
+		t.Errorf("Cancelled barrier shouldn't be stopped by %v", by)
+	}
+}
+
+func TestCombinedCompletionBarrierCompleted(t *testing.T) {
+	b := newCombinedCompletionBarrier(4, 9000*time.Second)
+	b.start()
+	for i := 0; i < 2; i++ {
+		if !b.tryGrabWork() {
+			t.Fatal("Expected to grab work")
+		}
+		b.jobDone()
+	}
+	if c := b.completed(); c != 0.5 {
+		t.Errorf("Expected progress of requests, 0.5, but got %v", c)
+	}
+	b = newCombinedCompletionBarrier(math.MaxUint64, 100*time.Millisecond)
+	b.start()
+	time.Sleep(50 * time.Millisecond)
+	if c := b.completed(); c < 0.5 || c >= 1 {
+		t.Errorf("Expected progress of time, about 0.5, but got %v", c)
+	}
+}
+
+func TestCombinedBarrierPanicOnBadDuration(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("shouldn't be empty")
+		}
+	}()
+	newCombinedCompletionBarrier(1, -1*time.Second)
+	t.Error("unreachable")
+}
+
+func approximatelyEqual(expected, actual, err time.Duration) bool {
+	return expected-err < actual && actual < expected+err
+}
@@ -0,0 +1,31 @@
+package bombard
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+)
+
+// bodyEncoders are keyed by the value of Content-Encoding header.
+var bodyEncoders = map[string]func(io.Writer) io.WriteCloser{
+	"gzip": func(w io.Writer) io.WriteCloser {
+		return gzip.NewWriter(w)
+	},
+	// "deflate" content coding is actually zlib format
+	"deflate": func(w io.Writer) io.WriteCloser {
+		return zlib.NewWriter(w)
+	},
+}
+
+func compressBody(body, encoding string) (string, error) {
+	buf := new(bytes.Buffer)
+	w := bodyEncoders[encoding](buf)
+	if _, err := io.WriteString(w, body); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
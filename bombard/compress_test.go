@@ -0,0 +1,46 @@
+package bombard
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestCompressBody(t *testing.T) {
+	body := strings.Repeat("compressible ", 100)
+	decoders := map[string]func(io.Reader) (io.Reader, error){
+		"gzip": func(r io.Reader) (io.Reader, error) {
+			return gzip.NewReader(r)
+		},
+		"deflate": func(r io.Reader) (io.Reader, error) {
+			return zlib.NewReader(r)
+		},
+	}
+	for encoding, decoder := range decoders {
+		compressed, err := compressBody(body, encoding)
+		if err != nil {
+			t.Errorf("%v: %v", encoding, err)
+			continue
+		}
+		if len(compressed) >= len(body) {
+			t.Errorf("%v: body wasn't compressed", encoding)
+		}
+		r, err := decoder(strings.NewReader(compressed))
+		if err != nil {
+			t.Errorf("%v: %v", encoding, err)
+			continue
+		}
+		decompressed, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Errorf("%v: %v", encoding, err)
+			continue
+		}
+		if string(decompressed) != body {
+			t.Errorf("%v: expected %q, but got %q",
+				encoding, body, decompressed)
+		}
+	}
+}
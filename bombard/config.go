@@ -0,0 +1,929 @@
+package bombard
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kostyay/bombardier/internal"
+)
+
+type config struct {
+	numConns                       uint64
+	numReqs                        *uint64
+	duration                       *time.Duration
+	url, method, certPath, keyPath string
+	body, bodyFilePath, bodyDir    string
+	stream, bodyTemplate, cookies  bool
+	headers                        *headersList
+	timeout, rampUp, warmup        time.Duration
+	// TODO(codesenberg): printLatencies should probably be
+	// re(named&maked) into printPercentiles
+	printLatencies, insecure bool
+	rate                     *uint64
+	ratePerConn              bool
+	clientType               clientTyp
+
+	// connectTimeout bounds establishing of connections, zero means
+	// only timeout does
+	connectTimeout time.Duration
+	// bodyGenerator is nil unless bodies are generated for every
+	// request, it can only be set from Go
+	bodyGenerator *internal.BodyGenerator
+	// bodySize is nil unless bodies of random sizes are generated
+	bodySize *bodySize
+	// onRequest and onTick are nil unless results are observed while
+	// the test runs, they can only be set from Go
+	onRequest *internal.RequestHook
+	onTick    *internal.TickHook
+	// loadProfile is nil unless rate changes over time
+	loadProfile loadProfile
+	// arrival is the process rate limited requests follow
+	arrival arrival
+	// correctLatency tells whether latency is measured from the time
+	// request was scheduled for, to correct coordinated omission
+	correctLatency bool
+	// kneeLatency is the latency requirement, violation of which
+	// marks the knee of step load profile, nil if it's not detected
+	kneeLatency *latencyAssertion
+	// thinkTime is nil unless connections pause between requests
+	thinkTime *thinkTime
+	// jitter is the upper bound of random delay of every request
+	// after rate limiter lets it through, zero if there is none
+	jitter time.Duration
+	// serverTimeHeader is the header server reports its processing
+	// time in, empty if it isn't read
+	serverTimeHeader string
+	// interArrival tells whether gaps between completions of
+	// requests are recorded
+	interArrival bool
+
+	// maxConnsPerHost limits connections to every host, zero means
+	// there is no limit
+	maxConnsPerHost uint64
+
+	// retries is the maximum number of times request is retried
+	// after an error or 5xx response
+	retries            uint64
+	latencyWithRetries bool
+
+	// maxRedirects is the maximum number of redirects followed for
+	// every request, they aren't followed if it's zero
+	maxRedirects    uint64
+	finalHopLatency bool
+
+	// abortErrorRate is the fraction of 5xx responses above which
+	// the test is aborted, nil means that it's never aborted
+	abortErrorRate *float64
+	// maxBytes is the number of bytes read and written the test is
+	// stopped at, nil means that there is no cap
+	maxBytes *uint64
+
+	// latenciesByStatus enables separate latency histograms for
+	// every status class
+	latenciesByStatus bool
+	// timeSeries enables recording of results for every second
+	timeSeries bool
+	// connStats enables recording of results for every connection
+	connStats bool
+	// interpolatePercentiles makes percentiles linearly interpolated
+	// between adjacent values instead of nearest-rank ones
+	interpolatePercentiles bool
+	// latencyDigits is the number of significant digits latencies
+	// are recorded with, zero means they are recorded exactly
+	latencyDigits uint64
+	// latencyCeiling is the highest latency that is tracked, zero
+	// means there is no limit
+	latencyCeiling time.Duration
+
+	// disableKeepAlive makes every request go over a new connection,
+	// numConns is then the number of requests sent at once
+	disableKeepAlive bool
+
+	// compressBody is the content coding body is compressed with,
+	// empty if it's sent as is
+	compressBody string
+	// noAutoContentType disables inference of Content-Type header
+	// from the extension of body file
+	noAutoContentType bool
+
+	// form holds fields of multipart/form-data body, nil unless
+	// body is built from them
+	form *formFieldsList
+
+	// certPEM and keyPEM hold client certificate and its key, when
+	// they are supplied in memory rather than read from files
+	certPEM, keyPEM string
+
+	// caCertPath is the path of CA bundle server certificates are
+	// verified against instead of system roots, if set
+	caCertPath string
+	// sni is the server name sent in TLS handshake instead of the
+	// host from URL, if set
+	sni string
+	// alpn lists protocols advertised in TLS handshake instead of
+	// the ones client advertises by default, nil if they aren't
+	// overridden
+	alpn *[]string
+
+	// resolve holds addresses connections go to instead of the
+	// ones in URLs, nil if there are none
+	resolve *resolveOverridesList
+	// pinDNS tells whether every host is resolved only once
+	pinDNS bool
+	// localAddrs are IP addresses connections are made from, nil if
+	// it's up to the OS
+	localAddrs *localAddrsList
+	// unixSocket is the path of Unix domain socket connections are
+	// made to instead of the host in URL, empty if not used
+	unixSocket string
+	// proxy is the URL of HTTP or SOCKS5 proxy connections go
+	// through, empty if they are made directly
+	proxy string
+
+	// allowBodyOnGet suppresses the warning of body sent with
+	// methods that usually don't have one
+	allowBodyOnGet bool
+
+	// resetAfterWarmup tells whether status code and byte counters
+	// should be reset once the warmup period is over
+	resetAfterWarmup bool
+	// primeConns makes every connection send a request, which isn't
+	// recorded, before the test starts
+	primeConns bool
+
+	// urls is only set when there is more than one target or
+	// targets are weighted, url holds the first of them in that case
+	urls *[]string
+	// weights of the corresponding urls, nil means that URLs are
+	// selected in a round-robin fashion
+	weights *[]uint
+	// grpc is nil unless unary gRPC method is called instead of
+	// sending HTTP requests, body is its request in JSON then
+	grpc *grpcMethod
+	// scenario is nil unless urls are steps every connection goes
+	// through in order
+	scenario *scenario
+	// replay is nil unless requests are replayed from access log,
+	// url is the base they are sent to then
+	replay *replay
+
+	printIntro, printProgress, printResult bool
+	// quiet tells that nothing but the result is written to stdout,
+	// errors and hints go to stderr instead
+	quiet bool
+	// dashboard replaces the progress bar with a full-screen view,
+	// when output is a terminal
+	dashboard bool
+
+	// dryRun tells that the test is only validated, not performed,
+	// dryRunRequest additionally sends one request to every target
+	dryRun, dryRunRequest bool
+	// smoke tells whether a single request is sent to check the
+	// target before the test
+	smoke bool
+
+	format format
+
+	csvPath     string
+	hdrPath     string
+	junitPath   string
+	samplesPath string
+
+	// dumpErrors is the number of failed requests dumped into
+	// dumpErrorsPath
+	dumpErrors     uint64
+	dumpErrorsPath string
+
+	prometheusAddr string
+
+	// influxURL is the InfluxDB write endpoint results are pushed to
+	influxURL string
+	// syslog enables writing summary of results to syslog, the one
+	// at syslogAddr or the local one if it's empty
+	syslog     bool
+	syslogAddr string
+
+	// expectedStatuses is nil if any status code is acceptable
+	expectedStatuses *[]int
+	// latencyAssertions is nil if there are no latency requirements
+	latencyAssertions *latencyAssertionsList
+	// percentiles is nil if defaultPercentiles should be used
+	percentiles *[]float64
+	// headerTemplates is nil if there are no templated headers
+	headerTemplates *headersList
+	// randomHeaders is nil if all headers are sent with every request
+	randomHeaders *randomHeadersList
+	// seed is nil if random number generators should be seeded
+	// with current time
+	seed *uint64
+	// oauth2 is nil if tokens shouldn't be obtained
+	oauth2 *oauth2Config
+	// basicAuth holds <user>:<password> sent in Authorization header,
+	// it's empty if basic authentication isn't used
+	basicAuth string
+	// sigV4 is nil if requests shouldn't be signed
+	sigV4 *sigV4Config
+	// tags is nil if no tags should be attached to pushed results
+	tags *tagsList
+}
+
+func (c *config) percentilesOrDefault() []float64 {
+	if c.percentiles == nil {
+		return defaultPercentiles
+	}
+	return *c.percentiles
+}
+
+type testTyp int
+
+const (
+	none testTyp = iota
+	timed
+	counted
+	// timedOrCounted test lasts until either its duration elapses or
+	// its requests complete, whichever comes first
+	timedOrCounted
+)
+
+// limitsTime tells whether test of this type is limited by duration.
+func (t testTyp) limitsTime() bool {
+	return t == timed || t == timedOrCounted
+}
+
+// limitsRequests tells whether test of this type is limited by number
+// of requests.
+func (t testTyp) limitsRequests() bool {
+	return t == counted || t == timedOrCounted
+}
+
+type invalidHTTPMethodError struct {
+	method string
+}
+
+func (i *invalidHTTPMethodError) Error() string {
+	return fmt.Sprintf("Unknown HTTP method: %v", i.method)
+}
+
+type unsupportedCompressionError struct {
+	encoding string
+}
+
+func (u *unsupportedCompressionError) Error() string {
+	return fmt.Sprintf(
+		"Unsupported body compression: %q (must be gzip or deflate)",
+		u.encoding)
+}
+
+type notASocketError struct {
+	path string
+}
+
+func (n *notASocketError) Error() string {
+	return fmt.Sprintf("%v is not a Unix domain socket", n.path)
+}
+
+type noCACertsError struct {
+	path string
+}
+
+func (n *noCACertsError) Error() string {
+	return fmt.Sprintf("No PEM certificates found in CA bundle %v", n.path)
+}
+
+type unexpectedStatusError struct {
+	code int
+}
+
+func (u *unexpectedStatusError) Error() string {
+	return fmt.Sprintf("unexpected status code: %v", u.code)
+}
+
+func (c *config) checkArgs() error {
+	c.checkOrSetDefaultTestType()
+	c.checkOrSetClientType()
+
+	checks := []func() error{
+		c.checkURL,
+		c.checkRate,
+		c.checkRunParameters,
+		c.checkTimeoutDuration,
+		c.checkLatencyResolution,
+		c.checkHTTPParameters,
+		c.checkGRPC,
+		c.checkScenario,
+		c.checkReplay,
+		c.checkCertPaths,
+		c.checkALPN,
+		c.checkUnixSocket,
+		c.checkProxy,
+		c.checkErrorDump,
+		c.checkOAuth2,
+		c.checkSigV4,
+		c.checkRedirects,
+		c.checkAuth,
+		c.checkInflux,
+		c.checkSyslog,
+	}
+
+	for _, check := range checks {
+		if err := check(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *config) checkOrSetDefaultTestType() {
+	if c.testType() == none && c.replay != nil {
+		// The whole log is replayed by default
+		numReqs := uint64(len(c.replay.entries))
+		c.numReqs = &numReqs
+	} else if c.testType() == none {
+		c.duration = &defaultTestDuration
+	}
+}
+
+// checkOrSetClientType switches from fasthttp to net/http client if
+// body is sent with GET or HEAD requests, since fasthttp drops it.
+func (c *config) checkOrSetClientType() {
+	if c.clientType != fhttp || c.grpc != nil {
+		return
+	}
+	for _, method := range c.bodyMethods() {
+		if !canHaveBody(method) {
+			c.clientType = nhttp1
+			return
+		}
+	}
+}
+
+func (c *config) testType() testTyp {
+	typ := none
+	if c.numReqs != nil && c.duration != nil {
+		typ = timedOrCounted
+	} else if c.numReqs != nil {
+		typ = counted
+	} else if c.duration != nil {
+		typ = timed
+	}
+	return typ
+}
+
+func (c *config) checkURL() error {
+	u, err := checkedURL(c.url)
+	if err != nil {
+		return err
+	}
+	c.url = u
+	if c.urls == nil {
+		return nil
+	}
+	urls := make([]string, len(*c.urls))
+	for i, raw := range *c.urls {
+		urls[i], err = checkedURL(raw)
+		if err != nil {
+			return err
+		}
+	}
+	c.urls = &urls
+	return c.checkWeights()
+}
+
+func (c *config) checkWeights() error {
+	if c.weights == nil {
+		return nil
+	}
+	if len(*c.weights) != len(c.targets()) {
+		return errWeightsMismatch
+	}
+	for _, w := range *c.weights {
+		if w == 0 {
+			return errZeroWeight
+		}
+	}
+	return nil
+}
+
+func checkedURL(raw string) (string, error) {
+	url, err := url.Parse(raw)
+	if err != nil {
+		return "", err
+	}
+	if url.Host == "" || (url.Scheme != "http" && url.Scheme != "https") {
+		return "", errInvalidURL
+	}
+	return url.String(), nil
+}
+
+// targets returns all URLs that should be used during the test.
+func (c *config) targets() []string {
+	if c.urls != nil {
+		return *c.urls
+	}
+	return []string{c.url}
+}
+
+func (c *config) checkRate() error {
+	if c.rate != nil && *c.rate < 1 {
+		return errZeroRate
+	}
+	if c.rate == nil && c.ratePerConn {
+		return errRatePerConnWithoutRate
+	}
+	if c.rate != nil && c.loadProfile != nil {
+		return errRateWithLoadProfile
+	}
+	if c.arrival != uniformArrival && c.rate == nil &&
+		c.loadProfile == nil {
+		return errArrivalWithoutRate
+	}
+	if c.correctLatency && c.rate == nil && c.loadProfile == nil &&
+		c.replay == nil {
+		return errCorrectLatencyWithoutRate
+	}
+	if _, ok := c.loadProfile.(*stepProfile); c.kneeLatency != nil && !ok {
+		return errKneeLatencyWithoutSteps
+	}
+	if c.abortErrorRate != nil &&
+		(*c.abortErrorRate < 0 || *c.abortErrorRate >= 1) {
+		return errInvalidAbortErrorRate
+	}
+	if c.maxBytes != nil && *c.maxBytes == 0 {
+		return errZeroMaxBytes
+	}
+	return nil
+}
+
+func (c *config) checkRunParameters() error {
+	if c.numConns < uint64(1) {
+		return errInvalidNumberOfConns
+	}
+	if c.testType().limitsRequests() && *c.numReqs < uint64(1) {
+		return errInvalidNumberOfRequests
+	}
+	if c.testType().limitsTime() && *c.duration < time.Second {
+		return errInvalidTestDuration
+	}
+	return nil
+}
+
+func (c *config) checkLatencyResolution() error {
+	if c.latencyDigits > maxLatencyDigits {
+		return errTooManyLatencyDigits
+	}
+	if c.latencyCeiling != 0 && c.latencyCeiling < time.Microsecond {
+		return errInvalidLatencyCeiling
+	}
+	return nil
+}
+
+func (c *config) checkTimeoutDuration() error {
+	if c.timeout < 0 {
+		return errNegativeTimeout
+	}
+	if c.connectTimeout < 0 {
+		return errNegativeConnectTimeout
+	}
+	if c.timeout > 0 && c.connectTimeout > c.timeout {
+		return errConnectTimeoutTooLong
+	}
+	if c.rampUp < 0 {
+		return errNegativeRampUp
+	}
+	if c.jitter < 0 {
+		return errNegativeJitter
+	}
+	if c.warmup < 0 {
+		return errNegativeWarmup
+	}
+	if c.testType().limitsTime() && c.warmup >= *c.duration {
+		return errWarmupTooLong
+	}
+	if c.resetAfterWarmup && c.warmup == 0 {
+		return errResetWithoutWarmup
+	}
+	if c.primeConns && c.disableKeepAlive {
+		return errPrimeWithoutKeepAlive
+	}
+	return nil
+}
+
+func (c *config) checkHTTPParameters() error {
+	if !allowedHTTPMethod(c.method) {
+		return &invalidHTTPMethodError{method: c.method}
+	}
+	sources := 0
+	for _, source := range []string{c.body, c.bodyFilePath, c.bodyDir} {
+		if source != "" {
+			sources++
+		}
+	}
+	if sources > 1 {
+		return errBodyProvidedTwice
+	}
+	if c.bodyTemplate && c.stream {
+		return errStreamedBodyTemplate
+	}
+	if c.bodyDir != "" && (c.bodyTemplate || c.stream) {
+		return errBodyDirTemplateOrStream
+	}
+	if c.bodyGenerator != nil && (sources > 0 || c.form != nil ||
+		c.bodyTemplate || c.stream || c.compressBody != "") {
+		return errBodyGeneratorWithBody
+	}
+	if c.bodySize != nil && (sources > 0 || c.form != nil ||
+		c.bodyGenerator != nil || c.bodyTemplate || c.stream ||
+		c.compressBody != "") {
+		return errBodySizeWithBody
+	}
+	if err := c.checkForm(); err != nil {
+		return err
+	}
+	if c.latencyWithRetries && c.retries == 0 {
+		return errLatencyWithRetriesWithoutRetries
+	}
+	if err := c.checkBodyCompression(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (c *config) checkReplay() error {
+	if c.replay == nil {
+		return nil
+	}
+	if c.urls != nil {
+		return errReplayWithURLs
+	}
+	if c.body != "" || c.bodyFilePath != "" || c.bodyDir != "" ||
+		c.form != nil || c.bodyGenerator != nil || c.bodySize != nil ||
+		c.bodyTemplate || c.stream || c.compressBody != "" {
+		return errReplayWithBody
+	}
+	if c.rate != nil || c.loadProfile != nil {
+		return errReplayWithRate
+	}
+	// NaN isn't positive either
+	if !(c.replay.speed > 0) {
+		return errInvalidReplaySpeed
+	}
+	for _, e := range c.replay.entries {
+		if !allowedHTTPMethod(e.method) {
+			return &invalidHTTPMethodError{method: e.method}
+		}
+	}
+	return nil
+}
+
+func (c *config) checkScenario() error {
+	if c.scenario == nil {
+		return nil
+	}
+	if c.body != "" || c.bodyFilePath != "" || c.bodyDir != "" ||
+		c.form != nil || c.bodyGenerator != nil || c.bodySize != nil ||
+		c.bodyTemplate || c.stream || c.compressBody != "" {
+		return errScenarioWithBody
+	}
+	for _, s := range c.scenario.steps {
+		if !allowedHTTPMethod(s.Method) {
+			return &invalidHTTPMethodError{method: s.Method}
+		}
+	}
+	return nil
+}
+
+func (c *config) checkBodyCompression() error {
+	if c.compressBody == "" {
+		return nil
+	}
+	if _, ok := bodyEncoders[c.compressBody]; !ok {
+		return &unsupportedCompressionError{c.compressBody}
+	}
+	if c.body == "" && c.bodyFilePath == "" && c.bodyDir == "" &&
+		c.form == nil {
+		return errCompressionWithoutBody
+	}
+	if c.bodyTemplate || c.stream {
+		return errUncompressibleBody
+	}
+	return nil
+}
+
+// checkGRPC makes sure that nothing but the JSON request is sent to
+// gRPC method and that options only HTTP requests understand aren't
+// silently ignored.
+func (c *config) checkGRPC() error {
+	if c.grpc == nil {
+		return nil
+	}
+	if c.bodyDir != "" || c.form != nil || c.bodyGenerator != nil ||
+		c.bodyTemplate || c.stream || c.compressBody != "" ||
+		c.bodySize != nil {
+		return errGRPCWithBody
+	}
+	if c.urls != nil || c.scenario != nil || c.replay != nil ||
+		c.cookies || c.headerTemplates != nil ||
+		c.randomHeaders != nil || c.dumpErrors > 0 || c.sigV4 != nil ||
+		c.maxRedirects > 0 || c.serverTimeHeader != "" {
+		return errGRPCWithHTTPOption
+	}
+	return nil
+}
+
+func (c *config) checkForm() error {
+	if c.form == nil {
+		return nil
+	}
+	if c.body != "" || c.bodyFilePath != "" || c.bodyDir != "" ||
+		c.bodyTemplate || c.stream {
+		return errFormWithBody
+	}
+	// Boundary is only known once the body is built
+	if c.hasHeader("Content-Type") {
+		return errFormWithContentType
+	}
+	return nil
+}
+
+func (c *config) checkUnixSocket() error {
+	if c.unixSocket == "" {
+		return nil
+	}
+	if c.resolve != nil || c.pinDNS {
+		return errUnixSocketWithResolve
+	}
+	if c.localAddrs != nil {
+		return errLocalAddrWithUnixSocket
+	}
+	info, err := os.Stat(c.unixSocket)
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return &notASocketError{c.unixSocket}
+	}
+	return nil
+}
+
+func (c *config) checkErrorDump() error {
+	if c.dumpErrorsPath != "" && c.dumpErrors == 0 {
+		return errDumpFileWithoutCount
+	}
+	return nil
+}
+
+func (c *config) checkProxy() error {
+	if c.proxy == "" {
+		return nil
+	}
+	if c.unixSocket != "" {
+		return errProxyWithUnixSocket
+	}
+	_, err := parseProxyURL(c.proxy)
+	return err
+}
+
+func (c *config) checkCertPaths() error {
+	if c.certPath != "" && c.certPEM != "" {
+		return errCertProvidedTwice
+	}
+	if c.keyPath != "" && c.keyPEM != "" {
+		return errKeyProvidedTwice
+	}
+	hasCert := c.certPath != "" || c.certPEM != ""
+	hasKey := c.keyPath != "" || c.keyPEM != ""
+	if hasCert && !hasKey {
+		return errNoPathToKey
+	} else if !hasCert && hasKey {
+		return errNoPathToCert
+	}
+	if c.caCertPath != "" && c.insecure {
+		return errCACertWithInsecure
+	}
+	// Certificates from memory are cheap to check right away, so
+	// that mistakes in them surface as usage errors
+	if c.certPEM != "" && c.keyPEM != "" {
+		if _, err := tls.X509KeyPair(
+			[]byte(c.certPEM), []byte(c.keyPEM),
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *config) checkALPN() error {
+	if c.alpn == nil {
+		return nil
+	}
+	if len(*c.alpn) == 0 {
+		return errEmptyALPNProtocol
+	}
+	for _, proto := range *c.alpn {
+		if proto == "" {
+			return errEmptyALPNProtocol
+		}
+		// Other clients would get HTTP/2 frames they can't read
+		if proto == "h2" && c.clientType != nhttp2 && c.grpc == nil {
+			return errALPNH2WithoutHTTP2
+		}
+	}
+	return nil
+}
+
+func (c *config) checkOAuth2() error {
+	if c.oauth2 == nil {
+		return nil
+	}
+	if c.oauth2.tokenURL == "" {
+		return errNoOAuth2TokenURL
+	}
+	if c.oauth2.clientID == "" {
+		return errNoOAuth2ClientID
+	}
+	return nil
+}
+
+func (c *config) checkSigV4() error {
+	if c.sigV4 == nil {
+		return nil
+	}
+	s := c.sigV4
+	if s.accessKey == "" || s.secretKey == "" || s.region == "" ||
+		s.service == "" {
+		return errIncompleteSigV4
+	}
+	if c.stream || c.bodyGenerator != nil {
+		return errSigV4WithStream
+	}
+	return nil
+}
+
+func (c *config) checkRedirects() error {
+	if c.finalHopLatency && c.maxRedirects == 0 {
+		return errFinalHopLatencyWithoutRedirects
+	}
+	if c.maxRedirects > 0 && c.sigV4 != nil {
+		return errRedirectsWithSigV4
+	}
+	return nil
+}
+
+// checkAuth makes sure that credentials are given in at most one way:
+// with --basic-auth, OAuth2, AWS SigV4 or Authorization header.
+func (c *config) checkAuth() error {
+	methods := 0
+	if c.basicAuth != "" {
+		if strings.Index(c.basicAuth, ":") < 1 {
+			return errInvalidBasicAuthFormat
+		}
+		methods++
+	}
+	if c.oauth2 != nil {
+		methods++
+	}
+	if c.sigV4 != nil {
+		methods++
+	}
+	if c.hasAuthorizationHeader() {
+		methods++
+	}
+	if methods > 1 {
+		return errConflictingAuth
+	}
+	return nil
+}
+
+func (c *config) hasAuthorizationHeader() bool {
+	var keys []string
+	for _, hs := range []*headersList{c.headers, c.headerTemplates} {
+		if hs != nil {
+			for _, h := range *hs {
+				keys = append(keys, h.key)
+			}
+		}
+	}
+	if c.randomHeaders != nil {
+		for _, h := range *c.randomHeaders {
+			keys = append(keys, h.key)
+		}
+	}
+	for _, k := range keys {
+		if strings.EqualFold(k, "Authorization") {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *config) checkInflux() error {
+	if c.influxURL == "" {
+		if c.tags != nil && !c.syslog {
+			return errTagsWithoutInfluxURL
+		}
+		return nil
+	}
+	u, err := checkedURL(c.influxURL)
+	if err != nil {
+		return err
+	}
+	c.influxURL = u
+	return nil
+}
+
+func (c *config) checkSyslog() error {
+	if c.syslogAddr != "" && !c.syslog {
+		return errSyslogAddrWithoutSyslog
+	}
+	_, _, err := parseSyslogAddr(c.syslogAddr)
+	return err
+}
+
+func (c *config) timeoutMillis() uint64 {
+	return uint64(c.timeout.Nanoseconds() / 1000)
+}
+
+func allowedHTTPMethod(method string) bool {
+	i := sort.SearchStrings(httpMethods, method)
+	return i < len(httpMethods) && httpMethods[i] == method
+}
+
+// hasBody tells whether requests are sent with body from any of its
+// sources.
+func (c *config) hasBody() bool {
+	return c.body != "" || c.bodyFilePath != "" || c.bodyDir != "" ||
+		c.form != nil || c.bodyGenerator != nil || c.bodySize != nil
+}
+
+// bodyMethods returns methods of requests that are sent with body,
+// either all of them or those of scenario steps.
+func (c *config) bodyMethods() []string {
+	if c.scenario == nil {
+		if c.hasBody() {
+			return []string{c.method}
+		}
+		return nil
+	}
+	var methods []string
+	for _, s := range c.scenario.steps {
+		if s.Body != "" {
+			methods = append(methods, s.Method)
+		}
+	}
+	return methods
+}
+
+// bodyWarning returns the warning of body sent with methods that
+// usually don't have one, it's empty if there is nothing to warn of
+// or the warning is suppressed.
+func (c *config) bodyWarning() string {
+	// Method is irrelevant for gRPC calls
+	if c.allowBodyOnGet || c.grpc != nil {
+		return ""
+	}
+	var methods []string
+	seen := make(map[string]bool)
+	for _, method := range c.bodyMethods() {
+		if (canHaveBody(method) && method != "DELETE") || seen[method] {
+			continue
+		}
+		seen[method] = true
+		methods = append(methods, method)
+	}
+	if len(methods) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("Warning: %v requests usually don't have body, "+
+		"some servers ignore or reject it", strings.Join(methods, ", "))
+}
+
+func canHaveBody(method string) bool {
+	i := sort.SearchStrings(cantHaveBody, method)
+	return !(i < len(cantHaveBody) && cantHaveBody[i] == method)
+}
+
+type clientTyp int
+
+const (
+	fhttp clientTyp = iota
+	nhttp1
+	nhttp2
+)
+
+func (ct clientTyp) String() string {
+	switch ct {
+	case fhttp:
+		return "FastHTTP"
+	case nhttp1:
+		return "net/http v1.x"
+	case nhttp2:
+		return "net/http v2.0"
+	}
+	return "unknown client"
+}
@@ -0,0 +1,35 @@
+package bombard
+
+import (
+	"sync/atomic"
+
+	"github.com/kostyay/bombardier/internal"
+)
+
+// connStats holds results of requests sent by a single connection.
+type connStats struct {
+	requests, errors uint64
+	// latencySum is the sum of latencies (in microseconds)
+	latencySum uint64
+}
+
+func (c *connStats) record(usTaken uint64, err error) {
+	atomic.AddUint64(&c.requests, 1)
+	atomic.AddUint64(&c.latencySum, usTaken)
+	if err != nil {
+		atomic.AddUint64(&c.errors, 1)
+	}
+}
+
+func (c *connStats) results(connID uint64) internal.ConnResults {
+	res := internal.ConnResults{
+		ConnID:   connID,
+		Requests: atomic.LoadUint64(&c.requests),
+		Errors:   atomic.LoadUint64(&c.errors),
+	}
+	if res.Requests > 0 {
+		res.MeanLatency = float64(atomic.LoadUint64(&c.latencySum)) /
+			float64(res.Requests)
+	}
+	return res
+}
@@ -0,0 +1,27 @@
+package bombard
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kostyay/bombardier/internal"
+)
+
+func TestConnStats(t *testing.T) {
+	var c connStats
+	if res := c.results(3); res != (internal.ConnResults{ConnID: 3}) {
+		t.Errorf("Expected empty results, but got %+v", res)
+	}
+	c.record(100, nil)
+	c.record(300, errors.New("error"))
+	c.record(200, nil)
+	exp := internal.ConnResults{
+		ConnID:      3,
+		Requests:    3,
+		Errors:      1,
+		MeanLatency: 200,
+	}
+	if res := c.results(3); res != exp {
+		t.Errorf("Expected %+v, but got %+v", exp, res)
+	}
+}
@@ -0,0 +1,48 @@
+package bombard
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// contentTypes maps extensions of body files to the Content-Type
+// header they are sent with, unless it's specified explicitly.
+var contentTypes = map[string]string{
+	".json":       "application/json",
+	".xml":        "application/xml",
+	".form":       "application/x-www-form-urlencoded",
+	".urlencoded": "application/x-www-form-urlencoded",
+}
+
+// autoContentType returns Content-Type inferred from the extension of
+// body file, empty if it shouldn't be set.
+func (c *config) autoContentType() string {
+	if c.noAutoContentType || c.bodyFilePath == "" ||
+		c.hasHeader("Content-Type") {
+		return ""
+	}
+	return contentTypes[strings.ToLower(filepath.Ext(c.bodyFilePath))]
+}
+
+// hasHeader tells whether header with such key is sent, at least
+// with some requests.
+func (c *config) hasHeader(key string) bool {
+	for _, hl := range []*headersList{c.headers, c.headerTemplates} {
+		if hl == nil {
+			continue
+		}
+		for _, h := range *hl {
+			if strings.EqualFold(h.key, key) {
+				return true
+			}
+		}
+	}
+	if c.randomHeaders != nil {
+		for _, h := range *c.randomHeaders {
+			if strings.EqualFold(h.key, key) {
+				return true
+			}
+		}
+	}
+	return false
+}
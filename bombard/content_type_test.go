@@ -0,0 +1,63 @@
+package bombard
+
+import (
+	"testing"
+)
+
+func TestAutoContentType(t *testing.T) {
+	expectations := []struct {
+		in  config
+		out string
+	}{
+		{config{}, ""},
+		{config{bodyFilePath: "body.txt"}, ""},
+		{config{bodyFilePath: "body"}, ""},
+		{config{bodyFilePath: "/tmp/body.json"}, "application/json"},
+		{config{bodyFilePath: "BODY.JSON"}, "application/json"},
+		{config{bodyFilePath: "body.xml"}, "application/xml"},
+		{
+			config{bodyFilePath: "body.form"},
+			"application/x-www-form-urlencoded",
+		},
+		{
+			config{bodyFilePath: "body.urlencoded"},
+			"application/x-www-form-urlencoded",
+		},
+		{config{bodyFilePath: "body.json", noAutoContentType: true}, ""},
+		{
+			config{
+				bodyFilePath: "body.json",
+				headers:      &headersList{{"content-type", "text/plain"}},
+			},
+			"",
+		},
+		{
+			config{
+				bodyFilePath:    "body.json",
+				headerTemplates: &headersList{{"Content-Type", "{{ .ConnID }}"}},
+			},
+			"",
+		},
+		{
+			config{
+				bodyFilePath: "body.json",
+				randomHeaders: &randomHeadersList{
+					{header{"Content-Type", "text/plain"}, 0.5},
+				},
+			},
+			"",
+		},
+		{
+			config{
+				bodyFilePath: "body.json",
+				headers:      &headersList{{"Accept", "text/plain"}},
+			},
+			"application/json",
+		},
+	}
+	for _, e := range expectations {
+		if act := e.in.autoContentType(); act != e.out {
+			t.Errorf("%+v: expected %q, but got %q", e.in, e.out, act)
+		}
+	}
+}
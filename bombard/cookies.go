@@ -0,0 +1,63 @@
+package bombard
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+
+	"github.com/valyala/fasthttp"
+)
+
+// cookieJars holds a separate cookie jar for every connection, so
+// that cookies set in response to one connection are only sent by
+// that same connection.
+type cookieJars []http.CookieJar
+
+func newCookieJars(numConns uint64) cookieJars {
+	jars := make(cookieJars, numConns)
+	for i := range jars {
+		// cookiejar.New never fails when options are nil
+		jars[i], _ = cookiejar.New(nil)
+	}
+	return jars
+}
+
+func (cj cookieJars) addToHTTPRequest(connID uint64, req *http.Request) {
+	for _, c := range cj[connID].Cookies(req.URL) {
+		req.AddCookie(c)
+	}
+}
+
+func (cj cookieJars) storeFromHTTPResponse(
+	connID uint64, u *url.URL, resp *http.Response,
+) {
+	if cookies := resp.Cookies(); len(cookies) > 0 {
+		cj[connID].SetCookies(u, cookies)
+	}
+}
+
+func (cj cookieJars) addToFastHTTPRequest(
+	connID uint64, u *url.URL, req *fasthttp.Request,
+) {
+	for _, c := range cj[connID].Cookies(u) {
+		req.Header.SetCookie(c.Name, c.Value)
+	}
+}
+
+func (cj cookieJars) storeFromFastHTTPResponse(
+	connID uint64, u *url.URL, resp *fasthttp.Response,
+) {
+	var setCookies []string
+	resp.Header.VisitAllCookie(func(_, value []byte) {
+		setCookies = append(setCookies, string(value))
+	})
+	if len(setCookies) == 0 {
+		return
+	}
+	// Let net/http do the parsing, so that both clients treat
+	// Set-Cookie headers the same way
+	parsed := (&http.Response{
+		Header: http.Header{"Set-Cookie": setCookies},
+	}).Cookies()
+	cj[connID].SetCookies(u, parsed)
+}
@@ -0,0 +1,163 @@
+package bombard
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	dashboardRefreshRate = 250 * time.Millisecond
+	// Number of refreshes covered by the latency sparkline
+	dashboardHistoryLen = 60
+	// Number of the most frequent errors shown
+	dashboardErrorsShown = 5
+
+	enterAltScreen = "\x1b[?1049h\x1b[?25l"
+	exitAltScreen  = "\x1b[?25h\x1b[?1049l"
+	clearScreen    = "\x1b[H\x1b[2J"
+)
+
+var sparkTicks = []rune("▁▂▃▄▅▆▇█")
+
+// dashboard is a full-screen alternative to the progress bar, which
+// shows live statistics of the test read from the same counters.
+type dashboard struct {
+	b *bombardier
+
+	// latencies holds mean latency (in microseconds) of requests
+	// completed between consecutive refreshes, oldest first
+	latencies []float64
+	rps       float64
+
+	lastUpdate                       time.Time
+	lastTotal, lastLatSum, lastCount uint64
+}
+
+func newDashboard(b *bombardier) *dashboard {
+	return &dashboard{
+		b:         b,
+		latencies: make([]float64, 0, dashboardHistoryLen),
+	}
+}
+
+func (d *dashboard) start() {
+	fmt.Fprint(d.b.out, enterAltScreen)
+	d.lastUpdate = time.Now()
+}
+
+func (d *dashboard) stop() {
+	fmt.Fprint(d.b.out, exitAltScreen)
+}
+
+// update samples the counters and redraws the screen.
+func (d *dashboard) update() {
+	d.sample(time.Now())
+	buf := new(bytes.Buffer)
+	buf.WriteString(clearScreen)
+	d.render(buf)
+	d.b.out.Write(buf.Bytes())
+}
+
+func (d *dashboard) sample(now time.Time) {
+	b := d.b
+	total := b.completedRequests()
+	latSum, count := uint64(0), uint64(0)
+	b.latencies.VisitAll(func(f uint64, c uint64) bool {
+		latSum += f * c
+		count += c
+		return true
+	})
+	if elapsed := now.Sub(d.lastUpdate).Seconds(); elapsed > 0 {
+		d.rps = float64(total-d.lastTotal) / elapsed
+	}
+	mean := 0.0
+	// Counters might have been reset after warmup
+	if count > d.lastCount && latSum >= d.lastLatSum {
+		mean = float64(latSum-d.lastLatSum) / float64(count-d.lastCount)
+	}
+	if len(d.latencies) == dashboardHistoryLen {
+		copy(d.latencies, d.latencies[1:])
+		d.latencies = d.latencies[:dashboardHistoryLen-1]
+	}
+	d.latencies = append(d.latencies, mean)
+	d.lastUpdate = now
+	d.lastTotal, d.lastLatSum, d.lastCount = total, latSum, count
+}
+
+func (d *dashboard) render(buf *bytes.Buffer) {
+	b := d.b
+	fmt.Fprintf(buf, "bombardier %v - %v\n\n", version, b.conf.url)
+	fmt.Fprintf(buf, "  Progress   %5.1f%%   Elapsed %v\n",
+		b.barrier.completed()*100,
+		time.Since(b.measureBegin).Round(time.Second))
+	if b.pauser.paused() {
+		buf.WriteString("  Paused\n")
+	}
+	if b.conf.rampUp > 0 {
+		fmt.Fprintf(buf, "  Connections %v/%v\n",
+			atomic.LoadInt64(&b.activeConns), b.conf.numConns)
+	}
+	fmt.Fprintf(buf, "  Reqs/sec   %.2f\n", d.rps)
+	last := 0.0
+	if len(d.latencies) > 0 {
+		last = d.latencies[len(d.latencies)-1]
+	}
+	fmt.Fprintf(buf, "  Latency    %v\n", formatTimeUs(last))
+	fmt.Fprintf(buf, "  %v\n\n", sparkline(d.latencies))
+	fmt.Fprintf(buf,
+		"  HTTP codes:\n    1xx - %v, 2xx - %v, 3xx - %v, 4xx - %v, "+
+			"5xx - %v\n    others - %v\n",
+		atomic.LoadUint64(&b.req1xx), atomic.LoadUint64(&b.req2xx),
+		atomic.LoadUint64(&b.req3xx), atomic.LoadUint64(&b.req4xx),
+		atomic.LoadUint64(&b.req5xx), atomic.LoadUint64(&b.others))
+	errors := b.errors.byFrequency()
+	if len(errors) == 0 {
+		return
+	}
+	buf.WriteString("  Errors:\n")
+	for i, e := range errors {
+		if i == dashboardErrorsShown {
+			fmt.Fprintf(buf, "    ... and %v more\n", len(errors)-i)
+			break
+		}
+		fmt.Fprintf(buf, "    %10v - %v\n", e.error, e.count)
+	}
+}
+
+// completedRequests returns the number of requests that got a
+// response or failed with an error so far.
+func (b *bombardier) completedRequests() uint64 {
+	return atomic.LoadUint64(&b.req1xx) + atomic.LoadUint64(&b.req2xx) +
+		atomic.LoadUint64(&b.req3xx) + atomic.LoadUint64(&b.req4xx) +
+		atomic.LoadUint64(&b.req5xx) + atomic.LoadUint64(&b.others) +
+		b.errors.sum()
+}
+
+// sparkline draws values as a line of bars, scaled to the largest
+// of them.
+func sparkline(values []float64) string {
+	max := 0.0
+	for _, v := range values {
+		if v > max {
+			max = v
+		}
+	}
+	var sb strings.Builder
+	for _, v := range values {
+		i := 0
+		if max > 0 {
+			i = int(v / max * float64(len(sparkTicks)-1))
+		}
+		sb.WriteRune(sparkTicks[i])
+	}
+	return sb.String()
+}
+
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,130 @@
+package bombard
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSparkline(t *testing.T) {
+	expectations := []struct {
+		in  []float64
+		out string
+	}{
+		{nil, ""},
+		{[]float64{0, 0}, "▁▁"},
+		{[]float64{0, 7, 14}, "▁▄█"},
+		{[]float64{1, 2, 3, 4, 5, 6, 7, 8}, "▁▂▃▄▅▆▇█"},
+	}
+	for _, e := range expectations {
+		if act := sparkline(e.in); act != e.out {
+			t.Errorf("%v: expected %q, but got %q", e.in, e.out, act)
+		}
+	}
+}
+
+func TestDashboardRender(t *testing.T) {
+	numReqs := uint64(100)
+	b, e := newBombardier(config{
+		numConns:  1,
+		numReqs:   &numReqs,
+		url:       "http://localhost:8080",
+		headers:   new(headersList),
+		timeout:   defaultTimeout,
+		method:    "GET",
+		format:    knownFormat("plain-text"),
+		dashboard: true,
+	})
+	if e != nil {
+		t.Fatal(e)
+	}
+	d := newDashboard(b)
+	start := time.Now()
+	d.lastUpdate = start
+	b.writeStatistics(200, 1000, true)
+	b.writeStatistics(200, 3000, true)
+	b.writeStatistics(503, 2000, true)
+	b.errors.add(errors.New("connection refused"))
+	d.sample(start.Add(time.Second))
+	if d.rps != 4 {
+		t.Errorf("Expected 4 reqs/sec, but got %v", d.rps)
+	}
+	b.writeStatistics(200, 8000, true)
+	d.sample(start.Add(2 * time.Second))
+	if exp := []float64{2000, 8000}; len(d.latencies) != 2 ||
+		d.latencies[0] != exp[0] || d.latencies[1] != exp[1] {
+		t.Errorf("Expected latencies %v, but got %v", exp, d.latencies)
+	}
+	buf := new(bytes.Buffer)
+	d.render(buf)
+	out := buf.String()
+	for _, s := range []string{
+		"Reqs/sec   1.00",
+		"Latency    8.00ms",
+		"▂█",
+		"2xx - 3",
+		"5xx - 1",
+		"connection refused - 1",
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("Expected dashboard to contain %q:\n%v", s, out)
+		}
+	}
+}
+
+func TestDashboardHistoryIsBounded(t *testing.T) {
+	numReqs := uint64(100)
+	b, e := newBombardier(config{
+		numConns: 1,
+		numReqs:  &numReqs,
+		url:      "http://localhost:8080",
+		headers:  new(headersList),
+		timeout:  defaultTimeout,
+		method:   "GET",
+		format:   knownFormat("plain-text"),
+	})
+	if e != nil {
+		t.Fatal(e)
+	}
+	d := newDashboard(b)
+	now := time.Now()
+	for i := 0; i < dashboardHistoryLen+10; i++ {
+		now = now.Add(time.Second)
+		b.writeStatistics(200, uint64(i+1), true)
+		d.sample(now)
+	}
+	if len(d.latencies) != dashboardHistoryLen {
+		t.Fatalf("Expected %v samples, but got %v",
+			dashboardHistoryLen, len(d.latencies))
+	}
+	if last := d.latencies[len(d.latencies)-1]; last != dashboardHistoryLen+10 {
+		t.Errorf("Expected last sample to be the most recent, but got %v", last)
+	}
+}
+
+func TestDashboardIsNotUsedWithoutTerminal(t *testing.T) {
+	if isTerminal(os.Stdout) {
+		t.Skip("stdout is a terminal")
+	}
+	numReqs := uint64(100)
+	b, e := newBombardier(config{
+		numConns:      1,
+		numReqs:       &numReqs,
+		url:           "http://localhost:8080",
+		headers:       new(headersList),
+		timeout:       defaultTimeout,
+		method:        "GET",
+		format:        knownFormat("plain-text"),
+		printProgress: true,
+		dashboard:     true,
+	})
+	if e != nil {
+		t.Fatal(e)
+	}
+	if b.dashboard != nil {
+		t.Error("Dashboard shouldn't be used when stdout isn't a terminal")
+	}
+}
@@ -0,0 +1,251 @@
+package bombard
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"sync/atomic"
+	"time"
+)
+
+type countingConn struct {
+	net.Conn
+	bytesRead, bytesWritten *int64
+}
+
+func (cc *countingConn) Read(b []byte) (n int, err error) {
+	n, err = cc.Conn.Read(b)
+
+	if err == nil {
+		atomic.AddInt64(cc.bytesRead, int64(n))
+	}
+
+	return
+}
+
+func (cc *countingConn) Write(b []byte) (n int, err error) {
+	n, err = cc.Conn.Write(b)
+
+	if err == nil {
+		atomic.AddInt64(cc.bytesWritten, int64(n))
+	}
+
+	return
+}
+
+// tracingConn remembers when the first byte of response to the most
+// recently written request was read and whether the connection was
+// used already. fasthttp reads whole responses at once and doesn't
+// expose its connection pool, so this is the only way to get such
+// information out of it.
+type tracingConn struct {
+	net.Conn
+	laddr *tracingAddr
+
+	// awaiting is non-zero between writing a request and reading
+	// the first byte of response to it
+	awaiting int32
+	// firstByte is in nanoseconds since the Unix epoch
+	firstByte int64
+	// used is non-zero once a request on this connection completed
+	used int32
+	// handshake is the duration of TLS handshake, zero if there
+	// was none
+	handshake int64
+}
+
+func newTracingConn(conn net.Conn) *tracingConn {
+	tc := &tracingConn{Conn: conn}
+	tc.laddr = &tracingAddr{Addr: conn.LocalAddr(), conn: tc}
+	return tc
+}
+
+func (tc *tracingConn) Read(b []byte) (n int, err error) {
+	n, err = tc.Conn.Read(b)
+	if n > 0 && atomic.CompareAndSwapInt32(&tc.awaiting, 1, 0) {
+		atomic.StoreInt64(&tc.firstByte, time.Now().UnixNano())
+	}
+	return
+}
+
+func (tc *tracingConn) Write(b []byte) (n int, err error) {
+	atomic.StoreInt32(&tc.awaiting, 1)
+	return tc.Conn.Write(b)
+}
+
+// LocalAddr returns address that leads back to the connection, which
+// allows to find out what connection fasthttp.Response came from.
+func (tc *tracingConn) LocalAddr() net.Addr {
+	return tc.laddr
+}
+
+type tracingAddr struct {
+	net.Addr
+	conn *tracingConn
+}
+
+// since returns time between start and the moment the first byte of
+// the last response was read.
+func (ta *tracingAddr) since(start time.Time) time.Duration {
+	return time.Duration(
+		atomic.LoadInt64(&ta.conn.firstByte) - start.UnixNano(),
+	)
+}
+
+// handshake returns duration of TLS handshake on the connection, zero
+// if it's not a TLS one.
+func (ta *tracingAddr) handshake() time.Duration {
+	return time.Duration(atomic.LoadInt64(&ta.conn.handshake))
+}
+
+// reused tells whether request that completed on the connection wasn't
+// the first one to do so.
+func (ta *tracingAddr) reused() bool {
+	return !atomic.CompareAndSwapInt32(&ta.conn.used, 0, 1)
+}
+
+// localAddr returns the address connection to address should be made
+// from, it's the connection to proxy if there is one.
+func localAddr(res *resolver, px *proxy, network, address string) net.Addr {
+	if res == nil {
+		return nil
+	}
+	if px != nil {
+		network, address = "tcp", px.url.Host
+	}
+	return res.localAddr(network, address)
+}
+
+// withConnectTimeout returns context that bounds establishing of
+// connection by timeout, if it's set.
+func withConnectTimeout(
+	ctx context.Context, timeout time.Duration,
+) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// connectError marks err as connect timeout if it was connCtx that ran
+// out of time, rather than ctx it was derived from. Deadlines are
+// compared instead of checking errors of contexts, since deadlines of
+// connections set from them may expire a bit sooner.
+func connectError(ctx, connCtx context.Context, err error) error {
+	deadline, ok := connCtx.Deadline()
+	if !ok || time.Now().Before(deadline) {
+		return err
+	}
+	if parent, ok := ctx.Deadline(); ok && !parent.After(deadline) {
+		return err
+	}
+	return &connectTimeoutError{err}
+}
+
+// connect resolves address, if there is a resolver, and connects to
+// it, through proxy if there is one.
+func connect(
+	ctx context.Context, res *resolver, px *proxy, network, address string,
+) (net.Conn, error) {
+	if res != nil {
+		var err error
+		network, address, err = res.resolve(ctx, address)
+		if err != nil {
+			return nil, err
+		}
+	}
+	local := localAddr(res, px, network, address)
+	if px != nil {
+		return px.dialFrom(ctx, local, address)
+	}
+	dialer := net.Dialer{LocalAddr: local}
+	return dialer.DialContext(ctx, network, address)
+}
+
+var fasthttpDialFunc = func(
+	bytesRead, bytesWritten *int64, connsOpened *uint64,
+	res *resolver, px *proxy, connectTimeout time.Duration,
+) func(string) (net.Conn, error) {
+	return func(address string) (net.Conn, error) {
+		ctx := context.Background()
+		connCtx, cancel := withConnectTimeout(ctx, connectTimeout)
+		conn, err := connect(connCtx, res, px, "tcp", address)
+		cancel()
+		if err != nil {
+			return nil, connectError(ctx, connCtx, err)
+		}
+		if res != nil {
+			res.record(conn.RemoteAddr())
+		}
+		atomic.AddUint64(connsOpened, 1)
+
+		wrappedConn := &countingConn{
+			Conn:         conn,
+			bytesRead:    bytesRead,
+			bytesWritten: bytesWritten,
+		}
+
+		return newTracingConn(wrappedConn), nil
+	}
+}
+
+// fasthttpTLSDialFunc performs TLS handshake over connections made by
+// dial, which must be tracing ones, instead of leaving it to fasthttp,
+// so that its duration can be measured.
+var fasthttpTLSDialFunc = func(
+	dial func(string) (net.Conn, error),
+	tlsConfig *tls.Config, timeout time.Duration,
+) func(string) (net.Conn, error) {
+	return func(address string) (net.Conn, error) {
+		conn, err := dial(address)
+		if err != nil {
+			return nil, err
+		}
+		if timeout > 0 {
+			if err = conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+				conn.Close()
+				return nil, err
+			}
+		}
+		tlsConn := tls.Client(conn, tlsConfig)
+		start := time.Now()
+		if err = tlsConn.Handshake(); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		atomic.StoreInt64(
+			&conn.(*tracingConn).handshake, int64(time.Since(start)),
+		)
+		if err = conn.SetDeadline(time.Time{}); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		return tlsConn, nil
+	}
+}
+
+var httpDialContextFunc = func(
+	bytesRead, bytesWritten *int64, connsOpened *uint64,
+	res *resolver, px *proxy, connectTimeout time.Duration,
+) func(context.Context, string, string) (net.Conn, error) {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		connCtx, cancel := withConnectTimeout(ctx, connectTimeout)
+		conn, err := connect(connCtx, res, px, network, address)
+		cancel()
+		if err != nil {
+			return nil, connectError(ctx, connCtx, err)
+		}
+		if res != nil {
+			res.record(conn.RemoteAddr())
+		}
+		atomic.AddUint64(connsOpened, 1)
+
+		wrappedConn := &countingConn{
+			Conn:         conn,
+			bytesRead:    bytesRead,
+			bytesWritten: bytesWritten,
+		}
+
+		return wrappedConn, nil
+	}
+}
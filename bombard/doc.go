@@ -0,0 +1,34 @@
+/*
+Package bombard is the engine of bombardier, it runs benchmarks from Go
+programs without going through the command line.
+
+Test is described with Options, zero values of its fields standing for
+the defaults of the corresponding flags:
+
+	info, err := bombard.Run(bombard.Options{
+		NumberOfConnections: 10,
+		NumberOfRequests:    1000,
+		URL:                 "http://localhost:8080",
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if info.Result.SuccessfulRequests() == 0 {
+		log.Fatal("no successful requests: ", info.Result.Errors)
+	}
+	stats := info.Result.LatenciesStats(info.Spec.Percentiles)
+	fmt.Println(stats.Mean, stats.Percentiles[0.99])
+
+Nothing is printed by Run, results are returned as TestInfo, the same
+structure user templates of bombardier are executed with. Methods
+of Results that calculate stats return nil when no requests were
+completed. RunContext
+additionally stops the test once its context is done, returning the
+results gathered so far.
+
+Progress of the test can be observed with Options.OnRequest, called
+once every request completes, and Options.OnTick, called every second
+with the snapshot of results. Both are called from the path results are
+recorded on and must not block.
+*/
+package bombard
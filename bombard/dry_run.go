@@ -0,0 +1,45 @@
+package bombard
+
+import (
+	"context"
+	"fmt"
+	"text/template"
+)
+
+var specTemplate = template.Must(
+	template.New("spec").Parse(jsonSpecTemplate),
+)
+
+// dryRun makes sure that hosts of the targets resolve and prints the
+// specification of the test, without generating any load. If asked
+// to, it also sends a single request to every target.
+func (b *bombardier) dryRun() error {
+	targets := b.conf.targets()
+	// Hosts are resolved by proxy, if there is one
+	if b.conf.proxy == "" {
+		err := b.resolver.checkURLs(context.Background(), targets)
+		if err != nil {
+			return err
+		}
+	}
+	if err := specTemplate.Execute(b.out, b.gatherInfo()); err != nil {
+		return err
+	}
+	fmt.Fprintln(b.out)
+	if !b.conf.dryRunRequest {
+		return nil
+	}
+	for i, c := range b.clients {
+		res := c.do(0)
+		if res.err != nil {
+			return fmt.Errorf("Request to %v failed: %v", targets[i], res.err)
+		}
+		if b.expectedStatuses != nil && !b.expectedStatuses[res.code] {
+			return fmt.Errorf("%v responded with unexpected status code %v",
+				targets[i], res.code)
+		}
+		fmt.Fprintf(b.out, "%v responded with status code %v in %v\n",
+			targets[i], res.code, formatTimeUs(float64(res.msTaken)))
+	}
+	return nil
+}
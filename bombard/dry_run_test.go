@@ -0,0 +1,100 @@
+package bombard
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDryRun(t *testing.T) {
+	testAllClients(t, testDryRun)
+}
+
+func testDryRun(clientType clientTyp, t *testing.T) {
+	var received uint64
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			atomic.AddUint64(&received, 1)
+		}),
+	)
+	defer s.Close()
+	for _, sendRequest := range []bool{false, true} {
+		numReqs := uint64(100)
+		b, e := newBombardier(config{
+			numConns:      10,
+			numReqs:       &numReqs,
+			url:           s.URL,
+			headers:       new(headersList),
+			timeout:       defaultTimeout,
+			method:        "GET",
+			clientType:    clientType,
+			format:        knownFormat("plain-text"),
+			dryRun:        true,
+			dryRunRequest: sendRequest,
+		})
+		if e != nil {
+			t.Error(e)
+			return
+		}
+		out := new(bytes.Buffer)
+		b.redirectOutputTo(out)
+		atomic.StoreUint64(&received, 0)
+		if err := b.dryRun(); err != nil {
+			t.Error(err)
+			continue
+		}
+		lines := strings.SplitN(out.String(), "\n", 2)
+		var spec map[string]interface{}
+		if err := json.Unmarshal([]byte(lines[0]), &spec); err != nil {
+			t.Errorf("Expected spec to be valid JSON, but got %v: %q",
+				err, lines[0])
+		} else if spec["numberOfRequests"] != 100.0 {
+			t.Errorf("Unexpected spec: %v", spec)
+		}
+		exp := uint64(0)
+		if sendRequest {
+			exp = 1
+		}
+		if act := atomic.LoadUint64(&received); act != exp {
+			t.Errorf("Expected %v requests, but got %v", exp, act)
+		}
+		if sendRequest && !strings.Contains(lines[1], "status code 200") {
+			t.Errorf("Expected status code to be printed, but got %q",
+				lines[1])
+		}
+	}
+}
+
+func TestDryRunReportsUnexpectedStatus(t *testing.T) {
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			rw.WriteHeader(http.StatusNotFound)
+		}),
+	)
+	defer s.Close()
+	numReqs := uint64(1)
+	b, e := newBombardier(config{
+		numConns:         1,
+		numReqs:          &numReqs,
+		url:              s.URL,
+		headers:          new(headersList),
+		timeout:          defaultTimeout,
+		method:           "GET",
+		clientType:       fhttp,
+		format:           knownFormat("plain-text"),
+		expectedStatuses: &[]int{200},
+		dryRun:           true,
+		dryRunRequest:    true,
+	})
+	if e != nil {
+		t.Fatal(e)
+	}
+	b.disableOutput()
+	if err := b.dryRun(); err == nil {
+		t.Error("Expected unexpected status code to be reported")
+	}
+}
@@ -0,0 +1,159 @@
+package bombard
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
+
+	"github.com/valyala/fasthttp"
+)
+
+// errorCategory is a broad kind of failure, so that errors with
+// different messages but the same cause are counted together.
+type errorCategory int
+
+const (
+	otherError errorCategory = iota
+	dialTimeoutError
+	connectTimeoutCategory
+	connRefusedError
+	connResetError
+	tlsError
+	readTimeoutError
+	writeError
+	eofError
+	unexpectedStatusCategory
+	portsExhaustedError
+
+	numErrorCategories
+)
+
+var errorCategoryNames = [numErrorCategories]string{
+	otherError:               "other",
+	dialTimeoutError:         "dial timeout",
+	connectTimeoutCategory:   "connect timeout",
+	connRefusedError:         "connection refused",
+	connResetError:           "connection reset",
+	tlsError:                 "TLS error",
+	readTimeoutError:         "read timeout",
+	writeError:               "write error",
+	eofError:                 "EOF",
+	unexpectedStatusCategory: "unexpected status",
+	portsExhaustedError:      "local ports exhausted",
+}
+
+func (c errorCategory) String() string {
+	return errorCategoryNames[c]
+}
+
+// categorizeError finds out the category of err by looking at the
+// errors it wraps. Order of the checks matters, since timeout while
+// dialing is a timeout as well, and so is timeout while writing.
+func categorizeError(err error) errorCategory {
+	var (
+		ue *unexpectedStatusError
+		ce *connectTimeoutError
+		oe *net.OpError
+		ne net.Error
+	)
+	isOp := errors.As(err, &oe)
+	switch {
+	case errors.As(err, &ue):
+		return unexpectedStatusCategory
+	case isPortsExhausted(err):
+		return portsExhaustedError
+	case errors.As(err, &ce):
+		return connectTimeoutCategory
+	case errors.Is(err, fasthttp.ErrDialTimeout),
+		isOp && oe.Op == "dial" && oe.Timeout():
+		return dialTimeoutError
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return connRefusedError
+	case isTLSError(err), isOp && oe.Op == "remote error":
+		return tlsError
+	case isOp && oe.Op == "write":
+		return writeError
+	case errors.Is(err, fasthttp.ErrTimeout),
+		errors.As(err, &ne) && ne.Timeout():
+		return readTimeoutError
+	case errors.Is(err, syscall.ECONNRESET):
+		return connResetError
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, fasthttp.ErrConnectionClosed):
+		return eofError
+	}
+	return otherError
+}
+
+// timeoutError marks error as a timeout without changing its message.
+type timeoutError struct {
+	error
+}
+
+func (t *timeoutError) Timeout() bool {
+	return true
+}
+
+func (t *timeoutError) Temporary() bool {
+	return true
+}
+
+func (t *timeoutError) Unwrap() error {
+	return t.error
+}
+
+// connectTimeoutError marks error of connection that couldn't be
+// established within --connect-timeout.
+type connectTimeoutError struct {
+	error
+}
+
+func (c *connectTimeoutError) Timeout() bool {
+	return true
+}
+
+func (c *connectTimeoutError) Temporary() bool {
+	return true
+}
+
+func (c *connectTimeoutError) Unwrap() error {
+	return c.error
+}
+
+// printPortsExhaustedHint suggests how to avoid running out of local
+// ports if some connections failed because of that.
+func (b *bombardier) printPortsExhaustedHint() {
+	n := b.errors.inCategory(portsExhaustedError)
+	if n == 0 {
+		return
+	}
+	fmt.Fprintf(b.errOut, "%v request(s) failed for lack of local ports: "+
+		"raise the open files limit (ulimit -n), widen the ephemeral "+
+		"port range or don't disable keep-alive\n", n)
+}
+
+// isPortsExhausted tells whether connection couldn't be made because
+// there are no local ports left.
+func isPortsExhausted(err error) bool {
+	var se *os.SyscallError
+	return errors.As(err, &se) && isPortsExhaustedErrno(se.Syscall, se.Err)
+}
+
+func isTLSError(err error) bool {
+	var (
+		rhe tls.RecordHeaderError
+		ae  tls.AlertError
+		cve *tls.CertificateVerificationError
+		uae x509.UnknownAuthorityError
+		he  x509.HostnameError
+		cie x509.CertificateInvalidError
+	)
+	return errors.As(err, &rhe) || errors.As(err, &ae) ||
+		errors.As(err, &cve) || errors.As(err, &uae) ||
+		errors.As(err, &he) || errors.As(err, &cie)
+}
@@ -0,0 +1,235 @@
+package bombard
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+func TestCategorizeError(t *testing.T) {
+	expectations := []struct {
+		in  error
+		out errorCategory
+	}{
+		{errors.New("something"), otherError},
+		{&unexpectedStatusError{500}, unexpectedStatusCategory},
+		{fasthttp.ErrDialTimeout, dialTimeoutError},
+		{
+			&net.OpError{Op: "dial", Net: "tcp",
+				Err: os.ErrDeadlineExceeded},
+			dialTimeoutError,
+		},
+		{
+			&url.Error{Op: "Get", URL: "http://localhost", Err: &net.OpError{
+				Op: "dial", Net: "tcp", Err: os.NewSyscallError(
+					"connect", syscall.ECONNREFUSED,
+				),
+			}},
+			connRefusedError,
+		},
+		{
+			&proxyError{"localhost:3128", &net.OpError{
+				Op: "dial", Net: "tcp", Err: os.NewSyscallError(
+					"connect", syscall.ECONNREFUSED,
+				),
+			}},
+			connRefusedError,
+		},
+		{
+			&net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError(
+				"read", syscall.ECONNRESET,
+			)},
+			connResetError,
+		},
+		{tls.RecordHeaderError{Msg: "not TLS"}, tlsError},
+		{
+			fmt.Errorf("handshake: %w", &tls.CertificateVerificationError{
+				Err: errors.New("unknown authority"),
+			}),
+			tlsError,
+		},
+		{
+			&net.OpError{Op: "remote error", Err: errors.New("bad cert")},
+			tlsError,
+		},
+		{fasthttp.ErrTimeout, readTimeoutError},
+		{
+			&net.OpError{Op: "read", Net: "tcp",
+				Err: os.ErrDeadlineExceeded},
+			readTimeoutError,
+		},
+		{
+			&net.OpError{Op: "write", Net: "tcp",
+				Err: os.ErrDeadlineExceeded},
+			writeError,
+		},
+		{
+			&net.OpError{Op: "write", Net: "tcp", Err: os.NewSyscallError(
+				"write", syscall.EPIPE,
+			)},
+			writeError,
+		},
+		{io.EOF, eofError},
+		{
+			&url.Error{Op: "Get", URL: "http://localhost",
+				Err: io.ErrUnexpectedEOF},
+			eofError,
+		},
+		{fasthttp.ErrConnectionClosed, eofError},
+		{&timeoutError{fasthttp.ErrConnectionClosed}, readTimeoutError},
+		{
+			&connectTimeoutError{&net.OpError{Op: "dial", Net: "tcp",
+				Err: os.ErrDeadlineExceeded}},
+			connectTimeoutCategory,
+		},
+		{
+			&url.Error{Op: "Get", URL: "http://localhost",
+				Err: &connectTimeoutError{&proxyError{
+					"localhost:3128", &net.OpError{Op: "read", Net: "tcp",
+						Err: os.ErrDeadlineExceeded},
+				}}},
+			connectTimeoutCategory,
+		},
+	}
+	for _, e := range expectations {
+		if c := categorizeError(e.in); c != e.out {
+			t.Errorf("%v: expected %v, but got %v", e.in, e.out, c)
+		}
+	}
+}
+
+func TestErrorMapByCategory(t *testing.T) {
+	m := newErrorMap()
+	m.add(io.EOF)
+	m.add(io.ErrUnexpectedEOF)
+	m.add(fasthttp.ErrTimeout)
+	m.add(errors.New("something"))
+	exp := map[string]uint64{
+		"EOF":          2,
+		"read timeout": 1,
+		"other":        1,
+	}
+	if got := m.byCategory(); !reflect.DeepEqual(got, exp) {
+		t.Errorf("Expected %v, but got %v", exp, got)
+	}
+}
+
+func TestBombardierErrorsByCategory(t *testing.T) {
+	testAllClients(t, testBombardierErrorsByCategory)
+}
+
+func testBombardierErrorsByCategory(clientType clientTyp, t *testing.T) {
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			time.Sleep(100 * time.Millisecond)
+		}),
+	)
+	defer s.Close()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	refused := "http://" + ln.Addr().String()
+	ln.Close()
+	for _, tc := range []struct {
+		url      string
+		category errorCategory
+	}{
+		{s.URL, readTimeoutError},
+		{refused, connRefusedError},
+	} {
+		numReqs := uint64(4)
+		b, e := newBombardier(config{
+			numConns:   2,
+			numReqs:    &numReqs,
+			url:        tc.url,
+			headers:    new(headersList),
+			timeout:    10 * time.Millisecond,
+			method:     "GET",
+			clientType: clientType,
+			format:     knownFormat("plain-text"),
+		})
+		if e != nil {
+			t.Error(e)
+			return
+		}
+		b.disableOutput()
+		b.bombard()
+		exp := map[string]uint64{tc.category.String(): numReqs}
+		got := b.gatherInfo().Result.ErrorsByCategory
+		if !reflect.DeepEqual(got, exp) {
+			t.Errorf("%v: expected %v, but got %v: %v",
+				tc.url, exp, got, b.errors.byFrequency())
+		}
+	}
+}
+
+func TestBombardierConnectTimeout(t *testing.T) {
+	testAllClients(t, testBombardierConnectTimeout)
+}
+
+func testBombardierConnectTimeout(clientType clientTyp, t *testing.T) {
+	// Proxy that never answers makes connections hang without relying
+	// on unroutable addresses
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		var conns []net.Conn
+		defer func() {
+			for _, c := range conns {
+				c.Close()
+			}
+		}()
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conns = append(conns, c)
+		}
+	}()
+	numReqs := uint64(4)
+	b, e := newBombardier(config{
+		numConns:       2,
+		numReqs:        &numReqs,
+		url:            "http://localhost:8080",
+		proxy:          "http://" + ln.Addr().String(),
+		headers:        new(headersList),
+		timeout:        5 * time.Second,
+		connectTimeout: 50 * time.Millisecond,
+		method:         "GET",
+		clientType:     clientType,
+		format:         knownFormat("plain-text"),
+	})
+	if e != nil {
+		t.Error(e)
+		return
+	}
+	b.disableOutput()
+	start := time.Now()
+	b.bombard()
+	if d := time.Since(start); d > 2*time.Second {
+		t.Errorf("Expected connections to time out quickly, but took %v", d)
+	}
+	exp := map[string]uint64{connectTimeoutCategory.String(): numReqs}
+	got := b.gatherInfo().Result.ErrorsByCategory
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("Expected %v, but got %v: %v",
+			exp, got, b.errors.byFrequency())
+	}
+}
@@ -0,0 +1,95 @@
+package bombard
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	defaultErrorDumpPath = "bombardier-errors.txt"
+	// Bodies of dumped requests and responses are cut at this size
+	maxDumpedBodySize = 64 << 10
+)
+
+// errorDumper writes requests and responses of the first few failed
+// requests into a file, as they happen. Only that many of them are
+// ever held in memory.
+type errorDumper struct {
+	max uint64
+	// reserved is the number of failures that will be dumped
+	reserved uint64
+
+	mu     sync.Mutex
+	file   *os.File
+	w      *bufio.Writer
+	closed bool
+	err    error
+}
+
+func newErrorDumper(path string, max uint64) (*errorDumper, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &errorDumper{max: max, file: f, w: bufio.NewWriter(f)}, nil
+}
+
+// reserve tells whether one more failure should be dumped, the caller
+// must call dump once it does.
+func (d *errorDumper) reserve() bool {
+	if atomic.LoadUint64(&d.reserved) >= d.max {
+		return false
+	}
+	return atomic.AddUint64(&d.reserved, 1) <= d.max
+}
+
+// dump writes the request and either the response to it or the error
+// it failed with.
+func (d *errorDumper) dump(
+	connID uint64, request, response []byte, err error,
+) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed || d.err != nil {
+		return
+	}
+	fmt.Fprintf(d.w, "=== connection %v ===\n", connID)
+	d.w.Write(request)
+	if response != nil {
+		fmt.Fprint(d.w, "\n--- response ---\n")
+		d.w.Write(response)
+	}
+	if err != nil {
+		fmt.Fprintf(d.w, "\n--- error ---\n%v", err)
+	}
+	_, d.err = fmt.Fprint(d.w, "\n\n")
+}
+
+// close flushes the dumps and closes the file, failures that happen
+// afterwards aren't dumped.
+func (d *errorDumper) close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.closed = true
+	err := d.err
+	if ferr := d.w.Flush(); err == nil {
+		err = ferr
+	}
+	if cerr := d.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// truncatedBody returns body cut at maxDumpedBodySize with a note
+// about how much was left out.
+func truncatedBody(body []byte) []byte {
+	if len(body) <= maxDumpedBodySize {
+		return body
+	}
+	note := fmt.Sprintf("\n[%v more bytes]", len(body)-maxDumpedBodySize)
+	return append(append([]byte{}, body[:maxDumpedBodySize]...), note...)
+}
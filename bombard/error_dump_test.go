@@ -0,0 +1,172 @@
+package bombard
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestErrorDumperReserve(t *testing.T) {
+	d, err := newErrorDumper(filepath.Join(t.TempDir(), "dump.txt"), 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.close()
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		reserved int
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				if d.reserve() {
+					mu.Lock()
+					reserved++
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if reserved != 5 {
+		t.Errorf("Expected 5 reserved dumps, but got %v", reserved)
+	}
+}
+
+func TestErrorDumperDump(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dump.txt")
+	d, err := newErrorDumper(path, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.dump(3, []byte("GET / HTTP/1.1\r\n\r\n"),
+		[]byte("HTTP/1.1 500 Internal Server Error\r\n\r\n"), nil)
+	d.dump(4, []byte("GET /x HTTP/1.1\r\n\r\n"), nil,
+		errors.New("connection refused"))
+	if err = d.close(); err != nil {
+		t.Fatal(err)
+	}
+	d.dump(5, []byte("GET /late HTTP/1.1\r\n\r\n"), nil,
+		errors.New("late"))
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := "=== connection 3 ===\n" +
+		"GET / HTTP/1.1\r\n\r\n" +
+		"\n--- response ---\n" +
+		"HTTP/1.1 500 Internal Server Error\r\n\r\n" +
+		"\n\n" +
+		"=== connection 4 ===\n" +
+		"GET /x HTTP/1.1\r\n\r\n" +
+		"\n--- error ---\nconnection refused" +
+		"\n\n"
+	if string(b) != exp {
+		t.Errorf("Expected dump %q, but got %q", exp, b)
+	}
+}
+
+func TestErrorDumperInvalidPath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "dump.txt")
+	if _, err := newErrorDumper(path, 1); err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+func TestTruncatedBody(t *testing.T) {
+	short := []byte("short body")
+	if got := truncatedBody(short); !bytes.Equal(got, short) {
+		t.Errorf("Expected %q, but got %q", short, got)
+	}
+	long := bytes.Repeat([]byte("a"), maxDumpedBodySize+10)
+	got := truncatedBody(long)
+	exp := string(long[:maxDumpedBodySize]) + "\n[10 more bytes]"
+	if string(got) != exp {
+		t.Errorf("Expected body cut at %v bytes, but got %v bytes",
+			maxDumpedBodySize, len(got))
+	}
+	if len(long) != maxDumpedBodySize+10 {
+		t.Error("Original body was modified")
+	}
+}
+
+func TestBombardierDumpsErrors(t *testing.T) {
+	testAllClients(t, testBombardierDumpsErrors)
+}
+
+func testBombardierDumpsErrors(clientType clientTyp, t *testing.T) {
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			rw.WriteHeader(http.StatusInternalServerError)
+			_, _ = rw.Write([]byte("something broke"))
+		}),
+	)
+	defer s.Close()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	refused := "http://" + ln.Addr().String()
+	ln.Close()
+	for _, tc := range []struct {
+		url, path, exp string
+	}{
+		{s.URL, "/broken", "--- response ---"},
+		{refused, "/refused", "--- error ---"},
+	} {
+		path := filepath.Join(t.TempDir(), "dump.txt")
+		numReqs := uint64(10)
+		b, e := newBombardier(config{
+			numConns:       2,
+			numReqs:        &numReqs,
+			url:            tc.url + tc.path,
+			headers:        new(headersList),
+			timeout:        defaultTimeout,
+			method:         "POST",
+			body:           "request body",
+			clientType:     clientType,
+			format:         knownFormat("plain-text"),
+			dumpErrors:     3,
+			dumpErrorsPath: path,
+		})
+		if e != nil {
+			t.Error(e)
+			return
+		}
+		b.disableOutput()
+		b.bombard()
+		dump, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		d := string(dump)
+		if n := strings.Count(d, "=== connection "); n != 3 {
+			t.Errorf("%v: expected 3 dumps, but got %v:\n%v", tc.url, n, d)
+		}
+		if n := strings.Count(d, tc.exp); n != 3 {
+			t.Errorf("%v: expected 3 %q sections, but got %v:\n%v",
+				tc.url, tc.exp, n, d)
+		}
+		if !strings.Contains(d, "POST "+tc.path+" HTTP/1.1") {
+			t.Errorf("%v: request line is missing:\n%v", tc.url, d)
+		}
+		if !strings.Contains(d, "request body") {
+			t.Errorf("%v: request body is missing:\n%v", tc.url, d)
+		}
+		if tc.exp == "--- response ---" &&
+			!strings.Contains(d, "something broke") {
+			t.Errorf("%v: response body is missing:\n%v", tc.url, d)
+		}
+	}
+}
@@ -1,4 +1,4 @@
-package main
+package bombard
 
 import (
 	"sort"
@@ -10,6 +10,8 @@ import (
 type errorMap struct {
 	mu sync.RWMutex
 	m  map[string]*uint64
+
+	categories [numErrorCategories]uint64
 }
 
 func newErrorMap() *errorMap {
@@ -19,6 +21,7 @@ func newErrorMap() *errorMap {
 }
 
 func (e *errorMap) add(err error) {
+	atomic.AddUint64(&e.categories[categorizeError(err)], 1)
 	s := err.Error()
 	e.mu.RLock()
 	c, ok := e.m[s]
@@ -56,6 +59,23 @@ func (e *errorMap) sum() uint64 {
 	return sum
 }
 
+// inCategory returns the number of errors of the given category.
+func (e *errorMap) inCategory(c errorCategory) uint64 {
+	return atomic.LoadUint64(&e.categories[c])
+}
+
+// byCategory returns the numbers of errors of every category that
+// occurred at least once.
+func (e *errorMap) byCategory() map[string]uint64 {
+	counts := make(map[string]uint64)
+	for c := range e.categories {
+		if n := atomic.LoadUint64(&e.categories[c]); n > 0 {
+			counts[errorCategory(c).String()] = n
+		}
+	}
+	return counts
+}
+
 type errorWithCount struct {
 	error string
 	count uint64
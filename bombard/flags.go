@@ -0,0 +1,278 @@
+package bombard
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kostyay/bombardier/internal"
+)
+
+const (
+	nilStr = "nil"
+)
+
+type nullableUint64 struct {
+	val *uint64
+}
+
+func (n *nullableUint64) String() string {
+	if n.val == nil {
+		return nilStr
+	}
+	return strconv.FormatUint(*n.val, 10)
+}
+
+func (n *nullableUint64) Set(value string) error {
+	res, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return err
+	}
+	n.val = new(uint64)
+	*n.val = res
+	return nil
+}
+
+type nullableDuration struct {
+	val *time.Duration
+}
+
+func (n *nullableDuration) String() string {
+	if n.val == nil {
+		return nilStr
+	}
+	return n.val.String()
+}
+
+func (n *nullableDuration) Set(value string) error {
+	res, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	n.val = &res
+	return nil
+}
+
+type nullableFloat64 struct {
+	val *float64
+}
+
+func (n *nullableFloat64) String() string {
+	if n.val == nil {
+		return nilStr
+	}
+	return strconv.FormatFloat(*n.val, 'g', -1, 64)
+}
+
+func (n *nullableFloat64) Set(value string) error {
+	res, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return err
+	}
+	n.val = &res
+	return nil
+}
+
+// nullableBytes is a number of bytes, either plain or with one of
+// binary units, i.e. "512KB" or "1GB".
+type nullableBytes struct {
+	val *uint64
+}
+
+func (n *nullableBytes) String() string {
+	if n.val == nil {
+		return nilStr
+	}
+	return formatBinary(float64(*n.val))
+}
+
+func (n *nullableBytes) Set(value string) error {
+	num, mult := strings.ToUpper(value), uint64(1)
+	for i, unit := range binaryUnits.units {
+		if strings.HasSuffix(num, unit) {
+			num = strings.TrimSuffix(num, unit)
+			for ; i >= 0; i-- {
+				mult *= binaryUnits.scale
+			}
+			break
+		}
+	}
+	num = strings.TrimSuffix(num, "B")
+	res, err := strconv.ParseFloat(num, 64)
+	if err != nil || !(res >= 0 && res*float64(mult) < math.MaxUint64) {
+		return &invalidBytesError{value}
+	}
+	n.val = new(uint64)
+	*n.val = uint64(res * float64(mult))
+	return nil
+}
+
+type invalidBytesError struct {
+	value string
+}
+
+func (i *invalidBytesError) Error() string {
+	return fmt.Sprintf("%q is not a valid number of bytes", i.value)
+}
+
+type nullableString struct {
+	val *string
+}
+
+func (n *nullableString) String() string {
+	if n.val == nil {
+		return nilStr
+	}
+	return *n.val
+}
+
+func (n *nullableString) Set(value string) error {
+	n.val = new(string)
+	*n.val = value
+	return nil
+}
+
+type tagsList []internal.Tag
+
+func (t *tagsList) String() string {
+	return fmt.Sprint(*t)
+}
+
+func (t *tagsList) IsCumulative() bool {
+	return true
+}
+
+func (t *tagsList) Set(value string) error {
+	res := strings.SplitN(value, "=", 2)
+	if len(res) != 2 || res[0] == "" || res[1] == "" {
+		return errInvalidTagFormat
+	}
+	*t = append(*t, internal.Tag{Key: res[0], Value: res[1]})
+	return nil
+}
+
+// formField is a field of multipart/form-data body, value of file
+// fields is the path of the file to upload.
+type formField struct {
+	name, value string
+	isFile      bool
+}
+
+type formFieldsList []formField
+
+func (f *formFieldsList) String() string {
+	return fmt.Sprint(*f)
+}
+
+func (f *formFieldsList) IsCumulative() bool {
+	return true
+}
+
+func (f *formFieldsList) Set(value string) error {
+	res := strings.SplitN(value, "=", 2)
+	if len(res) != 2 || res[0] == "" {
+		return errInvalidFormFieldFormat
+	}
+	field := formField{name: res[0], value: res[1]}
+	if strings.HasPrefix(field.value, "@") {
+		field.value, field.isFile = field.value[1:], true
+		if field.value == "" {
+			return errInvalidFormFieldFormat
+		}
+	}
+	*f = append(*f, field)
+	return nil
+}
+
+// resolveOverride makes connections to host:port go to ip instead.
+type resolveOverride struct {
+	host, port, ip string
+}
+
+func (r resolveOverride) String() string {
+	ip := r.ip
+	if strings.Contains(ip, ":") {
+		ip = "[" + ip + "]"
+	}
+	return r.host + ":" + r.port + ":" + ip
+}
+
+type resolveOverridesList []resolveOverride
+
+func (r *resolveOverridesList) String() string {
+	return fmt.Sprint(*r)
+}
+
+func (r *resolveOverridesList) IsCumulative() bool {
+	return true
+}
+
+func (r *resolveOverridesList) Set(value string) error {
+	res := strings.SplitN(value, ":", 3)
+	if len(res) != 3 || res[0] == "" {
+		return errInvalidResolveFormat
+	}
+	if _, err := strconv.ParseUint(res[1], 10, 16); err != nil {
+		return errInvalidResolveFormat
+	}
+	// IPv6 addresses may be enclosed in brackets, like in curl
+	ip := strings.TrimSuffix(strings.TrimPrefix(res[2], "["), "]")
+	if net.ParseIP(ip) == nil {
+		return errInvalidResolveFormat
+	}
+	*r = append(*r, resolveOverride{host: res[0], port: res[1], ip: ip})
+	return nil
+}
+
+type localAddrsList []string
+
+func (l *localAddrsList) String() string {
+	return fmt.Sprint(*l)
+}
+
+func (l *localAddrsList) IsCumulative() bool {
+	return true
+}
+
+func (l *localAddrsList) Set(value string) error {
+	ip := net.ParseIP(strings.TrimSuffix(strings.TrimPrefix(value, "["), "]"))
+	if ip == nil {
+		return errInvalidLocalAddr
+	}
+	*l = append(*l, ip.String())
+	return nil
+}
+
+type weightedURL struct {
+	url    string
+	weight uint
+}
+
+type weightedURLsList []weightedURL
+
+func (w *weightedURLsList) String() string {
+	return fmt.Sprint(*w)
+}
+
+func (w *weightedURLsList) IsCumulative() bool {
+	return true
+}
+
+func (w *weightedURLsList) Set(value string) error {
+	res := strings.SplitN(value, ":", 2)
+	if len(res) != 2 {
+		return errInvalidWeightedURLFormat
+	}
+	weight, err := strconv.ParseUint(res[0], 10, 32)
+	if err != nil {
+		return errInvalidWeightedURLFormat
+	}
+	*w = append(*w, weightedURL{
+		url:    res[1],
+		weight: uint(weight),
+	})
+	return nil
+}
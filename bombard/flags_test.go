@@ -0,0 +1,217 @@
+package bombard
+
+import (
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestNullableUint64ConversionToString(t *testing.T) {
+	nilint := &nullableUint64{val: nil}
+	if s := nilint.String(); s != "nil" {
+		t.Errorf("Expected \"nil\", but got %v", s)
+	}
+	v := uint64(42)
+	nonnilint := &nullableUint64{val: &v}
+	if s, e := nonnilint.String(), strconv.FormatUint(v, 10); s != e {
+		t.Errorf("Expected %v, but got %v", e, s)
+	}
+}
+
+func TestNullableUint64Parsing(t *testing.T) {
+	n := &nullableUint64{}
+	if err := n.Set("-1"); err == nil {
+		t.Error("Should fail on negative values")
+	}
+	if err := n.Set(""); err == nil {
+		t.Error("Should fail on empty string")
+	}
+	b := big.NewInt(0)
+	b.SetUint64(math.MaxUint64)
+	b.Add(b, big.NewInt(1))
+	if err := n.Set(b.String()); err == nil {
+		t.Error("Should fail on large values")
+	}
+	max := strconv.FormatUint(math.MaxUint64, 10)
+	if err := n.Set(max); err != nil || *n.val != uint64(18446744073709551615) {
+		t.Error("Shouldn't fail on max value")
+	}
+}
+
+func TestNullableDurationConversionToString(t *testing.T) {
+	nildur := &nullableDuration{val: nil}
+	if s := nildur.String(); s != "nil" {
+		t.Errorf("Expected \"nil\", but got %v", s)
+	}
+	d := time.Second
+	nonnildir := &nullableDuration{val: &d}
+	if s := nonnildir.String(); s != "1s" {
+		t.Errorf("Expected 1s, but got %v", s)
+	}
+}
+
+func TestNullableDurationParsing(t *testing.T) {
+	d := &nullableDuration{}
+	if err := d.Set(""); err == nil {
+		t.Error("Should fail on empty string")
+	}
+	if err := d.Set("Wubba lubba dub dub!"); err == nil {
+		t.Error("Should fail on incorrect values")
+	}
+	if err := d.Set("1s"); err != nil || *d.val != time.Second {
+		t.Error("Shouldn't fail on correct values")
+	}
+}
+
+func TestNullableFloat64ConversionToString(t *testing.T) {
+	nilfloat := &nullableFloat64{val: nil}
+	if s := nilfloat.String(); s != "nil" {
+		t.Errorf("Expected \"nil\", but got %v", s)
+	}
+	v := 0.05
+	nonnilfloat := &nullableFloat64{val: &v}
+	if s := nonnilfloat.String(); s != "0.05" {
+		t.Errorf("Expected 0.05, but got %v", s)
+	}
+}
+
+func TestNullableFloat64Parsing(t *testing.T) {
+	f := &nullableFloat64{}
+	if err := f.Set(""); err == nil {
+		t.Error("Should fail on empty string")
+	}
+	if err := f.Set("five percent"); err == nil {
+		t.Error("Should fail on incorrect values")
+	}
+	if err := f.Set("0.05"); err != nil || *f.val != 0.05 {
+		t.Error("Shouldn't fail on correct values")
+	}
+}
+
+func TestNullableBytesConversionToString(t *testing.T) {
+	nilbytes := &nullableBytes{val: nil}
+	if s := nilbytes.String(); s != "nil" {
+		t.Errorf("Expected \"nil\", but got %v", s)
+	}
+	v := uint64(1 << 30)
+	nonnilbytes := &nullableBytes{val: &v}
+	if s := nonnilbytes.String(); s != "1.00GB" {
+		t.Errorf("Expected 1.00GB, but got %v", s)
+	}
+}
+
+func TestNullableBytesParsing(t *testing.T) {
+	expectations := []struct {
+		in  string
+		out uint64
+	}{
+		{"100", 100},
+		{"100B", 100},
+		{"512KB", 512 << 10},
+		{"1GB", 1 << 30},
+		{"1gb", 1 << 30},
+		{"1.5MB", 3 << 19},
+		{"2TB", 2 << 40},
+		{"1PB", 1 << 50},
+	}
+	for _, e := range expectations {
+		b := &nullableBytes{}
+		if err := b.Set(e.in); err != nil {
+			t.Errorf("%q: %v", e.in, err)
+			continue
+		}
+		if *b.val != e.out {
+			t.Errorf("%q: expected %v, but got %v", e.in, e.out, *b.val)
+		}
+	}
+	for _, in := range []string{"", "GB", "lots", "-1KB", "NaN", "1e30PB"} {
+		b := &nullableBytes{}
+		if err := b.Set(in); err == nil {
+			t.Errorf("%q: should fail on incorrect values", in)
+		}
+	}
+}
+
+func TestNullableStringConversionToString(t *testing.T) {
+	ns := new(nullableString)
+	if act := ns.String(); act != nilStr {
+		t.Error("Unset nullableString should convert to \"nil\"")
+	}
+	someVal := "someval"
+	if err := ns.Set(someVal); err != nil {
+		t.Errorf("Couldn't set nullableString to %q", someVal)
+	}
+	if act := ns.String(); act != someVal {
+		t.Errorf("Expected %q, but got %q", someVal, act)
+	}
+}
+
+func TestTagsListParsing(t *testing.T) {
+	tl := new(tagsList)
+	for _, in := range []string{"env=staging", "q=a=b"} {
+		if err := tl.Set(in); err != nil {
+			t.Errorf("%q: unexpected error %v", in, err)
+		}
+	}
+	exp := tagsList{{Key: "env", Value: "staging"}, {Key: "q", Value: "a=b"}}
+	if !reflect.DeepEqual(*tl, exp) {
+		t.Errorf("Expected %v, but got %v", exp, *tl)
+	}
+	for _, in := range []string{"env", "=staging", "env="} {
+		if err := tl.Set(in); err != errInvalidTagFormat {
+			t.Errorf("%q: expected %v, but got %v", in, errInvalidTagFormat, err)
+		}
+	}
+}
+
+func TestResolveOverridesListParsing(t *testing.T) {
+	rl := new(resolveOverridesList)
+	for _, in := range []string{
+		"example.com:443:10.0.0.1", "example.com:80:[::1]",
+	} {
+		if err := rl.Set(in); err != nil {
+			t.Errorf("%q: unexpected error %v", in, err)
+		}
+	}
+	exp := resolveOverridesList{
+		{"example.com", "443", "10.0.0.1"},
+		{"example.com", "80", "::1"},
+	}
+	if !reflect.DeepEqual(*rl, exp) {
+		t.Errorf("Expected %v, but got %v", exp, *rl)
+	}
+	if s := (*rl)[1].String(); s != "example.com:80:[::1]" {
+		t.Errorf("Unexpected string representation: %q", s)
+	}
+	for _, in := range []string{
+		"example.com", "example.com:443", ":443:10.0.0.1",
+		"example.com:https:10.0.0.1", "example.com:443:example.org",
+	} {
+		if err := rl.Set(in); err != errInvalidResolveFormat {
+			t.Errorf("%q: expected %v, but got %v",
+				in, errInvalidResolveFormat, err)
+		}
+	}
+}
+
+func TestLocalAddrsListParsing(t *testing.T) {
+	l := new(localAddrsList)
+	for _, in := range []string{"10.0.0.5", "[::1]", "fe80:0::1"} {
+		if err := l.Set(in); err != nil {
+			t.Errorf("%q: unexpected error %v", in, err)
+		}
+	}
+	exp := localAddrsList{"10.0.0.5", "::1", "fe80::1"}
+	if !reflect.DeepEqual(*l, exp) {
+		t.Errorf("Expected %v, but got %v", exp, *l)
+	}
+	for _, in := range []string{"", "example.com", "10.0.0.5:80"} {
+		if err := l.Set(in); err != errInvalidLocalAddr {
+			t.Errorf("%q: expected %v, but got %v",
+				in, errInvalidLocalAddr, err)
+		}
+	}
+}
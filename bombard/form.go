@@ -0,0 +1,45 @@
+package bombard
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+)
+
+// buildForm builds multipart/form-data body out of fields, reading
+// files to upload, and returns it along with its Content-Type.
+func buildForm(fields formFieldsList) (string, string, error) {
+	buf := new(bytes.Buffer)
+	w := multipart.NewWriter(buf)
+	for _, f := range fields {
+		if !f.isFile {
+			if err := w.WriteField(f.name, f.value); err != nil {
+				return "", "", err
+			}
+			continue
+		}
+		if err := writeFormFile(w, f.name, f.value); err != nil {
+			return "", "", err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return "", "", err
+	}
+	return buf.String(), w.FormDataContentType(), nil
+}
+
+func writeFormFile(w *multipart.Writer, name, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	part, err := w.CreateFormFile(name, filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(part, file)
+	return err
+}
@@ -0,0 +1,103 @@
+package bombard
+
+import (
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFormFieldsListSet(t *testing.T) {
+	expectations := []struct {
+		in  string
+		out formField
+		err error
+	}{
+		{"a=b", formField{name: "a", value: "b"}, nil},
+		{"a=", formField{name: "a"}, nil},
+		{"a=b=c", formField{name: "a", value: "b=c"}, nil},
+		{"file=@/tmp/x.png", formField{"file", "/tmp/x.png", true}, nil},
+		{"a", formField{}, errInvalidFormFieldFormat},
+		{"=b", formField{}, errInvalidFormFieldFormat},
+		{"file=@", formField{}, errInvalidFormFieldFormat},
+	}
+	for _, e := range expectations {
+		var l formFieldsList
+		err := l.Set(e.in)
+		if err != e.err {
+			t.Errorf("%q: expected error %v, but got %v", e.in, e.err, err)
+			continue
+		}
+		if err == nil && !reflect.DeepEqual(l, formFieldsList{e.out}) {
+			t.Errorf("%q: expected %+v, but got %+v", e.in, e.out, l)
+		}
+	}
+}
+
+func TestBuildForm(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bombardier-form")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "upload.bin")
+	if err = ioutil.WriteFile(path, []byte("\x00\x01file"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	body, contentType, err := buildForm(formFieldsList{
+		{name: "title", value: "cat"},
+		{name: "image", value: path, isFile: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mediaType != "multipart/form-data" {
+		t.Fatalf("Unexpected media type: %q", mediaType)
+	}
+	form, err := multipart.NewReader(
+		strings.NewReader(body), params["boundary"],
+	).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer form.RemoveAll()
+	if v := form.Value["title"]; !reflect.DeepEqual(v, []string{"cat"}) {
+		t.Errorf("Unexpected title: %q", v)
+	}
+	files := form.File["image"]
+	if len(files) != 1 {
+		t.Fatalf("Expected 1 file, but got %v", len(files))
+	}
+	if files[0].Filename != "upload.bin" {
+		t.Errorf("Unexpected file name: %q", files[0].Filename)
+	}
+	f, err := files[0].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	content, err := ioutil.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "\x00\x01file" {
+		t.Errorf("Unexpected file content: %q", content)
+	}
+}
+
+func TestBuildFormMissingFile(t *testing.T) {
+	_, _, err := buildForm(formFieldsList{
+		{name: "image", value: "/this/file/does/not/exist", isFile: true},
+	})
+	if !os.IsNotExist(err) {
+		t.Errorf("Expected file not found error, but got %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package bombard
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+type units struct {
+	scale uint64
+	base  string
+	units []string
+}
+
+var (
+	binaryUnits = &units{
+		scale: 1024,
+		base:  "",
+		units: []string{"KB", "MB", "GB", "TB", "PB"},
+	}
+	timeUnitsUs = &units{
+		scale: 1000,
+		base:  "us",
+		units: []string{"ms", "s"},
+	}
+	timeUnitsS = &units{
+		scale: 60,
+		base:  "s",
+		units: []string{"m", "h"},
+	}
+)
+
+func formatUnits(n float64, m *units, prec int) string {
+	amt := n
+	unit := m.base
+
+	scale := float64(m.scale) * 0.85
+
+	for i := 0; i < len(m.units) && amt >= scale; i++ {
+		amt /= float64(m.scale)
+		unit = m.units[i]
+	}
+	return fmt.Sprintf("%.*f%s", prec, amt, unit)
+}
+
+func formatBinary(n float64) string {
+	return formatUnits(n, binaryUnits, 2)
+}
+
+func formatTimeUs(n float64) string {
+	units := timeUnitsUs
+	if n >= 1000000.0 {
+		n /= 1000000.0
+		units = timeUnitsS
+	}
+	return formatUnits(n, units, 2)
+}
+
+// formatPercentile formats fraction as percents, i.e. 0.999 as "99.9".
+func formatPercentile(pc float64) string {
+	// Round away errors of float arithmetic, so that parsed
+	// percents are printed back the same way.
+	pct := math.Round(pc*100*1e9) / 1e9
+	return strconv.FormatFloat(pct, 'f', -1, 64)
+}
+
+// formatStatusClass formats status class, i.e. 2 as "2xx" and 0 (failed
+// requests and other status codes) as "others".
+func formatStatusClass(class int) string {
+	if class == 0 {
+		return "others"
+	}
+	return strconv.Itoa(class) + "xx"
+}
+
+// markdownEscaper keeps text within a single cell of Markdown table.
+var markdownEscaper = strings.NewReplacer("|", "\\|", "\r", "", "\n", " ")
+
+// escapeMarkdown escapes s for use in a cell of Markdown table.
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
@@ -1,4 +1,4 @@
-package main
+package bombard
 
 import (
 	"testing"
@@ -63,3 +63,59 @@ func TestShouldFormatUs(t *testing.T) {
 		}
 	}
 }
+
+func TestShouldFormatPercentile(t *testing.T) {
+	expectations := []struct {
+		in  float64
+		out string
+	}{
+		{0, "0"},
+		{0.5, "50"},
+		{0.999, "99.9"},
+		{0.9990000000000001, "99.9"},
+		{0.99999, "99.999"},
+		{1, "100"},
+	}
+	for _, e := range expectations {
+		actual := formatPercentile(e.in)
+		expected := e.out
+		if expected != actual {
+			t.Errorf("Expected \"%v\", but got \"%v\"", expected, actual)
+		}
+	}
+}
+
+func TestShouldFormatStatusClass(t *testing.T) {
+	expectations := []struct {
+		in  int
+		out string
+	}{
+		{0, "others"},
+		{2, "2xx"},
+		{5, "5xx"},
+	}
+	for _, e := range expectations {
+		actual := formatStatusClass(e.in)
+		expected := e.out
+		if expected != actual {
+			t.Errorf("Expected \"%v\", but got \"%v\"", expected, actual)
+		}
+	}
+}
+
+func TestEscapeMarkdown(t *testing.T) {
+	expectations := []struct {
+		in  string
+		out string
+	}{
+		{"", ""},
+		{"timeout", "timeout"},
+		{"a|b", "a\\|b"},
+		{"line\r\nbreak", "line break"},
+	}
+	for _, e := range expectations {
+		if actual := escapeMarkdown(e.in); actual != e.out {
+			t.Errorf("Expected %q, but got %q", e.out, actual)
+		}
+	}
+}
@@ -0,0 +1,366 @@
+package bombard
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// grpcMethod is the unary gRPC method called instead of sending HTTP
+// requests, as described in descriptor set.
+type grpcMethod struct {
+	protoSet string
+	desc     protoreflect.MethodDescriptor
+}
+
+type invalidProtoSetError struct {
+	path string
+	err  error
+}
+
+func (i *invalidProtoSetError) Error() string {
+	return fmt.Sprintf("Invalid descriptor set %v: %v", i.path, i.err)
+}
+
+type grpcMethodNotFoundError struct {
+	method, protoSet string
+}
+
+func (g *grpcMethodNotFoundError) Error() string {
+	return fmt.Sprintf("gRPC method %v isn't described in %v",
+		g.method, g.protoSet)
+}
+
+type grpcStreamingMethodError struct {
+	method string
+}
+
+func (g *grpcStreamingMethodError) Error() string {
+	return fmt.Sprintf(
+		"gRPC method %v is a streaming one, only unary methods can be called",
+		g.method)
+}
+
+type invalidGRPCRequestError struct {
+	method string
+	err    error
+}
+
+func (i *invalidGRPCRequestError) Error() string {
+	return fmt.Sprintf("Invalid request to gRPC method %v: %v",
+		i.method, i.err)
+}
+
+// readGRPCMethod finds method, given as pkg.Service/Method or
+// pkg.Service.Method, in FileDescriptorSet stored at protoSet, like
+// the one protoc --descriptor_set_out --include_imports writes.
+func readGRPCMethod(protoSet, method string) (*grpcMethod, error) {
+	data, err := ioutil.ReadFile(protoSet)
+	if err != nil {
+		return nil, err
+	}
+	var set descriptorpb.FileDescriptorSet
+	if err = proto.Unmarshal(data, &set); err != nil {
+		return nil, &invalidProtoSetError{protoSet, err}
+	}
+	files, err := protodesc.NewFiles(&set)
+	if err != nil {
+		return nil, &invalidProtoSetError{protoSet, err}
+	}
+	name := strings.TrimPrefix(method, "/")
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		name = name[:i] + "." + name[i+1:]
+	}
+	d, err := files.FindDescriptorByName(protoreflect.FullName(name))
+	if err != nil {
+		return nil, &grpcMethodNotFoundError{method, protoSet}
+	}
+	md, ok := d.(protoreflect.MethodDescriptor)
+	if !ok {
+		return nil, &grpcMethodNotFoundError{method, protoSet}
+	}
+	if md.IsStreamingClient() || md.IsStreamingServer() {
+		return nil, &grpcStreamingMethodError{method}
+	}
+	return &grpcMethod{protoSet: protoSet, desc: md}, nil
+}
+
+// path returns the name method is called by on the wire.
+func (m *grpcMethod) path() string {
+	return "/" + string(m.desc.Parent().FullName()) + "/" +
+		string(m.desc.Name())
+}
+
+func (m *grpcMethod) String() string {
+	return m.path()[1:]
+}
+
+// encodeRequest converts request from JSON to protobuf, empty body
+// stands for the message with no fields set.
+func (m *grpcMethod) encodeRequest(body string) ([]byte, error) {
+	msg := dynamicpb.NewMessage(m.desc.Input())
+	if body != "" {
+		if err := protojson.Unmarshal([]byte(body), msg); err != nil {
+			return nil, &invalidGRPCRequestError{m.String(), err}
+		}
+	}
+	return proto.Marshal(msg)
+}
+
+// decodeResponse converts response from protobuf to JSON.
+func (m *grpcMethod) decodeResponse(data []byte) ([]byte, error) {
+	msg := dynamicpb.NewMessage(m.desc.Output())
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return nil, err
+	}
+	return protojson.MarshalOptions{Multiline: true}.Marshal(msg)
+}
+
+// rawCodec sends requests encoded beforehand as they are, so that
+// they aren't encoded over and over, and doesn't decode responses
+// either: only their size is kept, unless they are read into []byte.
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v interface{}) ([]byte, error) {
+	return *v.(*[]byte), nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v interface{}) error {
+	switch r := v.(type) {
+	case *int:
+		*r = len(data)
+	case *[]byte:
+		*r = append((*r)[:0], data...)
+	}
+	return nil
+}
+
+func (rawCodec) Name() string {
+	return "proto"
+}
+
+// formatGRPCCode returns name of numeric gRPC status code.
+func formatGRPCCode(code int) string {
+	return codes.Code(code).String()
+}
+
+// grpcStatusError is the outcome of call that ended with status other
+// than OK, which is what the call is recorded with.
+type grpcStatusError struct {
+	st *status.Status
+}
+
+func (g *grpcStatusError) Error() string {
+	return fmt.Sprintf("gRPC status %v: %v", g.st.Code(), g.st.Message())
+}
+
+func (g *grpcStatusError) Timeout() bool {
+	return g.st.Code() == codes.DeadlineExceeded
+}
+
+func (g *grpcStatusError) Temporary() bool {
+	return g.st.Code() == codes.Unavailable || g.Timeout()
+}
+
+type grpcClient struct {
+	// Every connection has a channel of its own, so that calls are
+	// spread over numConns HTTP/2 connections
+	conns []*grpc.ClientConn
+	// dialed is non-zero for connection that was (re)established
+	// since the last call over it completed
+	dialed []int32
+
+	method  *grpcMethod
+	path    string
+	request []byte
+	timeout time.Duration
+
+	md     metadata.MD
+	tokens *tokenSource
+}
+
+func newGRPCClient(
+	opts *clientOpts, method *grpcMethod, request []byte,
+) (*grpcClient, error) {
+	address, err := urlAddress(opts.url)
+	if err != nil {
+		// opts.url guaranteed to be valid at this point
+		panic(err)
+	}
+	u, _ := url.Parse(opts.url)
+	creds := insecure.NewCredentials()
+	if u.Scheme == "https" {
+		// h2 is advertised no matter what ALPN says
+		creds = credentials.NewTLS(opts.tlsConfig)
+	}
+	dial := httpDialContextFunc(
+		opts.bytesRead, opts.bytesWritten, opts.connsOpened,
+		opts.resolver, opts.proxy, opts.connectTimeout,
+	)
+	c := &grpcClient{
+		conns:   make([]*grpc.ClientConn, opts.maxConns),
+		dialed:  make([]int32, opts.maxConns),
+		method:  method,
+		path:    method.path(),
+		request: request,
+		timeout: opts.timeout,
+		md:      metadata.MD{},
+		tokens:  opts.tokens,
+	}
+	for _, h := range *opts.headers {
+		c.md.Append(h.key, h.value)
+	}
+	for i := range c.conns {
+		dialed := &c.dialed[i]
+		c.conns[i], err = grpc.NewClient("passthrough:///"+address,
+			grpc.WithTransportCredentials(creds),
+			grpc.WithContextDialer(func(
+				ctx context.Context, address string,
+			) (net.Conn, error) {
+				conn, err := dial(ctx, "tcp", address)
+				if err == nil {
+					atomic.StoreInt32(dialed, 1)
+				}
+				return conn, err
+			}),
+			grpc.WithDefaultCallOptions(grpc.ForceCodec(rawCodec{})),
+		)
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (c *grpcClient) do(connID uint64) requestResult {
+	return c.perform(connID, nil)
+}
+
+func (c *grpcClient) dump(connID uint64) (requestResult, []byte) {
+	var dump []byte
+	res := c.perform(connID, &dump)
+	return res, dump
+}
+
+// perform calls the method, response metadata and the response itself
+// are stored into dump unless it's nil.
+func (c *grpcClient) perform(
+	connID uint64, dump *[]byte,
+) (res requestResult) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	md := c.md
+	if c.tokens != nil {
+		md = metadata.Join(md,
+			metadata.Pairs("authorization", c.tokens.header()))
+	}
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	var (
+		size            int
+		resp            []byte
+		header, trailer metadata.MD
+		reply           interface{} = &size
+		callOpts        []grpc.CallOption
+	)
+	if dump != nil {
+		reply = &resp
+		callOpts = append(callOpts, grpc.Header(&header),
+			grpc.Trailer(&trailer))
+	}
+	start := time.Now()
+	err := c.conns[connID].Invoke(ctx, c.path, &c.request, reply,
+		callOpts...)
+	res.msTaken = uint64(time.Since(start).Nanoseconds() / 1000)
+
+	st := status.Convert(err)
+	res.code = int(st.Code())
+	if st.Code() != codes.OK {
+		res.err = &grpcStatusError{st}
+	} else {
+		// Response is read as a whole, its first byte can't be told
+		// apart from the rest
+		res.ttfb = res.msTaken
+		res.reused = !atomic.CompareAndSwapInt32(&c.dialed[connID], 1, 0)
+		res.bodySize = int64(size)
+		if dump != nil {
+			res.bodySize = int64(len(resp))
+		}
+	}
+	if dump != nil {
+		*dump = c.dumpResponse(st, header, resp, trailer)
+	}
+	return
+}
+
+// dumpResponse formats response the way it's received: metadata,
+// message converted to JSON and trailers, status included.
+func (c *grpcClient) dumpResponse(
+	st *status.Status, header metadata.MD, resp []byte, trailer metadata.MD,
+) []byte {
+	var buf bytes.Buffer
+	writeMetadata(&buf, header)
+	buf.WriteString("\n")
+	if st.Code() == codes.OK {
+		if msg, err := c.method.decodeResponse(resp); err == nil {
+			buf.Write(msg)
+		} else {
+			fmt.Fprintf(&buf, "<undecodable response: %v>", err)
+		}
+		buf.WriteString("\n\n")
+	}
+	fmt.Fprintf(&buf, "grpc-status: %d (%v)\n", st.Code(), st.Code())
+	if msg := st.Message(); msg != "" {
+		fmt.Fprintf(&buf, "grpc-message: %v\n", msg)
+	}
+	writeMetadata(&buf, trailer)
+	return buf.Bytes()
+}
+
+func writeMetadata(buf *bytes.Buffer, md metadata.MD) {
+	keys := make([]string, 0, len(md))
+	for k := range md {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range md[k] {
+			fmt.Fprintf(buf, "%v: %v\n", k, v)
+		}
+	}
+}
+
+// Close closes channels of all the connections.
+func (c *grpcClient) Close() error {
+	var err error
+	for _, conn := range c.conns {
+		if conn == nil {
+			continue
+		}
+		if cerr := conn.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
@@ -0,0 +1,355 @@
+package bombard
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// writeTestProtoSet writes descriptor set of test.Echo service, which
+// has unary Echo and streaming Stream methods taking and returning
+// messages with a single string field.
+func writeTestProtoSet(t *testing.T) string {
+	message := func(name string) *descriptorpb.DescriptorProto {
+		return &descriptorpb.DescriptorProto{
+			Name: proto.String(name),
+			Field: []*descriptorpb.FieldDescriptorProto{{
+				Name:     proto.String("text"),
+				JsonName: proto.String("text"),
+				Number:   proto.Int32(1),
+				Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.
+					Enum(),
+				Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+			}},
+		}
+	}
+	set := &descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{{
+			Name:    proto.String("echo.proto"),
+			Package: proto.String("test"),
+			Syntax:  proto.String("proto3"),
+			MessageType: []*descriptorpb.DescriptorProto{
+				message("EchoRequest"), message("EchoReply"),
+			},
+			Service: []*descriptorpb.ServiceDescriptorProto{{
+				Name: proto.String("Echo"),
+				Method: []*descriptorpb.MethodDescriptorProto{{
+					Name:       proto.String("Echo"),
+					InputType:  proto.String(".test.EchoRequest"),
+					OutputType: proto.String(".test.EchoReply"),
+				}, {
+					Name:            proto.String("Stream"),
+					InputType:       proto.String(".test.EchoRequest"),
+					OutputType:      proto.String(".test.EchoReply"),
+					ServerStreaming: proto.Bool(true),
+				}},
+			}},
+		}},
+	}
+	data, err := proto.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "echo.protoset")
+	if err = ioutil.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// startEchoServer starts gRPC server that sends requests back as
+// responses, unless "x-status" metadata asks for a status other than
+// OK. It returns address of the server and method names it was called
+// with.
+func startEchoServer(t *testing.T) (string, chan string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	called := make(chan string, 100)
+	s := grpc.NewServer(
+		grpc.ForceServerCodec(rawCodec{}),
+		grpc.UnknownServiceHandler(func(
+			_ interface{}, stream grpc.ServerStream,
+		) error {
+			method, _ := grpc.MethodFromServerStream(stream)
+			select {
+			case called <- method:
+			default:
+			}
+			var req []byte
+			if err := stream.RecvMsg(&req); err != nil {
+				return err
+			}
+			md, _ := metadata.FromIncomingContext(stream.Context())
+			if v := md.Get("x-status"); len(v) > 0 {
+				return status.Error(codes.NotFound, v[0])
+			}
+			return stream.SendMsg(&req)
+		}),
+	)
+	go s.Serve(l)
+	t.Cleanup(s.Stop)
+	return l.Addr().String(), called
+}
+
+func TestReadGRPCMethod(t *testing.T) {
+	path := writeTestProtoSet(t)
+	for _, name := range []string{
+		"test.Echo/Echo", "/test.Echo/Echo", "test.Echo.Echo",
+	} {
+		m, err := readGRPCMethod(path, name)
+		if err != nil {
+			t.Errorf("%v: %v", name, err)
+			continue
+		}
+		if m.path() != "/test.Echo/Echo" || m.String() != "test.Echo/Echo" {
+			t.Errorf("%v: got %v", name, m.path())
+		}
+	}
+	expectations := []struct {
+		path, method string
+		err          error
+	}{
+		{path, "test.Echo/Missing",
+			&grpcMethodNotFoundError{"test.Echo/Missing", path}},
+		{path, "test.EchoRequest",
+			&grpcMethodNotFoundError{"test.EchoRequest", path}},
+		{path, "test.Echo/Stream",
+			&grpcStreamingMethodError{"test.Echo/Stream"}},
+	}
+	for _, e := range expectations {
+		if _, err := readGRPCMethod(e.path, e.method); !reflect.DeepEqual(
+			err, e.err) {
+			t.Errorf("%v: expected %v, but got %v", e.method, e.err, err)
+		}
+	}
+	invalid := filepath.Join(t.TempDir(), "invalid.protoset")
+	if err := ioutil.WriteFile(invalid, []byte("not a descriptor set"),
+		0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := readGRPCMethod(invalid, "test.Echo/Echo")
+	if _, ok := err.(*invalidProtoSetError); !ok {
+		t.Errorf("Expected invalidProtoSetError, but got %v", err)
+	}
+	if _, err = readGRPCMethod("missing.protoset", "test.Echo/Echo"); !os.
+		IsNotExist(err) {
+		t.Errorf("Expected missing file error, but got %v", err)
+	}
+}
+
+func TestGRPCMethodEncodeRequest(t *testing.T) {
+	m, err := readGRPCMethod(writeTestProtoSet(t), "test.Echo/Echo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := m.encodeRequest(`{"text":"hi"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Field 1 of wire type 2, two bytes long
+	if !bytes.Equal(req, []byte("\x0a\x02hi")) {
+		t.Errorf("Unexpected encoded request %q", req)
+	}
+	resp, err := m.decodeResponse(req)
+	if err != nil || !strings.Contains(string(resp), `"hi"`) {
+		t.Errorf("Unexpected decoded response %s: %v", resp, err)
+	}
+	if req, err = m.encodeRequest(""); err != nil || len(req) != 0 {
+		t.Errorf("Expected empty request, but got %q: %v", req, err)
+	}
+	_, err = m.encodeRequest(`{"unknown":1}`)
+	if _, ok := err.(*invalidGRPCRequestError); !ok {
+		t.Errorf("Expected invalidGRPCRequestError, but got %v", err)
+	}
+}
+
+func TestGRPCStatusErrorCategory(t *testing.T) {
+	expectations := []struct {
+		in  codes.Code
+		out errorCategory
+	}{
+		{codes.DeadlineExceeded, readTimeoutError},
+		{codes.Unavailable, otherError},
+		{codes.NotFound, otherError},
+	}
+	for _, e := range expectations {
+		err := &grpcStatusError{status.New(e.in, "failed")}
+		if c := categorizeError(err); c != e.out {
+			t.Errorf("%v: expected %v, but got %v", e.in, e.out, c)
+		}
+	}
+}
+
+func TestBombardierGRPC(t *testing.T) {
+	addr, called := startEchoServer(t)
+	method, err := readGRPCMethod(writeTestProtoSet(t), "test.Echo/Echo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range []struct {
+		headers *headersList
+		code    codes.Code
+	}{
+		{new(headersList), codes.OK},
+		{&headersList{{"X-Status", "missing"}}, codes.NotFound},
+	} {
+		numReqs := uint64(20)
+		b, err := newBombardier(config{
+			numConns: 2,
+			numReqs:  &numReqs,
+			url:      "http://" + addr,
+			headers:  e.headers,
+			timeout:  defaultTimeout,
+			method:   "GET",
+			body:     `{"text":"hello"}`,
+			format:   knownFormat("plain-text"),
+			grpc:     method,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := new(bytes.Buffer)
+		b.disableOutput()
+		b.redirectOutputTo(out)
+		b.bombard()
+		if got := b.statusCodes[int(e.code)]; got != numReqs {
+			t.Errorf("Expected %v calls with status %v, but got %v",
+				numReqs, e.code, b.statusCodes)
+		}
+		if m := <-called; m != "/test.Echo/Echo" {
+			t.Errorf("Expected call to /test.Echo/Echo, but got %v", m)
+		}
+		info := b.gatherInfo()
+		if e.code == codes.OK {
+			if errs := info.Result.Errors; len(errs) != 0 {
+				t.Errorf("Expected no errors, but got %v", errs)
+			}
+			// Request is sent back, which is 7 bytes long
+			stats := info.Result.ResponseSizeStats([]float64{0.5})
+			if stats == nil || stats.Min != 7 || stats.Max != 7 {
+				t.Errorf("Expected responses of 7 bytes, but got %+v", stats)
+			}
+			if r := info.Result; r.NewConns != 2 ||
+				r.ReusedConns != numReqs-2 {
+				t.Errorf("Expected 2 new and %v reused connections, "+
+					"but got %v and %v", numReqs-2, r.NewConns, r.ReusedConns)
+			}
+		} else if b.errors.sum() != numReqs {
+			t.Errorf("Expected %v errors, but got %v",
+				numReqs, b.errors.sum())
+		}
+		if info.Spec.GRPC == nil ||
+			info.Spec.GRPC.Method != "test.Echo/Echo" {
+			t.Errorf("Unexpected gRPC spec %+v", info.Spec.GRPC)
+		}
+		b.printStats()
+		for _, expected := range []string{
+			"gRPC:      test.Echo/Echo from ",
+			"gRPC codes:\n    " + e.code.String() + " - 20",
+		} {
+			if !strings.Contains(out.String(), expected) {
+				t.Errorf("Expected %q in output, but got %v", expected, out)
+			}
+		}
+	}
+}
+
+func TestGRPCClientDump(t *testing.T) {
+	addr, _ := startEchoServer(t)
+	method, err := readGRPCMethod(writeTestProtoSet(t), "test.Echo/Echo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := method.encodeRequest(`{"text":"dumped"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var read, written int64
+	var opened uint64
+	c, err := newGRPCClient(&clientOpts{
+		maxConns:     1,
+		timeout:      defaultTimeout,
+		headers:      new(headersList),
+		url:          "http://" + addr,
+		bytesRead:    &read,
+		bytesWritten: &written,
+		connsOpened:  &opened,
+	}, method, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	res, dump := c.dump(0)
+	if res.err != nil || res.reused {
+		t.Fatalf("Unexpected result %+v", res)
+	}
+	for _, s := range []string{`"dumped"`, "grpc-status: 0 (OK)"} {
+		if !bytes.Contains(dump, []byte(s)) {
+			t.Errorf("Expected %q in dump, but got %s", s, dump)
+		}
+	}
+	if res = c.do(0); !res.reused {
+		t.Error("Expected the second call to reuse connection")
+	}
+	if read == 0 || written == 0 || opened != 1 {
+		t.Errorf("Expected counted traffic over one connection, but got "+
+			"%v read, %v written, %v opened", read, written, opened)
+	}
+}
+
+func TestGRPCJUnitReport(t *testing.T) {
+	addr, _ := startEchoServer(t)
+	method, err := readGRPCMethod(writeTestProtoSet(t), "test.Echo/Echo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range []struct {
+		headers *headersList
+		failure string
+	}{
+		{new(headersList), ""},
+		{&headersList{{"X-Status", "missing"}}, "10 call(s) failed"},
+	} {
+		numReqs := uint64(10)
+		b, err := newBombardier(config{
+			numConns: 1,
+			numReqs:  &numReqs,
+			url:      "http://" + addr,
+			headers:  e.headers,
+			timeout:  defaultTimeout,
+			method:   "GET",
+			format:   knownFormat("plain-text"),
+			grpc:     method,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.disableOutput()
+		b.bombard()
+		suite := b.junitReport()
+		if len(suite.Cases) != 1 || suite.Tests != 1 {
+			t.Fatalf("Expected a single case, but got %+v", suite.Cases)
+		}
+		failure := ""
+		if f := suite.Cases[0].Failure; f != nil {
+			failure = f.Message
+		}
+		if failure != e.failure {
+			t.Errorf("Expected failure %q, but got %q", e.failure, failure)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package bombard
+
+import "net/http"
+
+// httpResponseHeaderSize returns the number of bytes status line and
+// headers of the response take up in HTTP/1.1, which is what they
+// were read as, unless HPACK compressed them. Headers net/http moves
+// out of the map, such as Transfer-Encoding, aren't counted.
+func httpResponseHeaderSize(resp *http.Response) int64 {
+	// "HTTP/1.1 200 OK\r\n", Status holds the code as well
+	size := len(resp.Proto) + len(" ") + len(resp.Status) + len("\r\n")
+	for key, values := range resp.Header {
+		for _, value := range values {
+			size += len(key) + len(": ") + len(value) + len("\r\n")
+		}
+	}
+	return int64(size + len("\r\n"))
+}
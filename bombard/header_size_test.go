@@ -0,0 +1,29 @@
+package bombard
+
+import (
+	"bufio"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestHTTPResponseHeaderSize(t *testing.T) {
+	for _, raw := range []string{
+		"HTTP/1.1 204 No Content\r\n\r\n",
+		"HTTP/1.1 200 OK\r\n" +
+			"Content-Length: 5\r\n" +
+			"Set-Cookie: a=1\r\n" +
+			"Set-Cookie: b=2\r\n" +
+			"\r\n",
+	} {
+		resp, err := http.ReadResponse(
+			bufio.NewReader(strings.NewReader(raw+"hello")), nil,
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if act := httpResponseHeaderSize(resp); act != int64(len(raw)) {
+			t.Errorf("%q: expected %v, but got %v", raw, len(raw), act)
+		}
+	}
+}
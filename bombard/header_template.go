@@ -0,0 +1,47 @@
+package bombard
+
+import (
+	"math/rand"
+	"strings"
+	"text/template"
+)
+
+type headerTemplate struct {
+	key string
+	// tmpls holds a template for every connection
+	tmpls []*template.Template
+}
+
+// headerTemplates are headers with values rendered anew for every
+// request.
+type headerTemplates []headerTemplate
+
+func newHeaderTemplates(
+	h headersList, rngs []*rand.Rand,
+) (headerTemplates, error) {
+	hts := make(headerTemplates, len(h))
+	for i, header := range h {
+		tmpls, err := parseConnTemplates(header.key, header.value, rngs)
+		if err != nil {
+			return nil, err
+		}
+		hts[i] = headerTemplate{header.key, tmpls}
+	}
+	return hts, nil
+}
+
+// render calls set with the key and rendered value of every header.
+func (hts headerTemplates) render(
+	data templateData, set func(key, value string),
+) error {
+	var sb strings.Builder
+	value := data.value()
+	for _, ht := range hts {
+		sb.Reset()
+		if err := ht.tmpls[data.ConnID].Execute(&sb, value); err != nil {
+			return err
+		}
+		set(ht.key, sb.String())
+	}
+	return nil
+}
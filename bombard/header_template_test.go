@@ -0,0 +1,41 @@
+package bombard
+
+import (
+	"testing"
+)
+
+func TestHeaderTemplatesRender(t *testing.T) {
+	hts, err := newHeaderTemplates(headersList{
+		{"Authorization", "Bearer token-{{ .RequestNum }}"},
+		{"X-Conn", "{{ .ConnID }}"},
+	}, newConnRNGs(42, 8))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rendered := map[string]string{}
+	err = hts.render(templateData{RequestNum: 3, ConnID: 7},
+		func(key, value string) {
+			rendered[key] = value
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := map[string]string{
+		"Authorization": "Bearer token-3",
+		"X-Conn":        "7",
+	}
+	for k, v := range exp {
+		if rendered[k] != v {
+			t.Errorf("%v: expected %q, but got %q", k, v, rendered[k])
+		}
+	}
+}
+
+func TestHeaderTemplatesParseError(t *testing.T) {
+	_, err := newHeaderTemplates(
+		headersList{{"X-Bad", "{{ .ConnID "}}, newConnRNGs(42, 1),
+	)
+	if err == nil {
+		t.Error("invalid template parsed successfully")
+	}
+}
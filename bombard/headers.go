@@ -0,0 +1,133 @@
+package bombard
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+type header struct {
+	key, value string
+}
+
+type headersList []header
+
+func (h *headersList) String() string {
+	return fmt.Sprint(*h)
+}
+
+func (h *headersList) IsCumulative() bool {
+	return true
+}
+
+func (h *headersList) Set(value string) error {
+	parsed, err := parseHeader(value)
+	if err != nil {
+		return err
+	}
+	*h = append(*h, parsed)
+	return nil
+}
+
+func parseHeader(value string) (header, error) {
+	res := strings.SplitN(value, ":", 2)
+	if len(res) != 2 {
+		return header{}, errInvalidHeaderFormat
+	}
+	return header{
+		res[0], strings.Trim(res[1], " "),
+	}, nil
+}
+
+type invalidHeadersFileError struct {
+	path string
+	line int
+	err  error
+}
+
+func (i *invalidHeadersFileError) Error() string {
+	return fmt.Sprintf("Invalid headers file %v, line %v: %v",
+		i.path, i.line, i.err)
+}
+
+// readHeadersFile reads headers from "Key: Value" lines of the file,
+// empty lines and comments, starting with '#', are skipped.
+func readHeadersFile(path string) (headersList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var headers headersList
+	s := bufio.NewScanner(f)
+	for line := 1; s.Scan(); line++ {
+		text := strings.TrimSpace(s.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		h, err := parseHeader(text)
+		if err != nil {
+			return nil, &invalidHeadersFileError{path, line, err}
+		}
+		headers = append(headers, h)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return headers, nil
+}
+
+// mergeHeaders returns headers from the file followed by the given
+// ones, which replace headers from the file with the same keys.
+func mergeHeaders(fromFile, given headersList) headersList {
+	merged := make(headersList, 0, len(fromFile)+len(given))
+	for _, fh := range fromFile {
+		overridden := false
+		for _, h := range given {
+			if strings.EqualFold(fh.key, h.key) {
+				overridden = true
+				break
+			}
+		}
+		if !overridden {
+			merged = append(merged, fh)
+		}
+	}
+	return append(merged, given...)
+}
+
+// randomHeader is a header that is only sent with some requests.
+type randomHeader struct {
+	header
+	// probability of the header being sent with a request
+	probability float64
+}
+
+type randomHeadersList []randomHeader
+
+func (r *randomHeadersList) String() string {
+	return fmt.Sprint(*r)
+}
+
+func (r *randomHeadersList) IsCumulative() bool {
+	return true
+}
+
+func (r *randomHeadersList) Set(value string) error {
+	res := strings.SplitN(value, ":", 2)
+	if len(res) != 2 {
+		return errInvalidRandomHeaderFormat
+	}
+	p, err := strconv.ParseFloat(res[0], 64)
+	if err != nil || p <= 0 || p > 1 {
+		return errInvalidRandomHeaderFormat
+	}
+	h, err := parseHeader(res[1])
+	if err != nil {
+		return errInvalidRandomHeaderFormat
+	}
+	*r = append(*r, randomHeader{h, p})
+	return nil
+}
@@ -0,0 +1,156 @@
+package bombard
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestHeadersToStringConversion(t *testing.T) {
+	expectations := []struct {
+		in  headersList
+		out string
+	}{
+		{
+			[]header{},
+			"[]",
+		},
+		{
+			[]header{
+				{"Key1", "Value1"},
+				{"Key2", "Value2"}},
+			"[{Key1 Value1} {Key2 Value2}]",
+		},
+	}
+	for _, e := range expectations {
+		actual := e.in.String()
+		expected := e.out
+		if expected != actual {
+			t.Errorf("Expected \"%v\", but got \"%v\"", expected, actual)
+		}
+	}
+}
+
+func TestShouldErrorOnInvalidFormat(t *testing.T) {
+	h := new(headersList)
+	if err := h.Set("Yaba daba do"); err == nil {
+		t.Error("Should fail on strings without colon")
+	}
+}
+
+func TestShouldProperlyAddValidHeaders(t *testing.T) {
+	h := new(headersList)
+	for _, hs := range []string{"Key1: Value1", "Key2: Value2"} {
+		if err := h.Set(hs); err != nil {
+			t.Error(err)
+		}
+	}
+	e := []header{{"Key1", "Value1"}, {"Key2", "Value2"}}
+	for i, v := range *h {
+		if e[i] != v {
+			t.Fail()
+		}
+	}
+}
+
+func TestShouldTrimHeaderValues(t *testing.T) {
+	h := new(headersList)
+	if err := h.Set("Key:   Value   "); err != nil {
+		t.Error(err)
+	}
+	if (*h)[0].key != "Key" || (*h)[0].value != "Value" {
+		t.Fail()
+	}
+}
+
+func TestRandomHeadersListParsing(t *testing.T) {
+	expectations := []struct {
+		in  string
+		out randomHeader
+		err error
+	}{
+		{"0.3:X-Debug: 1", randomHeader{header{"X-Debug", "1"}, 0.3}, nil},
+		{"1:Key: a:b", randomHeader{header{"Key", "a:b"}, 1}, nil},
+		{"X-Debug: 1", randomHeader{}, errInvalidRandomHeaderFormat},
+		{"0:X-Debug: 1", randomHeader{}, errInvalidRandomHeaderFormat},
+		{"1.5:X-Debug: 1", randomHeader{}, errInvalidRandomHeaderFormat},
+		{"0.5:X-Debug", randomHeader{}, errInvalidRandomHeaderFormat},
+	}
+	for _, e := range expectations {
+		r := new(randomHeadersList)
+		err := r.Set(e.in)
+		if err != e.err {
+			t.Errorf("%q: expected error %v, but got %v", e.in, e.err, err)
+			continue
+		}
+		if err == nil && (*r)[0] != e.out {
+			t.Errorf("%q: expected %+v, but got %+v", e.in, e.out, (*r)[0])
+		}
+	}
+}
+
+func writeHeadersFile(t *testing.T, content string) string {
+	f, err := ioutil.TempFile("", "bombardier-headers-*.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err = f.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+	return f.Name()
+}
+
+func TestReadHeadersFile(t *testing.T) {
+	path := writeHeadersFile(t, "# Common headers\n"+
+		"Accept: application/json\n"+
+		"\n"+
+		"  X-Request-Source:  bench  \r\n"+
+		"Cookie: a=b; c=d\n")
+	defer os.Remove(path)
+	headers, err := readHeadersFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := headersList{
+		{"Accept", "application/json"},
+		{"X-Request-Source", "bench"},
+		{"Cookie", "a=b; c=d"},
+	}
+	if !reflect.DeepEqual(headers, exp) {
+		t.Errorf("Expected %v, but got %v", exp, headers)
+	}
+}
+
+func TestReadHeadersFileInvalidLine(t *testing.T) {
+	path := writeHeadersFile(t, "Accept: */*\nno colon here\n")
+	defer os.Remove(path)
+	_, err := readHeadersFile(path)
+	e, ok := err.(*invalidHeadersFileError)
+	if !ok || e.line != 2 || e.err != errInvalidHeaderFormat {
+		t.Errorf("Expected error on line 2, but got %v", err)
+	}
+	if _, err = readHeadersFile(path + ".missing"); !os.IsNotExist(err) {
+		t.Errorf("Expected missing file error, but got %v", err)
+	}
+}
+
+func TestMergeHeaders(t *testing.T) {
+	fromFile := headersList{
+		{"Accept", "*/*"},
+		{"X-Source", "file"},
+	}
+	given := headersList{
+		{"x-source", "flag"},
+		{"X-Extra", "1"},
+	}
+	exp := headersList{
+		{"Accept", "*/*"},
+		{"x-source", "flag"},
+		{"X-Extra", "1"},
+	}
+	if act := mergeHeaders(fromFile, given); !reflect.DeepEqual(act, exp) {
+		t.Errorf("Expected %v, but got %v", exp, act)
+	}
+}
@@ -0,0 +1,40 @@
+package bombard
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/kostyay/bombardier/internal"
+)
+
+// tick passes the snapshot of results, once elapsed time has passed
+// since the test began, to the hook observing them.
+func (b *bombardier) tick(elapsed time.Duration) {
+	s := b.snapshot(elapsed)
+	completed := s.Completed()
+	// Counters might have been reset after warmup
+	if completed < b.tickCompleted || elapsed < b.tickElapsed {
+		b.tickCompleted, b.tickElapsed = 0, 0
+	}
+	if d := elapsed - b.tickElapsed; d > 0 {
+		s.Rps = float64(completed-b.tickCompleted) / d.Seconds()
+	}
+	b.tickCompleted, b.tickElapsed = completed, elapsed
+	(*b.conf.onTick)(s)
+}
+
+func (b *bombardier) snapshot(elapsed time.Duration) internal.Snapshot {
+	return internal.Snapshot{
+		Elapsed:      elapsed,
+		InFlight:     b.inFlight.current(),
+		Req1XX:       atomic.LoadUint64(&b.req1xx),
+		Req2XX:       atomic.LoadUint64(&b.req2xx),
+		Req3XX:       atomic.LoadUint64(&b.req3xx),
+		Req4XX:       atomic.LoadUint64(&b.req4xx),
+		Req5XX:       atomic.LoadUint64(&b.req5xx),
+		Others:       atomic.LoadUint64(&b.others),
+		Errors:       b.errors.sum(),
+		BytesRead:    atomic.LoadInt64(&b.bytesRead),
+		BytesWritten: atomic.LoadInt64(&b.bytesWritten),
+	}
+}
@@ -0,0 +1,92 @@
+package bombard
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kostyay/bombardier/internal"
+)
+
+func TestBombardierHooks(t *testing.T) {
+	testAllClients(t, testBombardierHooks)
+}
+
+func testBombardierHooks(clientType clientTyp, t *testing.T) {
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			time.Sleep(5 * time.Millisecond)
+			if r.URL.Path == "/fail" {
+				rw.WriteHeader(http.StatusInternalServerError)
+			}
+		}),
+	)
+	defer s.Close()
+	var (
+		mu        sync.Mutex
+		statuses  = make(map[int]uint64)
+		latencies []time.Duration
+		snapshots []internal.Snapshot
+	)
+	onRequest := internal.RequestHook(
+		func(latency time.Duration, status int, err error) {
+			mu.Lock()
+			statuses[status]++
+			latencies = append(latencies, latency)
+			mu.Unlock()
+		},
+	)
+	onTick := internal.TickHook(func(s internal.Snapshot) {
+		snapshots = append(snapshots, s)
+	})
+	numReqs := uint64(40)
+	urls := []string{s.URL, s.URL + "/fail"}
+	b, e := newBombardier(config{
+		numConns:   2,
+		numReqs:    &numReqs,
+		url:        urls[0],
+		urls:       &urls,
+		headers:    new(headersList),
+		timeout:    defaultTimeout,
+		method:     "GET",
+		clientType: clientType,
+		format:     knownFormat("plain-text"),
+		onRequest:  &onRequest,
+		onTick:     &onTick,
+	})
+	if e != nil {
+		t.Error(e)
+		return
+	}
+	b.inFlight.interval = 10 * time.Millisecond
+	b.disableOutput()
+	b.bombard()
+	if statuses[200] != 20 || statuses[500] != 20 {
+		t.Errorf("Expected 20 successes and 20 failures, but got %v",
+			statuses)
+	}
+	for _, l := range latencies {
+		if l < 5*time.Millisecond {
+			t.Errorf("Expected latency of at least 5ms, but got %v", l)
+			break
+		}
+	}
+	if len(snapshots) < 2 {
+		t.Fatalf("Expected a few ticks, but got %+v", snapshots)
+	}
+	var prev time.Duration
+	for _, s := range snapshots {
+		if s.Elapsed <= prev || s.Rps < 0 {
+			t.Errorf("Unexpected snapshots: %+v", snapshots)
+			break
+		}
+		prev = s.Elapsed
+	}
+	last := snapshots[len(snapshots)-1]
+	if last.Completed() != numReqs || last.Req2XX != 20 ||
+		last.Req5XX != 20 || last.InFlight != 0 {
+		t.Errorf("Unexpected final snapshot: %+v", last)
+	}
+}
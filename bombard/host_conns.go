@@ -0,0 +1,138 @@
+package bombard
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/kostyay/bombardier/internal"
+
+	"github.com/valyala/fasthttp"
+)
+
+// hostConns caps the number of simultaneous connections to every host
+// and keeps track of how many of them were open at once. Clients of
+// targets on the same host share connection pool, so that the cap
+// applies to all of them together.
+type hostConns struct {
+	max uint64
+
+	// Pools are only created while clients are made, so they aren't
+	// guarded by mu
+	fasthttpClients map[string]*fasthttp.HostClient
+	// slots limit the number of requests fasthttp clients send to
+	// every host at once, since fasthttp fails requests instead of
+	// waiting for a free connection
+	slots         map[string]chan struct{}
+	httpTransport *http.Transport
+
+	mu         sync.Mutex
+	open, peak map[string]uint64
+}
+
+func newHostConns(max uint64) *hostConns {
+	return &hostConns{
+		max:             max,
+		fasthttpClients: make(map[string]*fasthttp.HostClient),
+		slots:           make(map[string]chan struct{}),
+		open:            make(map[string]uint64),
+		peak:            make(map[string]uint64),
+	}
+}
+
+// fasthttpClient returns the client shared by all targets with the
+// given scheme and address, creating it if there is none yet.
+func (h *hostConns) fasthttpClient(
+	scheme, address string, create func() *fasthttp.HostClient,
+) (*fasthttp.HostClient, chan struct{}) {
+	key := scheme + "://" + address
+	if hc, ok := h.fasthttpClients[key]; ok {
+		return hc, h.slots[key]
+	}
+	hc := create()
+	hc.MaxConns = int(h.max)
+	hc.Dial = h.fasthttpDial(address, hc.Dial)
+	h.fasthttpClients[key] = hc
+	h.slots[key] = make(chan struct{}, h.max)
+	return hc, h.slots[key]
+}
+
+// transport returns the transport shared by all targets, creating it
+// if there is none yet.
+func (h *hostConns) transport(create func() *http.Transport) *http.Transport {
+	if h.httpTransport != nil {
+		return h.httpTransport
+	}
+	tr := create()
+	tr.MaxConnsPerHost = int(h.max)
+	dial := tr.DialContext
+	tr.DialContext = func(
+		ctx context.Context, network, address string,
+	) (net.Conn, error) {
+		conn, err := dial(ctx, network, address)
+		if err != nil {
+			return nil, err
+		}
+		return h.track(address, conn), nil
+	}
+	h.httpTransport = tr
+	return tr
+}
+
+func (h *hostConns) fasthttpDial(
+	address string, dial func(string) (net.Conn, error),
+) func(string) (net.Conn, error) {
+	return func(addr string) (net.Conn, error) {
+		conn, err := dial(addr)
+		if err != nil {
+			return nil, err
+		}
+		return h.track(address, conn), nil
+	}
+}
+
+// track records that conn to address is open until it's closed.
+func (h *hostConns) track(address string, conn net.Conn) net.Conn {
+	h.mu.Lock()
+	h.open[address]++
+	if h.open[address] > h.peak[address] {
+		h.peak[address] = h.open[address]
+	}
+	h.mu.Unlock()
+	return &trackedConn{Conn: conn, closed: func() {
+		h.mu.Lock()
+		h.open[address]--
+		h.mu.Unlock()
+	}}
+}
+
+// peaks returns the highest numbers of connections that were open to
+// every host at once, sorted by host.
+func (h *hostConns) peaks() []internal.HostConns {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	peaks := make([]internal.HostConns, 0, len(h.peak))
+	for address, peak := range h.peak {
+		peaks = append(peaks, internal.HostConns{
+			Host: address, MaxOpen: peak,
+		})
+	}
+	sort.Slice(peaks, func(i, j int) bool {
+		return peaks[i].Host < peaks[j].Host
+	})
+	return peaks
+}
+
+// trackedConn calls closed once it's closed for the first time.
+type trackedConn struct {
+	net.Conn
+	once   sync.Once
+	closed func()
+}
+
+func (tc *trackedConn) Close() error {
+	tc.once.Do(tc.closed)
+	return tc.Conn.Close()
+}
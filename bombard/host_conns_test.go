@@ -0,0 +1,44 @@
+package bombard
+
+import (
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/kostyay/bombardier/internal"
+)
+
+func TestHostConnsPeaks(t *testing.T) {
+	h := newHostConns(2)
+	a1, a2 := net.Pipe()
+	defer a2.Close()
+	b1, b2 := net.Pipe()
+	defer b2.Close()
+	c1, c2 := net.Pipe()
+	defer c2.Close()
+	ca := h.track("b:80", a1)
+	cb := h.track("b:80", b1)
+	if err := ca.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// Closing twice must not be counted twice
+	_ = ca.Close()
+	cc := h.track("b:80", c1)
+	_ = cb.Close()
+	_ = cc.Close()
+	d1, d2 := net.Pipe()
+	defer d2.Close()
+	cd := h.track("a:443", d1)
+	defer cd.Close()
+	exp := []internal.HostConns{
+		{Host: "a:443", MaxOpen: 1},
+		{Host: "b:80", MaxOpen: 2},
+	}
+	if got := h.peaks(); !reflect.DeepEqual(got, exp) {
+		t.Errorf("Expected %+v, but got %+v", exp, got)
+	}
+	if h.open["b:80"] != 0 {
+		t.Errorf("Expected no open connections, but got %v",
+			h.open["b:80"])
+	}
+}
@@ -0,0 +1,58 @@
+package bombard
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/kostyay/bombardier/internal"
+)
+
+// inFlight counts requests that were sent, but haven't completed
+// yet, and samples their number over the course of the test.
+type inFlight struct {
+	cur, max int64
+
+	// interval between samples
+	interval time.Duration
+	// samples are only accessed by the goroutine that takes them,
+	// until the test is over
+	samples []internal.InFlightSample
+}
+
+func (f *inFlight) inc() {
+	n := atomic.AddInt64(&f.cur, 1)
+	for {
+		max := atomic.LoadInt64(&f.max)
+		if n <= max || atomic.CompareAndSwapInt64(&f.max, max, n) {
+			return
+		}
+	}
+}
+
+func (f *inFlight) dec() {
+	atomic.AddInt64(&f.cur, -1)
+}
+
+func (f *inFlight) current() uint64 {
+	return uint64(atomic.LoadInt64(&f.cur))
+}
+
+// maximum returns the highest number of requests in flight at once
+// since the last reset.
+func (f *inFlight) maximum() uint64 {
+	return uint64(atomic.LoadInt64(&f.max))
+}
+
+// resetMax forgets the maximum reached so far, i.e. during warmup.
+func (f *inFlight) resetMax() {
+	atomic.StoreInt64(&f.max, atomic.LoadInt64(&f.cur))
+}
+
+// sample records the number of requests in flight once elapsed time
+// has passed since the test began.
+func (f *inFlight) sample(elapsed time.Duration) {
+	f.samples = append(f.samples, internal.InFlightSample{
+		Elapsed:  elapsed,
+		Requests: f.current(),
+	})
+}
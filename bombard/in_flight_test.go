@@ -0,0 +1,52 @@
+package bombard
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestInFlight(t *testing.T) {
+	f := &inFlight{interval: time.Second}
+	f.inc()
+	f.inc()
+	f.inc()
+	f.dec()
+	f.sample(time.Second)
+	f.dec()
+	f.sample(2 * time.Second)
+	if f.current() != 1 || f.maximum() != 3 {
+		t.Errorf("Expected 1 in flight and 3 at most, but got %v and %v",
+			f.current(), f.maximum())
+	}
+	f.resetMax()
+	if f.maximum() != 1 {
+		t.Errorf("Expected maximum to be reset to 1, but got %v",
+			f.maximum())
+	}
+	if len(f.samples) != 2 ||
+		f.samples[0].Elapsed != time.Second || f.samples[0].Requests != 2 ||
+		f.samples[1].Elapsed != 2*time.Second || f.samples[1].Requests != 1 {
+		t.Errorf("Unexpected samples: %+v", f.samples)
+	}
+}
+
+func TestInFlightConcurrentMax(t *testing.T) {
+	f := &inFlight{interval: time.Second}
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	const n = 32
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			f.inc()
+		}()
+	}
+	close(start)
+	wg.Wait()
+	if f.maximum() != n {
+		t.Errorf("Expected maximum of %v, but got %v", n, f.maximum())
+	}
+}
@@ -0,0 +1,57 @@
+package bombard
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/kostyay/bombardier/internal"
+)
+
+const (
+	// Pushing results is best-effort, so it shouldn't hold up the exit
+	// for long if InfluxDB is unreachable
+	influxPushTimeout = 5 * time.Second
+	// Response bodies are included in errors up to this size
+	maxInfluxErrorBodySize = 512
+)
+
+type influxWriteError struct {
+	code int
+	body string
+}
+
+func (i *influxWriteError) Error() string {
+	return fmt.Sprintf("InfluxDB responded with status code %v: %v",
+		i.code, i.body)
+}
+
+// pushInflux writes results of the test as a single point in line
+// protocol to the configured InfluxDB endpoint.
+func (b *bombardier) pushInflux() error {
+	var tags []internal.Tag
+	if b.conf.tags != nil {
+		tags = *b.conf.tags
+	}
+	line := new(bytes.Buffer)
+	p := internal.NewInfluxPrinter(b.conf.percentilesOrDefault(), tags)
+	if err := p.WriteLine(line, b.gatherInfo(), b.measureBegin); err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: influxPushTimeout}
+	resp, err := client.Post(b.conf.influxURL, "text/plain; charset=utf-8", line)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := ioutil.ReadAll(
+			io.LimitReader(resp.Body, maxInfluxErrorBodySize),
+		)
+		return &influxWriteError{resp.StatusCode, string(body)}
+	}
+	return nil
+}
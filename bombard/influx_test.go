@@ -0,0 +1,96 @@
+package bombard
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBombardierPushesResultsToInflux(t *testing.T) {
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {}),
+	)
+	defer s.Close()
+	lines := make(chan string, 1)
+	influx := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Error(err)
+			}
+			if r.Method != "POST" {
+				t.Errorf("Expected POST request, but got %v", r.Method)
+			}
+			lines <- string(body)
+			w.WriteHeader(http.StatusNoContent)
+		},
+	))
+	defer influx.Close()
+	numReqs := uint64(10)
+	b, e := newBombardier(config{
+		numConns:  1,
+		numReqs:   &numReqs,
+		url:       s.URL,
+		headers:   new(headersList),
+		timeout:   defaultTimeout,
+		method:    "GET",
+		format:    knownFormat("plain-text"),
+		influxURL: influx.URL + "/write?db=bench",
+		tags:      &tagsList{{Key: "env", Value: "staging"}},
+	})
+	if e != nil {
+		t.Fatal(e)
+	}
+	b.disableOutput()
+	b.bombard()
+	if err := b.pushInflux(); err != nil {
+		t.Fatal(err)
+	}
+	line := <-lines
+	if !strings.HasPrefix(line, "bombardier,env=staging ") ||
+		!strings.Contains(line, ",req2xx=10i,") {
+		t.Errorf("Unexpected line: %q", line)
+	}
+}
+
+func TestBombardierInfluxPushFailure(t *testing.T) {
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {}),
+	)
+	defer s.Close()
+	influx := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "database not found", http.StatusNotFound)
+		},
+	))
+	defer influx.Close()
+	numReqs := uint64(1)
+	b, e := newBombardier(config{
+		numConns:  1,
+		numReqs:   &numReqs,
+		url:       s.URL,
+		headers:   new(headersList),
+		timeout:   defaultTimeout,
+		method:    "GET",
+		format:    knownFormat("plain-text"),
+		influxURL: influx.URL,
+	})
+	if e != nil {
+		t.Fatal(e)
+	}
+	b.disableOutput()
+	b.bombard()
+	err := b.pushInflux()
+	if ierr, ok := err.(*influxWriteError); !ok ||
+		ierr.code != http.StatusNotFound ||
+		!strings.Contains(ierr.body, "database not found") {
+		t.Errorf("Expected InfluxDB write error, but got %v", err)
+	}
+
+	influx.Close()
+	if err := b.pushInflux(); err == nil {
+		t.Error("Expected push to unreachable server to fail")
+	}
+}
@@ -0,0 +1,23 @@
+package bombard
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// recordInterArrival records the gap between completion of the request
+// and the one that completed before it on any connection, the first
+// completion only marks the time. Completions racing each other are
+// recorded as simultaneous.
+func (b *bombardier) recordInterArrival(now time.Time) {
+	cur := now.UnixNano()
+	prev := atomic.SwapInt64(&b.lastCompletion, cur)
+	if prev == 0 {
+		return
+	}
+	var gap uint64
+	if cur > prev {
+		gap = uint64((cur - prev) / 1000)
+	}
+	b.interArrivals.Increment(gap)
+}
@@ -0,0 +1,27 @@
+package bombard
+
+import (
+	"testing"
+	"time"
+
+	uhist "github.com/codesenberg/concurrent/uint64/histogram"
+)
+
+func TestRecordInterArrival(t *testing.T) {
+	b := &bombardier{interArrivals: uhist.Default()}
+	start := time.Unix(1000, 0)
+	for _, offset := range []time.Duration{
+		0, time.Millisecond, 3 * time.Millisecond,
+		// Completion that lost the race to the previous one
+		2 * time.Millisecond,
+	} {
+		b.recordInterArrival(start.Add(offset))
+	}
+	expectations := map[uint64]uint64{1000: 1, 2000: 1, 0: 1}
+	for gap, count := range expectations {
+		if act := b.interArrivals.Get(gap); act != count {
+			t.Errorf("Expected %v gap(s) of %vus, but got %v",
+				count, gap, act)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package bombard
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// junitSuite is the root element of JUnit XML report, which CI systems
+// show alongside results of the tests they run.
+type junitSuite struct {
+	XMLName  xml.Name    `xml:"testsuite"`
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Time     string      `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// junitReport turns checks of the test into JUnit test cases: one per
+// latency assertion and one per status class, the latter failing if
+// requests of the class got unexpected status codes. Without expected
+// status codes, only 5xx responses are unexpected. Requests that
+// failed, connection errors included, fail the "others" case. gRPC
+// calls get a single status case instead.
+func (b *bombardier) junitReport() junitSuite {
+	info := b.gatherInfo()
+	suite := junitSuite{
+		Name: "bombardier",
+		Time: strconv.FormatFloat(info.Result.TimeTaken.Seconds(), 'f', 3, 64),
+	}
+	add := func(class, name, failure string) {
+		c := junitCase{Name: name, ClassName: "bombardier." + class}
+		if failure != "" {
+			c.Failure = &junitFailure{Message: failure, Text: failure}
+			suite.Failures++
+		}
+		suite.Cases = append(suite.Cases, c)
+	}
+
+	if b.conf.latencyAssertions != nil {
+		breached := make(map[latencyAssertion]latencyBreach)
+		for _, lb := range b.checkLatencyAssertions() {
+			breached[lb.assertion] = lb
+		}
+		for _, la := range *b.conf.latencyAssertions {
+			failure := ""
+			if lb, ok := breached[la]; ok {
+				failure = lb.String()
+			}
+			pc := strconv.FormatFloat(la.percentile*100, 'f', -1, 64)
+			add("latency", fmt.Sprintf("p%v latency <= %v", pc, la.max),
+				failure)
+		}
+	}
+
+	res := info.Result
+	if info.Spec.GRPC != nil {
+		// Status classes don't apply to gRPC codes, calls fail unless
+		// they end with OK
+		failure := ""
+		failed := res.NumberOfRequests() - res.SuccessfulRequests()
+		if failed > 0 {
+			failure = fmt.Sprintf("%v call(s) failed", failed)
+		}
+		add("status", "gRPC calls", failure)
+		suite.Tests = len(suite.Cases)
+		return suite
+	}
+	expected := make(map[int]bool)
+	for _, code := range info.Spec.ExpectedStatusCodes {
+		expected[code] = true
+	}
+	for class := 1; class <= 5; class++ {
+		failure := ""
+		if len(expected) > 0 {
+			failure = unexpectedCodesFailure(res.StatusCodes, class, expected)
+		} else if class == 5 && res.Req5XX > 0 {
+			failure = fmt.Sprintf("%v response(s) with 5xx status code",
+				res.Req5XX)
+		}
+		add("status", formatStatusClass(class)+" responses", failure)
+	}
+	failure := ""
+	if res.Others > 0 {
+		failure = fmt.Sprintf("%v request(s) failed or got status "+
+			"code outside of 1xx-5xx", res.Others)
+	}
+	add("status", "others", failure)
+
+	suite.Tests = len(suite.Cases)
+	return suite
+}
+
+// unexpectedCodesFailure describes responses of the given status class
+// that got status codes other than expected, if there were any.
+func unexpectedCodesFailure(
+	codes map[int]uint64, class int, expected map[int]bool,
+) string {
+	var (
+		unexpected []int
+		count      uint64
+	)
+	for code, n := range codes {
+		if code/100 == class && !expected[code] {
+			unexpected = append(unexpected, code)
+			count += n
+		}
+	}
+	if count == 0 {
+		return ""
+	}
+	sort.Ints(unexpected)
+	ss := make([]string, len(unexpected))
+	for i, code := range unexpected {
+		ss[i] = strconv.Itoa(code)
+	}
+	return fmt.Sprintf("%v response(s) with unexpected status code(s) %v",
+		count, strings.Join(ss, ", "))
+}
+
+func (b *bombardier) writeJUnit() error {
+	f, err := os.Create(b.conf.junitPath)
+	if err != nil {
+		return err
+	}
+	_, err = f.WriteString(xml.Header)
+	if err == nil {
+		enc := xml.NewEncoder(f)
+		enc.Indent("", "  ")
+		err = enc.Encode(b.junitReport())
+	}
+	if err == nil {
+		_, err = f.WriteString("\n")
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
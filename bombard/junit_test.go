@@ -0,0 +1,134 @@
+package bombard
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriteJUnit(t *testing.T) {
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/missing" {
+				rw.WriteHeader(http.StatusNotFound)
+			}
+		}),
+	)
+	defer s.Close()
+	dir, err := ioutil.TempDir("", "bombardier-junit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	numReqs := uint64(10)
+	b, err := newBombardier(config{
+		numConns:         1,
+		numReqs:          &numReqs,
+		url:              s.URL,
+		urls:             &[]string{s.URL, s.URL + "/missing"},
+		headers:          new(headersList),
+		timeout:          defaultTimeout,
+		method:           "GET",
+		clientType:       fhttp,
+		format:           knownFormat("plain-text"),
+		expectedStatuses: &[]int{200},
+		latencyAssertions: &latencyAssertionsList{
+			{percentile: 0.5, max: time.Minute},
+			{percentile: 0.99, max: time.Nanosecond},
+		},
+		junitPath: filepath.Join(dir, "report.xml"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.disableOutput()
+	b.bombard()
+	if err = b.writeJUnit(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(b.conf.junitPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var suite junitSuite
+	if err = xml.Unmarshal(data, &suite); err != nil {
+		t.Fatalf("%v in %q", err, data)
+	}
+	if suite.Tests != 8 || len(suite.Cases) != 8 || suite.Failures != 2 {
+		t.Fatalf("Expected 8 test cases with 2 failures, but got %+v", suite)
+	}
+	failures := map[string]string{
+		"p99 latency <= 1ns": "",
+		"4xx responses":      "5 response(s) with unexpected status code(s) 404",
+	}
+	for _, c := range suite.Cases {
+		exp, shouldFail := failures[c.Name]
+		switch {
+		case shouldFail && c.Failure == nil:
+			t.Errorf("Expected %q to fail", c.Name)
+		case !shouldFail && c.Failure != nil:
+			t.Errorf("Expected %q to pass, but got %q", c.Name, c.Failure.Message)
+		case exp != "" && c.Failure.Message != exp:
+			t.Errorf("Expected %q, but got %q", exp, c.Failure.Message)
+		}
+	}
+}
+
+func TestJUnitReportFailsOn5xxByDefault(t *testing.T) {
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+		}),
+	)
+	defer s.Close()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Nothing listens on the address anymore, connections are refused
+	refused := "http://" + l.Addr().String()
+	l.Close()
+	numReqs := uint64(10)
+	b, err := newBombardier(config{
+		numConns:   1,
+		numReqs:    &numReqs,
+		url:        s.URL,
+		urls:       &[]string{s.URL, refused},
+		headers:    new(headersList),
+		timeout:    defaultTimeout,
+		method:     "GET",
+		clientType: nhttp1,
+		format:     knownFormat("plain-text"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.disableOutput()
+	b.bombard()
+	suite := b.junitReport()
+	if suite.Tests != 6 || suite.Failures != 2 {
+		t.Fatalf("Expected 6 test cases with 2 failures, but got %+v", suite)
+	}
+	failures := map[string]string{
+		"5xx responses": "5 response(s) with 5xx status code",
+		"others": "5 request(s) failed or got status code outside " +
+			"of 1xx-5xx",
+	}
+	for _, c := range suite.Cases {
+		exp, shouldFail := failures[c.Name]
+		switch {
+		case shouldFail && c.Failure == nil:
+			t.Errorf("Expected %q to fail", c.Name)
+		case !shouldFail && c.Failure != nil:
+			t.Errorf("Expected %q to pass, but got %q", c.Name, c.Failure.Message)
+		case shouldFail && c.Failure.Message != exp:
+			t.Errorf("Expected %q, but got %q", exp, c.Failure.Message)
+		}
+	}
+}
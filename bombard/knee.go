@@ -0,0 +1,66 @@
+package bombard
+
+import (
+	"time"
+
+	uhist "github.com/codesenberg/concurrent/uint64/histogram"
+	"github.com/kostyay/bombardier/internal"
+)
+
+// detectKnee checks latencies of requests completed during every
+// step of the step load profile and records the first step at which
+// they exceeded the knee latency. finished is closed once it's done.
+func (b *bombardier) detectKnee(finished chan<- struct{}) {
+	defer close(finished)
+	profile := b.conf.loadProfile.(*stepProfile)
+	ticker := time.NewTicker(profile.interval)
+	defer ticker.Stop()
+	done := b.barrier.done()
+	// Histogram counts as of the end of the previous step
+	prev := make(map[uint64]uint64)
+	for step := uint64(1); ; step++ {
+		select {
+		case <-ticker.C:
+			if b.checkKneeStep(profile, step, prev) {
+				return
+			}
+		case <-done:
+			// Last step might have been cut short, but its requests
+			// are still worth checking
+			b.workers.Wait()
+			b.checkKneeStep(profile, step, prev)
+			return
+		}
+	}
+}
+
+// checkKneeStep tells whether latency exceeded the knee latency
+// during the step, in which case it's recorded as the knee.
+func (b *bombardier) checkKneeStep(
+	profile *stepProfile, step uint64, prev map[uint64]uint64,
+) bool {
+	delta := uhist.Default()
+	b.latencies.VisitAll(func(f uint64, c uint64) bool {
+		if c > prev[f] {
+			delta.Add(f, c-prev[f])
+		}
+		prev[f] = c
+		return true
+	})
+	knee := b.conf.kneeLatency
+	res := internal.Results{Latencies: delta}
+	stats := res.LatenciesStats([]float64{knee.percentile})
+	if stats == nil {
+		return false
+	}
+	latency := stats.Percentiles[knee.percentile]
+	if time.Duration(latency)*time.Microsecond <= knee.max {
+		return false
+	}
+	b.knee = &internal.KneeStep{
+		Step:    step,
+		Rate:    profile.rate(time.Duration(step-1) * profile.interval),
+		Latency: latency,
+	}
+	return true
+}
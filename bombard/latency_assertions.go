@@ -0,0 +1,103 @@
+package bombard
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// latencyAssertion requires that specified percentile of latencies
+// doesn't exceed the limit.
+type latencyAssertion struct {
+	// percentile is in [0, 1] range
+	percentile float64
+	max        time.Duration
+}
+
+func (la latencyAssertion) String() string {
+	return "p" + strconv.FormatFloat(la.percentile*100, 'f', -1, 64) +
+		":" + la.max.String()
+}
+
+type latencyAssertionsList []latencyAssertion
+
+func (l *latencyAssertionsList) String() string {
+	return fmt.Sprint(*l)
+}
+
+func (l *latencyAssertionsList) IsCumulative() bool {
+	return true
+}
+
+func (l *latencyAssertionsList) Set(value string) error {
+	res := strings.SplitN(value, ":", 2)
+	if len(res) != 2 {
+		return errInvalidLatencyAssertionFormat
+	}
+	pc, err := strconv.ParseFloat(strings.TrimPrefix(res[0], "p"), 64)
+	if err != nil || pc < 0 || pc > 100 {
+		return errInvalidLatencyAssertionFormat
+	}
+	max, err := time.ParseDuration(res[1])
+	if err != nil || max < 0 {
+		return errInvalidLatencyAssertionFormat
+	}
+	*l = append(*l, latencyAssertion{
+		percentile: pc / 100,
+		max:        max,
+	})
+	return nil
+}
+
+// latencyBreach describes an assertion that didn't hold.
+type latencyBreach struct {
+	assertion latencyAssertion
+	// actual is nil if there weren't enough data to compute the
+	// percentile
+	actual *time.Duration
+}
+
+func (lb latencyBreach) String() string {
+	la := lb.assertion
+	pc := strconv.FormatFloat(la.percentile*100, 'f', -1, 64)
+	if lb.actual == nil {
+		return fmt.Sprintf(
+			"p%v latency is unknown (not enough data), expected <= %v",
+			pc, la.max)
+	}
+	return fmt.Sprintf("p%v latency is %v, expected <= %v",
+		pc, *lb.actual, la.max)
+}
+
+func (b *bombardier) checkLatencyAssertions() []latencyBreach {
+	if b.conf.latencyAssertions == nil {
+		return nil
+	}
+	assertions := *b.conf.latencyAssertions
+	percentiles := make([]float64, len(assertions))
+	for i, la := range assertions {
+		percentiles[i] = la.percentile
+	}
+	stats := b.gatherInfo().Result.LatenciesStats(percentiles)
+	var breaches []latencyBreach
+	for _, la := range assertions {
+		if stats == nil {
+			breaches = append(breaches, latencyBreach{la, nil})
+			continue
+		}
+		actual := time.Duration(stats.Percentiles[la.percentile]) *
+			time.Microsecond
+		if actual > la.max {
+			breaches = append(breaches, latencyBreach{la, &actual})
+		}
+	}
+	return breaches
+}
+
+func (b *bombardier) printLatencyBreaches(breaches []latencyBreach) {
+	fmt.Fprintln(b.errOut, "Latency assertions failed:")
+	for _, lb := range breaches {
+		fmt.Fprintf(b.errOut, "  %v\n", lb)
+	}
+}
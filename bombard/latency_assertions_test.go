@@ -0,0 +1,72 @@
+package bombard
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	fhist "github.com/codesenberg/concurrent/float64/histogram"
+	uhist "github.com/codesenberg/concurrent/uint64/histogram"
+)
+
+func TestLatencyAssertionsListSet(t *testing.T) {
+	expectations := []struct {
+		in  string
+		out latencyAssertion
+		err error
+	}{
+		{"p99:50ms", latencyAssertion{0.99, 50 * time.Millisecond}, nil},
+		{"50:1s", latencyAssertion{0.5, time.Second}, nil},
+		{"p99.5:100us", latencyAssertion{0.995, 100 * time.Microsecond}, nil},
+		{"p99", latencyAssertion{}, errInvalidLatencyAssertionFormat},
+		{"pxx:1s", latencyAssertion{}, errInvalidLatencyAssertionFormat},
+		{"p101:1s", latencyAssertion{}, errInvalidLatencyAssertionFormat},
+		{"p99:1", latencyAssertion{}, errInvalidLatencyAssertionFormat},
+	}
+	for _, e := range expectations {
+		l := new(latencyAssertionsList)
+		err := l.Set(e.in)
+		if err != e.err {
+			t.Errorf("%q: expected error %v, but got %v", e.in, e.err, err)
+			continue
+		}
+		if err == nil && !reflect.DeepEqual((*l)[0], e.out) {
+			t.Errorf("%q: expected %v, but got %v", e.in, e.out, (*l)[0])
+		}
+	}
+}
+
+func TestCheckLatencyAssertions(t *testing.T) {
+	b := &bombardier{
+		latencies: uhist.Default(),
+		requests:  fhist.Default(),
+		errors:    newErrorMap(),
+		conf: config{
+			headers: new(headersList),
+			latencyAssertions: &latencyAssertionsList{
+				{0.5, 2 * time.Millisecond},
+				{0.99, 2 * time.Millisecond},
+			},
+		},
+	}
+	if breaches := b.checkLatencyAssertions(); len(breaches) != 2 ||
+		breaches[0].actual != nil || breaches[1].actual != nil {
+		t.Errorf("Expected assertions to fail without data, but got %v",
+			breaches)
+	}
+	for i := 0; i < 98; i++ {
+		b.latencies.Increment(1000)
+	}
+	b.latencies.Add(5000, 2)
+	breaches := b.checkLatencyAssertions()
+	if len(breaches) != 1 {
+		t.Fatalf("Expected exactly one breach, but got %v", breaches)
+	}
+	if exp, act := 5*time.Millisecond, *breaches[0].actual; exp != act {
+		t.Errorf("Expected actual latency to be %v, but got %v", exp, act)
+	}
+	exp := "p99 latency is 5ms, expected <= 2ms"
+	if act := breaches[0].String(); act != exp {
+		t.Errorf("Expected %q, but got %q", exp, act)
+	}
+}
@@ -0,0 +1,73 @@
+package bombard
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// maxLatencyDigits is the highest number of significant digits
+// latencies can be recorded with, it's more than enough for any
+// latency measured in microseconds.
+const maxLatencyDigits = 12
+
+// latencyResolution rounds latencies before they are recorded, so
+// that histograms hold fewer distinct values, and clamps the ones
+// above the ceiling.
+type latencyResolution struct {
+	// digits is the number of significant digits latencies are
+	// rounded to, zero means they are recorded exactly
+	digits uint64
+	// exact is the lowest latency that doesn't fit into digits
+	exact uint64
+	// ceiling is the highest latency (in microseconds) that is
+	// tracked, zero means there is no limit
+	ceiling uint64
+	// clamped is the number of latencies that exceeded the ceiling
+	clamped uint64
+}
+
+func newLatencyResolution(
+	digits uint64, ceiling time.Duration,
+) *latencyResolution {
+	if digits == 0 && ceiling == 0 {
+		return nil
+	}
+	return &latencyResolution{
+		digits:  digits,
+		exact:   pow10(digits),
+		ceiling: uint64(ceiling / time.Microsecond),
+	}
+}
+
+// apply returns latency (in microseconds) the way it's recorded.
+func (r *latencyResolution) apply(us uint64) uint64 {
+	if r.ceiling > 0 && us > r.ceiling {
+		atomic.AddUint64(&r.clamped, 1)
+		us = r.ceiling
+	}
+	if r.digits == 0 {
+		return us
+	}
+	div := uint64(1)
+	for v := us; v >= r.exact; v /= 10 {
+		div *= 10
+	}
+	us = (us + div/2) / div * div
+	if r.ceiling > 0 && us > r.ceiling {
+		// Rounded up past the ceiling
+		us = r.ceiling
+	}
+	return us
+}
+
+func (r *latencyResolution) clampedCount() uint64 {
+	return atomic.LoadUint64(&r.clamped)
+}
+
+func pow10(n uint64) uint64 {
+	p := uint64(1)
+	for i := uint64(0); i < n; i++ {
+		p *= 10
+	}
+	return p
+}
@@ -0,0 +1,94 @@
+package bombard
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLatencyResolution(t *testing.T) {
+	if r := newLatencyResolution(0, 0); r != nil {
+		t.Errorf("Expected latencies to be recorded exactly, but got %+v", r)
+	}
+	expectations := []struct {
+		digits  uint64
+		ceiling time.Duration
+		in, out uint64
+		clamped bool
+	}{
+		{2, 0, 7, 7, false},
+		{2, 0, 42, 42, false},
+		{2, 0, 123, 120, false},
+		{2, 0, 125, 130, false},
+		{2, 0, 9960, 10000, false},
+		{3, 0, 1234567, 1230000, false},
+		{0, time.Millisecond, 999, 999, false},
+		{0, time.Millisecond, 1000, 1000, false},
+		{0, time.Millisecond, 1001, 1000, true},
+		{1, time.Millisecond, 960, 1000, false},
+		{1, 1500 * time.Microsecond, 1400, 1000, false},
+		{1, 1500 * time.Microsecond, 1490, 1000, false},
+		{1, 1500 * time.Microsecond, 1499, 1000, false},
+		{2, 1500 * time.Microsecond, 1498, 1500, false},
+		{1, 1500 * time.Microsecond, 2000, 1500, true},
+	}
+	for _, e := range expectations {
+		r := newLatencyResolution(e.digits, e.ceiling)
+		if out := r.apply(e.in); out != e.out {
+			t.Errorf("Expected %v with %v digit(s) and ceiling %v "+
+				"to be recorded as %v, but got %v",
+				e.in, e.digits, e.ceiling, e.out, out)
+		}
+		if clamped := r.clampedCount() == 1; clamped != e.clamped {
+			t.Errorf("Expected clamping of %v at %v to be %v",
+				e.in, e.ceiling, e.clamped)
+		}
+	}
+}
+
+func TestBombardierClampsLatencies(t *testing.T) {
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/slow" {
+				time.Sleep(5 * time.Millisecond)
+			}
+		}),
+	)
+	defer s.Close()
+	numReqs := uint64(20)
+	urls := []string{s.URL, s.URL + "/slow"}
+	b, e := newBombardier(config{
+		numConns:       2,
+		numReqs:        &numReqs,
+		url:            urls[0],
+		urls:           &urls,
+		headers:        new(headersList),
+		timeout:        defaultTimeout,
+		method:         "GET",
+		format:         knownFormat("plain-text"),
+		latencyDigits:  1,
+		latencyCeiling: 2 * time.Millisecond,
+	})
+	if e != nil {
+		t.Fatal(e)
+	}
+	b.disableOutput()
+	b.bombard()
+	res := b.gatherInfo().Result
+	if res.ClampedLatencies != 10 {
+		t.Errorf("Expected 10 clamped latencies, but got %v",
+			res.ClampedLatencies)
+	}
+	b.latencies.VisitAll(func(us uint64, c uint64) bool {
+		// With a single significant digit the rest are zeros
+		rounded := true
+		for v := us; v >= 10; v /= 10 {
+			rounded = rounded && v%10 == 0
+		}
+		if us > 2000 || !rounded {
+			t.Errorf("Unexpected latency recorded: %vus", us)
+		}
+		return true
+	})
+}
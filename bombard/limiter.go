@@ -1,4 +1,4 @@
-package main
+package bombard
 
 import (
 	"math"
@@ -19,6 +19,13 @@ type limiter interface {
 	pace(<-chan struct{}) token
 }
 
+// scheduler is a limiter that also tells when request was meant to
+// be sent, which may be long before it actually is.
+type scheduler interface {
+	limiter
+	schedule(<-chan struct{}) (token, time.Time)
+}
+
 type nooplimiter struct{}
 
 func (n *nooplimiter) pace(<-chan struct{}) token {
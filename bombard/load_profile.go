@@ -0,0 +1,227 @@
+package bombard
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// loadProfile describes how rate of requests changes over the
+// course of the test.
+type loadProfile interface {
+	// rate returns the number of requests per second that should be
+	// sent once elapsed time has passed since the start of the test.
+	rate(elapsed time.Duration) float64
+	String() string
+}
+
+type unknownLoadProfileError struct {
+	name string
+}
+
+func (u *unknownLoadProfileError) Error() string {
+	return fmt.Sprintf("Unknown load profile: %q", u.name)
+}
+
+// parseLoadProfile parses profile in the form of
+// <name>:<key>=<value>,<key>=<value>,...
+func parseLoadProfile(spec string) (loadProfile, error) {
+	parts := strings.SplitN(spec, ":", 2)
+	params := map[string]string{}
+	if len(parts) == 2 {
+		for _, kv := range strings.Split(parts[1], ",") {
+			res := strings.SplitN(kv, "=", 2)
+			if len(res) != 2 {
+				return nil, errInvalidLoadProfileFormat
+			}
+			params[res[0]] = res[1]
+		}
+	}
+	switch parts[0] {
+	case "sine":
+		return parseSineProfile(params)
+	case "step":
+		return parseStepProfile(params)
+	default:
+		return nil, &unknownLoadProfileError{parts[0]}
+	}
+}
+
+// sineProfile makes the rate oscillate between min and max, starting
+// at min and reaching max halfway through every period.
+type sineProfile struct {
+	min, max uint64
+	period   time.Duration
+}
+
+func parseSineProfile(params map[string]string) (*sineProfile, error) {
+	if len(params) != 3 {
+		return nil, errInvalidSineProfile
+	}
+	min, err := strconv.ParseUint(params["min"], decBase, 64)
+	if err != nil {
+		return nil, errInvalidSineProfile
+	}
+	max, err := strconv.ParseUint(params["max"], decBase, 64)
+	if err != nil {
+		return nil, errInvalidSineProfile
+	}
+	period, err := time.ParseDuration(params["period"])
+	if err != nil || min < 1 || max < min || period <= 0 {
+		return nil, errInvalidSineProfile
+	}
+	return &sineProfile{min, max, period}, nil
+}
+
+func (s *sineProfile) rate(elapsed time.Duration) float64 {
+	phase := 2 * math.Pi * float64(elapsed) / float64(s.period)
+	amplitude := float64(s.max-s.min) / 2
+	return float64(s.min) + amplitude*(1-math.Cos(phase))
+}
+
+func (s *sineProfile) String() string {
+	return fmt.Sprintf("sine:min=%v,max=%v,period=%v", s.min, s.max, s.period)
+}
+
+// stepProfile starts at the given rate and increases it by step
+// every interval.
+type stepProfile struct {
+	start, step uint64
+	interval    time.Duration
+}
+
+func parseStepProfile(params map[string]string) (*stepProfile, error) {
+	if len(params) != 3 {
+		return nil, errInvalidStepProfile
+	}
+	start, err := strconv.ParseUint(params["start"], decBase, 64)
+	if err != nil {
+		return nil, errInvalidStepProfile
+	}
+	step, err := strconv.ParseUint(params["step"], decBase, 64)
+	if err != nil {
+		return nil, errInvalidStepProfile
+	}
+	interval, err := time.ParseDuration(params["interval"])
+	if err != nil || start < 1 || step < 1 || interval <= 0 {
+		return nil, errInvalidStepProfile
+	}
+	return &stepProfile{start, step, interval}, nil
+}
+
+func (s *stepProfile) rate(elapsed time.Duration) float64 {
+	return float64(s.start + s.step*uint64(elapsed/s.interval))
+}
+
+func (s *stepProfile) String() string {
+	return fmt.Sprintf("step:start=%v,step=%v,interval=%v",
+		s.start, s.step, s.interval)
+}
+
+// profileLimiter spaces requests according to the rate that load
+// profile gives at the time each of them is scheduled for, intervals
+// between them follow the arrival process.
+type profileLimiter struct {
+	profile   loadProfile
+	arrival   arrival
+	rng       *rand.Rand
+	timerPool *sync.Pool
+
+	// keepSchedule tells whether requests that fell behind the
+	// schedule keep their place in it
+	keepSchedule bool
+	// pausedFor returns total time requests weren't generated for,
+	// the schedule is shifted by it. nil if it's never paused.
+	pausedFor func() time.Duration
+
+	mu    sync.Mutex
+	start time.Time
+	// next is the time the next request is allowed at
+	next   time.Time
+	paused time.Duration
+}
+
+// newProfileLimiter creates limiter that draws intervals between
+// requests from rng, it must not be used concurrently elsewhere.
+func newProfileLimiter(
+	profile loadProfile, arr arrival, rng *rand.Rand,
+) *profileLimiter {
+	return &profileLimiter{
+		profile: profile,
+		arrival: arr,
+		rng:     rng,
+		timerPool: &sync.Pool{
+			New: func() interface{} {
+				return time.NewTimer(math.MaxInt64)
+			},
+		},
+	}
+}
+
+// newScheduler creates limiter that keeps to the schedule even if
+// requests fall behind it, they are sent right away then. Time spent
+// paused, according to pausedFor, shifts the schedule.
+func newScheduler(
+	profile loadProfile, arr arrival, rng *rand.Rand,
+	pausedFor func() time.Duration,
+) *profileLimiter {
+	p := newProfileLimiter(profile, arr, rng)
+	p.keepSchedule = true
+	p.pausedFor = pausedFor
+	return p
+}
+
+func (p *profileLimiter) pace(done <-chan struct{}) token {
+	res, _ := p.schedule(done)
+	return res
+}
+
+// schedule waits until the next request is allowed and returns the
+// time it was scheduled for.
+func (p *profileLimiter) schedule(
+	done <-chan struct{},
+) (res token, at time.Time) {
+	p.mu.Lock()
+	now := time.Now()
+	if p.start.IsZero() {
+		p.start, p.next = now, now
+		if p.pausedFor != nil {
+			p.paused = p.pausedFor()
+		}
+	}
+	if p.pausedFor != nil {
+		if paused := p.pausedFor(); paused > p.paused {
+			p.next = p.next.Add(paused - p.paused)
+			p.paused = paused
+		}
+	}
+	// Unless the schedule is kept, requests that fell behind it
+	// aren't made up for with a burst, same as with the bucket
+	// limiter
+	if !p.keepSchedule && p.next.Before(now) {
+		p.next = now
+	}
+	at = p.next
+	rate := p.profile.rate(at.Sub(p.start))
+	p.next = at.Add(p.arrival.interval(rate, p.rng))
+	p.mu.Unlock()
+
+	wd := at.Sub(now)
+	if wd <= 0 {
+		return cont, at
+	}
+	timer := p.timerPool.Get().(*time.Timer)
+	timer.Reset(wd)
+	select {
+	case <-timer.C:
+		res = cont
+	case <-done:
+		res = brk
+	}
+	p.timerPool.Put(timer)
+	return
+}
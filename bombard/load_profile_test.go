@@ -0,0 +1,199 @@
+package bombard
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseLoadProfile(t *testing.T) {
+	expectations := []struct {
+		in  string
+		out loadProfile
+		err error
+	}{
+		{
+			"sine:min=100,max=1000,period=60s",
+			&sineProfile{100, 1000, time.Minute},
+			nil,
+		},
+		{
+			"sine:period=2s,max=5,min=5",
+			&sineProfile{5, 5, 2 * time.Second},
+			nil,
+		},
+		{"sine", nil, errInvalidSineProfile},
+		{"sine:min=100,max=1000", nil, errInvalidSineProfile},
+		{"sine:min=100,max=1000,period=60s,x=1", nil, errInvalidSineProfile},
+		{"sine:min=0,max=1000,period=60s", nil, errInvalidSineProfile},
+		{"sine:min=100,max=10,period=60s", nil, errInvalidSineProfile},
+		{"sine:min=100,max=1000,period=0s", nil, errInvalidSineProfile},
+		{"sine:min=a,max=1000,period=60s", nil, errInvalidSineProfile},
+		{"sine:min=100,max,period=60s", nil, errInvalidLoadProfileFormat},
+		{
+			"step:start=100,step=50,interval=10s",
+			&stepProfile{100, 50, 10 * time.Second},
+			nil,
+		},
+		{"step:start=100,step=50", nil, errInvalidStepProfile},
+		{"step:start=0,step=50,interval=10s", nil, errInvalidStepProfile},
+		{"step:start=100,step=0,interval=10s", nil, errInvalidStepProfile},
+		{"step:start=100,step=50,interval=-1s", nil, errInvalidStepProfile},
+		{
+			"square:min=1,max=2,period=1s", nil,
+			&unknownLoadProfileError{"square"},
+		},
+	}
+	for _, e := range expectations {
+		p, err := parseLoadProfile(e.in)
+		if !reflect.DeepEqual(err, e.err) {
+			t.Errorf("%q: expected error %v, but got %v", e.in, e.err, err)
+			continue
+		}
+		if err == nil && !reflect.DeepEqual(p, e.out) {
+			t.Errorf("%q: expected %v, but got %v", e.in, e.out, p)
+		}
+	}
+}
+
+func TestSineProfileRate(t *testing.T) {
+	p := &sineProfile{100, 1000, 4 * time.Second}
+	expectations := []struct {
+		elapsed time.Duration
+		rate    float64
+	}{
+		{0, 100},
+		{time.Second, 550},
+		{2 * time.Second, 1000},
+		{3 * time.Second, 550},
+		{4 * time.Second, 100},
+		{6 * time.Second, 1000},
+	}
+	for _, e := range expectations {
+		if act := p.rate(e.elapsed); math.Abs(act-e.rate) > 1e-9 {
+			t.Errorf("%v: expected rate %v, but got %v", e.elapsed, e.rate, act)
+		}
+	}
+	if s := p.String(); s != "sine:min=100,max=1000,period=4s" {
+		t.Errorf("Unexpected string representation: %q", s)
+	}
+}
+
+func TestStepProfileRate(t *testing.T) {
+	p := &stepProfile{100, 50, 10 * time.Second}
+	expectations := []struct {
+		elapsed time.Duration
+		rate    float64
+	}{
+		{0, 100},
+		{9 * time.Second, 100},
+		{10 * time.Second, 150},
+		{25 * time.Second, 200},
+	}
+	for _, e := range expectations {
+		if act := p.rate(e.elapsed); act != e.rate {
+			t.Errorf("%v: expected rate %v, but got %v", e.elapsed, e.rate, act)
+		}
+	}
+	if s := p.String(); s != "step:start=100,step=50,interval=10s" {
+		t.Errorf("Unexpected string representation: %q", s)
+	}
+}
+
+// switchProfile switches from one rate to another at the given time.
+type switchProfile struct {
+	before, after float64
+	at            time.Duration
+}
+
+func (s *switchProfile) rate(elapsed time.Duration) float64 {
+	if elapsed < s.at {
+		return s.before
+	}
+	return s.after
+}
+
+func (s *switchProfile) String() string {
+	return "switch"
+}
+
+func TestProfileLimiterFollowsRate(t *testing.T) {
+	const step = 500 * time.Millisecond
+	lim := newProfileLimiter(
+		&switchProfile{100, 1000, step}, uniformArrival, nil,
+	)
+	done := make(chan struct{})
+	before, after := uint64(0), uint64(0)
+	start := time.Now()
+	waitChan := make(chan struct{})
+	go func() {
+		defer close(waitChan)
+		for lim.pace(done) == cont {
+			if time.Since(start) < step {
+				before++
+			} else {
+				after++
+			}
+		}
+	}()
+	time.Sleep(2 * step)
+	close(done)
+	select {
+	case <-waitChan:
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("failed to complete")
+	}
+	for _, e := range []struct {
+		act uint64
+		exp float64
+	}{
+		{before, 100 * step.Seconds()},
+		{after, 1000 * step.Seconds()},
+	} {
+		if float64(e.act) < e.exp*0.9 || float64(e.act) > e.exp*1.1+5 {
+			t.Errorf("Expected about %v requests, but got %v", e.exp, e.act)
+		}
+	}
+}
+
+func TestSchedulerKeepsSchedule(t *testing.T) {
+	var paused time.Duration
+	lim := newScheduler(&constantProfile{100}, uniformArrival, nil,
+		func() time.Duration { return paused })
+	done := make(chan struct{})
+	res, first := lim.schedule(done)
+	if res != cont {
+		t.Fatal("Expected the first request to be let through")
+	}
+	time.Sleep(50 * time.Millisecond)
+	// Requests behind the schedule are let through right away, but
+	// keep the times they were scheduled for
+	for i := 1; i <= 3; i++ {
+		start := time.Now()
+		res, at := lim.schedule(done)
+		if res != cont {
+			t.Fatal("Expected request to be let through")
+		}
+		exp := first.Add(time.Duration(i) * 10 * time.Millisecond)
+		if !at.Equal(exp) {
+			t.Errorf("Expected request %v to be scheduled for %v, "+
+				"but got %v", i, exp, at)
+		}
+		if waited := time.Since(start); waited > 5*time.Millisecond {
+			t.Errorf("Expected request %v not to wait, but it waited %v",
+				i, waited)
+		}
+	}
+	// Time spent paused shifts the schedule
+	paused = time.Hour
+	close(done)
+	res, at := lim.schedule(done)
+	if exp := first.Add(time.Hour + 40*time.Millisecond); !at.Equal(exp) {
+		t.Errorf("Expected request to be scheduled for %v, but got %v",
+			exp, at)
+	}
+	if res != brk {
+		t.Error("Expected waiting to be interrupted")
+	}
+}
@@ -0,0 +1,196 @@
+package bombard
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/alecthomas/kingpin"
+	"github.com/kostyay/bombardier/internal"
+)
+
+const mergeCommand = "merge"
+
+var savedClientTypes = map[string]internal.ClientType{
+	"fasthttp":    internal.FastHTTP,
+	"net/http.v1": internal.NetHTTP1,
+	"net/http.v2": internal.NetHTTP2,
+}
+
+// mergeResults combines results of runs that were performed at the
+// same time, i.e. by different machines, into results of a single
+// test. Counters and histograms are summed, time taken is the longest
+// one, while the rest of specification is taken from the first run.
+func mergeResults(runs []*savedResults) internal.TestInfo {
+	first := runs[0]
+	info := internal.TestInfo{
+		Spec: internal.Spec{
+			Method:      first.Spec.Method,
+			URL:         first.Spec.URL,
+			Timeout:     secondsToDuration(first.Spec.TimeoutSeconds),
+			ClientType:  savedClientTypes[first.Spec.Client],
+			Percentiles: savedPercentiles(first),
+			Seed:        first.Spec.Seed,
+		},
+	}
+	switch first.Spec.TestType {
+	case "timed":
+		info.Spec.TestType = internal.ByTime
+	case "timed-or-number-of-requests":
+		info.Spec.TestType = internal.ByTimeOrNumberOfReqs
+	default:
+		info.Spec.TestType = internal.ByNumberOfReqs
+	}
+
+	latencies := make(internal.Uint64Histogram)
+	requests := make(internal.Float64Histogram)
+	errors := make(map[string]uint64)
+	categories := make(map[string]uint64)
+	addresses := make(map[string]struct{})
+	s, r := &info.Spec, &info.Result
+	for _, run := range runs {
+		res := run.Result
+		s.NumberOfConnections += run.Spec.NumberOfConnections
+		s.NumberOfRequests += run.Spec.NumberOfRequests
+		duration := secondsToDuration(run.Spec.TestDurationSeconds)
+		if duration > s.TestDuration {
+			s.TestDuration = duration
+		}
+
+		r.BytesRead += res.BytesRead
+		r.BytesWritten += res.BytesWritten
+		r.HeaderBytesRead += res.HeaderBytesRead
+		r.BodyBytesRead += res.BodyBytesRead
+		timeTaken := secondsToDuration(res.TimeTakenSeconds)
+		if timeTaken > r.TimeTaken {
+			r.TimeTaken = timeTaken
+		}
+		r.Req1XX += res.Req1XX
+		r.Req2XX += res.Req2XX
+		r.Req3XX += res.Req3XX
+		r.Req4XX += res.Req4XX
+		r.Req5XX += res.Req5XX
+		r.Others += res.Others
+		r.Retries += res.Retries
+		r.NewConns += res.NewConns
+		r.ReusedConns += res.ReusedConns
+		r.ConnsOpened += res.ConnsOpened
+		r.Aborted = r.Aborted || res.Aborted
+		r.BytesCapReached = r.BytesCapReached || res.BytesCapReached
+		r.Interrupted = r.Interrupted || res.Interrupted
+		r.InterpolatePercentiles = r.InterpolatePercentiles ||
+			res.Interpolated
+		for _, e := range res.Errors {
+			errors[e.Description] += e.Count
+		}
+		for c, n := range res.ErrorsByCategory {
+			categories[c] += n
+		}
+		for _, a := range res.RemoteAddresses {
+			addresses[a] = struct{}{}
+		}
+		latencies.Merge(res.Histograms.Latency)
+		requests.Merge(res.Histograms.Requests)
+	}
+	r.Latencies = latencies
+	r.Requests = requests
+
+	byFreq := make(errorsByFrequency, 0, len(errors))
+	for e, count := range errors {
+		byFreq = append(byFreq, &errorWithCount{e, count})
+	}
+	// Errors that occurred equally often are ordered by description
+	sort.Slice(byFreq, func(i, j int) bool {
+		return byFreq[i].error < byFreq[j].error
+	})
+	sort.Stable(byFreq)
+	for _, ewc := range byFreq {
+		r.Errors = append(r.Errors,
+			internal.ErrorWithCount{Error: ewc.error, Count: ewc.count})
+	}
+	if len(categories) > 0 {
+		r.ErrorsByCategory = categories
+	}
+	for a := range addresses {
+		r.RemoteAddresses = append(r.RemoteAddresses, a)
+	}
+	sort.Strings(r.RemoteAddresses)
+	return info
+}
+
+// savedPercentiles recovers percentiles results were printed with from
+// the keys of request rate percentiles, which are always printed.
+func savedPercentiles(run *savedResults) []float64 {
+	pcs := make([]float64, 0, len(run.Result.Rps.Percentiles))
+	for k := range run.Result.Rps.Percentiles {
+		// Shifting the point keeps i.e. 99.9 from becoming
+		// 0.9990000000000001
+		if pc, err := strconv.ParseFloat(k+"e-2", 64); err == nil {
+			pcs = append(pcs, pc)
+		}
+	}
+	if len(pcs) == 0 {
+		return defaultPercentiles
+	}
+	sort.Float64s(pcs)
+	return pcs
+}
+
+func secondsToDuration(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// runMerge merges results saved by several runs and prints them, given
+// the arguments that follow merge command.
+func runMerge(args []string, out io.Writer) error {
+	var (
+		paths         []string
+		formatSpec    string
+		withLatencies bool
+	)
+	app := kingpin.New("bombardier "+mergeCommand,
+		"Merge results of runs performed at the same time, "+
+			"saved in JSON format")
+	app.Flag("latencies", "Print latency statistics").
+		Short('l').
+		BoolVar(&withLatencies)
+	app.Flag("format", "Which format to use to output merged results, "+
+		"accepts the same <spec> as --format of the test").
+		Short('o').
+		PlaceHolder("<spec>").
+		Default("plain-text").
+		StringVar(&formatSpec)
+	app.Arg("results", "Results of the runs").
+		Required().
+		StringsVar(&paths)
+	if _, err := app.Parse(args); err != nil {
+		return err
+	}
+	f := formatFromString(formatSpec)
+	if f == nil {
+		return fmt.Errorf(
+			"unknown format or invalid format spec %q", formatSpec,
+		)
+	}
+	runs := make([]*savedResults, 0, len(paths))
+	for _, path := range paths {
+		run, err := readResults(path)
+		if err != nil {
+			return err
+		}
+		if run.Result.Histograms == nil {
+			return fmt.Errorf("%v: %v", path, errNoHistograms)
+		}
+		runs = append(runs, run)
+	}
+	b := &bombardier{
+		conf: config{format: f, printLatencies: withLatencies},
+	}
+	t, err := b.prepareTemplate()
+	if err != nil {
+		return err
+	}
+	return t.Execute(out, mergeResults(runs))
+}
@@ -0,0 +1,187 @@
+package bombard
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kostyay/bombardier/internal"
+)
+
+func TestMergeResults(t *testing.T) {
+	first := writeResults(t, `{"schemaVersion":1,"spec":{
+		"numberOfConnections":10,"testType":"timed","testDurationSeconds":10,
+		"method":"GET","url":"http://localhost","timeoutSeconds":2,
+		"client":"net/http.v1","seed":42},
+		"result":{"bytesRead":1000,"bytesWritten":100,"timeTakenSeconds":10,
+		"headerBytesRead":400,"bodyBytesRead":500,
+		"req2xx":5,"req5xx":1,"newConns":2,"reusedConns":4,
+		"interpolatePercentiles":true,"remoteAddresses":["10.0.0.2:80"],
+		"errors":[{"description":"b","count":1},{"description":"a","count":3}],
+		"errorsByCategory":{"other":4},
+		"rps":{"percentiles":{"50":1,"99.9":2}},
+		"histograms":{"latency":[[10,3],[20,3]],"requests":[[0.5,10]]}}}`)
+	second := writeResults(t, `{"schemaVersion":1,"spec":{
+		"numberOfConnections":20,"testType":"timed","testDurationSeconds":10,
+		"method":"POST","url":"http://other","timeoutSeconds":1,
+		"client":"fasthttp","seed":7},
+		"result":{"bytesRead":3000,"bytesWritten":300,"timeTakenSeconds":10.5,
+		"headerBytesRead":1200,"bodyBytesRead":1500,
+		"req2xx":6,"req4xx":2,"newConns":3,"reusedConns":5,"interrupted":true,
+		"remoteAddresses":["10.0.0.1:80","10.0.0.2:80"],
+		"errors":[{"description":"b","count":2}],
+		"errorsByCategory":{"other":2},
+		"histograms":{"latency":[[20,2],[40,1]],"requests":[[0.5,5],[1.5,5]]}}}`)
+	var runs []*savedResults
+	for _, path := range []string{first, second} {
+		r, err := readResults(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		runs = append(runs, r)
+	}
+	info := mergeResults(runs)
+
+	s := info.Spec
+	if s.NumberOfConnections != 30 || !s.IsTimedTest() ||
+		s.TestDuration != 10*time.Second {
+		t.Errorf("Unexpected spec: %+v", s)
+	}
+	if s.Method != "GET" || s.URL != "http://localhost" ||
+		s.Timeout != 2*time.Second || !s.IsNetHTTPV1() || s.Seed != 42 {
+		t.Errorf("Expected spec of the first run, but got %+v", s)
+	}
+	if exp := []float64{0.5, 0.999}; !reflect.DeepEqual(s.Percentiles, exp) {
+		t.Errorf("Expected percentiles %v, but got %v", exp, s.Percentiles)
+	}
+
+	r := info.Result
+	if r.BytesRead != 4000 || r.BytesWritten != 400 ||
+		r.HeaderBytesRead != 1600 || r.BodyBytesRead != 2000 ||
+		r.TimeTaken != 10500*time.Millisecond {
+		t.Errorf("Unexpected bytes or time taken: %+v", r)
+	}
+	if r.Req2XX != 11 || r.Req4XX != 2 || r.Req5XX != 1 ||
+		r.NewConns != 5 || r.ReusedConns != 9 || !r.Interrupted ||
+		!r.InterpolatePercentiles {
+		t.Errorf("Unexpected counters: %+v", r)
+	}
+	expErrors := []internal.ErrorWithCount{
+		{Error: "a", Count: 3}, {Error: "b", Count: 3},
+	}
+	if !reflect.DeepEqual(r.Errors, expErrors) {
+		t.Errorf("Expected errors %v, but got %v", expErrors, r.Errors)
+	}
+	if exp := map[string]uint64{"other": 6}; !reflect.DeepEqual(
+		r.ErrorsByCategory, exp) {
+		t.Errorf("Expected %v, but got %v", exp, r.ErrorsByCategory)
+	}
+	expAddrs := []string{"10.0.0.1:80", "10.0.0.2:80"}
+	if !reflect.DeepEqual(r.RemoteAddresses, expAddrs) {
+		t.Errorf("Expected %v, but got %v", expAddrs, r.RemoteAddresses)
+	}
+	expLatencies := internal.Uint64Histogram{10: 3, 20: 5, 40: 1}
+	if !reflect.DeepEqual(r.Latencies, expLatencies) {
+		t.Errorf("Expected latencies %v, but got %v",
+			expLatencies, r.Latencies)
+	}
+	stats := r.LatenciesStats(s.Percentiles)
+	if stats.Min != 10 || stats.Max != 40 || stats.Percentiles[0.5] != 20 {
+		t.Errorf("Unexpected latency stats: %+v", stats)
+	}
+	if rs := r.RequestsStats(s.Percentiles); rs.Mean != 0.75 || rs.Max != 1.5 {
+		t.Errorf("Unexpected request stats: %+v", rs)
+	}
+}
+
+func TestRunMerge(t *testing.T) {
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			_, _ = rw.Write([]byte("hello"))
+		}),
+	)
+	defer s.Close()
+	var paths []string
+	for i := 0; i < 2; i++ {
+		numReqs := uint64(20)
+		b, e := newBombardier(config{
+			numConns:       2,
+			numReqs:        &numReqs,
+			url:            s.URL,
+			headers:        new(headersList),
+			timeout:        defaultTimeout,
+			method:         "GET",
+			format:         knownFormat("json"),
+			printLatencies: true,
+		})
+		if e != nil {
+			t.Fatal(e)
+		}
+		out := new(bytes.Buffer)
+		b.redirectOutputTo(out)
+		b.bombard()
+		out.Reset()
+		b.printStats()
+		paths = append(paths, writeResults(t, out.String()))
+	}
+
+	out := new(bytes.Buffer)
+	err := runMerge(append([]string{"-o", "json"}, paths...), out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var merged struct {
+		Spec struct {
+			NumberOfRequests uint64
+		}
+		Result struct {
+			Req2XX     uint64
+			Histograms struct {
+				Latency [][2]uint64
+			}
+		}
+	}
+	if err := json.Unmarshal(out.Bytes(), &merged); err != nil {
+		t.Fatalf("%v: %v", err, out)
+	}
+	if merged.Spec.NumberOfRequests != 40 || merged.Result.Req2XX != 40 {
+		t.Errorf("Expected 40 requests, but got %+v", merged)
+	}
+	count := uint64(0)
+	for _, p := range merged.Result.Histograms.Latency {
+		count += p[1]
+	}
+	if count != 40 {
+		t.Errorf("Expected 40 latencies, but got %v", count)
+	}
+
+	// Merged results can be merged again
+	path := writeResults(t, out.String())
+	out.Reset()
+	if err := runMerge([]string{"-l", path, paths[0]}, out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "2xx - 60") ||
+		!strings.Contains(out.String(), "Latency Distribution") {
+		t.Errorf("Unexpected output:\n%v", out)
+	}
+}
+
+func TestRunMergeFailures(t *testing.T) {
+	old := writeResults(t, `{"schemaVersion":1,"spec":{},"result":{}}`)
+	for _, args := range [][]string{
+		{},
+		{"-o", "xml", old},
+		{old},
+		{"missing.json"},
+	} {
+		if err := runMerge(args, new(bytes.Buffer)); err == nil {
+			t.Errorf("%v: expected an error", args)
+		}
+	}
+}
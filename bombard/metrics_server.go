@@ -0,0 +1,131 @@
+package bombard
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/kostyay/bombardier/internal"
+)
+
+const metricsShutdownTimeout = 5 * time.Second
+
+// metricsServer exposes live statistics of the running test in
+// Prometheus text exposition format.
+type metricsServer struct {
+	b        *bombardier
+	listener net.Listener
+	server   *http.Server
+}
+
+func newMetricsServer(addr string, b *bombardier) (*metricsServer, error) {
+	// Listen right away, so that problems with address surface
+	// before the test starts.
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	m := &metricsServer{
+		b:        b,
+		listener: ln,
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", m.handle)
+	m.server = &http.Server{Handler: mux}
+	return m, nil
+}
+
+func (m *metricsServer) serve() {
+	err := m.server.Serve(m.listener)
+	if err != nil && err != http.ErrServerClosed {
+		fmt.Fprintln(m.b.errOut, err)
+	}
+}
+
+func (m *metricsServer) shutdown() error {
+	ctx, cancel := context.WithTimeout(
+		context.Background(), metricsShutdownTimeout,
+	)
+	defer cancel()
+	return m.server.Shutdown(ctx)
+}
+
+func (m *metricsServer) handle(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	m.write(w)
+}
+
+func (m *metricsServer) write(w io.Writer) {
+	b := m.b
+	codes := []struct {
+		class   string
+		counter *uint64
+	}{
+		{"1xx", &b.req1xx},
+		{"2xx", &b.req2xx},
+		{"3xx", &b.req3xx},
+		{"4xx", &b.req4xx},
+		{"5xx", &b.req5xx},
+		{"other", &b.others},
+	}
+	total := uint64(0)
+	counts := make([]uint64, len(codes))
+	for i, c := range codes {
+		counts[i] = atomic.LoadUint64(c.counter)
+		total += counts[i]
+	}
+
+	writeMetricHeader(w, "bombardier_requests_total", "counter",
+		"Total number of completed requests.")
+	fmt.Fprintf(w, "bombardier_requests_total %v\n", total)
+
+	writeMetricHeader(w, "bombardier_responses_total", "counter",
+		"Number of responses by status code class.")
+	for i, c := range codes {
+		fmt.Fprintf(w, "bombardier_responses_total{code=%q} %v\n",
+			c.class, counts[i])
+	}
+
+	writeMetricHeader(w, "bombardier_bytes_read_total", "counter",
+		"Total number of bytes read.")
+	fmt.Fprintf(w, "bombardier_bytes_read_total %v\n",
+		atomic.LoadInt64(&b.bytesRead))
+	writeMetricHeader(w, "bombardier_bytes_written_total", "counter",
+		"Total number of bytes written.")
+	fmt.Fprintf(w, "bombardier_bytes_written_total %v\n",
+		atomic.LoadInt64(&b.bytesWritten))
+
+	writeMetricHeader(w, "bombardier_latency_microseconds", "summary",
+		"Latency of requests.")
+	sum, count := uint64(0), uint64(0)
+	b.latencies.VisitAll(func(f uint64, c uint64) bool {
+		sum += f * c
+		count += c
+		return true
+	})
+	res := internal.Results{Latencies: b.latencies}
+	if stats := res.LatenciesStats(b.conf.percentilesOrDefault()); stats != nil {
+		pcs := make([]float64, 0, len(stats.Percentiles))
+		for pc := range stats.Percentiles {
+			pcs = append(pcs, pc)
+		}
+		sort.Float64s(pcs)
+		for _, pc := range pcs {
+			fmt.Fprintf(w,
+				"bombardier_latency_microseconds{quantile=%q} %v\n",
+				strconv.FormatFloat(pc, 'f', -1, 64), stats.Percentiles[pc])
+		}
+	}
+	fmt.Fprintf(w, "bombardier_latency_microseconds_sum %v\n", sum)
+	fmt.Fprintf(w, "bombardier_latency_microseconds_count %v\n", count)
+}
+
+func writeMetricHeader(w io.Writer, name, typ, help string) {
+	fmt.Fprintf(w, "# HELP %v %v\n# TYPE %v %v\n", name, help, name, typ)
+}
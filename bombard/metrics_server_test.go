@@ -0,0 +1,66 @@
+package bombard
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	uhist "github.com/codesenberg/concurrent/uint64/histogram"
+)
+
+func TestMetricsServer(t *testing.T) {
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {}),
+	)
+	defer s.Close()
+	numReqs := uint64(10)
+	b, e := newBombardier(config{
+		numConns:       defaultNumberOfConns,
+		numReqs:        &numReqs,
+		url:            s.URL,
+		headers:        new(headersList),
+		timeout:        defaultTimeout,
+		method:         "GET",
+		format:         knownFormat("plain-text"),
+		prometheusAddr: "127.0.0.1:0",
+	})
+	if e != nil {
+		t.Fatal(e)
+	}
+	b.disableOutput()
+	addr := b.metrics.listener.Addr().String()
+	b.bombard()
+
+	sb := new(strings.Builder)
+	b.metrics.write(sb)
+	for _, exp := range []string{
+		"bombardier_requests_total 10\n",
+		"bombardier_responses_total{code=\"2xx\"} 10\n",
+		"bombardier_latency_microseconds_count 10\n",
+	} {
+		if !strings.Contains(sb.String(), exp) {
+			t.Errorf("Expected metrics to contain %q, but got:\n%v",
+				exp, sb.String())
+		}
+	}
+
+	// Server must be shut down after the test is finished
+	if _, err := http.Get("http://" + addr + "/metrics"); err == nil {
+		t.Error("metrics server is still running")
+	}
+}
+
+func TestMetricsServerHandler(t *testing.T) {
+	m := &metricsServer{b: &bombardier{latencies: uhist.Default()}}
+	rec := httptest.NewRecorder()
+	m.handle(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, err := ioutil.ReadAll(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(body), "bombardier_requests_total 0\n") {
+		t.Errorf("unexpected metrics:\n%s", body)
+	}
+}
@@ -0,0 +1,141 @@
+package bombard
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// Tokens are refreshed once this fraction of their lifetime
+	// has passed
+	tokenRefreshRatio = 0.9
+	// Failed refreshes are retried after this interval, while the
+	// current token is still in use
+	tokenRetryInterval = time.Second
+	// Response bodies are included in errors up to this size
+	maxTokenErrorBodySize = 512
+)
+
+type oauth2Config struct {
+	tokenURL, clientID, clientSecret, scope string
+}
+
+type tokenRequestError struct {
+	code int
+	body string
+}
+
+func (t *tokenRequestError) Error() string {
+	return fmt.Sprintf("Token endpoint responded with status code %v: %v",
+		t.code, t.body)
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// tokenSource obtains bearer tokens using OAuth2 client credentials
+// grant and keeps the most recent one.
+type tokenSource struct {
+	conf   oauth2Config
+	client *http.Client
+
+	// authorization holds the value of Authorization header
+	authorization atomic.Value
+	// expiresIn is the lifetime of the last fetched token, zero if
+	// it doesn't expire
+	expiresIn time.Duration
+}
+
+func newTokenSource(
+	conf oauth2Config, tlsConfig *tls.Config, timeout time.Duration,
+) *tokenSource {
+	return &tokenSource{
+		conf: conf,
+		client: &http.Client{
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+			Timeout:   timeout,
+		},
+	}
+}
+
+// fetch requests a new token and makes it current.
+func (ts *tokenSource) fetch() error {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if ts.conf.scope != "" {
+		form.Set("scope", ts.conf.scope)
+	}
+	req, err := http.NewRequest(
+		"POST", ts.conf.tokenURL, strings.NewReader(form.Encode()),
+	)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(
+		url.QueryEscape(ts.conf.clientID),
+		url.QueryEscape(ts.conf.clientSecret),
+	)
+	resp, err := ts.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := ioutil.ReadAll(
+			io.LimitReader(resp.Body, maxTokenErrorBodySize),
+		)
+		return &tokenRequestError{resp.StatusCode, string(body)}
+	}
+	var tr tokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tr); err != nil {
+		return fmt.Errorf("Invalid token response: %v", err)
+	}
+	if tr.AccessToken == "" {
+		return errNoAccessToken
+	}
+	ts.authorization.Store("Bearer " + tr.AccessToken)
+	ts.expiresIn = time.Duration(tr.ExpiresIn) * time.Second
+	return nil
+}
+
+func (ts *tokenSource) header() string {
+	return ts.authorization.Load().(string)
+}
+
+// refreshUntil keeps refreshing the token before it expires until
+// done is closed. Errors are written to errs, the current token stays
+// in use until a refresh succeeds.
+func (ts *tokenSource) refreshUntil(done <-chan struct{}, errs io.Writer) {
+	if ts.expiresIn <= 0 {
+		return
+	}
+	interval := time.Duration(float64(ts.expiresIn) * tokenRefreshRatio)
+	for {
+		timer := time.NewTimer(interval)
+		select {
+		case <-done:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		if err := ts.fetch(); err != nil {
+			fmt.Fprintln(errs, err)
+			interval = tokenRetryInterval
+			continue
+		}
+		if ts.expiresIn <= 0 {
+			return
+		}
+		interval = time.Duration(float64(ts.expiresIn) * tokenRefreshRatio)
+	}
+}
@@ -0,0 +1,117 @@
+package bombard
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTokenServer(
+	t *testing.T, expiresIn int, issued *uint64,
+) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			id, secret, ok := r.BasicAuth()
+			if !ok || id != "client" || secret != "s3cr3t" {
+				w.WriteHeader(http.StatusUnauthorized)
+				fmt.Fprint(w, `{"error":"invalid_client"}`)
+				return
+			}
+			if err := r.ParseForm(); err != nil {
+				t.Error(err)
+			}
+			if gt := r.PostForm.Get("grant_type"); gt != "client_credentials" {
+				t.Errorf("Unexpected grant type: %q", gt)
+			}
+			if scope := r.PostForm.Get("scope"); scope != "read write" {
+				t.Errorf("Unexpected scope: %q", scope)
+			}
+			n := atomic.AddUint64(issued, 1)
+			fmt.Fprintf(w, `{"access_token":"token-%v",`+
+				`"token_type":"bearer","expires_in":%v}`, n, expiresIn)
+		},
+	))
+}
+
+func TestTokenSourceFetch(t *testing.T) {
+	issued := uint64(0)
+	s := newTokenServer(t, 3600, &issued)
+	defer s.Close()
+	ts := newTokenSource(oauth2Config{
+		tokenURL:     s.URL,
+		clientID:     "client",
+		clientSecret: "s3cr3t",
+		scope:        "read write",
+	}, nil, defaultTimeout)
+	if err := ts.fetch(); err != nil {
+		t.Fatal(err)
+	}
+	if act := ts.header(); act != "Bearer token-1" {
+		t.Errorf("Expected %q, but got %q", "Bearer token-1", act)
+	}
+	if ts.expiresIn != time.Hour {
+		t.Errorf("Expected token to expire in %v, but got %v",
+			time.Hour, ts.expiresIn)
+	}
+}
+
+func TestTokenSourceFetchFailures(t *testing.T) {
+	issued := uint64(0)
+	s := newTokenServer(t, 3600, &issued)
+	defer s.Close()
+	ts := newTokenSource(oauth2Config{
+		tokenURL:     s.URL,
+		clientID:     "client",
+		clientSecret: "wrong",
+	}, nil, defaultTimeout)
+	err := ts.fetch()
+	if terr, ok := err.(*tokenRequestError); !ok ||
+		terr.code != http.StatusUnauthorized {
+		t.Errorf("Expected token request error, but got %v", err)
+	}
+
+	empty := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"token_type":"bearer"}`)
+		},
+	))
+	defer empty.Close()
+	ts = newTokenSource(oauth2Config{
+		tokenURL: empty.URL,
+		clientID: "client",
+	}, nil, defaultTimeout)
+	if err := ts.fetch(); err != errNoAccessToken {
+		t.Errorf("Expected %v, but got %v", errNoAccessToken, err)
+	}
+}
+
+func TestTokenSourceRefresh(t *testing.T) {
+	issued := uint64(0)
+	s := newTokenServer(t, 1, &issued)
+	defer s.Close()
+	ts := newTokenSource(oauth2Config{
+		tokenURL:     s.URL,
+		clientID:     "client",
+		clientSecret: "s3cr3t",
+		scope:        "read write",
+	}, nil, defaultTimeout)
+	if err := ts.fetch(); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		ts.refreshUntil(done, ioutil.Discard)
+		close(stopped)
+	}()
+	time.Sleep(1500 * time.Millisecond)
+	close(done)
+	<-stopped
+	if act := ts.header(); act != "Bearer token-2" {
+		t.Errorf("Expected %q, but got %q", "Bearer token-2", act)
+	}
+}
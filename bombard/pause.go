@@ -0,0 +1,71 @@
+package bombard
+
+import (
+	"sync"
+	"time"
+)
+
+// pauser suspends request generation on demand and keeps track of
+// how long it was suspended for. Connections stay open while paused.
+type pauser struct {
+	mu sync.Mutex
+	// resumed is closed once generation is resumed, nil unless paused
+	resumed chan struct{}
+	since   time.Time
+	total   time.Duration
+}
+
+func (p *pauser) pause() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.resumed != nil {
+		return
+	}
+	p.resumed = make(chan struct{})
+	p.since = time.Now()
+}
+
+func (p *pauser) resume() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.resumed == nil {
+		return
+	}
+	close(p.resumed)
+	p.resumed = nil
+	p.total += time.Since(p.since)
+}
+
+func (p *pauser) paused() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.resumed != nil
+}
+
+// wait blocks while generation is paused. It returns brk if done is
+// closed in the meantime.
+func (p *pauser) wait(done <-chan struct{}) token {
+	p.mu.Lock()
+	resumed := p.resumed
+	p.mu.Unlock()
+	if resumed == nil {
+		return cont
+	}
+	select {
+	case <-resumed:
+		return cont
+	case <-done:
+		return brk
+	}
+}
+
+// pausedFor returns total time spent paused, including the ongoing
+// pause, if any.
+func (p *pauser) pausedFor() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.resumed != nil {
+		return p.total + time.Since(p.since)
+	}
+	return p.total
+}
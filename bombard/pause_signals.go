@@ -0,0 +1,26 @@
+//go:build !windows
+// +build !windows
+
+package bombard
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// listenForPauseSignals pauses request generation on SIGUSR1 and
+// resumes it on SIGUSR2.
+func listenForPauseSignals(p *pauser) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGUSR1, syscall.SIGUSR2)
+	go func() {
+		for s := range c {
+			if s == syscall.SIGUSR1 {
+				p.pause()
+			} else {
+				p.resume()
+			}
+		}
+	}()
+}
@@ -0,0 +1,5 @@
+package bombard
+
+// listenForPauseSignals does nothing, since there are no SIGUSR1 and
+// SIGUSR2 on Windows.
+func listenForPauseSignals(p *pauser) {}
@@ -0,0 +1,57 @@
+package bombard
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPauserWaitsUntilResumed(t *testing.T) {
+	p := new(pauser)
+	done := make(chan struct{})
+	if p.wait(done) != cont {
+		t.Error("Expected not to wait while not paused")
+	}
+	p.pause()
+	if !p.paused() {
+		t.Error("Expected to be paused")
+	}
+	time.AfterFunc(50*time.Millisecond, p.resume)
+	if p.wait(done) != cont {
+		t.Error("Expected to continue once resumed")
+	}
+	if p.paused() {
+		t.Error("Expected not to be paused after resume")
+	}
+	if paused := p.pausedFor(); paused < 50*time.Millisecond {
+		t.Errorf("Expected to be paused for at least 50ms, but got %v",
+			paused)
+	}
+}
+
+func TestPauserWaitBreaksOnDone(t *testing.T) {
+	p := new(pauser)
+	p.pause()
+	done := make(chan struct{})
+	close(done)
+	if p.wait(done) != brk {
+		t.Error("Expected to break once done")
+	}
+}
+
+func TestPauserPausedForIncludesOngoingPause(t *testing.T) {
+	p := new(pauser)
+	p.pause()
+	p.pause()
+	time.Sleep(20 * time.Millisecond)
+	first := p.pausedFor()
+	if first < 20*time.Millisecond {
+		t.Errorf("Expected to be paused for at least 20ms, but got %v",
+			first)
+	}
+	p.resume()
+	p.resume()
+	if second := p.pausedFor(); second < first {
+		t.Errorf("Expected paused time to grow, but got %v after %v",
+			second, first)
+	}
+}
@@ -0,0 +1,23 @@
+//go:build !windows
+// +build !windows
+
+package bombard
+
+import (
+	"errors"
+	"syscall"
+)
+
+// isPortsExhaustedErrno tells whether system call failed because there
+// are no local ports left. connect fails with EADDRNOTAVAIL then, and
+// so does bind with EADDRINUSE if connections are made from a given
+// local address.
+func isPortsExhaustedErrno(syscallName string, err error) bool {
+	switch syscallName {
+	case "connect":
+		return errors.Is(err, syscall.EADDRNOTAVAIL)
+	case "bind":
+		return errors.Is(err, syscall.EADDRINUSE)
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+//go:build !windows
+// +build !windows
+
+package bombard
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestPortsExhaustedCategory(t *testing.T) {
+	expectations := []struct {
+		in  error
+		out errorCategory
+	}{
+		{
+			&url.Error{Op: "Get", URL: "http://localhost", Err: &net.OpError{
+				Op: "dial", Net: "tcp", Err: os.NewSyscallError(
+					"connect", syscall.EADDRNOTAVAIL,
+				),
+			}},
+			portsExhaustedError,
+		},
+		{
+			&net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError(
+				"bind", syscall.EADDRINUSE,
+			)},
+			portsExhaustedError,
+		},
+		// Local address that isn't assigned to any interface
+		{
+			&net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError(
+				"bind", syscall.EADDRNOTAVAIL,
+			)},
+			otherError,
+		},
+		{syscall.EADDRNOTAVAIL, otherError},
+	}
+	for _, e := range expectations {
+		if c := categorizeError(e.in); c != e.out {
+			t.Errorf("%v: expected %v, but got %v", e.in, e.out, c)
+		}
+	}
+}
+
+func TestPrintPortsExhaustedHint(t *testing.T) {
+	out := new(bytes.Buffer)
+	b := &bombardier{errors: newErrorMap(), out: out, errOut: out}
+	b.errors.add(errors.New("something"))
+	b.printPortsExhaustedHint()
+	if out.Len() != 0 {
+		t.Errorf("Expected no hint, but got %q", out.String())
+	}
+	exhausted := &net.OpError{Op: "dial", Net: "tcp",
+		Err: os.NewSyscallError("connect", syscall.EADDRNOTAVAIL)}
+	b.errors.add(exhausted)
+	b.errors.add(exhausted)
+	b.printPortsExhaustedHint()
+	if s := out.String(); !strings.HasPrefix(s, "2 request(s) failed") ||
+		strings.Count(s, "\n") != 1 {
+		t.Errorf("Expected one-line hint about 2 requests, but got %q", s)
+	}
+}
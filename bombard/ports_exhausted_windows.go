@@ -0,0 +1,23 @@
+package bombard
+
+import (
+	"errors"
+	"syscall"
+)
+
+// Windows Sockets error codes, which syscall package doesn't define
+const (
+	wsaeaddrinuse syscall.Errno = 10048
+	wsaenobufs    syscall.Errno = 10055
+)
+
+// isPortsExhaustedErrno tells whether system call failed because there
+// are no local ports left, in which case Windows reports either that
+// address is in use or that there is no buffer space.
+func isPortsExhaustedErrno(syscallName string, err error) bool {
+	switch syscallName {
+	case "connectex", "bind":
+		return errors.Is(err, wsaeaddrinuse) || errors.Is(err, wsaenobufs)
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+package bombard
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// primeConnections sends one request per connection to every target
+// at once, so that connections are established (and TLS handshakes
+// done) before the test starts. Results of these requests aren't
+// recorded and neither are bytes they transferred.
+func (b *bombardier) primeConnections() {
+	start := time.Now()
+	var (
+		wg     sync.WaitGroup
+		failed uint64
+	)
+	for _, cl := range b.clients {
+		for i := uint64(0); i < b.conf.numConns; i++ {
+			wg.Add(1)
+			go func(cl client, connID uint64) {
+				defer wg.Done()
+				if res := cl.do(connID); res.err != nil {
+					atomic.AddUint64(&failed, 1)
+				}
+			}(cl, i)
+		}
+	}
+	wg.Wait()
+	b.primingTime = time.Since(start)
+	atomic.StoreInt64(&b.bytesRead, 0)
+	atomic.StoreInt64(&b.bytesWritten, 0)
+	if !b.conf.printIntro {
+		return
+	}
+	total := uint64(len(b.clients)) * b.conf.numConns
+	fmt.Fprintf(b.out, "Primed %v connection(s) in %v", total,
+		b.primingTime.Round(time.Microsecond))
+	if failed > 0 {
+		fmt.Fprintf(b.out, ", %v priming request(s) failed", failed)
+	}
+	fmt.Fprintln(b.out)
+}
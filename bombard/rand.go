@@ -0,0 +1,25 @@
+package bombard
+
+import "math/rand"
+
+// newConnRNGs returns a random number generator for every connection,
+// each seeded with a distinct seed derived from the given one. Every
+// connection is only used by a single worker at a time, so these
+// need no locking.
+func newConnRNGs(seed int64, numConns uint64) []*rand.Rand {
+	rngs := make([]*rand.Rand, numConns)
+	for i := range rngs {
+		rngs[i] = rand.New(rand.NewSource(connSeed(seed, uint64(i))))
+	}
+	return rngs
+}
+
+// connSeed derives seed of the connection from the given one using
+// SplitMix64 finalizer, so that sequences of neighbouring connections
+// aren't correlated.
+func connSeed(seed int64, connID uint64) int64 {
+	z := uint64(seed) + (connID+1)*0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return int64(z ^ (z >> 31))
+}
@@ -0,0 +1,17 @@
+package bombard
+
+import "testing"
+
+func TestConnSeedsAreDistinct(t *testing.T) {
+	seen := make(map[int64]bool)
+	for connID := uint64(0); connID < 1000; connID++ {
+		s := connSeed(42, connID)
+		if seen[s] {
+			t.Fatalf("Seed of connection %v was already used", connID)
+		}
+		seen[s] = true
+	}
+	if connSeed(42, 0) != connSeed(42, 0) {
+		t.Error("Expected sub-seeds to be deterministic")
+	}
+}
@@ -0,0 +1,26 @@
+package bombard
+
+import "math/rand"
+
+// randomHeaders are headers that are only added to some requests,
+// each with its own probability. Decisions of every connection are
+// drawn from its own RNG.
+type randomHeaders struct {
+	headers randomHeadersList
+	rngs    []*rand.Rand
+}
+
+func newRandomHeaders(h randomHeadersList, rngs []*rand.Rand) *randomHeaders {
+	return &randomHeaders{h, rngs}
+}
+
+// add calls set with the key and value of every header that was
+// chosen to be sent with the next request of the connection.
+func (rh *randomHeaders) add(connID uint64, set func(key, value string)) {
+	rng := rh.rngs[connID]
+	for _, h := range rh.headers {
+		if rng.Float64() < h.probability {
+			set(h.key, h.value)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package bombard
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRandomHeadersAreDeterministicWhenSeeded(t *testing.T) {
+	headers := randomHeadersList{
+		{header{"X-Debug", "1"}, 0.3},
+		{header{"X-Always", "1"}, 1},
+	}
+	draw := func(seed int64) [][]string {
+		rh := newRandomHeaders(headers, newConnRNGs(seed, 2))
+		var keys [][]string
+		for i := 0; i < 100; i++ {
+			var sent []string
+			rh.add(uint64(i%2), func(key, _ string) {
+				sent = append(sent, key)
+			})
+			keys = append(keys, sent)
+		}
+		return keys
+	}
+	first, second := draw(42), draw(42)
+	if !reflect.DeepEqual(first, second) {
+		t.Error("Expected the same headers to be chosen with the same seed")
+	}
+	debug := 0
+	for _, sent := range first {
+		if len(sent) == 0 || sent[len(sent)-1] != "X-Always" {
+			t.Fatalf("Expected X-Always to be sent every time, but got %v",
+				sent)
+		}
+		if len(sent) == 2 {
+			debug++
+		}
+	}
+	if debug < 15 || debug > 45 {
+		t.Errorf("Expected X-Debug to be sent ~30 times, but got %v", debug)
+	}
+	if reflect.DeepEqual(first, draw(43)) {
+		t.Error("Expected different seeds to produce different choices")
+	}
+}
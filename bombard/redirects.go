@@ -0,0 +1,107 @@
+package bombard
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+// redirectHops records redirects net/http client followed on behalf of
+// a single request, it travels with the request's context.
+type redirectHops struct {
+	count uint64
+	// last is when the last redirect was followed
+	last time.Time
+}
+
+type redirectHopsKey struct{}
+
+// checkRedirect returns http.Client.CheckRedirect that follows at most
+// maxRedirects redirects, returning the last response once there are
+// more of them.
+func checkRedirect(
+	maxRedirects uint64,
+) func(*http.Request, []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if uint64(len(via)) > maxRedirects {
+			return http.ErrUseLastResponse
+		}
+		hops, ok := req.Context().Value(redirectHopsKey{}).(*redirectHops)
+		if ok {
+			hops.count++
+			hops.last = time.Now()
+		}
+		return nil
+	}
+}
+
+type crossHostRedirectError struct {
+	host string
+}
+
+func (c *crossHostRedirectError) Error() string {
+	return fmt.Sprintf("redirect to another host: %v", c.host)
+}
+
+func isRedirect(code int) bool {
+	switch code {
+	case http.StatusMovedPermanently, http.StatusFound,
+		http.StatusSeeOther, http.StatusTemporaryRedirect,
+		http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
+
+// followRedirect turns req into the request to the location resp
+// redirects to, the same way net/http does it. It returns false if
+// resp isn't a redirect or it can't be followed, i.e. body of the
+// request was streamed and can't be resent. fasthttp client is bound
+// to a single host, so redirects to other hosts are errors.
+func (c *fasthttpClient) followRedirect(
+	req *fasthttp.Request, resp *fasthttp.Response, streamed bool,
+) (bool, error) {
+	code := resp.StatusCode()
+	location := resp.Header.Peek("Location")
+	if !isRedirect(code) || len(location) == 0 {
+		return false, nil
+	}
+	current, err := c.url.Parse(string(req.RequestURI()))
+	if err != nil {
+		return false, err
+	}
+	next, err := current.Parse(string(location))
+	if err != nil {
+		return false, err
+	}
+	if !sameHost(c.url, next) {
+		return false, &crossHostRedirectError{next.Host}
+	}
+	if code == http.StatusTemporaryRedirect ||
+		code == http.StatusPermanentRedirect {
+		if streamed {
+			return false, nil
+		}
+	} else {
+		method := string(req.Header.Method())
+		if method != "GET" && method != "HEAD" {
+			req.Header.SetMethod("GET")
+		}
+		req.ResetBody()
+		req.Header.Del("Content-Type")
+	}
+	req.SetRequestURI(next.RequestURI())
+	return true, nil
+}
+
+func sameHost(a, b *url.URL) bool {
+	if a.Scheme != b.Scheme {
+		return false
+	}
+	aAddr, aerr := urlAddress(a.String())
+	bAddr, berr := urlAddress(b.String())
+	return aerr == nil && berr == nil && aAddr == bAddr
+}
@@ -0,0 +1,34 @@
+package bombard
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestSameHost(t *testing.T) {
+	expectations := []struct {
+		a, b string
+		out  bool
+	}{
+		{"http://localhost:8080/a", "http://localhost:8080/b", true},
+		{"https://example.com:443/", "https://example.com/x", true},
+		{"http://example.com:80/", "http://example.com/", true},
+		{"http://example.com/", "https://example.com/", false},
+		{"http://example.com/", "http://example.org/", false},
+		{"http://localhost:8080/", "http://localhost:8081/", false},
+	}
+	for _, e := range expectations {
+		a, err := url.Parse(e.a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := url.Parse(e.b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if act := sameHost(a, b); act != e.out {
+			t.Errorf("%v, %v: expected %v, but got %v",
+				e.a, e.b, e.out, act)
+		}
+	}
+}
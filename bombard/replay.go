@@ -0,0 +1,290 @@
+package bombard
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// logRecord is a request parsed from a line of access log.
+type logRecord struct {
+	time               time.Time
+	method, requestURI string
+}
+
+// logParser parses a single line of access log.
+type logParser func(line string) (logRecord, error)
+
+// logFormats maps names of access log formats --replay understands to
+// their parsers.
+var logFormats = map[string]logParser{
+	"common": parseCommonLogLine,
+	"json":   parseJSONLogLine,
+}
+
+const (
+	defaultLogFormat = "common"
+	commonLogTime    = "02/Jan/2006:15:04:05 -0700"
+)
+
+// parseCommonLogLine parses a line in Common Log Format, i.e.
+//
+//	127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "GET /a.gif HTTP/1.0" 200 2326
+//
+// anything following the size of response, like referer and user
+// agent of Combined Log Format, is ignored.
+func parseCommonLogLine(line string) (logRecord, error) {
+	var rec logRecord
+	open := strings.IndexByte(line, '[')
+	closing := strings.IndexByte(line, ']')
+	if open < 0 || closing < open {
+		return rec, errors.New("time is missing")
+	}
+	t, err := time.Parse(commonLogTime, line[open+1:closing])
+	if err != nil {
+		return rec, err
+	}
+	rest := line[closing+1:]
+	open = strings.IndexByte(rest, '"')
+	if open < 0 {
+		return rec, errors.New("request line is missing")
+	}
+	closing = strings.IndexByte(rest[open+1:], '"')
+	if closing < 0 {
+		return rec, errors.New("request line is missing")
+	}
+	parts := strings.Fields(rest[open+1 : open+1+closing])
+	if len(parts) < 2 {
+		return rec, fmt.Errorf("invalid request line %q",
+			rest[open+1:open+1+closing])
+	}
+	return logRecord{t, parts[0], parts[1]}, nil
+}
+
+// parseJSONLogLine parses a line that is a JSON object with "time"
+// (in RFC 3339 format), "method" and "path" of the request.
+func parseJSONLogLine(line string) (logRecord, error) {
+	var rec struct {
+		Time   time.Time `json:"time"`
+		Method string    `json:"method"`
+		Path   string    `json:"path"`
+	}
+	if err := json.Unmarshal([]byte(line), &rec); err != nil {
+		return logRecord{}, err
+	}
+	if rec.Time.IsZero() || rec.Method == "" || rec.Path == "" {
+		return logRecord{}, errors.New("time, method or path is missing")
+	}
+	return logRecord{rec.Time, rec.Method, rec.Path}, nil
+}
+
+// replayEntry is a request of the replayed log.
+type replayEntry struct {
+	// offset is the time since the first request of the log
+	offset time.Duration
+	method string
+	uri    *url.URL
+}
+
+// replay sends requests of access log to the target, preserving their
+// relative timing. Every connection sends the next request of the log
+// once it's due, so there must be enough of them to keep up.
+type replay struct {
+	path, format string
+	// speed is how many times faster than logged requests are
+	// replayed
+	speed   float64
+	entries []replayEntry
+
+	// current holds the index of the entry every connection sends,
+	// each of them is only accessed by the connection's worker
+	current []int
+	// stop is called once there are no requests left to replay
+	stop func()
+	// pausedFor returns total time requests weren't generated for,
+	// the schedule is shifted by it. nil if it's never paused.
+	pausedFor func() time.Duration
+
+	timerPool *sync.Pool
+	mu        sync.Mutex
+	start     time.Time
+	paused    time.Duration
+	next      int
+}
+
+type invalidReplayError struct {
+	path string
+	line int
+	err  error
+}
+
+func (i *invalidReplayError) Error() string {
+	return fmt.Sprintf("Invalid access log %v, line %v: %v",
+		i.path, i.line, i.err)
+}
+
+// readReplay reads access log in the given format, empty lines are
+// skipped. Requests are sorted by time, since lines may be logged
+// slightly out of order.
+func readReplay(path, format string, speed float64) (*replay, error) {
+	parse, ok := logFormats[format]
+	if !ok {
+		return nil, &unknownLogFormatError{format}
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	type timedEntry struct {
+		time time.Time
+		replayEntry
+	}
+	var timed []timedEntry
+	s := bufio.NewScanner(f)
+	s.Buffer(nil, 1024*1024)
+	for line := 1; s.Scan(); line++ {
+		if strings.TrimSpace(s.Text()) == "" {
+			continue
+		}
+		rec, err := parse(s.Text())
+		if err == nil && !strings.HasPrefix(rec.requestURI, "/") {
+			err = fmt.Errorf("%q isn't a path", rec.requestURI)
+		}
+		var uri *url.URL
+		if err == nil {
+			uri, err = url.ParseRequestURI(rec.requestURI)
+		}
+		if err != nil {
+			return nil, &invalidReplayError{path, line, err}
+		}
+		timed = append(timed, timedEntry{
+			rec.time, replayEntry{method: rec.method, uri: uri},
+		})
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if len(timed) == 0 {
+		return nil, errEmptyReplay
+	}
+	sort.SliceStable(timed, func(i, j int) bool {
+		return timed[i].time.Before(timed[j].time)
+	})
+	entries := make([]replayEntry, len(timed))
+	for i, t := range timed {
+		entries[i] = t.replayEntry
+		entries[i].offset = t.time.Sub(timed[0].time)
+	}
+	return &replay{
+		path:    path,
+		format:  format,
+		speed:   speed,
+		entries: entries,
+		timerPool: &sync.Pool{
+			New: func() interface{} {
+				return time.NewTimer(math.MaxInt64)
+			},
+		},
+	}, nil
+}
+
+type unknownLogFormatError struct {
+	format string
+}
+
+func (u *unknownLogFormatError) Error() string {
+	names := make([]string, 0, len(logFormats))
+	for name := range logFormats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return fmt.Sprintf("Unknown access log format %q (must be one of %v)",
+		u.format, strings.Join(names, ", "))
+}
+
+// prepare readies replay to be sent over numConns connections, stop is
+// called once all the requests are sent.
+func (r *replay) prepare(
+	numConns uint64, stop func(), pausedFor func() time.Duration,
+) {
+	r.current = make([]int, numConns)
+	r.stop, r.pausedFor = stop, pausedFor
+}
+
+// limiter returns the scheduler of connection's requests.
+func (r *replay) limiter(connID uint64) scheduler {
+	return &replayLimiter{r, connID}
+}
+
+// entry returns the request connection sends.
+func (r *replay) entry(connID uint64) *replayEntry {
+	return &r.entries[r.current[connID]]
+}
+
+// replayLimiter makes connection wait until the next request of the
+// replayed log is due and then send it.
+type replayLimiter struct {
+	r      *replay
+	connID uint64
+}
+
+func (l *replayLimiter) pace(done <-chan struct{}) token {
+	res, _ := l.schedule(done)
+	return res
+}
+
+func (l *replayLimiter) schedule(
+	done <-chan struct{},
+) (res token, at time.Time) {
+	r := l.r
+	r.mu.Lock()
+	now := time.Now()
+	if r.start.IsZero() {
+		r.start = now
+		if r.pausedFor != nil {
+			r.paused = r.pausedFor()
+		}
+	}
+	if r.next == len(r.entries) {
+		r.mu.Unlock()
+		if r.stop != nil {
+			r.stop()
+		}
+		return brk, now
+	}
+	if r.pausedFor != nil {
+		if paused := r.pausedFor(); paused > r.paused {
+			r.start = r.start.Add(paused - r.paused)
+			r.paused = paused
+		}
+	}
+	r.current[l.connID] = r.next
+	offset := r.entries[r.next].offset
+	r.next++
+	at = r.start.Add(time.Duration(float64(offset) / r.speed))
+	r.mu.Unlock()
+
+	wd := at.Sub(now)
+	if wd <= 0 {
+		return cont, at
+	}
+	timer := r.timerPool.Get().(*time.Timer)
+	timer.Reset(wd)
+	select {
+	case <-timer.C:
+		res = cont
+	case <-done:
+		res = brk
+	}
+	r.timerPool.Put(timer)
+	return
+}
@@ -0,0 +1,198 @@
+package bombard
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeAccessLog(t *testing.T, content string) string {
+	dir, err := ioutil.TempDir("", "bombardier-replay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "access.log")
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseCommonLogLine(t *testing.T) {
+	rec, err := parseCommonLogLine(`127.0.0.1 - frank ` +
+		`[10/Oct/2000:13:55:36 -0700] "GET /a.gif?x=1 HTTP/1.0" 200 2326 ` +
+		`"http://example.com/" "Mozilla/4.08"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expTime := time.Date(2000, 10, 10, 20, 55, 36, 0, time.UTC)
+	if !rec.time.Equal(expTime) || rec.method != "GET" ||
+		rec.requestURI != "/a.gif?x=1" {
+		t.Errorf("Unexpected record: %+v", rec)
+	}
+	for _, line := range []string{
+		`127.0.0.1 - - "GET / HTTP/1.0" 200 1`,
+		`127.0.0.1 - - [10/Oct/2000] "GET / HTTP/1.0" 200 1`,
+		`127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] 200 1`,
+		`127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "GET / HTTP/1.0`,
+		`127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "-" 400 0`,
+	} {
+		if _, err := parseCommonLogLine(line); err == nil {
+			t.Errorf("%v: expected an error", line)
+		}
+	}
+}
+
+func TestParseJSONLogLine(t *testing.T) {
+	rec, err := parseJSONLogLine(`{"time":"2000-10-10T13:55:36.5Z",` +
+		`"method":"POST","path":"/items","status":201}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expTime := time.Date(2000, 10, 10, 13, 55, 36, 5e8, time.UTC)
+	if !rec.time.Equal(expTime) || rec.method != "POST" ||
+		rec.requestURI != "/items" {
+		t.Errorf("Unexpected record: %+v", rec)
+	}
+	for _, line := range []string{
+		`{"time":"2000-10-10T13:55:36Z","method":"GET"}`,
+		`{"method":"GET","path":"/"}`,
+		`{"time":"yesterday","method":"GET","path":"/"}`,
+		`GET /`,
+	} {
+		if _, err := parseJSONLogLine(line); err == nil {
+			t.Errorf("%v: expected an error", line)
+		}
+	}
+}
+
+func TestReadReplay(t *testing.T) {
+	path := writeAccessLog(t, `
+- - - [10/Oct/2000:13:55:38 +0000] "GET /c HTTP/1.1" 200 1
+- - - [10/Oct/2000:13:55:36 +0000] "POST /a?x=1 HTTP/1.1" 200 1
+
+- - - [10/Oct/2000:13:55:37 +0000] "GET /b HTTP/1.1" 200 1
+- - - [10/Oct/2000:13:55:36 +0000] "PUT /a HTTP/1.1" 200 1
+`)
+	defer os.RemoveAll(filepath.Dir(path))
+	r, err := readReplay(path, "common", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.path != path || r.format != "common" || r.speed != 2 {
+		t.Errorf("Unexpected replay: %+v", r)
+	}
+	exp := []struct {
+		offset      time.Duration
+		method, uri string
+	}{
+		{0, "POST", "/a?x=1"},
+		{0, "PUT", "/a"},
+		{time.Second, "GET", "/b"},
+		{2 * time.Second, "GET", "/c"},
+	}
+	if len(r.entries) != len(exp) {
+		t.Fatalf("Expected %v entries, but got %v", len(exp), len(r.entries))
+	}
+	for i, e := range exp {
+		got := r.entries[i]
+		if got.offset != e.offset || got.method != e.method ||
+			got.uri.RequestURI() != e.uri {
+			t.Errorf("Entry %v: expected %v, but got %+v", i, e, got)
+		}
+	}
+}
+
+func TestReadReplayFailures(t *testing.T) {
+	valid := writeAccessLog(t,
+		`- - - [10/Oct/2000:13:55:36 +0000] "GET / HTTP/1.1" 200 1`)
+	defer os.RemoveAll(filepath.Dir(valid))
+	empty := writeAccessLog(t, "\n\n")
+	defer os.RemoveAll(filepath.Dir(empty))
+	invalid := writeAccessLog(t,
+		"- - - [10/Oct/2000:13:55:36 +0000] \"GET / HTTP/1.1\" 200 1\n"+
+			"- - - [10/Oct/2000:13:55:36 +0000] \"GET a HTTP/1.1\" 200 1\n")
+	defer os.RemoveAll(filepath.Dir(invalid))
+	if _, err := readReplay(valid, "xml", 1); err == nil {
+		t.Error("Expected unknown format to be rejected")
+	} else if _, ok := err.(*unknownLogFormatError); !ok {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if _, err := readReplay(valid, "json", 1); err == nil {
+		t.Error("Expected log in other format to be rejected")
+	}
+	if _, err := readReplay(empty, "common", 1); err != errEmptyReplay {
+		t.Errorf("Expected %v, but got %v", errEmptyReplay, err)
+	}
+	_, err := readReplay(invalid, "common", 1)
+	if ire, ok := err.(*invalidReplayError); !ok || ire.line != 2 {
+		t.Errorf("Expected error on line 2, but got %v", err)
+	}
+	if _, err := readReplay("missing.log", "common", 1); err == nil {
+		t.Error("Expected missing log to be rejected")
+	}
+}
+
+func TestReplayLimiter(t *testing.T) {
+	path := writeAccessLog(t, `
+- - - [10/Oct/2000:13:55:36 +0000] "GET /a HTTP/1.1" 200 1
+- - - [10/Oct/2000:13:55:36 +0000] "GET /b HTTP/1.1" 200 1
+- - - [10/Oct/2000:13:55:37 +0000] "GET /c HTTP/1.1" 200 1
+`)
+	defer os.RemoveAll(filepath.Dir(path))
+	r, err := readReplay(path, "common", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stopped := 0
+	r.prepare(2, func() { stopped++ }, nil)
+	done := make(chan struct{})
+	first, second := r.limiter(0), r.limiter(1)
+
+	start := time.Now()
+	if first.pace(done) != cont || second.pace(done) != cont {
+		t.Fatal("Expected requests to be sent")
+	}
+	if d := time.Since(start); d > 50*time.Millisecond {
+		t.Errorf("Expected simultaneous requests to be sent at once, "+
+			"but it took %v", d)
+	}
+	if p := r.entry(0).uri.Path + r.entry(1).uri.Path; p != "/a/b" {
+		t.Errorf("Expected /a and /b to be sent, but got %v", p)
+	}
+	res, at := first.schedule(done)
+	if res != cont || r.entry(0).uri.Path != "/c" {
+		t.Errorf("Expected /c to be sent, but got %v", r.entry(0).uri)
+	}
+	if d := at.Sub(start); d < 90*time.Millisecond ||
+		d > 150*time.Millisecond {
+		t.Errorf("Expected /c to be sent in 100ms, but it was sent in %v", d)
+	}
+	if second.pace(done) != brk || stopped != 1 {
+		t.Error("Expected replay to stop once there are no requests left")
+	}
+}
+
+func TestReplayLimiterIsInterrupted(t *testing.T) {
+	path := writeAccessLog(t, `
+- - - [10/Oct/2000:13:55:36 +0000] "GET /a HTTP/1.1" 200 1
+- - - [10/Oct/2000:14:55:36 +0000] "GET /b HTTP/1.1" 200 1
+`)
+	defer os.RemoveAll(filepath.Dir(path))
+	r, err := readReplay(path, "common", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.prepare(1, nil, nil)
+	done := make(chan struct{})
+	l := r.limiter(0)
+	if l.pace(done) != cont {
+		t.Fatal("Expected the first request to be sent")
+	}
+	time.AfterFunc(10*time.Millisecond, func() { close(done) })
+	if l.pace(done) != brk {
+		t.Error("Expected waiting for the next request to be interrupted")
+	}
+}
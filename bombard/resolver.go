@@ -0,0 +1,200 @@
+package bombard
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// resolver picks addresses clients actually connect to and records
+// remote addresses of the established connections.
+type resolver struct {
+	// unixSocket is the path all connections go to, if set
+	unixSocket string
+	// overrides map host:port to IP address, like curl's --resolve
+	overrides map[string]string
+	// pin tells whether every host is resolved only once
+	pin bool
+	// local are the addresses connections are made from in turn,
+	// empty if it's up to the OS
+	local     []net.IP
+	nextLocal uint64
+
+	mu sync.Mutex
+	// pinned maps hosts to the IP addresses they were resolved to
+	pinned map[string]string
+	used   map[string]struct{}
+}
+
+func newResolver(
+	overrides resolveOverridesList, pin bool, unixSocket string,
+) *resolver {
+	r := &resolver{
+		unixSocket: unixSocket,
+		overrides:  make(map[string]string, len(overrides)),
+		pin:        pin,
+		pinned:     make(map[string]string),
+		used:       make(map[string]struct{}),
+	}
+	for _, o := range overrides {
+		r.overrides[net.JoinHostPort(o.host, o.port)] = o.ip
+	}
+	return r
+}
+
+// pinURLs resolves hosts of the URLs beforehand, so that resolution
+// errors surface before the test starts. Does nothing unless hosts
+// are pinned.
+func (r *resolver) pinURLs(ctx context.Context, urls []string) error {
+	if !r.pin {
+		return nil
+	}
+	for _, rawURL := range urls {
+		address, err := urlAddress(rawURL)
+		if err != nil {
+			return err
+		}
+		if _, _, err = r.resolve(ctx, address); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkURLs makes sure that hosts of the URLs can be resolved,
+// whether they are pinned or not.
+func (r *resolver) checkURLs(ctx context.Context, urls []string) error {
+	for _, rawURL := range urls {
+		address, err := urlAddress(rawURL)
+		if err != nil {
+			return err
+		}
+		network, address, err := r.resolve(ctx, address)
+		if err != nil {
+			return err
+		}
+		if network != "tcp" {
+			continue
+		}
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return err
+		}
+		if net.ParseIP(host) != nil {
+			continue
+		}
+		if _, err := net.DefaultResolver.LookupIPAddr(ctx, host); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// urlAddress returns host:port the URL points to.
+func urlAddress(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	return net.JoinHostPort(u.Hostname(), port), nil
+}
+
+// resolve returns network and address that should be dialed instead
+// of TCP address.
+func (r *resolver) resolve(
+	ctx context.Context, address string,
+) (string, string, error) {
+	if r.unixSocket != "" {
+		return "unix", r.unixSocket, nil
+	}
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return "", "", err
+	}
+	if ip, ok := r.overrides[net.JoinHostPort(host, port)]; ok {
+		return "tcp", net.JoinHostPort(ip, port), nil
+	}
+	if !r.pin || net.ParseIP(host) != nil {
+		return "tcp", address, nil
+	}
+	ip, err := r.lookup(ctx, host)
+	if err != nil {
+		return "", "", err
+	}
+	return "tcp", net.JoinHostPort(ip, port), nil
+}
+
+// bind makes connections go out from the given IP addresses in turn.
+func (r *resolver) bind(ips []string) {
+	for _, ip := range ips {
+		r.local = append(r.local, net.ParseIP(ip))
+	}
+}
+
+// localAddr returns the address connection to address (as returned
+// by resolve) should be made from, nil if any will do. Only addresses
+// of the same family as the remote one are picked.
+func (r *resolver) localAddr(network, address string) net.Addr {
+	if len(r.local) == 0 || network != "tcp" {
+		return nil
+	}
+	var remote net.IP
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		remote = net.ParseIP(host)
+	}
+	n := atomic.AddUint64(&r.nextLocal, 1) - 1
+	for i := range r.local {
+		ip := r.local[(n+uint64(i))%uint64(len(r.local))]
+		if remote == nil || (ip.To4() == nil) == (remote.To4() == nil) {
+			return &net.TCPAddr{IP: ip}
+		}
+	}
+	// Dialing fails with mismatched addresses, rather than going
+	// out from an unexpected one
+	return &net.TCPAddr{IP: r.local[n%uint64(len(r.local))]}
+}
+
+func (r *resolver) lookup(ctx context.Context, host string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if ip, ok := r.pinned[host]; ok {
+		return ip, nil
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return "", err
+	}
+	// LookupIPAddr never succeeds without returning an address
+	ip := addrs[0].IP.String()
+	r.pinned[host] = ip
+	return ip, nil
+}
+
+func (r *resolver) record(addr net.Addr) {
+	r.mu.Lock()
+	r.used[addr.String()] = struct{}{}
+	r.mu.Unlock()
+}
+
+// addresses returns sorted remote addresses of all the connections
+// made so far.
+func (r *resolver) addresses() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	addrs := make([]string, 0, len(r.used))
+	for a := range r.used {
+		addrs = append(addrs, a)
+	}
+	sort.Strings(addrs)
+	return addrs
+}
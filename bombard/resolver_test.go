@@ -0,0 +1,157 @@
+package bombard
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestResolverOverrides(t *testing.T) {
+	r := newResolver(resolveOverridesList{
+		{"example.com", "443", "10.0.0.1"},
+		{"example.com", "80", "::1"},
+	}, false, "")
+	expectations := []struct {
+		in, out string
+	}{
+		{"example.com:443", "10.0.0.1:443"},
+		{"example.com:80", "[::1]:80"},
+		{"example.com:8080", "example.com:8080"},
+		{"example.org:443", "example.org:443"},
+	}
+	for _, e := range expectations {
+		network, act, err := r.resolve(context.Background(), e.in)
+		if err != nil {
+			t.Errorf("%v: unexpected error %v", e.in, err)
+			continue
+		}
+		if network != "tcp" || act != e.out {
+			t.Errorf("%v: expected tcp %v, but got %v %v",
+				e.in, e.out, network, act)
+		}
+	}
+}
+
+func TestResolverPinsHosts(t *testing.T) {
+	r := newResolver(nil, true, "")
+	if err := r.pinURLs(context.Background(), []string{
+		"http://localhost:8080/a", "https://127.0.0.1/b",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	pinned := r.pinned["localhost"]
+	if net.ParseIP(pinned) == nil {
+		t.Fatalf("Expected localhost to be pinned, but got %q", pinned)
+	}
+	for i := 0; i < 3; i++ {
+		_, act, err := r.resolve(context.Background(), "localhost:8080")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exp := net.JoinHostPort(pinned, "8080"); act != exp {
+			t.Errorf("Expected %v, but got %v", exp, act)
+		}
+	}
+	_, act, err := r.resolve(context.Background(), "127.0.0.1:443")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if act != "127.0.0.1:443" {
+		t.Errorf("Expected IP address to be dialed as is, but got %v", act)
+	}
+	if _, ok := r.pinned["127.0.0.1"]; ok {
+		t.Error("IP addresses shouldn't be looked up")
+	}
+}
+
+func TestResolverUnixSocket(t *testing.T) {
+	r := newResolver(nil, false, "/tmp/app.sock")
+	network, act, err := r.resolve(context.Background(), "localhost:80")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if network != "unix" || act != "/tmp/app.sock" {
+		t.Errorf("Expected unix /tmp/app.sock, but got %v %v", network, act)
+	}
+}
+
+func TestResolverAddresses(t *testing.T) {
+	r := newResolver(nil, false, "")
+	for _, addr := range []string{
+		"10.0.0.2:80", "10.0.0.1:80", "10.0.0.2:80",
+	} {
+		tcpAddr, err := net.ResolveTCPAddr("tcp", addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.record(tcpAddr)
+	}
+	exp := []string{"10.0.0.1:80", "10.0.0.2:80"}
+	if act := r.addresses(); !reflect.DeepEqual(act, exp) {
+		t.Errorf("Expected %v, but got %v", exp, act)
+	}
+}
+
+func TestResolverCheckURLs(t *testing.T) {
+	r := newResolver(resolveOverridesList{
+		{"somehost.invalid", "443", "10.0.0.1"},
+	}, false, "")
+	if err := r.checkURLs(context.Background(), []string{
+		"http://localhost:8080/a",
+		"https://127.0.0.1/b",
+		"https://somehost.invalid/c",
+	}); err != nil {
+		t.Error(err)
+	}
+	if err := r.checkURLs(context.Background(), []string{
+		"http://somehost.invalid/",
+	}); err == nil {
+		t.Error("Expected unresolvable host to be reported")
+	}
+	if len(r.pinned) != 0 {
+		t.Errorf("Expected hosts not to be pinned, but got %v", r.pinned)
+	}
+	r = newResolver(nil, false, "/tmp/some.sock")
+	if err := r.checkURLs(context.Background(), []string{
+		"http://somehost.invalid/",
+	}); err != nil {
+		t.Errorf("Expected hosts not to be resolved with Unix socket, "+
+			"but got %v", err)
+	}
+}
+
+func TestResolverLocalAddr(t *testing.T) {
+	r := newResolver(nil, false, "")
+	if a := r.localAddr("tcp", "127.0.0.1:80"); a != nil {
+		t.Errorf("Expected no local address, but got %v", a)
+	}
+	r.bind([]string{"10.0.0.1", "::1", "10.0.0.2"})
+	expectations := []struct {
+		network, address, out string
+	}{
+		{"tcp", "example.com:80", "10.0.0.1:0"},
+		{"tcp", "example.com:80", "[::1]:0"},
+		{"tcp", "127.0.0.1:80", "10.0.0.2:0"},
+		{"tcp", "127.0.0.1:80", "10.0.0.1:0"},
+		{"tcp", "[::1]:80", "[::1]:0"},
+		{"tcp", "[::1]:80", "[::1]:0"},
+		{"tcp", "127.0.0.1:80", "10.0.0.1:0"},
+	}
+	for i, e := range expectations {
+		a := r.localAddr(e.network, e.address)
+		if a == nil || a.String() != e.out {
+			t.Errorf("%v: %v: expected %v, but got %v",
+				i, e.address, e.out, a)
+		}
+	}
+	if a := r.localAddr("unix", "/tmp/app.sock"); a != nil {
+		t.Errorf("Expected no local address for socket, but got %v", a)
+	}
+	r = newResolver(nil, false, "")
+	r.bind([]string{"10.0.0.1"})
+	if a := r.localAddr("tcp", "[::1]:80"); a == nil ||
+		a.String() != "10.0.0.1:0" {
+		t.Errorf("Expected mismatched address, but got %v", a)
+	}
+}
@@ -0,0 +1,463 @@
+package bombard
+
+import (
+	"context"
+	"time"
+
+	"github.com/kostyay/bombardier/internal"
+)
+
+type (
+	// TestInfo holds specification the test was performed with and
+	// its results.
+	TestInfo = internal.TestInfo
+	// Spec describes the test that was performed.
+	Spec = internal.Spec
+	// Results holds results of the test, statistics are calculated
+	// with its methods.
+	Results = internal.Results
+	// LatenciesStats describes distribution of latencies.
+	LatenciesStats = internal.LatenciesStats
+	// RequestsStats describes distribution of request rates.
+	RequestsStats = internal.RequestsStats
+	// SizeStats describes distribution of response sizes.
+	SizeStats = internal.SizeStats
+	// Header is HTTP header sent with requests.
+	Header = internal.Header
+	// FormField is a field of multipart/form-data body.
+	FormField = internal.FormField
+	// WeightedURL is a target picked according to its weight.
+	WeightedURL = internal.WeightedURL
+	// OAuth2 describes how bearer tokens were obtained.
+	OAuth2 = internal.OAuth2
+	// SigV4 describes how requests were signed.
+	SigV4 = internal.SigV4
+	// Replay describes access log requests are replayed from.
+	Replay = internal.Replay
+	// GRPC describes unary gRPC method that is called.
+	GRPC = internal.GRPC
+	// BodyGenerator returns body of the request with the given number.
+	BodyGenerator = internal.BodyGenerator
+	// RequestHook is called once every request completes.
+	RequestHook = internal.RequestHook
+	// TickHook is called every second with results so far.
+	TickHook = internal.TickHook
+	// Snapshot holds results of the test at some point of it.
+	Snapshot = internal.Snapshot
+	// TestType tells what the test is limited by.
+	TestType = internal.TestType
+	// ClientType is the HTTP client requests are sent with.
+	ClientType = internal.ClientType
+)
+
+// Types of tests.
+const (
+	ByTime               = internal.ByTime
+	ByNumberOfReqs       = internal.ByNumberOfReqs
+	ByTimeOrNumberOfReqs = internal.ByTimeOrNumberOfReqs
+)
+
+// Types of HTTP clients.
+const (
+	FastHTTP = internal.FastHTTP
+	NetHTTP1 = internal.NetHTTP1
+	NetHTTP2 = internal.NetHTTP2
+)
+
+// Options describes the test Run performs. Fields are interpreted just
+// like the flags they correspond to, zero values standing for the
+// defaults of the flags, so that i.e. zero NumberOfConnections means
+// 125 connections and test that has neither NumberOfRequests nor
+// TestDuration lasts 10 seconds.
+type Options struct {
+	NumberOfConnections uint64
+	// MaxConnsPerHost is the maximum number of concurrent connections
+	// to every host, zero means no limit.
+	MaxConnsPerHost uint64
+
+	// TestType only needs to be set for tests limited by zero
+	// NumberOfRequests or TestDuration, it's inferred otherwise.
+	TestType         TestType
+	NumberOfRequests uint64
+	TestDuration     time.Duration
+
+	Method string
+	URL    string
+	// URLs are targets requests are spread over in a round-robin
+	// fashion, URL is ignored if they are set.
+	URLs []string
+	// WeightedURLs are targets picked at random according to their
+	// weights, URL and URLs are ignored if they are set.
+	WeightedURLs []WeightedURL
+	// Scenario is the path of JSON file with steps every connection
+	// goes through in order, it's used instead of URLs.
+	Scenario string
+	// Replay describes access log requests are replayed from, their
+	// paths are appended to URL. Zero Speed means real time.
+	Replay *Replay
+	// GRPC describes unary gRPC method that is called instead of
+	// sending HTTP requests, Body is its request in JSON.
+	GRPC *GRPC
+
+	// Headers are sent with every request. Those with IsTemplate set
+	// are rendered for every request, those with Probability set are
+	// only sent with some of them.
+	Headers []Header
+
+	Body         string
+	BodyFilePath string
+	// BodyDir is the directory bodies are picked from at random.
+	BodyDir string
+	// BodyGenerator produces body of every request, there is no
+	// flag counterpart of it.
+	BodyGenerator BodyGenerator
+	// BodySize makes random bodies be sent, of fixed size or drawn
+	// from the range and distribution, i.e. "1KB-1MB:lognormal".
+	BodySize string
+	// CompressBody is the content coding body is compressed with
+	// before sending.
+	CompressBody string
+	Form         []FormField
+	BodyTemplate bool
+	Stream       bool
+	Cookies      bool
+	// Resolve holds overrides of addresses connections are made to,
+	// in the form of host:port:ip.
+	Resolve    []string
+	PinDNS     bool
+	UnixSocket string
+	Proxy      string
+	LocalAddrs []string
+	// BasicAuth holds <user>:<password> sent with HTTP Basic
+	// authentication.
+	BasicAuth string
+
+	CertPath   string
+	KeyPath    string
+	CACertPath string
+	SNI        string
+	// ALPN lists protocols advertised in TLS handshake instead of the
+	// ones client advertises by default.
+	ALPN     []string
+	Insecure bool
+
+	Timeout          time.Duration
+	ConnectTimeout   time.Duration
+	ClientType       ClientType
+	RampUp           time.Duration
+	Warmup           time.Duration
+	ResetAfterWarmup bool
+	PrimeConnections bool
+
+	Rate             *uint64
+	RatePerConn      bool
+	LoadProfile      string
+	Arrival          string
+	CorrectedLatency bool
+	DisableKeepAlive bool
+	ThinkTime        string
+	Jitter           time.Duration
+	ServerTimeHeader string
+	KneeLatency      string
+	// LatencyDigits is the number of significant digits latencies are
+	// recorded with, zero means they are recorded exactly.
+	LatencyDigits  uint64
+	LatencyCeiling time.Duration
+	// ConnStats makes results be recorded for every connection
+	// separately, in addition to the totals.
+	ConnStats bool
+	// InterArrival makes gaps between completions of requests be
+	// recorded.
+	InterArrival bool
+
+	// Percentiles are fractions (in [0, 1] range) for which latency
+	// and request rate percentiles are calculated.
+	Percentiles         []float64
+	ExpectedStatusCodes []int
+
+	Retries            uint64
+	LatencyWithRetries bool
+	AbortErrorRate     *float64
+	MaxRedirects       uint64
+	FinalHopLatency    bool
+	// MaxBytes is the number of bytes read and written the test is
+	// stopped at, nil means no cap.
+	MaxBytes *uint64
+
+	// OnRequest is called once every request completes, there is no
+	// flag counterpart of it.
+	OnRequest RequestHook
+	// OnTick is called every second with results so far, there is no
+	// flag counterpart of it.
+	OnTick TickHook
+
+	// Seed is what random number generators are seeded with, zero
+	// means a random one.
+	Seed uint64
+}
+
+// Run performs the test described by opts and returns its results.
+// Nothing is printed.
+func Run(opts Options) (TestInfo, error) {
+	return RunContext(context.Background(), opts)
+}
+
+// RunContext is like Run, but stops sending requests once ctx is done.
+// Test is then interrupted just like with Ctrl+C: in-flight requests
+// are given a grace period to complete and TestInfo holds partial
+// results, with Result.Interrupted set, alongside with ctx.Err().
+func RunContext(ctx context.Context, opts Options) (TestInfo, error) {
+	c, err := optionsToConfig(opts)
+	if err != nil {
+		return TestInfo{}, err
+	}
+	b, err := newBombardier(c)
+	if err != nil {
+		return TestInfo{}, err
+	}
+	b.disableOutput()
+	finished := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			b.interrupt()
+		case <-finished:
+		}
+	}()
+	b.bombard()
+	close(finished)
+	info := b.gatherInfo()
+	if b.isInterrupted() {
+		return info, ctx.Err()
+	}
+	return info, nil
+}
+
+// optionsToConfig is the Go counterpart of parsing command line
+// arguments.
+func optionsToConfig(o Options) (config, error) {
+	c := config{
+		numConns:         o.NumberOfConnections,
+		maxConnsPerHost:  o.MaxConnsPerHost,
+		method:           o.Method,
+		headers:          new(headersList),
+		body:             o.Body,
+		bodyFilePath:     o.BodyFilePath,
+		bodyDir:          o.BodyDir,
+		compressBody:     o.CompressBody,
+		bodyTemplate:     o.BodyTemplate,
+		cookies:          o.Cookies,
+		pinDNS:           o.PinDNS,
+		unixSocket:       o.UnixSocket,
+		proxy:            o.Proxy,
+		certPath:         o.CertPath,
+		keyPath:          o.KeyPath,
+		caCertPath:       o.CACertPath,
+		sni:              o.SNI,
+		basicAuth:        o.BasicAuth,
+		insecure:         o.Insecure,
+		stream:           o.Stream,
+		timeout:          o.Timeout,
+		connectTimeout:   o.ConnectTimeout,
+		clientType:       clientTyp(o.ClientType),
+		rampUp:           o.RampUp,
+		jitter:           o.Jitter,
+		serverTimeHeader: o.ServerTimeHeader,
+		interArrival:     o.InterArrival,
+		warmup:           o.Warmup,
+		resetAfterWarmup: o.ResetAfterWarmup,
+		primeConns:       o.PrimeConnections,
+		rate:             o.Rate,
+		ratePerConn:      o.RatePerConn,
+		correctLatency:   o.CorrectedLatency,
+		disableKeepAlive: o.DisableKeepAlive,
+		retries:          o.Retries,
+		abortErrorRate:   o.AbortErrorRate,
+		maxBytes:         o.MaxBytes,
+		format:           knownFormat("plain-text"),
+
+		latencyWithRetries: o.LatencyWithRetries,
+		maxRedirects:       o.MaxRedirects,
+		finalHopLatency:    o.FinalHopLatency,
+		latencyDigits:      o.LatencyDigits,
+		latencyCeiling:     o.LatencyCeiling,
+		connStats:          o.ConnStats,
+	}
+	if c.numConns == 0 {
+		c.numConns = defaultNumberOfConns
+	}
+	if c.method == "" {
+		c.method = "GET"
+	}
+	if c.timeout == 0 {
+		c.timeout = defaultTimeout
+	}
+	switch {
+	case o.TestType == ByTimeOrNumberOfReqs || o.TestType == 0 &&
+		o.NumberOfRequests > 0 && o.TestDuration > 0:
+		numReqs, duration := o.NumberOfRequests, o.TestDuration
+		c.numReqs, c.duration = &numReqs, &duration
+	case o.TestType == ByNumberOfReqs || o.NumberOfRequests > 0:
+		numReqs := o.NumberOfRequests
+		c.numReqs = &numReqs
+	case o.TestType == ByTime || o.TestDuration > 0:
+		duration := o.TestDuration
+		c.duration = &duration
+	}
+	if o.BodyGenerator != nil {
+		gen := o.BodyGenerator
+		c.bodyGenerator = &gen
+	}
+	if o.OnRequest != nil {
+		hook := o.OnRequest
+		c.onRequest = &hook
+	}
+	if o.OnTick != nil {
+		hook := o.OnTick
+		c.onTick = &hook
+	}
+	if o.Seed != 0 {
+		seed := o.Seed
+		c.seed = &seed
+	}
+	if len(o.ALPN) > 0 {
+		alpn := append([]string(nil), o.ALPN...)
+		c.alpn = &alpn
+	}
+
+	var (
+		templates headersList
+		random    randomHeadersList
+	)
+	for _, h := range o.Headers {
+		hdr := header{h.Key, h.Value}
+		switch {
+		case h.IsTemplate:
+			templates = append(templates, hdr)
+		case h.Probability > 0:
+			random = append(random, randomHeader{hdr, h.Probability})
+		default:
+			*c.headers = append(*c.headers, hdr)
+		}
+	}
+	if len(templates) > 0 {
+		c.headerTemplates = &templates
+	}
+	if len(random) > 0 {
+		c.randomHeaders = &random
+	}
+	if len(o.Form) > 0 {
+		form := make(formFieldsList, 0, len(o.Form))
+		for _, f := range o.Form {
+			form = append(form, formField{f.Name, f.Value, f.IsFile})
+		}
+		c.form = &form
+	}
+	if len(o.Resolve) > 0 {
+		resolve := new(resolveOverridesList)
+		for _, r := range o.Resolve {
+			if err := resolve.Set(r); err != nil {
+				return emptyConf, err
+			}
+		}
+		c.resolve = resolve
+	}
+	if len(o.LocalAddrs) > 0 {
+		addrs := new(localAddrsList)
+		for _, a := range o.LocalAddrs {
+			if err := addrs.Set(a); err != nil {
+				return emptyConf, err
+			}
+		}
+		c.localAddrs = addrs
+	}
+	if len(o.Percentiles) > 0 {
+		pcs := append([]float64(nil), o.Percentiles...)
+		c.percentiles = &pcs
+	}
+	if len(o.ExpectedStatusCodes) > 0 {
+		codes := append([]int(nil), o.ExpectedStatusCodes...)
+		c.expectedStatuses = &codes
+	}
+
+	var err error
+	if o.LoadProfile != "" {
+		if c.loadProfile, err = parseLoadProfile(o.LoadProfile); err != nil {
+			return emptyConf, err
+		}
+	}
+	if o.Arrival != "" {
+		if c.arrival, err = parseArrival(o.Arrival); err != nil {
+			return emptyConf, err
+		}
+	}
+	if o.BodySize != "" {
+		if c.bodySize, err = parseBodySize(o.BodySize); err != nil {
+			return emptyConf, err
+		}
+	}
+	if o.ThinkTime != "" {
+		if c.thinkTime, err = parseThinkTime(o.ThinkTime); err != nil {
+			return emptyConf, err
+		}
+	}
+	if o.KneeLatency != "" {
+		var l latencyAssertionsList
+		if err = l.Set(o.KneeLatency); err != nil {
+			return emptyConf, err
+		}
+		c.kneeLatency = &l[0]
+	}
+	if g := o.GRPC; g != nil {
+		if c.grpc, err = readGRPCMethod(g.ProtoSet, g.Method); err != nil {
+			return emptyConf, err
+		}
+	}
+
+	rawURLs := o.URLs
+	if len(rawURLs) == 0 && o.URL != "" {
+		rawURLs = []string{o.URL}
+	}
+	if len(o.WeightedURLs) > 0 {
+		rawURLs = nil
+		weights := make([]uint, 0, len(o.WeightedURLs))
+		for _, wu := range o.WeightedURLs {
+			rawURLs = append(rawURLs, wu.URL)
+			weights = append(weights, wu.Weight)
+		}
+		c.weights = &weights
+	}
+	if o.Scenario != "" {
+		if c.scenario, err = readScenario(o.Scenario, c.method); err != nil {
+			return emptyConf, err
+		}
+		rawURLs = c.scenario.urls()
+	}
+	if r := o.Replay; r != nil {
+		format := r.Format
+		if format == "" {
+			format = defaultLogFormat
+		}
+		speed := r.Speed
+		if speed == 0 {
+			speed = 1
+		}
+		if c.replay, err = readReplay(r.Path, format, speed); err != nil {
+			return emptyConf, err
+		}
+	}
+	if len(rawURLs) == 0 {
+		return emptyConf, errNoURL
+	}
+	urls := make([]string, len(rawURLs))
+	for i, raw := range rawURLs {
+		if urls[i], err = tryParseURL(raw); err != nil {
+			return emptyConf, err
+		}
+	}
+	c.url = urls[0]
+	if len(urls) > 1 || c.weights != nil || c.scenario != nil {
+		c.urls = &urls
+	}
+	return c, nil
+}
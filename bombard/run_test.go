@@ -0,0 +1,284 @@
+package bombard
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	var reqs uint64
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			atomic.AddUint64(&reqs, 1)
+			if r.Header.Get("X-Test") != "run" {
+				rw.WriteHeader(http.StatusBadRequest)
+			}
+		}),
+	)
+	defer s.Close()
+	info, err := Run(Options{
+		NumberOfConnections: 3,
+		NumberOfRequests:    30,
+		URL:                 s.URL,
+		Headers:             []Header{{Key: "X-Test", Value: "run"}},
+		ClientType:          NetHTTP1,
+		Percentiles:         []float64{0.5, 0.99},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := atomic.LoadUint64(&reqs); got != 30 {
+		t.Errorf("Expected 30 requests, but got %v", got)
+	}
+	if !info.Spec.IsTestWithNumberOfReqs() {
+		t.Error("Test should be limited by the number of requests")
+	}
+	if info.Spec.Method != "GET" || info.Spec.Timeout != defaultTimeout {
+		t.Errorf(
+			"Expected defaults to be applied, but got %v and %v",
+			info.Spec.Method, info.Spec.Timeout,
+		)
+	}
+	if info.Result.Req2XX != 30 {
+		t.Errorf("Expected 30 2xx responses, but got %v", info.Result.Req2XX)
+	}
+	stats := info.Result.LatenciesStats(info.Spec.Percentiles)
+	if stats == nil {
+		t.Fatal("Latencies weren't recorded")
+	}
+	if len(stats.Percentiles) != 2 || stats.Max < stats.Mean {
+		t.Errorf("Unexpected latency stats: %+v", stats)
+	}
+}
+
+func TestRunTimed(t *testing.T) {
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {}),
+	)
+	defer s.Close()
+	info, err := Run(Options{
+		NumberOfConnections: 1,
+		TestDuration:        time.Second,
+		URL:                 s.URL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.Spec.IsTimedTest() {
+		t.Error("Test should be limited by time")
+	}
+	if info.Result.Req2XX == 0 {
+		t.Error("No requests were sent")
+	}
+}
+
+func TestRunBodyGenerator(t *testing.T) {
+	var mismatched uint64
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil || !strings.HasPrefix(string(body), "request ") {
+				atomic.AddUint64(&mismatched, 1)
+			}
+		}),
+	)
+	defer s.Close()
+	info, err := Run(Options{
+		NumberOfConnections: 2,
+		NumberOfRequests:    10,
+		Method:              "POST",
+		URL:                 s.URL,
+		BodyGenerator: func(n uint64) io.Reader {
+			return strings.NewReader(fmt.Sprintf("request %v", n))
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := atomic.LoadUint64(&mismatched); got != 0 {
+		t.Errorf("%v requests had unexpected bodies", got)
+	}
+	if !info.Spec.BodyGenerated {
+		t.Error("Spec should tell that bodies were generated")
+	}
+	if _, err := json.Marshal(info); err != nil {
+		t.Errorf("Results should be marshallable, but got %v", err)
+	}
+}
+
+func TestRunContext(t *testing.T) {
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			time.Sleep(10 * time.Millisecond)
+		}),
+	)
+	defer s.Close()
+	ctx, cancel := context.WithTimeout(
+		context.Background(), 300*time.Millisecond,
+	)
+	defer cancel()
+	start := time.Now()
+	info, err := RunContext(ctx, Options{
+		NumberOfConnections: 2,
+		TestDuration:        time.Minute,
+		URL:                 s.URL,
+	})
+	if err != context.DeadlineExceeded {
+		t.Errorf("Expected %v, but got %v", context.DeadlineExceeded, err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("Test wasn't stopped in time, took %v", elapsed)
+	}
+	if !info.Result.Interrupted {
+		t.Error("Results should be marked as partial")
+	}
+	if info.Result.Req2XX == 0 {
+		t.Error("Results gathered before cancellation are missing")
+	}
+}
+
+func TestRunContextFinished(t *testing.T) {
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {}),
+	)
+	defer s.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	info, err := RunContext(ctx, Options{
+		NumberOfConnections: 1,
+		NumberOfRequests:    5,
+		URL:                 s.URL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Result.Interrupted || info.Result.Req2XX != 5 {
+		t.Errorf("Unexpected results: %+v", info.Result)
+	}
+}
+
+func TestOptionsToConfig(t *testing.T) {
+	rate := uint64(50)
+	c, err := optionsToConfig(Options{
+		URL: "localhost:8080",
+		WeightedURLs: []WeightedURL{
+			{URL: "http://localhost:8080/a", Weight: 3},
+			{URL: "http://localhost:8080/b", Weight: 1},
+		},
+		Headers: []Header{
+			{Key: "A", Value: "a"},
+			{Key: "B", Value: "{{ .RequestNumber }}", IsTemplate: true},
+			{Key: "C", Value: "c", Probability: 0.5},
+		},
+		Rate: &rate,
+		Seed: 42,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expURLs := []string{"http://localhost:8080/a", "http://localhost:8080/b"}
+	if c.urls == nil || !reflect.DeepEqual(*c.urls, expURLs) {
+		t.Errorf("Expected URLs %v, but got %v", expURLs, c.urls)
+	}
+	if c.weights == nil || !reflect.DeepEqual(*c.weights, []uint{3, 1}) {
+		t.Errorf("Unexpected weights: %v", c.weights)
+	}
+	if !reflect.DeepEqual(*c.headers, headersList{{"A", "a"}}) {
+		t.Errorf("Unexpected headers: %v", *c.headers)
+	}
+	if c.headerTemplates == nil || len(*c.headerTemplates) != 1 {
+		t.Errorf("Unexpected header templates: %v", c.headerTemplates)
+	}
+	if c.randomHeaders == nil || len(*c.randomHeaders) != 1 {
+		t.Errorf("Unexpected random headers: %v", c.randomHeaders)
+	}
+	if c.rate != &rate || c.seed == nil || *c.seed != 42 {
+		t.Errorf("Unexpected rate %v or seed %v", c.rate, c.seed)
+	}
+	if c.numConns != defaultNumberOfConns || c.numReqs != nil {
+		t.Errorf("Unexpected connections %v or requests %v",
+			c.numConns, c.numReqs)
+	}
+}
+
+func TestOptionsToConfigTestType(t *testing.T) {
+	expectations := []struct {
+		in  Options
+		out testTyp
+	}{
+		{Options{}, none},
+		{Options{TestDuration: time.Second}, timed},
+		{Options{NumberOfRequests: 10}, counted},
+		{Options{NumberOfRequests: 10, TestDuration: time.Second}, timedOrCounted},
+		{
+			Options{
+				TestType:         ByNumberOfReqs,
+				NumberOfRequests: 10,
+				TestDuration:     time.Second,
+			},
+			counted,
+		},
+		{
+			Options{
+				TestType:         ByTimeOrNumberOfReqs,
+				NumberOfRequests: 10,
+				TestDuration:     time.Second,
+			},
+			timedOrCounted,
+		},
+	}
+	for _, e := range expectations {
+		e.in.URL = "localhost:8080"
+		c, err := optionsToConfig(e.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if typ := c.testType(); typ != e.out {
+			t.Errorf("%+v: expected %v, but got %v", e.in, e.out, typ)
+		}
+	}
+}
+
+func TestRunInvalidOptions(t *testing.T) {
+	expectations := []struct {
+		in  Options
+		out string
+	}{
+		{
+			Options{},
+			errNoURL.Error(),
+		},
+		{
+			Options{URL: "http://localhost", Timeout: -time.Second},
+			errNegativeTimeout.Error(),
+		},
+		{
+			Options{URL: "http://localhost", BasicAuth: "user"},
+			errInvalidBasicAuthFormat.Error(),
+		},
+		{
+			Options{URL: "http://localhost", Arrival: "bursty"},
+			"",
+		},
+	}
+	for _, e := range expectations {
+		_, err := Run(e.in)
+		if err == nil {
+			t.Errorf("Expected %+v to be rejected", e.in)
+			continue
+		}
+		if e.out != "" && err.Error() != e.out {
+			t.Errorf("Expected %q, but got %q", e.out, err)
+		}
+	}
+}
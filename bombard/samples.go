@@ -0,0 +1,72 @@
+package bombard
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"time"
+)
+
+const samplesBufferSize = 4096
+
+// sample describes a single completed request.
+type sample struct {
+	Timestamp  time.Time `json:"timestamp"`
+	LatencyUs  uint64    `json:"latencyUs"`
+	StatusCode int       `json:"statusCode"`
+	BytesRead  int64     `json:"bytesRead"`
+	ConnID     uint64    `json:"connId"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// samplesWriter writes samples to the file in JSON Lines format.
+// Writing is done in a separate goroutine, so that slow disk doesn't
+// affect the requests being measured.
+type samplesWriter struct {
+	file    *os.File
+	samples chan sample
+	done    chan error
+}
+
+func newSamplesWriter(path string) (*samplesWriter, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	sw := &samplesWriter{
+		file:    f,
+		samples: make(chan sample, samplesBufferSize),
+		done:    make(chan error, 1),
+	}
+	go sw.run()
+	return sw, nil
+}
+
+func (sw *samplesWriter) run() {
+	w := bufio.NewWriter(sw.file)
+	enc := json.NewEncoder(w)
+	var err error
+	for s := range sw.samples {
+		if err == nil {
+			err = enc.Encode(s)
+		}
+	}
+	if ferr := w.Flush(); err == nil {
+		err = ferr
+	}
+	if cerr := sw.file.Close(); err == nil {
+		err = cerr
+	}
+	sw.done <- err
+}
+
+func (sw *samplesWriter) add(s sample) {
+	sw.samples <- s
+}
+
+// close flushes all the samples written so far and closes the file.
+// No samples can be added after close is called.
+func (sw *samplesWriter) close() error {
+	close(sw.samples)
+	return <-sw.done
+}
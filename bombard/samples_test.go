@@ -0,0 +1,77 @@
+package bombard
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSamplesWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bombardier-samples")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "samples.jsonl")
+
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			_, _ = rw.Write([]byte("hello"))
+		}),
+	)
+	defer s.Close()
+	numReqs := uint64(50)
+	b, e := newBombardier(config{
+		numConns:    4,
+		numReqs:     &numReqs,
+		url:         s.URL,
+		headers:     new(headersList),
+		timeout:     defaultTimeout,
+		method:      "GET",
+		format:      knownFormat("plain-text"),
+		samplesPath: path,
+	})
+	if e != nil {
+		t.Fatal(e)
+	}
+	b.disableOutput()
+	b.bombard()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	count := uint64(0)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var smp sample
+		if err := json.Unmarshal(scanner.Bytes(), &smp); err != nil {
+			t.Fatal(err)
+		}
+		if smp.StatusCode != http.StatusOK || smp.BytesRead != 5 ||
+			smp.ConnID >= 4 || smp.Timestamp.IsZero() || smp.Error != "" {
+			t.Errorf("unexpected sample: %+v", smp)
+		}
+		count++
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if count != numReqs {
+		t.Errorf("Expected %v samples, but got %v", numReqs, count)
+	}
+}
+
+func TestSamplesWriterInvalidPath(t *testing.T) {
+	if _, err := newSamplesWriter(
+		filepath.Join("nonexistent", "dir", "samples.jsonl"),
+	); err == nil {
+		t.Error("samples writer created with invalid path")
+	}
+}
@@ -0,0 +1,164 @@
+package bombard
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"sort"
+)
+
+// scenario is a sequence of requests every connection sends over and
+// over again, in order.
+type scenario struct {
+	path  string
+	steps []scenarioStep
+}
+
+// scenarioStep describes a single request of scenario.
+type scenarioStep struct {
+	Name    string            `json:"name"`
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+	Body    string            `json:"body"`
+	// Template tells whether body and values of headers are
+	// templates rendered for every request
+	Template bool `json:"template"`
+	// Capture maps names of variables to sources of their values
+	// in response
+	Capture map[string]string `json:"capture"`
+
+	captures captures
+}
+
+type invalidScenarioError struct {
+	path string
+	err  error
+}
+
+func (i *invalidScenarioError) Error() string {
+	return fmt.Sprintf("Invalid scenario %v: %v", i.path, i.err)
+}
+
+// readScenario reads scenario from JSON file. Steps without method
+// use the given one.
+func readScenario(path, method string) (*scenario, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Steps []scenarioStep `json:"steps"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, &invalidScenarioError{path, err}
+	}
+	if len(file.Steps) == 0 {
+		return nil, errEmptyScenario
+	}
+	for i := range file.Steps {
+		s := &file.Steps[i]
+		if s.URL == "" {
+			return nil, &invalidScenarioError{
+				path, fmt.Errorf("step %v has no URL", i+1),
+			}
+		}
+		if s.Method == "" {
+			s.Method = method
+		}
+		if len(s.Capture) > 0 {
+			s.captures, err = parseCaptures(s.Capture)
+			if err != nil {
+				return nil, &invalidScenarioError{
+					path, fmt.Errorf("step %v: %v", i+1, err),
+				}
+			}
+		}
+	}
+	return &scenario{path: path, steps: file.Steps}, nil
+}
+
+func (s *scenario) urls() []string {
+	urls := make([]string, len(s.steps))
+	for i, step := range s.steps {
+		urls[i] = step.URL
+	}
+	return urls
+}
+
+// names returns names of the steps, unnamed ones are named after
+// their method and URL.
+func (s *scenario) names() []string {
+	names := make([]string, len(s.steps))
+	for i, step := range s.steps {
+		names[i] = step.Name
+		if names[i] == "" {
+			names[i] = step.Method + " " + step.URL
+		}
+	}
+	return names
+}
+
+// templated tells whether any of the steps uses templates.
+func (s *scenario) templated() bool {
+	for _, step := range s.steps {
+		if step.Template {
+			return true
+		}
+	}
+	return false
+}
+
+// variables returns names of all the variables captured by steps.
+func (s *scenario) variables() []string {
+	var names []string
+	for _, step := range s.steps {
+		for _, c := range step.captures {
+			names = append(names, c.name)
+		}
+	}
+	return names
+}
+
+// headers returns headers of the step sorted by name, so that they
+// are always sent in the same order.
+func (s *scenarioStep) headers() headersList {
+	keys := make([]string, 0, len(s.Headers))
+	for k := range s.Headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	hs := make(headersList, len(keys))
+	for i, k := range keys {
+		hs[i] = header{k, s.Headers[k]}
+	}
+	return hs
+}
+
+// apply makes client send requests of the step, on top of the
+// headers common to all of them.
+func (s *scenarioStep) apply(cc *clientOpts, rngs []*rand.Rand) error {
+	cc.method = s.Method
+	cc.captures = s.captures
+	headers := s.headers()
+	if s.Template {
+		body, err := newBodyTemplate(s.Body, rngs)
+		if err != nil {
+			return err
+		}
+		cc.body, cc.bodyTmpl = nil, body
+		htmpls, err := newHeaderTemplates(headers, rngs)
+		if err != nil {
+			return err
+		}
+		cc.headerTmpls = append(append(headerTemplates{}, cc.headerTmpls...),
+			htmpls...)
+		return nil
+	}
+	body := s.Body
+	cc.body = &body
+	all := append(append(headersList{}, *cc.headers...), headers...)
+	cc.headers = &all
+	return nil
+}
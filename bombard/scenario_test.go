@@ -0,0 +1,94 @@
+package bombard
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeScenario(t *testing.T, content string) string {
+	dir, err := ioutil.TempDir("", "bombardier-scenario")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "scenario.json")
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadScenario(t *testing.T) {
+	path := writeScenario(t, `{"steps": [
+		{"name": "login", "method": "POST", "url": "http://localhost/login",
+		 "headers": {"X-B": "2", "X-A": "1"}, "body": "{}",
+		 "capture": {"token": "$.token"}},
+		{"url": "http://localhost/items"}
+	]}`)
+	defer os.RemoveAll(filepath.Dir(path))
+	s, err := readScenario(path, "PUT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.path != path || len(s.steps) != 2 {
+		t.Fatalf("Unexpected scenario: %+v", s)
+	}
+	if s.steps[1].Method != "PUT" {
+		t.Errorf("Expected default method to be used, but got %v",
+			s.steps[1].Method)
+	}
+	expNames := []string{"login", "PUT http://localhost/items"}
+	if names := s.names(); !reflect.DeepEqual(names, expNames) {
+		t.Errorf("Expected names %v, but got %v", expNames, names)
+	}
+	expURLs := []string{"http://localhost/login", "http://localhost/items"}
+	if urls := s.urls(); !reflect.DeepEqual(urls, expURLs) {
+		t.Errorf("Expected URLs %v, but got %v", expURLs, urls)
+	}
+	expHeaders := headersList{{"X-A", "1"}, {"X-B", "2"}}
+	if hs := s.steps[0].headers(); !reflect.DeepEqual(hs, expHeaders) {
+		t.Errorf("Expected headers %v, but got %v", expHeaders, hs)
+	}
+	if s.templated() {
+		t.Error("Expected scenario not to use templates")
+	}
+	if vars := s.variables(); !reflect.DeepEqual(vars, []string{"token"}) {
+		t.Errorf("Expected token to be captured, but got %v", vars)
+	}
+}
+
+func TestReadScenarioErrors(t *testing.T) {
+	expectations := []struct {
+		content string
+		err     error
+	}{
+		{`{"steps": []}`, errEmptyScenario},
+		{`{}`, errEmptyScenario},
+	}
+	for _, e := range expectations {
+		path := writeScenario(t, e.content)
+		_, err := readScenario(path, "GET")
+		os.RemoveAll(filepath.Dir(path))
+		if err != e.err {
+			t.Errorf("%v: expected %v, but got %v", e.content, e.err, err)
+		}
+	}
+	for _, content := range []string{
+		`{"steps": [{"method": "GET"}]}`,
+		`{"steps": [{"url": "a.b", "capture": {"token": "token"}}]}`,
+		`{"steps": [`,
+	} {
+		path := writeScenario(t, content)
+		_, err := readScenario(path, "GET")
+		os.RemoveAll(filepath.Dir(path))
+		if _, ok := err.(*invalidScenarioError); !ok {
+			t.Errorf("%v: expected invalid scenario error, but got %v",
+				content, err)
+		}
+	}
+	if _, err := readScenario("/does/not/exist.json", "GET"); err == nil {
+		t.Error("Expected missing file to be reported")
+	}
+}
@@ -0,0 +1,55 @@
+package bombard
+
+import (
+	"bytes"
+	"math"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+// parseServerTime parses processing time reported by server in
+// a header like X-Response-Time, either as a duration (i.e. "12.5ms")
+// or as a plain number of milliseconds (i.e. "12.5"). The result is in
+// microseconds.
+func parseServerTime(s string) (uint64, error) {
+	s = strings.TrimSpace(s)
+	if d, err := time.ParseDuration(s); err == nil {
+		if d < 0 {
+			return 0, errInvalidServerTime
+		}
+		return uint64(d.Nanoseconds() / 1000), nil
+	}
+	ms, err := strconv.ParseFloat(s, 64)
+	if err != nil || ms < 0 || math.IsNaN(ms) || math.IsInf(ms, 0) {
+		return 0, errInvalidServerTime
+	}
+	return uint64(ms * 1000), nil
+}
+
+// recordServerTime records processing time reported by server in the
+// header with the value, responses without it or with malformed one
+// are only counted.
+func (b *bombardier) recordServerTime(value string) {
+	us, err := parseServerTime(value)
+	if err != nil {
+		atomic.AddUint64(&b.serverTimeErrors, 1)
+		return
+	}
+	b.serverTimes.Increment(us)
+}
+
+// peekResponseHeader returns value of the header regardless of case of
+// its name, which fasthttp client doesn't normalize.
+func peekResponseHeader(h *fasthttp.ResponseHeader, key string) []byte {
+	var value []byte
+	h.VisitAll(func(k, v []byte) {
+		if value == nil && bytes.EqualFold(k, []byte(key)) {
+			value = v
+		}
+	})
+	return value
+}
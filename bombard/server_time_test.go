@@ -0,0 +1,50 @@
+package bombard
+
+import (
+	"testing"
+
+	"github.com/valyala/fasthttp"
+)
+
+func TestParseServerTime(t *testing.T) {
+	expectations := []struct {
+		in  string
+		out uint64
+		err error
+	}{
+		{"12ms", 12000, nil},
+		{"12.5ms", 12500, nil},
+		{" 1.5s ", 1500000, nil},
+		{"250us", 250, nil},
+		{"12", 12000, nil},
+		{"0.25", 250, nil},
+		{"0", 0, nil},
+		{"", 0, errInvalidServerTime},
+		{"fast", 0, errInvalidServerTime},
+		{"-1ms", 0, errInvalidServerTime},
+		{"-3", 0, errInvalidServerTime},
+		{"NaN", 0, errInvalidServerTime},
+		{"Inf", 0, errInvalidServerTime},
+	}
+	for _, e := range expectations {
+		us, err := parseServerTime(e.in)
+		if us != e.out || err != e.err {
+			t.Errorf("%q: expected %v, %v, but got %v, %v",
+				e.in, e.out, e.err, us, err)
+		}
+	}
+}
+
+func TestPeekResponseHeader(t *testing.T) {
+	var h fasthttp.ResponseHeader
+	h.DisableNormalizing()
+	h.Set("x-response-time", "12ms")
+	for _, key := range []string{"X-Response-Time", "x-response-time"} {
+		if v := string(peekResponseHeader(&h, key)); v != "12ms" {
+			t.Errorf("%v: expected 12ms, but got %q", key, v)
+		}
+	}
+	if v := peekResponseHeader(&h, "X-Other"); v != nil {
+		t.Errorf("Expected no value, but got %q", v)
+	}
+}
@@ -0,0 +1,144 @@
+package bombard
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	sigV4Algorithm  = "AWS4-HMAC-SHA256"
+	sigV4TimeFormat = "20060102T150405Z"
+	sigV4DateFormat = "20060102"
+)
+
+// sigV4Config holds credentials and scope requests are signed with
+// using AWS Signature Version 4.
+type sigV4Config struct {
+	accessKey, secretKey, sessionToken string
+	region, service                    string
+}
+
+// sigV4Signer signs requests, every one of them separately, since
+// signature covers time of the request and its body.
+type sigV4Signer struct {
+	sigV4Config
+	now func() time.Time
+}
+
+func newSigV4Signer(c sigV4Config) *sigV4Signer {
+	return &sigV4Signer{c, time.Now}
+}
+
+// sign computes signature of the request and adds headers that carry
+// it with set. Only host and headers added by sign itself are signed,
+// so that headers sent with some requests only don't matter.
+func (s *sigV4Signer) sign(
+	method, host string, u *url.URL, body []byte,
+	set func(key, value string),
+) {
+	now := s.now().UTC()
+	amzDate := now.Format(sigV4TimeFormat)
+	headers := [][2]string{
+		{"host", host},
+		{"x-amz-date", amzDate},
+	}
+	if s.sessionToken != "" {
+		headers = append(headers,
+			[2]string{"x-amz-security-token", s.sessionToken})
+	}
+	var canonicalHeaders strings.Builder
+	names := make([]string, len(headers))
+	for i, h := range headers {
+		canonicalHeaders.WriteString(h[0] + ":" + h[1] + "\n")
+		names[i] = h[0]
+	}
+	signedHeaders := strings.Join(names, ";")
+	payloadHash := sha256.Sum256(body)
+	canonicalRequest := strings.Join([]string{
+		method,
+		sigV4CanonicalPath(u),
+		sigV4CanonicalQuery(u),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	date := now.Format(sigV4DateFormat)
+	scope := date + "/" + s.region + "/" + s.service + "/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := sigV4Algorithm + "\n" + amzDate + "\n" + scope + "\n" +
+		hex.EncodeToString(requestHash[:])
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), date)
+	for _, part := range []string{s.region, s.service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	set("X-Amz-Date", amzDate)
+	if s.sessionToken != "" {
+		set("X-Amz-Security-Token", s.sessionToken)
+	}
+	set("Authorization", fmt.Sprintf(
+		"%v Credential=%v/%v, SignedHeaders=%v, Signature=%v",
+		sigV4Algorithm, s.accessKey, scope, signedHeaders, signature,
+	))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// sigV4CanonicalPath returns escaped path escaped once more, the way
+// all services but S3 expect it.
+func sigV4CanonicalPath(u *url.URL) string {
+	path := u.EscapedPath()
+	if path == "" {
+		return "/"
+	}
+	return sigV4Escape(path, false)
+}
+
+func sigV4CanonicalQuery(u *url.URL) string {
+	query := u.Query()
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var pairs []string
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			pairs = append(pairs,
+				sigV4Escape(k, true)+"="+sigV4Escape(v, true))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// sigV4Escape percent-encodes everything but unreserved characters
+// and, unless escapeSlash is set, slashes.
+func sigV4Escape(s string, escapeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z',
+			'0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~',
+			c == '/' && !escapeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,106 @@
+package bombard
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Expectations are taken from AWS Signature Version 4 test suite.
+func TestSigV4Sign(t *testing.T) {
+	signedAt := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	s := &sigV4Signer{
+		sigV4Config{
+			accessKey: "AKIDEXAMPLE",
+			secretKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+			region:    "us-east-1",
+			service:   "service",
+		},
+		func() time.Time { return signedAt },
+	}
+	expectations := []struct {
+		in  string
+		out string
+	}{
+		{
+			"/",
+			"AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/" +
+				"us-east-1/service/aws4_request, " +
+				"SignedHeaders=host;x-amz-date, Signature=" +
+				"5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		},
+		{
+			"/?Param2=value2&Param1=value1",
+			"AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/" +
+				"us-east-1/service/aws4_request, " +
+				"SignedHeaders=host;x-amz-date, Signature=" +
+				"b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500",
+		},
+	}
+	for _, e := range expectations {
+		u, err := url.ParseRequestURI(e.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		headers := make(map[string]string)
+		s.sign("GET", "example.amazonaws.com", u, nil,
+			func(k, v string) { headers[k] = v })
+		if act := headers["Authorization"]; act != e.out {
+			t.Errorf("%q: expected %q, but got %q", e.in, e.out, act)
+		}
+		if act := headers["X-Amz-Date"]; act != "20150830T123600Z" {
+			t.Errorf("%q: unexpected X-Amz-Date %q", e.in, act)
+		}
+		if _, ok := headers["X-Amz-Security-Token"]; ok {
+			t.Errorf("%q: unexpected X-Amz-Security-Token", e.in)
+		}
+	}
+}
+
+func TestSigV4SignSessionToken(t *testing.T) {
+	s := newSigV4Signer(sigV4Config{
+		accessKey:    "id",
+		secretKey:    "secret",
+		sessionToken: "token",
+		region:       "eu-west-1",
+		service:      "execute-api",
+	})
+	headers := make(map[string]string)
+	s.sign("GET", "localhost", &url.URL{Path: "/"}, nil,
+		func(k, v string) { headers[k] = v })
+	if act := headers["X-Amz-Security-Token"]; act != "token" {
+		t.Errorf("Expected session token, but got %q", act)
+	}
+	exp := "SignedHeaders=host;x-amz-date;x-amz-security-token,"
+	if act := headers["Authorization"]; !strings.Contains(act, exp) {
+		t.Errorf("Expected %q in %q", exp, act)
+	}
+}
+
+func TestSigV4Canonicalization(t *testing.T) {
+	expectations := []struct {
+		in          string
+		path, query string
+	}{
+		{"", "/", ""},
+		{"/a/b", "/a/b", ""},
+		{"/a%20b", "/a%2520b", ""},
+		{"/?b=2&a=3&a=1", "/", "a=1&a=3&b=2"},
+		{"/?k=a+b&x=%2F", "/", "k=a%20b&x=%2F"},
+		{"/?novalue", "/", "novalue="},
+	}
+	for _, e := range expectations {
+		u, err := url.Parse(e.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if act := sigV4CanonicalPath(u); act != e.path {
+			t.Errorf("%q: expected path %q, but got %q", e.in, e.path, act)
+		}
+		if act := sigV4CanonicalQuery(u); act != e.query {
+			t.Errorf("%q: expected query %q, but got %q",
+				e.in, e.query, act)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package bombard
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// smoke sends a single request to the first target using the same
+// client the test would use and prints the whole response. It fails
+// unless the response has the expected status code, which is any 2xx
+// one (or OK for gRPC calls) if there are no expectations.
+func (b *bombardier) smoke() error {
+	res, dump := b.clients[0].dump(0)
+	if res.err != nil {
+		return fmt.Errorf("Smoke request failed: %v", res.err)
+	}
+	if b.conf.printIntro {
+		fmt.Fprintf(b.out, "Smoke request to %v:\n%s\n", b.conf.url, dump)
+	}
+	// gRPC calls that didn't end with OK status failed already
+	ok := res.code/100 == 2 || b.conf.grpc != nil
+	if b.expectedStatuses != nil {
+		ok = b.expectedStatuses[res.code]
+	}
+	if !ok {
+		return fmt.Errorf("Smoke request failed with status code %v",
+			res.code)
+	}
+	// Bytes of the smoke request aren't part of the test
+	atomic.StoreInt64(&b.bytesRead, 0)
+	atomic.StoreInt64(&b.bytesWritten, 0)
+	return nil
+}
@@ -0,0 +1,111 @@
+package bombard
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSmoke(t *testing.T) {
+	testAllClients(t, testSmoke)
+}
+
+func testSmoke(clientType clientTyp, t *testing.T) {
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-Token") != "secret" {
+				rw.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			rw.Header().Set("X-Smoke", "passed")
+			rw.Write([]byte("smoke body"))
+		}),
+	)
+	defer s.Close()
+	for _, e := range []struct {
+		headers *headersList
+		ok      bool
+	}{
+		{&headersList{{"X-Token", "secret"}}, true},
+		{new(headersList), false},
+	} {
+		numReqs := uint64(1)
+		b, err := newBombardier(config{
+			numConns:   1,
+			numReqs:    &numReqs,
+			url:        s.URL,
+			headers:    e.headers,
+			timeout:    defaultTimeout,
+			method:     "GET",
+			clientType: clientType,
+			format:     knownFormat("plain-text"),
+			printIntro: true,
+			smoke:      true,
+		})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		out := new(bytes.Buffer)
+		b.redirectOutputTo(out)
+		err = b.smoke()
+		if e.ok != (err == nil) {
+			t.Errorf("Expected success to be %v, but got %v", e.ok, err)
+		}
+		if !e.ok {
+			continue
+		}
+		for _, exp := range []string{
+			"200 OK", "X-Smoke: passed", "smoke body",
+		} {
+			if !strings.Contains(out.String(), exp) {
+				t.Errorf("Expected %q in the output, but got %q",
+					exp, out.String())
+			}
+		}
+		if b.bytesRead != 0 || b.bytesWritten != 0 {
+			t.Errorf("Expected byte counters to be reset, but got %v/%v",
+				b.bytesRead, b.bytesWritten)
+		}
+	}
+}
+
+func TestSmokeDoesntShortenTimedTest(t *testing.T) {
+	var reqs uint64
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			if atomic.AddUint64(&reqs, 1) == 1 {
+				time.Sleep(600 * time.Millisecond)
+			}
+		}),
+	)
+	defer s.Close()
+	duration := time.Second
+	b, err := newBombardier(config{
+		numConns:   1,
+		duration:   &duration,
+		url:        s.URL,
+		headers:    new(headersList),
+		timeout:    defaultTimeout,
+		method:     "GET",
+		clientType: nhttp1,
+		format:     knownFormat("plain-text"),
+		smoke:      true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.disableOutput()
+	if err = b.smoke(); err != nil {
+		t.Fatal(err)
+	}
+	b.bombard()
+	if b.timeTaken < duration-50*time.Millisecond {
+		t.Errorf("Expected test to last %v, but it took %v",
+			duration, b.timeTaken)
+	}
+}
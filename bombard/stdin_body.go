@@ -0,0 +1,26 @@
+package bombard
+
+import (
+	"io/ioutil"
+	"os"
+)
+
+// stdinBodyPath is the value of --body-file that tells bombardier to
+// read body from stdin.
+const stdinBodyPath = "-"
+
+// stdin is a variable, so it can be replaced in tests.
+var stdin = os.Stdin
+
+func readBodyFromStdin() ([]byte, error) {
+	fi, err := stdin.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if fi.Mode()&os.ModeCharDevice != 0 {
+		// Reading from terminal would block until user types in
+		// something, which is most likely not what was intended.
+		return nil, errBodyFromTerminal
+	}
+	return ioutil.ReadAll(stdin)
+}
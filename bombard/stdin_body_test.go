@@ -0,0 +1,106 @@
+package bombard
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func withStdin(t *testing.T, content string, f func()) {
+	tmp, err := ioutil.TempFile("", "bombardier-stdin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	if _, err = tmp.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = tmp.Seek(0, 0); err != nil {
+		t.Fatal(err)
+	}
+	oldStdin := stdin
+	stdin = tmp
+	defer func() {
+		stdin = oldStdin
+	}()
+	f()
+}
+
+func TestReadBodyFromStdin(t *testing.T) {
+	withStdin(t, "abracadabra", func() {
+		body, err := readBodyFromStdin()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(body) != "abracadabra" {
+			t.Errorf("Expected %q, but got %q", "abracadabra", body)
+		}
+	})
+}
+
+func TestReadBodyFromStdinTerminal(t *testing.T) {
+	tty, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer tty.Close()
+	fi, err := tty.Stat()
+	if err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		t.Skip("null device is not a character device on this platform")
+	}
+	oldStdin := stdin
+	stdin = tty
+	defer func() {
+		stdin = oldStdin
+	}()
+	if _, err := readBodyFromStdin(); err != errBodyFromTerminal {
+		t.Errorf("Expected %v, but got %v", errBodyFromTerminal, err)
+	}
+}
+
+func TestBombardierSendsBodyFromStdin(t *testing.T) {
+	testAllClients(t, testBombardierSendsBodyFromStdin)
+}
+
+func testBombardierSendsBodyFromStdin(clientType clientTyp, t *testing.T) {
+	requestBody := "body from stdin"
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if string(body) != requestBody {
+				t.Errorf("Expected %q, but got %q", requestBody, body)
+			}
+		}),
+	)
+	defer s.Close()
+	numReqs := uint64(10)
+	for _, stream := range []bool{false, true} {
+		withStdin(t, requestBody, func() {
+			b, e := newBombardier(config{
+				numConns:     defaultNumberOfConns,
+				numReqs:      &numReqs,
+				url:          s.URL,
+				headers:      new(headersList),
+				timeout:      defaultTimeout,
+				method:       "POST",
+				bodyFilePath: stdinBodyPath,
+				stream:       stream,
+				clientType:   clientType,
+				format:       knownFormat("plain-text"),
+			})
+			if e != nil {
+				t.Error(e)
+				return
+			}
+			b.disableOutput()
+			b.bombard()
+		})
+	}
+}
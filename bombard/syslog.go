@@ -0,0 +1,83 @@
+package bombard
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/kostyay/bombardier/internal"
+)
+
+const (
+	// Writing to syslog is best-effort, so it shouldn't hold up the
+	// exit for long if the server is unreachable
+	syslogTimeout = 5 * time.Second
+	// syslogTag is what messages are tagged with
+	syslogTag = "bombardier"
+)
+
+var errSyslogTimeout = errors.New("Timed out writing to syslog")
+
+type invalidSyslogAddrError struct {
+	addr string
+}
+
+func (i *invalidSyslogAddrError) Error() string {
+	return fmt.Sprintf(
+		"Invalid syslog address %q(must be [udp|tcp]://host:port)", i.addr,
+	)
+}
+
+// parseSyslogAddr splits address of remote syslog server into network
+// and host:port, empty address stands for the local syslog.
+func parseSyslogAddr(addr string) (network, raddr string, err error) {
+	if addr == "" {
+		return "", "", nil
+	}
+	network, raddr = "udp", addr
+	if i := strings.Index(addr, "://"); i >= 0 {
+		network, raddr = addr[:i], addr[i+len("://"):]
+	}
+	if network != "udp" && network != "tcp" {
+		return "", "", &invalidSyslogAddrError{addr}
+	}
+	if _, _, err := net.SplitHostPort(raddr); err != nil {
+		return "", "", &invalidSyslogAddrError{addr}
+	}
+	return network, raddr, nil
+}
+
+// writeSyslog writes summary of results as a single line of key=value
+// pairs to syslog. If that fails, the line is written to fallback
+// instead.
+func (b *bombardier) writeSyslog(fallback io.Writer) error {
+	var tags []internal.Tag
+	if b.conf.tags != nil {
+		tags = *b.conf.tags
+	}
+	line := new(bytes.Buffer)
+	p := internal.NewLogfmtPrinter(b.conf.percentilesOrDefault(), tags)
+	if err := p.WriteLine(line, b.gatherInfo()); err != nil {
+		return err
+	}
+	network, raddr, err := parseSyslogAddr(b.conf.syslogAddr)
+	if err == nil {
+		sent := make(chan error, 1)
+		go func() {
+			sent <- sendToSyslog(network, raddr, line.String())
+		}()
+		select {
+		case err = <-sent:
+		case <-time.After(syslogTimeout):
+			err = errSyslogTimeout
+		}
+	}
+	if err != nil {
+		_, _ = fallback.Write(line.Bytes())
+	}
+	return err
+}
@@ -0,0 +1,19 @@
+//go:build !windows
+// +build !windows
+
+package bombard
+
+import "log/syslog"
+
+// sendToSyslog writes msg with informational severity to the syslog
+// server at raddr, or to the local one if network is empty.
+func sendToSyslog(network, raddr, msg string) error {
+	w, err := syslog.Dial(
+		network, raddr, syslog.LOG_INFO|syslog.LOG_USER, syslogTag,
+	)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+	return w.Info(msg)
+}
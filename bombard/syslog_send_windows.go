@@ -0,0 +1,10 @@
+package bombard
+
+import "errors"
+
+var errSyslogUnsupported = errors.New("syslog isn't supported on Windows")
+
+// sendToSyslog always fails, since there is no log/syslog on Windows.
+func sendToSyslog(network, raddr, msg string) error {
+	return errSyslogUnsupported
+}
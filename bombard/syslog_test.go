@@ -0,0 +1,119 @@
+package bombard
+
+import (
+	"bytes"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSyslogAddr(t *testing.T) {
+	expectations := []struct {
+		in             string
+		network, raddr string
+		ok             bool
+	}{
+		{"", "", "", true},
+		{"logs:514", "udp", "logs:514", true},
+		{"udp://logs:514", "udp", "logs:514", true},
+		{"tcp://127.0.0.1:601", "tcp", "127.0.0.1:601", true},
+		{"logs", "", "", false},
+		{"http://logs:514", "", "", false},
+		{"tcp://", "", "", false},
+	}
+	for _, e := range expectations {
+		network, raddr, err := parseSyslogAddr(e.in)
+		if ok := err == nil; ok != e.ok {
+			t.Errorf("%q: expected ok to be %v, but got %v", e.in, e.ok, err)
+			continue
+		}
+		if network != e.network || raddr != e.raddr {
+			t.Errorf("%q: expected %v %v, but got %v %v",
+				e.in, e.network, e.raddr, network, raddr)
+		}
+	}
+}
+
+func newSyslogTestBombardier(t *testing.T, syslogAddr string) *bombardier {
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {}),
+	)
+	t.Cleanup(s.Close)
+	numReqs := uint64(10)
+	b, e := newBombardier(config{
+		numConns:   1,
+		numReqs:    &numReqs,
+		url:        s.URL,
+		headers:    new(headersList),
+		timeout:    defaultTimeout,
+		method:     "GET",
+		format:     knownFormat("plain-text"),
+		syslog:     true,
+		syslogAddr: syslogAddr,
+		tags:       &tagsList{{Key: "env", Value: "staging"}},
+	})
+	if e != nil {
+		t.Fatal(e)
+	}
+	b.disableOutput()
+	b.bombard()
+	return b
+}
+
+func TestBombardierWritesResultsToSyslog(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("syslog isn't supported on Windows")
+	}
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	b := newSyslogTestBombardier(t, "udp://"+conn.LocalAddr().String())
+	fallback := new(bytes.Buffer)
+	if err := b.writeSyslog(fallback); err != nil {
+		t.Fatal(err)
+	}
+	if fallback.Len() != 0 {
+		t.Errorf("Unexpected fallback output: %q", fallback)
+	}
+	buf := make([]byte, 4096)
+	if err := conn.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := string(buf[:n])
+	for _, exp := range []string{
+		syslogTag + "[", "env=staging url=", " req2xx=10 ",
+	} {
+		if !strings.Contains(msg, exp) {
+			t.Errorf("Expected %q in %q", exp, msg)
+		}
+	}
+}
+
+func TestBombardierSyslogFallback(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Nothing listens on the address anymore, connections are refused
+	addr := l.Addr().String()
+	l.Close()
+	b := newSyslogTestBombardier(t, "tcp://"+addr)
+	fallback := new(bytes.Buffer)
+	if err := b.writeSyslog(fallback); err == nil {
+		t.Error("Expected write to unreachable server to fail")
+	}
+	if line := fallback.String(); !strings.HasPrefix(line, "env=staging ") ||
+		!strings.Contains(line, " req2xx=10 ") {
+		t.Errorf("Unexpected fallback output: %q", line)
+	}
+}
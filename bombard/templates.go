@@ -0,0 +1,922 @@
+package bombard
+
+import "strings"
+
+var (
+	templates = map[string][]byte{
+		"plain-text": []byte(plainTextTemplate),
+		"json":       []byte(jsonTemplate),
+		"markdown":   []byte(markdownTemplate),
+	}
+)
+
+type format interface{}
+type knownFormat string
+
+func (kf knownFormat) template() []byte {
+	return templates[string(kf)]
+}
+
+type filePath string
+type userDefinedTemplate filePath
+
+func formatFromString(formatSpec string) format {
+	const prefix = "path:"
+	if strings.HasPrefix(formatSpec, prefix) {
+		return userDefinedTemplate(formatSpec[len(prefix):])
+	}
+	switch formatSpec {
+	case "pt", "plain-text":
+		return knownFormat("plain-text")
+	case "j", "json":
+		return knownFormat("json")
+	case "md", "markdown":
+		return knownFormat("markdown")
+	}
+	// nil represents unknown format
+	return nil
+}
+
+const (
+	plainTextTemplate = `
+{{- printf "%10v %10v %10v %10v %10v" "Statistics" "Avg" "Stdev" "Min" "Max" }}
+{{ with .Result.RequestsStats $.Spec.Percentiles }}
+	{{- printf "  %-10v %10.2f %10.2f %10v %10.2f" "Reqs/sec" .Mean .Stddev "-" .Max -}}
+{{ else }}
+	{{- print "  There wasn't enough data to compute statistics for requests." }}
+{{ end }}
+{{ with .Result.LatenciesStats $.Spec.Percentiles }}
+	{{- printf "  %-10v %10v %10v %10v %10v" "Latency" (FormatTimeUs .Mean) (FormatTimeUs .Stddev) (FormatTimeUs .Min) (FormatTimeUs .Max) }}
+	{{- if WithLatencies }}
+  		{{- "\n  Latency Distribution" }}
+		{{- range $pc, $lat := .Percentiles }}
+			{{- printf "\n     %2v%% %10s" (FormatPercentile $pc) (FormatTimeUsUint64 $lat) -}}
+		{{ end -}}
+	{{ end }}
+{{ else }}
+	{{- print "  There wasn't enough data to compute statistics for latencies." }}
+{{ end -}}
+{{ with .Result.LatenciesStatsByStatusClass $.Spec.Percentiles }}
+	{{- "  Latency by status class:" }}
+	{{- range $class, $stats := . }}
+		{{- with $stats }}
+			{{- printf "\n    %-8v %10v %10v %10v %10v" (FormatStatusClass $class) (FormatTimeUs .Mean) (FormatTimeUs .Stddev) (FormatTimeUs .Min) (FormatTimeUs .Max) }}
+			{{- if WithLatencies }}
+				{{- range $pc, $lat := .Percentiles }}
+					{{- printf "\n       %2v%% %10s" (FormatPercentile $pc) (FormatTimeUsUint64 $lat) -}}
+				{{ end -}}
+			{{ end }}
+		{{- end }}
+	{{- end }}
+{{ end -}}
+{{ with .Result.TTFBStats $.Spec.Percentiles }}
+	{{- printf "  %-10v %10v %10v %10v %10v" "TTFB" (FormatTimeUs .Mean) (FormatTimeUs .Stddev) (FormatTimeUs .Min) (FormatTimeUs .Max) }}
+	{{- if WithLatencies }}
+  		{{- "\n  TTFB Distribution" }}
+		{{- range $pc, $ttfb := .Percentiles }}
+			{{- printf "\n     %2v%% %10s" (FormatPercentile $pc) (FormatTimeUsUint64 $ttfb) -}}
+		{{ end -}}
+	{{ end }}
+{{ end -}}
+{{ with .Result.ServerTimeStats $.Spec.Percentiles }}
+	{{- printf "  %-10v %10v %10v %10v %10v" "Server" (FormatTimeUs .Mean) (FormatTimeUs .Stddev) (FormatTimeUs .Min) (FormatTimeUs .Max) }}
+	{{- if WithLatencies }}
+		{{- $latencies := $.Result.LatenciesStats $.Spec.Percentiles }}
+		{{- "\n  Server Time vs. Latency Distribution" }}
+		{{- range $pc, $st := .Percentiles }}
+			{{- printf "\n     %2v%% %10s %10s" (FormatPercentile $pc) (FormatTimeUsUint64 $st) (FormatTimeUsUint64 (index $latencies.Percentiles $pc)) -}}
+		{{ end -}}
+	{{ end }}
+{{ end -}}
+{{ with .Result.HandshakeStats $.Spec.Percentiles }}
+	{{- printf "  %-10v %10v %10v %10v %10v" "Handshake" (FormatTimeUs .Mean) (FormatTimeUs .Stddev) (FormatTimeUs .Min) (FormatTimeUs .Max) }}
+	{{- if WithLatencies }}
+  		{{- "\n  Handshake Distribution" }}
+		{{- range $pc, $hs := .Percentiles }}
+			{{- printf "\n     %2v%% %10s" (FormatPercentile $pc) (FormatTimeUsUint64 $hs) -}}
+		{{ end -}}
+	{{ end }}
+{{ end -}}
+{{ with .Result.InterArrivalStats $.Spec.Percentiles }}
+	{{- printf "  %-10v %10v %10v %10v %10v" "Gap" (FormatTimeUs .Mean) (FormatTimeUs .Stddev) (FormatTimeUs .Min) (FormatTimeUs .Max) }}
+	{{- if WithLatencies }}
+		{{- "\n  Inter-arrival Gap Distribution" }}
+		{{- range $pc, $gap := .Percentiles }}
+			{{- printf "\n     %2v%% %10s" (FormatPercentile $pc) (FormatTimeUsUint64 $gap) -}}
+		{{ end -}}
+	{{ end }}
+{{ end -}}
+{{ with .Result.ResponseSizeStats $.Spec.Percentiles }}
+	{{- printf "  %-10v %10v %10v %10v %10v" "Resp. size" (FormatBinary .Mean) (FormatBinary .Stddev) (FormatBinary .Min) (FormatBinary .Max) }}
+	{{- if WithLatencies }}
+		{{- "\n  Response Size Distribution" }}
+		{{- range $pc, $size := .Percentiles }}
+			{{- printf "\n     %2v%% %10s" (FormatPercentile $pc) (FormatBinaryUint64 $size) -}}
+		{{ end -}}
+	{{ end }}
+{{ end -}}
+{{ with .Result.RequestSizeStats $.Spec.Percentiles }}
+	{{- printf "  %-10v %10v %10v %10v %10v" "Req. size" (FormatBinary .Mean) (FormatBinary .Stddev) (FormatBinary .Min) (FormatBinary .Max) }}
+	{{- if WithLatencies }}
+		{{- "\n  Request Size Distribution" }}
+		{{- range $pc, $size := .Percentiles }}
+			{{- printf "\n     %2v%% %10s" (FormatPercentile $pc) (FormatBinaryUint64 $size) -}}
+		{{ end -}}
+	{{ end }}
+{{ end -}}
+{{ with .Result -}}
+{{ if and .NumberOfRequests (not .SuccessfulRequests) -}}
+{{ printf "  No successful requests, all %v of them failed." .NumberOfRequests }}
+{{ end -}}
+{{ with .ClampedLatencies -}}
+{{ printf "  Latencies of %v request(s) exceeded %v and were clamped." . $.Spec.LatencyCeiling }}
+{{ end -}}
+{{ end -}}
+{{ with .Result -}}
+{{ if $.Spec.GRPC -}}
+{{ "  gRPC codes:" }}
+	{{- range $code, $count := .StatusCodes }}
+		{{- printf "\n    %v - %v" (FormatGRPCCode $code) $count }}
+	{{- end }}
+{{- else -}}
+{{ "  HTTP codes:" }}
+{{ printf "    1xx - %v, 2xx - %v, 3xx - %v, 4xx - %v, 5xx - %v, 502 - %v" .Req1XX .Req2XX .Req3XX .Req4XX .Req5XX .Req502 }}
+	{{- printf "\n    others - %v" .Others }}
+{{- end }}
+	{{- with $.Spec.ExpectedStatusCodes }}
+		{{- printf "\n    expected - %v" (JoinInts .) }}
+	{{- end }}
+	{{- if $.Spec.Retries }}
+		{{- printf "\n    retries - %v" .Retries }}
+	{{- end }}
+	{{- if $.Spec.MaxRedirects }}
+		{{- printf "\n    redirects - %v" .Redirects }}
+	{{- end }}
+	{{- with .Errors }}
+		{{- "\n  Errors:"}}
+		{{- range . }}
+			{{- printf "\n    %10v - %v" .Error .Count }}
+		{{- end -}}
+	{{ end -}}
+	{{- with .ErrorsByCategory }}
+		{{- "\n  Errors by category:"}}
+		{{- range $category, $count := . }}
+			{{- printf "\n    %10v - %v" $category $count }}
+		{{- end -}}
+	{{ end -}}
+	{{ if not $.Spec.GRPC -}}
+	{{ "\n\n  HTTP codes detailed:" }}
+	{{- range $key, $value := .StatusCodes }}
+		{{- printf "\n    %10v - %v" $key $value }}
+	{{ end -}}
+	{{ end -}}
+	{{- with .URLs }}
+		{{- "\n  URLs:" }}
+		{{- range . }}
+			{{- printf "\n    %v" .URL }}
+			{{- with .Name }}{{ printf " (%v)" . }}{{ end }}
+			{{- printf "\n      requests - %v, errors - %v" .NumberOfRequests .Errors }}
+			{{- with .LatenciesStats (FloatsToArray 0.5) }}
+				{{- printf ", latency - %v" (FormatTimeUs .Mean) }}
+			{{- end }}
+		{{- end }}
+	{{ end -}}
+	{{- with .Conns }}
+		{{- "\n  Per connection:" }}
+		{{- range . }}
+			{{- printf "\n    #%-5v requests - %v, errors - %v, latency - %v" .ConnID .Requests .Errors (FormatTimeUs .MeanLatency) }}
+		{{- end }}
+	{{ end -}}
+{{ end }}
+{{ with .Result }}
+	{{- if or .NewConns .ReusedConns }}
+		{{- printf "  Connections: new - %v, reused - %v (%.2f%% reused)\n" .NewConns .ReusedConns .ConnReusePercentage }}
+	{{- end }}
+	{{- with .MaxInFlight }}
+		{{- printf "  In flight:   max - %v requests\n" . }}
+	{{- end }}
+	{{- with .ServerTimeErrors }}
+		{{- printf "  Server time: missing or malformed %v header in %v response(s)\n" $.Spec.ServerTimeHeader . }}
+	{{- end }}
+	{{- with .RemoteAddresses }}
+		{{- printf "  Remote addresses: %v\n" (JoinStrings .) }}
+	{{- end }}
+	{{- with .HostConns }}
+		{{- "  Max open connections (limit " }}{{ $.Spec.MaxConnsPerHost }}{{ " per host):" }}
+		{{- range $index, $host := . }}
+			{{- if ne $index 0 }},{{ end }}
+			{{- printf " %v - %v" .Host .MaxOpen }}
+		{{- end }}
+		{{- "\n" }}
+	{{- end }}
+	{{- with .BodyFiles }}
+		{{- "  Body files:" }}
+		{{- range $index, $file := . }}
+			{{- if ne $index 0 }},{{ end }}
+			{{- printf " %v - %v" .Name .Count }}
+		{{- end }}
+		{{- "\n" }}
+	{{- end }}
+{{- end }}
+{{- with .Spec.RequestedRate }}
+	{{- printf "  Rate:        requested %.2f/s, achieved %.2f/s" . $.Result.AchievedRate }}
+	{{- if gt $.RateShortfall 0.01 }}
+		{{- printf " (%.2f%% short)" (Multiply $.RateShortfall 100) }}
+	{{- end }}
+	{{- "\n" }}
+{{- end }}
+{{- printf "  %-10v %10v/s\n" "Throughput:" (FormatBinary .Result.Throughput)}}
+{{- with .Result }}
+	{{- if or .HeaderBytesRead .BodyBytesRead }}
+		{{- printf "  Read:      headers - %v, body - %v\n" (FormatBinaryInt64 .HeaderBytesRead) (FormatBinaryInt64 .BodyBytesRead) }}
+	{{- end }}
+{{- end }}
+{{- printf "  %-10v %10v\n" "Seed:" .Spec.Seed }}
+{{- with .Spec.Replay }}
+	{{- printf "  Replay:    %v (%v format) at %vx speed\n" .Path .Format .Speed }}
+{{- end }}
+{{- with .Spec.GRPC }}
+	{{- printf "  gRPC:      %v from %v\n" .Method .ProtoSet }}
+{{- end }}
+{{- with .Spec.LoadProfile }}
+	{{- printf "  Load profile: %v\n" . }}
+{{- end }}
+{{- with .Spec.Arrival }}
+	{{- printf "  Arrival:   %v\n" . }}
+{{- end }}
+{{- if .Spec.CorrectedLatency }}
+	{{- printf "  Latency:   corrected for coordinated omission\n" }}
+{{- end }}
+{{- if .Spec.DisableKeepAlive }}
+	{{- printf "  Keep-alive: disabled, %v connection(s) opened\n" .Result.ConnsOpened }}
+{{- end }}
+{{- if .Spec.PrimeConnections }}
+	{{- printf "  Priming:   took %v, not part of the test\n" (FormatDuration .Result.PrimingTime) }}
+{{- end }}
+{{- with .Spec.ThinkTime }}
+	{{- printf "  Think time: %v\n" . }}
+{{- end }}
+{{- with .Spec.BodySize }}
+	{{- printf "  Body size: %v\n" . }}
+{{- end }}
+{{- with .Spec.Jitter }}
+	{{- printf "  Jitter:    up to %v\n" (FormatDuration .) }}
+{{- end }}
+{{- with .Spec.KneeLatency }}
+	{{- with $.Result.Knee }}
+		{{- printf "  Knee:      step %v (%.0f reqs/sec), latency - %v\n" .Step .Rate (FormatTimeUsUint64 .Latency) }}
+	{{- else }}
+		{{- printf "  Knee:      not reached (%v)\n" . }}
+	{{- end }}
+{{- end }}
+{{- if .Result.Interrupted }}
+	{{- "  Interrupted: results are partial\n" }}
+{{- end }}
+{{- if .Result.Aborted }}
+	{{- with .Spec.AbortErrorRate }}
+		{{- "  Aborted: rate of 5xx responses exceeded " }}{{ . }}{{ "\n" }}
+	{{- end }}
+{{- end }}
+{{- if .Result.StoppedByTime }}
+	{{- printf "  Stopped: %v elapsed before all requests completed\n" .Spec.TestDuration }}
+{{- else if .Result.StoppedByNumberOfReqs }}
+	{{- printf "  Stopped: all %v requests completed\n" .Spec.NumberOfRequests }}
+{{- end }}
+{{- if .Result.BytesCapReached }}
+	{{- with .Spec.MaxBytes }}
+		{{- printf "  Stopped: reached byte cap of %v\n" (FormatBinaryUint64 .) }}
+	{{- end }}
+{{- end }}`
+
+	// jsonSpecTemplate outputs the specification of the test alone,
+	// it is also printed on its own by --dry-run
+	jsonSpecTemplate = `{
+{{- with .Spec -}}
+"numberOfConnections":{{ .NumberOfConnections }}
+{{- with .MaxConnsPerHost -}}
+,"maxConnsPerHost":{{ . }}
+{{- end -}}
+
+{{- if .IsTimedTest -}}
+,"testType":"timed","testDurationSeconds":{{ .TestDuration.Seconds }}
+{{- else if .IsTestByTimeOrNumberOfReqs -}}
+,"testType":"timed-or-number-of-requests","testDurationSeconds":{{ .TestDuration.Seconds }},"numberOfRequests":{{ .NumberOfRequests }}
+{{- else -}}
+,"testType":"number-of-requests","numberOfRequests":{{ .NumberOfRequests }}
+{{- end -}}
+
+,"method":"{{ .Method }}","url":{{ .URL | printf "%q" }}
+
+{{- if .WeightedURLs -}}
+,"weightedUrls":[
+{{- range $index, $wu :=  .WeightedURLs -}}
+{{- if ne $index 0 -}},{{- end -}}
+{"url":{{ .URL | printf "%q" }},"weight":{{ .Weight }}}
+{{- end -}}
+]
+{{- else if .URLs -}}
+,"urls":[
+{{- range $index, $url :=  .URLs -}}
+{{- if ne $index 0 -}},{{- end -}}
+{{ $url | printf "%q" }}
+{{- end -}}
+]
+{{- end -}}
+{{- with .Scenario -}}
+,"scenario":{{ . | printf "%q" }}
+{{- end -}}
+{{- with .Replay -}}
+,"replay":{"path":{{ .Path | printf "%q" }},"format":{{ .Format | printf "%q" }},"speed":{{ .Speed }}}
+{{- end -}}
+{{- with .GRPC -}}
+,"grpc":{"method":{{ .Method | printf "%q" }},"protoSet":{{ .ProtoSet | printf "%q" }}}
+{{- end -}}
+
+{{- with .Headers -}}
+,"headers":[
+{{- range $index, $header :=  . -}}
+{{- if ne $index 0 -}},{{- end -}}
+{"key":{{ .Key | printf "%q" }},"value":{{ .Value | printf "%q" }}
+{{- if .IsTemplate -}},"template":true{{- end -}}
+{{- with .Probability -}},"probability":{{ . }}{{- end -}}
+}
+{{- end -}}
+]
+{{- end -}}
+
+{{- if .BodyFilePath -}}
+,"bodyFilePath":{{ .BodyFilePath | printf "%q" }}
+{{- else if .BodyDir -}}
+,"bodyDir":{{ .BodyDir | printf "%q" }}
+{{- else if .BodyGenerated -}}
+,"bodyGenerator":true
+{{- else if .BodySize -}}
+,"bodySize":{{ .BodySize | printf "%q" }}
+{{- else -}}
+,"body":{{ .Body | printf "%q" }}
+{{- end -}}
+
+{{- with .Form -}}
+,"form":[
+{{- range $index, $field := . -}}
+{{- if ne $index 0 -}},{{- end -}}
+{"name":{{ .Name | printf "%q" }},"value":{{ .Value | printf "%q" }}
+{{- if .IsFile -}},"file":true{{- end -}}
+}
+{{- end -}}
+]
+{{- end -}}
+{{- with .CompressBody -}}
+,"compressBody":{{ . | printf "%q" }}
+{{- end -}}
+{{- if .BodyTemplate -}}
+,"bodyTemplate":true
+{{- end -}}
+{{- if .Cookies -}}
+,"cookies":true
+{{- end -}}
+{{- with .Resolve -}}
+,"resolve":[
+{{- range $index, $override := . -}}
+{{- if ne $index 0 -}},{{- end -}}
+{{ $override | printf "%q" }}
+{{- end -}}
+]
+{{- end -}}
+{{- if .PinDNS -}}
+,"pinDns":true
+{{- end -}}
+{{- with .UnixSocket -}}
+,"unixSocket":{{ . | printf "%q" }}
+{{- end -}}
+{{- with .Proxy -}}
+,"proxy":{{ . | printf "%q" }}
+{{- end -}}
+{{- with .LocalAddrs -}}
+,"localAddresses":[
+{{- range $index, $addr := . -}}
+{{- if ne $index 0 -}},{{- end -}}
+{{ $addr | printf "%q" }}
+{{- end -}}
+]
+{{- end -}}
+{{- with .OAuth2 -}}
+,"oauth2":{"tokenUrl":{{ .TokenURL | printf "%q" }},"clientId":{{ .ClientID | printf "%q" }}
+{{- if .Scope -}},"scope":{{ .Scope | printf "%q" }}{{- end -}}
+}
+{{- end -}}
+{{- with .BasicAuthUser -}}
+,"basicAuthUser":{{ . | printf "%q" }}
+{{- end -}}
+{{- with .SigV4 -}}
+,"sigV4":{"accessKey":{{ .AccessKey | printf "%q" }},"region":{{ .Region | printf "%q" }},"service":{{ .Service | printf "%q" }}}
+{{- end -}}
+
+{{- if .CertPath -}}
+,"certPath":{{ .CertPath | printf "%q" }}
+{{- end -}}
+{{- if .KeyPath -}}
+,"keyPath":{{ .KeyPath | printf "%q" }}
+{{- end -}}
+{{- if .CertFromMemory -}}
+,"certFromMemory":true
+{{- end -}}
+{{- with .CACertPath -}}
+,"caCertPath":{{ . | printf "%q" }}
+{{- end -}}
+{{- with .SNI -}}
+,"sni":{{ . | printf "%q" }}
+{{- end -}}
+{{- with .ALPN -}}
+,"alpn":[
+{{- range $i, $proto := . -}}
+{{- if $i -}},{{- end -}}{{- printf "%q" $proto -}}
+{{- end -}}
+]
+{{- end -}}
+
+,"stream":{{ .Stream }},"timeoutSeconds":{{ .Timeout.Seconds }}
+{{- if .ConnectTimeout -}}
+,"connectTimeoutSeconds":{{ .ConnectTimeout.Seconds }}
+{{- end -}}
+
+{{- if .RampUp -}}
+,"rampUpSeconds":{{ .RampUp.Seconds }}
+{{- end -}}
+{{- if .Warmup -}}
+,"warmupSeconds":{{ .Warmup.Seconds }},"resetAfterWarmup":{{ .ResetAfterWarmup }}
+{{- end -}}
+{{- if .PrimeConnections -}}
+,"primeConnections":true
+{{- end -}}
+
+{{- if .IsFastHTTP -}}
+,"client":"fasthttp"
+{{- end -}}
+{{- if .IsNetHTTPV1 -}}
+,"client":"net/http.v1"
+{{- end -}}
+{{- if .IsNetHTTPV2 -}}
+,"client":"net/http.v2"
+{{- end -}}
+
+{{- with .Rate -}}
+,"rate":{{ . }}
+{{- end -}}
+{{- if .RatePerConn -}}
+,"ratePerConnection":true
+{{- end -}}
+{{- with .LoadProfile -}}
+,"loadProfile":{{ . | printf "%q" }}
+{{- end -}}
+{{- with .Arrival -}}
+,"arrival":{{ . | printf "%q" }}
+{{- end -}}
+{{- if .CorrectedLatency -}}
+,"correctedLatency":true
+{{- end -}}
+{{- if .DisableKeepAlive -}}
+,"disableKeepAlive":true
+{{- end -}}
+{{- with .ThinkTime -}}
+,"thinkTime":{{ . | printf "%q" }}
+{{- end -}}
+{{- if .Jitter -}}
+,"jitterSeconds":{{ .Jitter.Seconds }}
+{{- end -}}
+{{- with .ServerTimeHeader -}}
+,"serverTimeHeader":{{ . | printf "%q" }}
+{{- end -}}
+{{- if .InterArrival -}}
+,"interArrival":true
+{{- end -}}
+{{- with .KneeLatency -}}
+,"kneeLatency":{{ . | printf "%q" }}
+{{- end -}}
+{{- with .AbortErrorRate -}}
+,"abortOnErrorRate":{{ . }}
+{{- end -}}
+{{- with .MaxBytes -}}
+,"maxBytes":{{ . }}
+{{- end -}}
+{{- with .LatencyDigits -}}
+,"latencyDigits":{{ . }}
+{{- end -}}
+{{- if .LatencyCeiling -}}
+,"latencyCeilingSeconds":{{ .LatencyCeiling.Seconds }}
+{{- end -}}
+,"seed":{{ .Seed }}
+
+{{- with .Retries -}}
+,"retries":{{ . }}
+{{- if $.Spec.LatencyWithRetries -}}
+,"latencyWithRetries":true
+{{- end -}}
+{{- end -}}
+{{- with .MaxRedirects -}}
+,"maxRedirects":{{ . }}
+{{- if $.Spec.FinalHopLatency -}}
+,"finalHopLatency":true
+{{- end -}}
+{{- end -}}
+{{- with .ExpectedStatusCodes -}}
+,"expectedStatusCodes":[
+{{- range $index, $code :=  . -}}
+{{- if ne $index 0 -}},{{- end -}}
+{{ $code }}
+{{- end -}}
+]
+{{- end -}}
+{{- end -}}
+}`
+
+	// jsonSchemaVersion is the version of JSON output, which must be
+	// bumped on every breaking change of it (removed or renamed fields,
+	// changes in their meaning or type). Fields are always output in
+	// the same order, with keys of objects built from maps sorted.
+	jsonSchemaVersion = "1"
+
+	jsonTemplate = `{"schemaVersion":` + jsonSchemaVersion + `,"spec":` +
+		jsonSpecTemplate + `,
+
+{{- with .Result -}}
+"result":{"bytesRead":{{ .BytesRead -}}
+,"bytesWritten":{{ .BytesWritten -}}
+,"headerBytesRead":{{ .HeaderBytesRead -}}
+,"bodyBytesRead":{{ .BodyBytesRead -}}
+,"timeTakenSeconds":{{ .TimeTaken.Seconds -}}
+{{- if $.Spec.PrimeConnections -}}
+,"primingTimeSeconds":{{ .PrimingTime.Seconds -}}
+{{- end -}}
+
+,"req1xx":{{ .Req1XX -}}
+,"req2xx":{{ .Req2XX -}}
+,"req3xx":{{ .Req3XX -}}
+,"req4xx":{{ .Req4XX -}}
+,"req5xx":{{ .Req5XX -}}
+,"others":{{ .Others -}}
+,"retries":{{ .Retries -}}
+{{- if $.Spec.MaxRedirects -}}
+,"redirects":{{ .Redirects -}}
+{{- end -}}
+,"newConns":{{ .NewConns -}}
+,"reusedConns":{{ .ReusedConns -}}
+,"connsOpened":{{ .ConnsOpened -}}
+,"maxInFlight":{{ .MaxInFlight -}}
+{{- if $.Spec.RequestedRate -}}
+,"achievedRate":{{ .AchievedRate -}}
+{{- end -}}
+{{- with .InFlight -}}
+,"inFlight":[
+{{- range $index, $sample := . -}}
+{{- if ne $index 0 -}},{{- end -}}
+{"elapsedSeconds":{{ .Elapsed.Seconds }},"requests":{{ .Requests }}}
+{{- end -}}
+]
+{{- end -}}
+{{- with .TimeSeries -}}
+,"timeSeries":[
+{{- range $index, $point := . -}}
+{{- if ne $index 0 -}},{{- end -}}
+{"elapsedSeconds":{{ .Elapsed.Seconds -}}
+,"rps":{{ .Rps -}}
+,"latencyMean":{{ .LatencyMean -}}
+,"errors":{{ .Errors }}}
+{{- end -}}
+]
+{{- end -}}
+{{- with .RemoteAddresses -}}
+,"remoteAddresses":[
+{{- range $index, $addr := . -}}
+{{- if ne $index 0 -}},{{- end -}}
+{{ $addr | printf "%q" }}
+{{- end -}}
+]
+{{- end -}}
+{{- with .HostConns -}}
+,"hostConns":[
+{{- range $index, $host := . -}}
+{{- if ne $index 0 -}},{{- end -}}
+{"host":{{ .Host | printf "%q" }},"maxOpen":{{ .MaxOpen }}}
+{{- end -}}
+]
+{{- end -}}
+{{- with .BodyFiles -}}
+,"bodyFiles":[
+{{- range $index, $file := . -}}
+{{- if ne $index 0 -}},{{- end -}}
+{"name":{{ .Name | printf "%q" }},"count":{{ .Count }}}
+{{- end -}}
+]
+{{- end -}}
+{{- if .Aborted -}}
+,"aborted":true
+{{- end -}}
+{{- if .BytesCapReached -}}
+,"bytesCapReached":true
+{{- end -}}
+{{- if .StoppedByTime -}}
+,"stoppedBy":"timed"
+{{- else if .StoppedByNumberOfReqs -}}
+,"stoppedBy":"number-of-requests"
+{{- end -}}
+{{- if .Interrupted -}}
+,"interrupted":true
+{{- end -}}
+{{- if .InterpolatePercentiles -}}
+,"interpolatePercentiles":true
+{{- end -}}
+{{- with .ClampedLatencies -}}
+,"clampedLatencies":{{ . }}
+{{- end -}}
+{{- with .Knee -}}
+,"knee":{"step":{{ .Step }},"rate":{{ .Rate }},"latency":{{ .Latency }}}
+{{- end -}}
+
+{{- with .Errors -}}
+,"errors":[
+{{- range $index, $error :=  . -}}
+{{- if ne $index 0 -}},{{- end -}}
+{"description":{{ .Error | printf "%q" }},"count":{{ .Count }}}
+{{- end -}}
+]
+{{- end -}}
+
+{{- with .ErrorsByCategory -}}
+,"errorsByCategory":{
+{{- $first := true -}}
+{{- range $category, $count := . -}}
+{{- if not $first -}},{{- end -}}{{- $first = false -}}
+{{ $category | printf "%q" }}:{{ $count }}
+{{- end -}}
+}
+{{- end -}}
+
+{{- with .URLs -}}
+,"urls":[
+{{- range $index, $url :=  . -}}
+{{- if ne $index 0 -}},{{- end -}}
+{"url":{{ .URL | printf "%q" -}}
+{{- with .Name -}},"name":{{ . | printf "%q" }}{{- end -}}
+,"req1xx":{{ .Req1XX -}}
+,"req2xx":{{ .Req2XX -}}
+,"req3xx":{{ .Req3XX -}}
+,"req4xx":{{ .Req4XX -}}
+,"req5xx":{{ .Req5XX -}}
+,"others":{{ .Others -}}
+,"errors":{{ .Errors -}}
+{{- with .LatenciesStats (FloatsToArray 0.5) -}}
+,"latency":{"mean":{{ .Mean -}}
+,"stddev":{{ .Stddev -}}
+,"max":{{ .Max -}}
+}
+{{- end -}}
+}
+{{- end -}}
+]
+{{- end -}}
+{{- with .Conns -}}
+,"conns":[
+{{- range $index, $conn := . -}}
+{{- if ne $index 0 -}},{{- end -}}
+{"connId":{{ .ConnID }},"requests":{{ .Requests }},"errors":{{ .Errors }},"meanLatency":{{ .MeanLatency }}}
+{{- end -}}
+]
+{{- end -}}
+
+{{- with .LatenciesStats $.Spec.Percentiles -}}
+,"latency":{"mean":{{ .Mean -}}
+,"stddev":{{ .Stddev -}}
+,"min":{{ .Min -}}
+,"max":{{ .Max -}}
+,"cv":{{ .CV -}}
+,"meanPercentile":{{ .MeanPercentile -}}
+,"iqr":{{ .IQR -}}
+,"outliers":{{ .Outliers -}}
+,"mad":{{ .MAD -}}
+
+{{- if WithLatencies -}}
+,"percentiles":{
+{{- $first := true -}}
+{{- range $pc, $lat := .Percentiles }}
+{{- if not $first -}},{{- end -}}{{- $first = false -}}
+{{- printf "%q:%d" (FormatPercentile $pc) $lat -}}
+{{- end -}}
+}
+{{- end -}}
+
+}
+{{- end -}}
+
+{{- with .LatenciesStatsByStatusClass $.Spec.Percentiles -}}
+,"latencyByStatusClass":{
+{{- $first := true -}}
+{{- range $class, $stats := . -}}
+{{- if not $first -}},{{- end -}}{{- $first = false -}}
+{{- with $stats -}}
+{{ FormatStatusClass $class | printf "%q" }}:{"mean":{{ .Mean -}}
+,"stddev":{{ .Stddev -}}
+,"min":{{ .Min -}}
+,"max":{{ .Max -}}
+
+{{- if WithLatencies -}}
+,"percentiles":{
+{{- $first := true -}}
+{{- range $pc, $lat := .Percentiles }}
+{{- if not $first -}},{{- end -}}{{- $first = false -}}
+{{- printf "%q:%d" (FormatPercentile $pc) $lat -}}
+{{- end -}}
+}
+{{- end -}}
+
+}
+{{- end -}}
+{{- end -}}
+}
+{{- end -}}
+
+{{- with .TTFBStats $.Spec.Percentiles -}}
+,"ttfb":{"mean":{{ .Mean -}}
+,"stddev":{{ .Stddev -}}
+,"min":{{ .Min -}}
+,"max":{{ .Max -}}
+
+{{- if WithLatencies -}}
+,"percentiles":{
+{{- $first := true -}}
+{{- range $pc, $ttfb := .Percentiles }}
+{{- if not $first -}},{{- end -}}{{- $first = false -}}
+{{- printf "%q:%d" (FormatPercentile $pc) $ttfb -}}
+{{- end -}}
+}
+{{- end -}}
+
+}
+{{- end -}}
+
+{{- with .ServerTimeStats $.Spec.Percentiles -}}
+,"serverTime":{"mean":{{ .Mean -}}
+,"stddev":{{ .Stddev -}}
+,"min":{{ .Min -}}
+,"max":{{ .Max -}}
+
+{{- if WithLatencies -}}
+,"percentiles":{
+{{- $first := true -}}
+{{- range $pc, $st := .Percentiles }}
+{{- if not $first -}},{{- end -}}{{- $first = false -}}
+{{- printf "%q:%d" (FormatPercentile $pc) $st -}}
+{{- end -}}
+}
+{{- end -}}
+
+}
+{{- end -}}
+{{- if $.Spec.ServerTimeHeader -}}
+,"serverTimeErrors":{{ .ServerTimeErrors -}}
+{{- end -}}
+
+{{- with .HandshakeStats $.Spec.Percentiles -}}
+,"handshake":{"mean":{{ .Mean -}}
+,"stddev":{{ .Stddev -}}
+,"min":{{ .Min -}}
+,"max":{{ .Max -}}
+
+{{- if WithLatencies -}}
+,"percentiles":{
+{{- $first := true -}}
+{{- range $pc, $hs := .Percentiles }}
+{{- if not $first -}},{{- end -}}{{- $first = false -}}
+{{- printf "%q:%d" (FormatPercentile $pc) $hs -}}
+{{- end -}}
+}
+{{- end -}}
+
+}
+{{- end -}}
+
+{{- with .InterArrivalStats $.Spec.Percentiles -}}
+,"interArrival":{"mean":{{ .Mean -}}
+,"stddev":{{ .Stddev -}}
+,"min":{{ .Min -}}
+,"max":{{ .Max -}}
+
+{{- if WithLatencies -}}
+,"percentiles":{
+{{- $first := true -}}
+{{- range $pc, $gap := .Percentiles }}
+{{- if not $first -}},{{- end -}}{{- $first = false -}}
+{{- printf "%q:%d" (FormatPercentile $pc) $gap -}}
+{{- end -}}
+}
+{{- end -}}
+
+}
+{{- end -}}
+
+{{- with .ResponseSizeStats $.Spec.Percentiles -}}
+,"responseSize":{"mean":{{ .Mean -}}
+,"stddev":{{ .Stddev -}}
+,"min":{{ .Min -}}
+,"max":{{ .Max -}}
+
+{{- if WithLatencies -}}
+,"percentiles":{
+{{- $first := true -}}
+{{- range $pc, $size := .Percentiles }}
+{{- if not $first -}},{{- end -}}{{- $first = false -}}
+{{- printf "%q:%d" (FormatPercentile $pc) $size -}}
+{{- end -}}
+}
+{{- end -}}
+
+}
+{{- end -}}
+
+{{- with .RequestSizeStats $.Spec.Percentiles -}}
+,"requestSize":{"mean":{{ .Mean -}}
+,"stddev":{{ .Stddev -}}
+,"min":{{ .Min -}}
+,"max":{{ .Max -}}
+
+{{- if WithLatencies -}}
+,"percentiles":{
+{{- $first := true -}}
+{{- range $pc, $size := .Percentiles }}
+{{- if not $first -}},{{- end -}}{{- $first = false -}}
+{{- printf "%q:%d" (FormatPercentile $pc) $size -}}
+{{- end -}}
+}
+{{- end -}}
+
+}
+{{- end -}}
+
+{{- with .RequestsStats $.Spec.Percentiles -}}
+,"rps":{"mean":{{ .Mean -}}
+,"stddev":{{ .Stddev -}}
+,"max":{{ .Max -}}
+,"percentiles":{
+{{- $first := true -}}
+{{- range $pc, $rps := .Percentiles }}
+{{- if not $first -}},{{- end -}}{{- $first = false -}}
+{{- printf "%q:%f" (FormatPercentile $pc) $rps -}}
+{{- end -}}
+}}
+{{- end -}}
+,"histograms":{"latency":{{ Uint64HistogramJSON .Latencies -}}
+,"requests":{{ Float64HistogramJSON .Requests -}}
+}}}
+{{- end -}}`
+
+	// markdownTemplate outputs the result as GitHub-flavored Markdown
+	// tables, i.e. for pasting into pull requests and wikis
+	markdownTemplate = `
+{{- "| Statistics | Avg | Stdev | Min | Max |\n" }}
+{{- "| --- | ---: | ---: | ---: | ---: |\n" }}
+{{- with .Result.RequestsStats $.Spec.Percentiles }}
+	{{- printf "| Reqs/sec | %.2f | %.2f | - | %.2f |\n" .Mean .Stddev .Max }}
+{{- end }}
+{{- with .Result.LatenciesStats $.Spec.Percentiles }}
+	{{- printf "| Latency | %v | %v | %v | %v |\n" (FormatTimeUs .Mean) (FormatTimeUs .Stddev) (FormatTimeUs .Min) (FormatTimeUs .Max) }}
+	{{- $server := $.Result.ServerTimeStats $.Spec.Percentiles }}
+	{{- with $server }}
+		{{- printf "| Server time | %v | %v | %v | %v |\n" (FormatTimeUs .Mean) (FormatTimeUs .Stddev) (FormatTimeUs .Min) (FormatTimeUs .Max) }}
+	{{- end }}
+	{{- with .Percentiles }}
+		{{- if $server }}
+			{{- "\n| Percentile | Latency | Server time |\n" }}
+			{{- "| ---: | ---: | ---: |\n" }}
+			{{- range $pc, $lat := . }}
+				{{- printf "| %v%% | %v | %v |\n" (FormatPercentile $pc) (FormatTimeUsUint64 $lat) (FormatTimeUsUint64 (index $server.Percentiles $pc)) }}
+			{{- end }}
+		{{- else }}
+			{{- "\n| Percentile | Latency |\n" }}
+			{{- "| ---: | ---: |\n" }}
+			{{- range $pc, $lat := . }}
+				{{- printf "| %v%% | %v |\n" (FormatPercentile $pc) (FormatTimeUsUint64 $lat) }}
+			{{- end }}
+		{{- end }}
+	{{- end }}
+{{- end }}
+{{- with .Result }}
+	{{- "\n| HTTP codes | Count |\n" }}
+	{{- "| --- | ---: |\n" }}
+	{{- printf "| 1xx | %v |\n" .Req1XX }}
+	{{- printf "| 2xx | %v |\n" .Req2XX }}
+	{{- printf "| 3xx | %v |\n" .Req3XX }}
+	{{- printf "| 4xx | %v |\n" .Req4XX }}
+	{{- printf "| 5xx | %v |\n" .Req5XX }}
+	{{- printf "| others | %v |\n" .Others }}
+	{{- with .Errors }}
+		{{- "\n| Errors | Count |\n" }}
+		{{- "| --- | ---: |\n" }}
+		{{- range . }}
+			{{- printf "| %v | %v |\n" (EscapeMarkdown .Error) .Count }}
+		{{- end }}
+	{{- end }}
+	{{- "\n| Requests | Duration | Throughput |\n" }}
+	{{- "| ---: | ---: | ---: |\n" }}
+	{{- printf "| %v | %v | %v/s |\n" .NumberOfRequests (FormatDuration .TimeTaken) (FormatBinary .Throughput) }}
+{{- end }}`
+)
@@ -0,0 +1,47 @@
+package bombard
+
+import (
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// thinkTime is the pause between consecutive requests of connection,
+// drawn uniformly from [min, max] range.
+type thinkTime struct {
+	min, max time.Duration
+}
+
+// parseThinkTime parses either a single duration (i.e. "100ms") or
+// a range of them (i.e. "50ms-200ms").
+func parseThinkTime(s string) (*thinkTime, error) {
+	bounds := strings.SplitN(s, "-", 2)
+	min, err := time.ParseDuration(bounds[0])
+	if err != nil {
+		return nil, errInvalidThinkTime
+	}
+	max := min
+	if len(bounds) == 2 {
+		if max, err = time.ParseDuration(bounds[1]); err != nil {
+			return nil, errInvalidThinkTime
+		}
+	}
+	if min < 0 || max < min {
+		return nil, errInvalidThinkTime
+	}
+	return &thinkTime{min, max}, nil
+}
+
+func (t *thinkTime) String() string {
+	if t.min == t.max {
+		return t.min.String()
+	}
+	return t.min.String() + "-" + t.max.String()
+}
+
+func (t *thinkTime) next(rng *rand.Rand) time.Duration {
+	if t.min == t.max {
+		return t.min
+	}
+	return t.min + time.Duration(rng.Int63n(int64(t.max-t.min)+1))
+}
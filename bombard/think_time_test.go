@@ -0,0 +1,60 @@
+package bombard
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseThinkTime(t *testing.T) {
+	ms := time.Millisecond
+	expectations := []struct {
+		in  string
+		out *thinkTime
+		err error
+	}{
+		{"100ms", &thinkTime{100 * ms, 100 * ms}, nil},
+		{"50ms-200ms", &thinkTime{50 * ms, 200 * ms}, nil},
+		{"0s", &thinkTime{0, 0}, nil},
+		{"", nil, errInvalidThinkTime},
+		{"fast", nil, errInvalidThinkTime},
+		{"-5ms", nil, errInvalidThinkTime},
+		{"200ms-50ms", nil, errInvalidThinkTime},
+		{"50ms-", nil, errInvalidThinkTime},
+	}
+	for _, e := range expectations {
+		act, err := parseThinkTime(e.in)
+		if err != e.err {
+			t.Errorf("%q: expected error %v, but got %v", e.in, e.err, err)
+			continue
+		}
+		if e.out != nil && *act != *e.out {
+			t.Errorf("%q: expected %v, but got %v", e.in, e.out, act)
+		}
+	}
+}
+
+func TestThinkTimeString(t *testing.T) {
+	for _, s := range []string{"100ms", "50ms-200ms"} {
+		tt, err := parseThinkTime(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if act := tt.String(); act != s {
+			t.Errorf("Expected %q, but got %q", s, act)
+		}
+	}
+}
+
+func TestThinkTimeNext(t *testing.T) {
+	tt := &thinkTime{50 * time.Millisecond, 60 * time.Millisecond}
+	rng := newConnRNGs(42, 1)[0]
+	for i := 0; i < 1000; i++ {
+		if d := tt.next(rng); d < tt.min || d > tt.max {
+			t.Fatalf("Expected think time within %v, but got %v", tt, d)
+		}
+	}
+	fixed := &thinkTime{time.Second, time.Second}
+	if d := fixed.next(rng); d != time.Second {
+		t.Errorf("Expected fixed think time, but got %v", d)
+	}
+}
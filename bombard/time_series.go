@@ -0,0 +1,66 @@
+package bombard
+
+import (
+	"sync"
+	"time"
+
+	"github.com/kostyay/bombardier/internal"
+)
+
+// timeSeries accumulates completed requests and turns them into
+// a point of the series on every tick, so that it's known how
+// throughput and latency changed over the course of the test.
+type timeSeries struct {
+	mu sync.Mutex
+	// Accumulated since the previous point
+	reqs, errors, latencySum uint64
+	// prev is the time elapsed when the previous point was taken
+	prev time.Duration
+
+	// points are only accessed by the goroutine that takes them,
+	// until the test is over
+	points []internal.TimeSeriesPoint
+}
+
+// record accounts for a request that took latency microseconds.
+func (ts *timeSeries) record(latency uint64, failed bool) {
+	ts.mu.Lock()
+	ts.reqs++
+	ts.latencySum += latency
+	if failed {
+		ts.errors++
+	}
+	ts.mu.Unlock()
+}
+
+// point turns requests accumulated so far into a point, once elapsed
+// time has passed since the test began.
+func (ts *timeSeries) point(elapsed time.Duration) {
+	ts.mu.Lock()
+	reqs, errors, latencySum := ts.reqs, ts.errors, ts.latencySum
+	ts.reqs, ts.errors, ts.latencySum = 0, 0, 0
+	duration := elapsed - ts.prev
+	ts.prev = elapsed
+	ts.mu.Unlock()
+	if duration <= 0 {
+		return
+	}
+	p := internal.TimeSeriesPoint{
+		Elapsed: elapsed,
+		Rps:     float64(reqs) / duration.Seconds(),
+		Errors:  errors,
+	}
+	if reqs > 0 {
+		p.LatencyMean = float64(latencySum) / float64(reqs)
+	}
+	ts.points = append(ts.points, p)
+}
+
+// reset forgets requests accumulated so far, i.e. during warmup, with
+// the next point covering the time since elapsed.
+func (ts *timeSeries) reset(elapsed time.Duration) {
+	ts.mu.Lock()
+	ts.reqs, ts.errors, ts.latencySum = 0, 0, 0
+	ts.prev = elapsed
+	ts.mu.Unlock()
+}
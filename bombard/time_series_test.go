@@ -0,0 +1,100 @@
+package bombard
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTimeSeries(t *testing.T) {
+	ts := new(timeSeries)
+	ts.record(100, false)
+	ts.record(300, true)
+	ts.point(time.Second)
+	ts.point(3 * time.Second)
+	ts.record(1000, false)
+	ts.reset(4 * time.Second)
+	ts.record(50, false)
+	ts.point(4500 * time.Millisecond)
+	if len(ts.points) != 3 {
+		t.Fatalf("Expected 3 points, but got %+v", ts.points)
+	}
+	exp := []struct {
+		elapsed      time.Duration
+		rps, latency float64
+		errors       uint64
+	}{
+		{time.Second, 2, 200, 1},
+		{3 * time.Second, 0, 0, 0},
+		{4500 * time.Millisecond, 2, 50, 0},
+	}
+	for i, e := range exp {
+		p := ts.points[i]
+		if p.Elapsed != e.elapsed || p.Rps != e.rps ||
+			p.LatencyMean != e.latency || p.Errors != e.errors {
+			t.Errorf("Expected point %+v, but got %+v", e, p)
+		}
+	}
+}
+
+func TestBombardierTimeSeries(t *testing.T) {
+	testAllClients(t, testBombardierTimeSeries)
+}
+
+func testBombardierTimeSeries(clientType clientTyp, t *testing.T) {
+	s := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			time.Sleep(5 * time.Millisecond)
+			if r.URL.Path == "/fail" {
+				rw.WriteHeader(http.StatusInternalServerError)
+			}
+		}),
+	)
+	defer s.Close()
+	numReqs := uint64(40)
+	urls := []string{s.URL, s.URL + "/fail"}
+	b, e := newBombardier(config{
+		numConns:         2,
+		numReqs:          &numReqs,
+		url:              urls[0],
+		urls:             &urls,
+		headers:          new(headersList),
+		timeout:          defaultTimeout,
+		method:           "GET",
+		clientType:       clientType,
+		format:           knownFormat("plain-text"),
+		expectedStatuses: &[]int{200},
+		timeSeries:       true,
+	})
+	if e != nil {
+		t.Error(e)
+		return
+	}
+	b.inFlight.interval = 10 * time.Millisecond
+	b.disableOutput()
+	b.bombard()
+	points := b.gatherInfo().Result.TimeSeries
+	if len(points) == 0 {
+		t.Fatal("Expected time series to be recorded")
+	}
+	var (
+		prev   time.Duration
+		errors uint64
+	)
+	for _, p := range points {
+		if p.Elapsed <= prev || p.Rps < 0 {
+			t.Errorf("Unexpected points: %+v", points)
+			break
+		}
+		if p.Rps > 0 && p.LatencyMean < 5000 {
+			t.Errorf("Expected mean latency of at least 5ms, but got %+v", p)
+		}
+		prev = p.Elapsed
+		errors += p.Errors
+	}
+	if errors != b.errors.sum() {
+		t.Errorf("Expected %v errors in time series, but got %v",
+			b.errors.sum(), errors)
+	}
+}
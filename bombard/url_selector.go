@@ -0,0 +1,84 @@
+package bombard
+
+import (
+	"math/rand"
+	"sort"
+	"sync/atomic"
+)
+
+type urlSelector interface {
+	// next returns index of the URL the next request of the
+	// connection should be sent to
+	next(connID uint64) int
+}
+
+type roundRobinSelector struct {
+	counter, n uint64
+}
+
+func newRoundRobinSelector(n int) urlSelector {
+	if n < 1 {
+		panic("roundRobinSelector: no URLs to select from")
+	}
+	return &roundRobinSelector{n: uint64(n)}
+}
+
+func (r *roundRobinSelector) next(uint64) int {
+	if r.n == 1 {
+		return 0
+	}
+	return int((atomic.AddUint64(&r.counter, 1) - 1) % r.n)
+}
+
+// sequenceSelector makes every connection go through the URLs in
+// order, starting over once it's done with the last of them.
+type sequenceSelector struct {
+	n int
+	// positions holds the index of the next URL of every connection,
+	// each of them is only accessed by the connection's worker
+	positions []int
+}
+
+func newSequenceSelector(n int, numConns uint64) urlSelector {
+	if n < 1 {
+		panic("sequenceSelector: no URLs to select from")
+	}
+	return &sequenceSelector{n: n, positions: make([]int, numConns)}
+}
+
+func (s *sequenceSelector) next(connID uint64) int {
+	i := s.positions[connID]
+	s.positions[connID] = (i + 1) % s.n
+	return i
+}
+
+type weightedSelector struct {
+	// cumulative[i] is the sum of weights of URLs [0..i]
+	cumulative []uint64
+	// rngs holds a random number generator for every connection
+	rngs []*rand.Rand
+}
+
+func newWeightedSelector(weights []uint, rngs []*rand.Rand) urlSelector {
+	if len(weights) < 1 {
+		panic("weightedSelector: no URLs to select from")
+	}
+	cumulative := make([]uint64, len(weights))
+	total := uint64(0)
+	for i, w := range weights {
+		total += uint64(w)
+		cumulative[i] = total
+	}
+	if total == 0 {
+		panic("weightedSelector: total weight is zero")
+	}
+	return &weightedSelector{cumulative, rngs}
+}
+
+func (w *weightedSelector) next(connID uint64) int {
+	total := w.cumulative[len(w.cumulative)-1]
+	r := uint64(w.rngs[connID].Int63n(int64(total)))
+	return sort.Search(len(w.cumulative), func(i int) bool {
+		return w.cumulative[i] > r
+	})
+}
@@ -0,0 +1,59 @@
+package bombard
+
+import (
+	"testing"
+)
+
+func TestRoundRobinSelector(t *testing.T) {
+	s := newRoundRobinSelector(3)
+	for i := 0; i < 10; i++ {
+		if act := s.next(uint64(i)); act != i%3 {
+			t.Errorf("Expected %v, but got %v", i%3, act)
+		}
+	}
+}
+
+func TestRoundRobinSelectorPanicsWithoutURLs(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("shouldn't be able to create selector without URLs")
+		}
+	}()
+	newRoundRobinSelector(0)
+}
+
+func TestWeightedSelector(t *testing.T) {
+	weights := []uint{80, 20, 0}
+	s := newWeightedSelector(weights, newConnRNGs(42, 2))
+	counts := make([]int, len(weights))
+	total := 100000
+	for i := 0; i < total; i++ {
+		counts[s.next(uint64(i%2))]++
+	}
+	for i, w := range weights {
+		exp := float64(total) * float64(w) / 100
+		if act := float64(counts[i]); act < exp*0.95 || act > exp*1.05 {
+			t.Errorf("Expected ~%v selections of %v, but got %v", exp, i, act)
+		}
+	}
+}
+
+func TestWeightedSelectorPanicsWithZeroTotalWeight(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("shouldn't be able to create selector with zero weights")
+		}
+	}()
+	newWeightedSelector([]uint{0, 0}, newConnRNGs(42, 1))
+}
+
+func TestSequenceSelector(t *testing.T) {
+	s := newSequenceSelector(3, 2)
+	for i := 0; i < 10; i++ {
+		for connID := uint64(0); connID < 2; connID++ {
+			if act := s.next(connID); act != i%3 {
+				t.Errorf("%v: expected %v, but got %v", connID, i%3, act)
+			}
+		}
+	}
+}
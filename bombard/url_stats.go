@@ -0,0 +1,67 @@
+package bombard
+
+import (
+	"sync/atomic"
+
+	"github.com/kostyay/bombardier/internal"
+
+	uhist "github.com/codesenberg/concurrent/uint64/histogram"
+)
+
+type urlStats struct {
+	url string
+	// name of the scenario step, if URL belongs to one
+	name string
+
+	req1xx, req2xx, req3xx, req4xx, req5xx, others uint64
+	errors                                         uint64
+
+	latencies *uhist.Histogram
+}
+
+func newURLStats(url string) *urlStats {
+	return &urlStats{
+		url:       url,
+		latencies: uhist.Default(),
+	}
+}
+
+func (u *urlStats) record(code int, usTaken uint64, err error) {
+	u.latencies.Increment(usTaken)
+	if err != nil {
+		atomic.AddUint64(&u.errors, 1)
+	}
+	var counter *uint64
+	switch code / 100 {
+	case 1:
+		counter = &u.req1xx
+	case 2:
+		counter = &u.req2xx
+	case 3:
+		counter = &u.req3xx
+	case 4:
+		counter = &u.req4xx
+	case 5:
+		counter = &u.req5xx
+	default:
+		counter = &u.others
+	}
+	atomic.AddUint64(counter, 1)
+}
+
+func (u *urlStats) results() internal.URLResults {
+	return internal.URLResults{
+		URL:  u.url,
+		Name: u.name,
+
+		Req1XX: atomic.LoadUint64(&u.req1xx),
+		Req2XX: atomic.LoadUint64(&u.req2xx),
+		Req3XX: atomic.LoadUint64(&u.req3xx),
+		Req4XX: atomic.LoadUint64(&u.req4xx),
+		Req5XX: atomic.LoadUint64(&u.req5xx),
+		Others: atomic.LoadUint64(&u.others),
+		Errors: atomic.LoadUint64(&u.errors),
+
+		Latencies: u.latencies,
+	}
+}
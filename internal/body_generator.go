@@ -0,0 +1,12 @@
+package internal
+
+import "io"
+
+// BodyGenerator returns body of the request with the given number,
+// requests are numbered from 1 across all the connections. It's
+// called concurrently, once per request, and the body is streamed
+// from the returned reader, so it may be produced lazily. Reader is
+// closed once the request is sent, if it's an io.Closer. Bodies of
+// known length, i.e. ones read from *bytes.Reader or *strings.Reader,
+// are sent with Content-Length, the rest are sent chunked.
+type BodyGenerator func(reqNum uint64) io.Reader
@@ -0,0 +1,83 @@
+package internal
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+// CSVPrinter writes results of the test as comma-separated values.
+// Every call to WriteRow emits exactly one row, so results of
+// multiple runs can be appended to the same file.
+type CSVPrinter struct {
+	// Percentiles of latency to output. Each of them gets its own
+	// column, so the list should stay the same between runs for the
+	// header row to remain stable.
+	Percentiles []float64
+}
+
+// NewCSVPrinter creates a CSVPrinter that outputs given percentiles
+// of latency.
+func NewCSVPrinter(percentiles []float64) *CSVPrinter {
+	return &CSVPrinter{
+		Percentiles: percentiles,
+	}
+}
+
+// Header returns names of the columns.
+func (p *CSVPrinter) Header() []string {
+	header := make([]string, 0, len(p.Percentiles)+9)
+	for _, pc := range p.Percentiles {
+		header = append(header,
+			"latency_p"+strconv.FormatFloat(pc*100, 'f', -1, 64)+"_us")
+	}
+	return append(header,
+		"req1xx", "req2xx", "req3xx", "req4xx", "req5xx", "others",
+		"bytes_read", "bytes_written", "throughput_bytes_per_sec",
+		"time_taken_seconds",
+	)
+}
+
+// WriteHeader writes the header row to w.
+func (p *CSVPrinter) WriteHeader(w io.Writer) error {
+	return p.write(w, p.Header())
+}
+
+// WriteRow writes a single row, containing results from info, to w.
+// Percentiles that can't be calculated are left empty.
+func (p *CSVPrinter) WriteRow(w io.Writer, info TestInfo) error {
+	r := info.Result
+	row := make([]string, 0, len(p.Percentiles)+9)
+	stats := r.LatenciesStats(p.Percentiles)
+	for _, pc := range p.Percentiles {
+		cell := ""
+		if stats != nil {
+			if lat, ok := stats.Percentiles[pc]; ok {
+				cell = strconv.FormatUint(lat, 10)
+			}
+		}
+		row = append(row, cell)
+	}
+	row = append(row,
+		strconv.FormatUint(r.Req1XX, 10),
+		strconv.FormatUint(r.Req2XX, 10),
+		strconv.FormatUint(r.Req3XX, 10),
+		strconv.FormatUint(r.Req4XX, 10),
+		strconv.FormatUint(r.Req5XX, 10),
+		strconv.FormatUint(r.Others, 10),
+		strconv.FormatInt(r.BytesRead, 10),
+		strconv.FormatInt(r.BytesWritten, 10),
+		strconv.FormatFloat(r.Throughput(), 'f', 2, 64),
+		strconv.FormatFloat(r.TimeTaken.Seconds(), 'f', -1, 64),
+	)
+	return p.write(w, row)
+}
+
+func (p *CSVPrinter) write(w io.Writer, record []string) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(record); err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
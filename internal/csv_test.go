@@ -0,0 +1,68 @@
+package internal
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	fhist "github.com/codesenberg/concurrent/float64/histogram"
+	uhist "github.com/codesenberg/concurrent/uint64/histogram"
+)
+
+func TestCSVPrinterHeader(t *testing.T) {
+	p := NewCSVPrinter([]float64{0.5, 0.99})
+	b := new(bytes.Buffer)
+	if err := p.WriteHeader(b); err != nil {
+		t.Fatal(err)
+	}
+	exp := "latency_p50_us,latency_p99_us,req1xx,req2xx,req3xx,req4xx," +
+		"req5xx,others,bytes_read,bytes_written," +
+		"throughput_bytes_per_sec,time_taken_seconds\n"
+	if act := b.String(); act != exp {
+		t.Errorf("Expected %q, but got %q", exp, act)
+	}
+}
+
+func TestCSVPrinterRow(t *testing.T) {
+	latencies := uhist.Default()
+	latencies.Increment(100)
+	latencies.Increment(200)
+	info := TestInfo{
+		Result: Results{
+			BytesRead:    300,
+			BytesWritten: 100,
+			TimeTaken:    2 * time.Second,
+			Req2XX:       2,
+			Latencies:    latencies,
+			Requests:     fhist.Default(),
+		},
+	}
+	p := NewCSVPrinter([]float64{0.5, 1})
+	b := new(bytes.Buffer)
+	if err := p.WriteRow(b, info); err != nil {
+		t.Fatal(err)
+	}
+	exp := "100,200,0,2,0,0,0,0,300,100,200.00,2\n"
+	if act := b.String(); act != exp {
+		t.Errorf("Expected %q, but got %q", exp, act)
+	}
+}
+
+func TestCSVPrinterRowWithoutLatencies(t *testing.T) {
+	info := TestInfo{
+		Result: Results{
+			TimeTaken: time.Second,
+			Latencies: uhist.Default(),
+			Requests:  fhist.Default(),
+		},
+	}
+	p := NewCSVPrinter([]float64{0.5})
+	b := new(bytes.Buffer)
+	if err := p.WriteRow(b, info); err != nil {
+		t.Fatal(err)
+	}
+	exp := ",0,0,0,0,0,0,0,0,0.00,1\n"
+	if act := b.String(); act != exp {
+		t.Errorf("Expected %q, but got %q", exp, act)
+	}
+}
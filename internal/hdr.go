@@ -0,0 +1,184 @@
+package internal
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"math/bits"
+	"time"
+)
+
+const (
+	// Same as in the reference implementation, lowest bit of
+	// word size nibble indicates zero-compressed counts.
+	hdrEncodingCookie           = 0x1c849303 | 0x10
+	hdrCompressedEncodingCookie = 0x1c849304 | 0x10
+
+	// HdrSignificantDigits is the precision with which latencies are
+	// exported, values with up to this many significant digits are
+	// preserved exactly.
+	HdrSignificantDigits = 3
+)
+
+// hdrLayout describes how values map to the counts array of
+// HdrHistogram with lowest discernible value of 1.
+type hdrLayout struct {
+	subBucketHalfCountMagnitude uint
+	subBucketHalfCount          int
+	subBucketMask               uint64
+}
+
+func newHdrLayout(significantDigits int) hdrLayout {
+	largestValueWithSingleUnitResolution := 2 * math.Pow10(significantDigits)
+	subBucketCountMagnitude := uint(math.Ceil(
+		math.Log2(largestValueWithSingleUnitResolution),
+	))
+	subBucketCount := 1 << subBucketCountMagnitude
+	return hdrLayout{
+		subBucketHalfCountMagnitude: subBucketCountMagnitude - 1,
+		subBucketHalfCount:          subBucketCount / 2,
+		subBucketMask:               uint64(subBucketCount - 1),
+	}
+}
+
+func (l hdrLayout) countsIndex(v uint64) int {
+	bucket := int(63-l.subBucketHalfCountMagnitude) -
+		bits.LeadingZeros64(v|l.subBucketMask)
+	subBucket := int(v >> uint(bucket))
+	return (bucket+1)<<l.subBucketHalfCountMagnitude +
+		subBucket - l.subBucketHalfCount
+}
+
+// EncodeHdrHistogram encodes histogram in HdrHistogram's compressed V2
+// format. Values that differ only beyond HdrSignificantDigits end up
+// in the same bucket.
+func EncodeHdrHistogram(h ReadonlyUint64Histogram) ([]byte, error) {
+	max := uint64(0)
+	h.VisitAll(func(v uint64, c uint64) bool {
+		if v > max {
+			max = v
+		}
+		return true
+	})
+	l := newHdrLayout(HdrSignificantDigits)
+	counts := make([]uint64, l.countsIndex(max)+1)
+	h.VisitAll(func(v uint64, c uint64) bool {
+		counts[l.countsIndex(v)] += c
+		return true
+	})
+
+	payload := new(bytes.Buffer)
+	for i := 0; i < len(counts); i++ {
+		if counts[i] != 0 {
+			putZigZag(payload, int64(counts[i]))
+			continue
+		}
+		// Runs of empty buckets are written as negative numbers
+		zeros := int64(1)
+		for i+1 < len(counts) && counts[i+1] == 0 {
+			zeros++
+			i++
+		}
+		if zeros > 1 {
+			putZigZag(payload, -zeros)
+		} else {
+			putZigZag(payload, 0)
+		}
+	}
+
+	highest := max
+	if highest < 2 {
+		highest = 2
+	}
+	encoded := new(bytes.Buffer)
+	header := struct {
+		Cookie                 uint32
+		PayloadLength          int32
+		NormalizingIndexOffset int32
+		SignificantDigits      int32
+		LowestDiscernibleValue int64
+		HighestTrackableValue  int64
+		ConversionRatio        float64
+	}{
+		Cookie:                 hdrEncodingCookie,
+		PayloadLength:          int32(payload.Len()),
+		SignificantDigits:      HdrSignificantDigits,
+		LowestDiscernibleValue: 1,
+		HighestTrackableValue:  int64(highest),
+		ConversionRatio:        1,
+	}
+	if err := binary.Write(encoded, binary.BigEndian, header); err != nil {
+		return nil, err
+	}
+	encoded.Write(payload.Bytes())
+
+	compressed := new(bytes.Buffer)
+	zw := zlib.NewWriter(compressed)
+	if _, err := zw.Write(encoded.Bytes()); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	out := new(bytes.Buffer)
+	err := binary.Write(out, binary.BigEndian, []uint32{
+		hdrCompressedEncodingCookie, uint32(compressed.Len()),
+	})
+	if err != nil {
+		return nil, err
+	}
+	out.Write(compressed.Bytes())
+	return out.Bytes(), nil
+}
+
+// putZigZag writes v as ZigZag-encoded LEB128 varint, except that,
+// like in the reference implementation, ninth byte carries full 8 bits.
+func putZigZag(buf *bytes.Buffer, v int64) {
+	u := uint64((v << 1) ^ (v >> 63))
+	for i := 0; i < 8; i++ {
+		if u < 0x80 {
+			buf.WriteByte(byte(u))
+			return
+		}
+		buf.WriteByte(byte(u&0x7f | 0x80))
+		u >>= 7
+	}
+	buf.WriteByte(byte(u))
+}
+
+// WriteHdrHistogramLog writes latencies (in microseconds) from the
+// results as a single interval in HdrHistogram log format, which can
+// be processed and merged with the existing HdrHistogram tooling.
+// Interval max is written in milliseconds.
+func WriteHdrHistogramLog(w io.Writer, r Results, start time.Time) error {
+	encoded, err := EncodeHdrHistogram(r.Latencies)
+	if err != nil {
+		return err
+	}
+	max := uint64(0)
+	r.Latencies.VisitAll(func(v uint64, c uint64) bool {
+		if v > max {
+			max = v
+		}
+		return true
+	})
+	startSec := float64(start.UnixNano()) / 1e9
+	_, err = fmt.Fprintf(w,
+		"#[Histogram log format version 1.3]\n"+
+			"#[StartTime: %.3f (seconds since epoch), %v]\n"+
+			"#[BaseTime: %.3f (seconds since epoch)]\n"+
+			"#[TestDuration: %.3f seconds]\n"+
+			"\"StartTimestamp\",\"Interval_Length\","+
+			"\"Interval_Max\",\"Interval_Compressed_Histogram\"\n"+
+			"%.3f,%.3f,%.3f,%v\n",
+		startSec, start.Format(time.RFC1123), startSec,
+		r.TimeTaken.Seconds(),
+		0.0, r.TimeTaken.Seconds(), float64(max)/1000,
+		base64.StdEncoding.EncodeToString(encoded),
+	)
+	return err
+}
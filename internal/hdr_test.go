@@ -0,0 +1,182 @@
+package internal
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	uhist "github.com/codesenberg/concurrent/uint64/histogram"
+)
+
+func TestHdrCountsIndex(t *testing.T) {
+	l := newHdrLayout(3)
+	expectations := []struct {
+		in  uint64
+		out int
+	}{
+		{0, 0},
+		{1, 1},
+		{2047, 2047},
+		{2048, 2048},
+		{2049, 2048},
+		{2050, 2049},
+		{4096, 3072},
+		{4100, 3073},
+	}
+	for _, e := range expectations {
+		if act := l.countsIndex(e.in); act != e.out {
+			t.Errorf("%v: expected index %v, but got %v", e.in, e.out, act)
+		}
+	}
+}
+
+func TestPutZigZag(t *testing.T) {
+	expectations := []struct {
+		in  int64
+		out []byte
+	}{
+		{0, []byte{0}},
+		{1, []byte{2}},
+		{-1, []byte{1}},
+		{64, []byte{0x80, 0x01}},
+		{-3, []byte{5}},
+		{
+			-1 << 63,
+			[]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		},
+	}
+	for _, e := range expectations {
+		b := new(bytes.Buffer)
+		putZigZag(b, e.in)
+		if act := b.Bytes(); !bytes.Equal(act, e.out) {
+			t.Errorf("%v: expected %x, but got %x", e.in, e.out, act)
+		}
+	}
+}
+
+func TestEncodeHdrHistogram(t *testing.T) {
+	h := uhist.Default()
+	for v, c := range map[uint64]uint64{3: 2, 5: 1, 2049: 7, 2050: 1} {
+		for i := uint64(0); i < c; i++ {
+			h.Increment(v)
+		}
+	}
+	encoded, err := EncodeHdrHistogram(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts, highest := decodeHdrHistogram(t, encoded)
+	if highest != 2050 {
+		t.Errorf("Expected highest trackable value to be 2050, but got %v",
+			highest)
+	}
+	exp := make([]int64, 2050)
+	exp[3], exp[5], exp[2048], exp[2049] = 2, 1, 7, 1
+	if !reflect.DeepEqual(counts, exp) {
+		t.Errorf("Unexpected counts: %v", counts)
+	}
+}
+
+func TestWriteHdrHistogramLog(t *testing.T) {
+	h := uhist.Default()
+	h.Increment(1500)
+	b := new(bytes.Buffer)
+	err := WriteHdrHistogramLog(b, Results{
+		Latencies: h,
+		TimeTaken: 2500 * time.Millisecond,
+	}, time.Unix(1500000000, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 6 {
+		t.Fatalf("Expected 6 lines, but got %q", lines)
+	}
+	for i, prefix := range []string{
+		"#[Histogram log format version 1.3]",
+		"#[StartTime: 1500000000.000 (seconds since epoch), ",
+		"#[BaseTime: 1500000000.000 (seconds since epoch)]",
+		"#[TestDuration: 2.500 seconds]",
+		`"StartTimestamp","Interval_Length","Interval_Max",` +
+			`"Interval_Compressed_Histogram"`,
+		"0.000,2.500,1.500,",
+	} {
+		if !strings.HasPrefix(lines[i], prefix) {
+			t.Errorf("Expected line %q to start with %q", lines[i], prefix)
+		}
+	}
+	fields := strings.Split(lines[5], ",")
+	encoded, err := base64.StdEncoding.DecodeString(fields[3])
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts, _ := decodeHdrHistogram(t, encoded)
+	if act := counts[len(counts)-1]; act != 1 {
+		t.Errorf("Expected last count to be 1, but got %v", act)
+	}
+}
+
+func decodeHdrHistogram(t *testing.T, encoded []byte) ([]int64, int64) {
+	var outer [2]uint32
+	r := bytes.NewReader(encoded)
+	if err := binary.Read(r, binary.BigEndian, &outer); err != nil {
+		t.Fatal(err)
+	}
+	if outer[0] != hdrCompressedEncodingCookie {
+		t.Fatalf("Unexpected compressed cookie: %x", outer[0])
+	}
+	if int(outer[1]) != r.Len() {
+		t.Fatalf("Expected %v compressed bytes, but got %v", outer[1], r.Len())
+	}
+	zr, err := zlib.NewReader(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decompressed, err := ioutil.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var header struct {
+		Cookie                 uint32
+		PayloadLength          int32
+		NormalizingIndexOffset int32
+		SignificantDigits      int32
+		LowestDiscernibleValue int64
+		HighestTrackableValue  int64
+		ConversionRatio        float64
+	}
+	r = bytes.NewReader(decompressed)
+	if err := binary.Read(r, binary.BigEndian, &header); err != nil {
+		t.Fatal(err)
+	}
+	if header.Cookie != hdrEncodingCookie ||
+		header.SignificantDigits != HdrSignificantDigits ||
+		header.LowestDiscernibleValue != 1 ||
+		header.ConversionRatio != 1 {
+		t.Fatalf("Unexpected header: %+v", header)
+	}
+	if int(header.PayloadLength) != r.Len() {
+		t.Fatalf("Expected payload of %v bytes, but got %v",
+			header.PayloadLength, r.Len())
+	}
+	counts := []int64{}
+	for r.Len() > 0 {
+		u, err := binary.ReadUvarint(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := int64(u>>1) ^ -int64(u&1)
+		if v < 0 {
+			counts = append(counts, make([]int64, -v)...)
+		} else {
+			counts = append(counts, v)
+		}
+	}
+	return counts, header.HighestTrackableValue
+}
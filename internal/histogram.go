@@ -0,0 +1,164 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// Uint64Histogram is an in-memory histogram with uint64 keys. Unlike
+// the one results are gathered into during the test, it isn't safe
+// for concurrent use, but can be read from JSON and merged with
+// other histograms.
+type Uint64Histogram map[uint64]uint64
+
+// Get returns the counter associated with the key.
+func (h Uint64Histogram) Get(k uint64) uint64 {
+	return h[k]
+}
+
+// VisitAll calls fn for every key-value pair until it returns false.
+func (h Uint64Histogram) VisitAll(fn func(uint64, uint64) bool) {
+	for k, v := range h {
+		if !fn(k, v) {
+			return
+		}
+	}
+}
+
+// Count returns the number of keys in histogram.
+func (h Uint64Histogram) Count() uint64 {
+	return uint64(len(h))
+}
+
+// Merge adds counters of other histogram to this one.
+func (h Uint64Histogram) Merge(other ReadonlyUint64Histogram) {
+	other.VisitAll(func(k, v uint64) bool {
+		h[k] += v
+		return true
+	})
+}
+
+// UnmarshalJSON reads histogram from an array of [key, count] pairs,
+// as written by MarshalUint64Histogram.
+func (h *Uint64Histogram) UnmarshalJSON(b []byte) error {
+	var pairs [][2]uint64
+	if err := json.Unmarshal(b, &pairs); err != nil {
+		return err
+	}
+	*h = make(Uint64Histogram, len(pairs))
+	for _, p := range pairs {
+		(*h)[p[0]] += p[1]
+	}
+	return nil
+}
+
+// MarshalUint64Histogram encodes histogram as an array of [key, count]
+// pairs sorted by key.
+func MarshalUint64Histogram(h ReadonlyUint64Histogram) []byte {
+	keys := make([]uint64, 0, h.Count())
+	h.VisitAll(func(k, v uint64) bool {
+		keys = append(keys, k)
+		return true
+	})
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i] < keys[j]
+	})
+	b := new(bytes.Buffer)
+	b.WriteByte('[')
+	for i, k := range keys {
+		if i != 0 {
+			b.WriteByte(',')
+		}
+		b.WriteByte('[')
+		b.WriteString(strconv.FormatUint(k, 10))
+		b.WriteByte(',')
+		b.WriteString(strconv.FormatUint(h.Get(k), 10))
+		b.WriteByte(']')
+	}
+	b.WriteByte(']')
+	return b.Bytes()
+}
+
+// Float64Histogram is the same as Uint64Histogram, but with float64
+// keys.
+type Float64Histogram map[float64]uint64
+
+// Get returns the counter associated with the key.
+func (h Float64Histogram) Get(k float64) uint64 {
+	return h[k]
+}
+
+// VisitAll calls fn for every key-value pair until it returns false.
+func (h Float64Histogram) VisitAll(fn func(float64, uint64) bool) {
+	for k, v := range h {
+		if !fn(k, v) {
+			return
+		}
+	}
+}
+
+// Count returns the number of keys in histogram.
+func (h Float64Histogram) Count() uint64 {
+	return uint64(len(h))
+}
+
+// Merge adds counters of other histogram to this one.
+func (h Float64Histogram) Merge(other ReadonlyFloat64Histogram) {
+	other.VisitAll(func(k float64, v uint64) bool {
+		h[k] += v
+		return true
+	})
+}
+
+// UnmarshalJSON reads histogram from an array of [key, count] pairs,
+// as written by MarshalFloat64Histogram.
+func (h *Float64Histogram) UnmarshalJSON(b []byte) error {
+	var pairs [][2]json.Number
+	if err := json.Unmarshal(b, &pairs); err != nil {
+		return err
+	}
+	*h = make(Float64Histogram, len(pairs))
+	for _, p := range pairs {
+		k, err := p[0].Float64()
+		if err != nil {
+			return err
+		}
+		v, err := strconv.ParseUint(p[1].String(), 10, 64)
+		if err != nil {
+			return err
+		}
+		(*h)[k] += v
+	}
+	return nil
+}
+
+// MarshalFloat64Histogram encodes histogram as an array of [key, count]
+// pairs sorted by key. Infinite and NaN keys, which can't be
+// represented in JSON, are left out.
+func MarshalFloat64Histogram(h ReadonlyFloat64Histogram) []byte {
+	keys := make([]float64, 0, h.Count())
+	h.VisitAll(func(k float64, v uint64) bool {
+		if !math.IsInf(k, 0) && !math.IsNaN(k) {
+			keys = append(keys, k)
+		}
+		return true
+	})
+	sort.Float64s(keys)
+	b := new(bytes.Buffer)
+	b.WriteByte('[')
+	for i, k := range keys {
+		if i != 0 {
+			b.WriteByte(',')
+		}
+		b.WriteByte('[')
+		b.WriteString(strconv.FormatFloat(k, 'g', -1, 64))
+		b.WriteByte(',')
+		b.WriteString(strconv.FormatUint(h.Get(k), 10))
+		b.WriteByte(']')
+	}
+	b.WriteByte(']')
+	return b.Bytes()
+}
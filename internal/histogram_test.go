@@ -0,0 +1,72 @@
+package internal
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestUint64HistogramJSON(t *testing.T) {
+	h := Uint64Histogram{300: 1, 5: 2, 40: 3}
+	b := MarshalUint64Histogram(h)
+	if exp := "[[5,2],[40,3],[300,1]]"; string(b) != exp {
+		t.Errorf("Expected %v, but got %s", exp, b)
+	}
+	var read Uint64Histogram
+	if err := json.Unmarshal(b, &read); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(read, h) {
+		t.Errorf("Expected %v, but got %v", h, read)
+	}
+	if err := json.Unmarshal([]byte(`[[1,-1]]`), &read); err == nil {
+		t.Error("Expected an error")
+	}
+	empty := MarshalUint64Histogram(Uint64Histogram{})
+	if string(empty) != "[]" {
+		t.Errorf("Expected empty array, but got %s", empty)
+	}
+}
+
+func TestFloat64HistogramJSON(t *testing.T) {
+	h := Float64Histogram{
+		1234.5678901234: 1, 0.5: 2, 1e21: 3,
+		math.Inf(1): 4, math.NaN(): 5,
+	}
+	b := MarshalFloat64Histogram(h)
+	if exp := "[[0.5,2],[1234.5678901234,1],[1e+21,3]]"; string(b) != exp {
+		t.Errorf("Expected %v, but got %s", exp, b)
+	}
+	var read Float64Histogram
+	if err := json.Unmarshal(b, &read); err != nil {
+		t.Fatal(err)
+	}
+	exp := Float64Histogram{1234.5678901234: 1, 0.5: 2, 1e21: 3}
+	if !reflect.DeepEqual(read, exp) {
+		t.Errorf("Expected %v, but got %v", exp, read)
+	}
+	if err := json.Unmarshal([]byte(`[[1,0.5]]`), &read); err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+func TestHistogramMerge(t *testing.T) {
+	u := Uint64Histogram{1: 1, 2: 2}
+	u.Merge(Uint64Histogram{2: 3, 4: 4})
+	if exp := (Uint64Histogram{1: 1, 2: 5, 4: 4}); !reflect.DeepEqual(u, exp) {
+		t.Errorf("Expected %v, but got %v", exp, u)
+	}
+	if u.Count() != 3 || u.Get(2) != 5 || u.Get(3) != 0 {
+		t.Errorf("Unexpected histogram %v", u)
+	}
+	f := Float64Histogram{0.5: 1}
+	f.Merge(Float64Histogram{0.5: 1, 1.5: 2})
+	if exp := (Float64Histogram{0.5: 2, 1.5: 2}); !reflect.DeepEqual(f, exp) {
+		t.Errorf("Expected %v, but got %v", exp, f)
+	}
+	stats := Results{Latencies: u}.LatenciesStats([]float64{0.5})
+	if stats.Max != 4 || stats.Percentiles[0.5] != 2 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+}
@@ -0,0 +1,40 @@
+package internal
+
+import "time"
+
+// RequestHook is called once every request completes, with its
+// latency, status code (zero if no response was received) and error,
+// if it failed. It's called concurrently by all the connections right
+// from the path results are recorded on, so it must not block, or it
+// would slow the test down and skew latencies.
+type RequestHook func(latency time.Duration, status int, err error)
+
+// TickHook is called every second of the test, and once more when
+// it's over, with the snapshot of results so far. It isn't called
+// during warmup. Calls are sequential, but, just like RequestHook, it
+// must not block, since requests per second are sampled by the same
+// goroutine.
+type TickHook func(Snapshot)
+
+// Snapshot holds results of the test at some point of it.
+type Snapshot struct {
+	// Elapsed is the time since the test began.
+	Elapsed time.Duration
+	// Rps is the number of requests per second completed since the
+	// previous snapshot.
+	Rps float64
+	// InFlight is the number of requests sent, but not completed yet.
+	InFlight uint64
+
+	Req1XX, Req2XX, Req3XX, Req4XX, Req5XX uint64
+	Others, Errors                         uint64
+
+	BytesRead, BytesWritten int64
+}
+
+// Completed returns the number of requests completed so far, either
+// with a response or an error.
+func (s Snapshot) Completed() uint64 {
+	return s.Req1XX + s.Req2XX + s.Req3XX + s.Req4XX + s.Req5XX +
+		s.Others + s.Errors
+}
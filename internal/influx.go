@@ -0,0 +1,87 @@
+package internal
+
+import (
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// InfluxMeasurement is the name of measurement results are written as.
+const InfluxMeasurement = "bombardier"
+
+// Tag is a key-value pair attached to the results when they are
+// written in InfluxDB line protocol.
+type Tag struct {
+	Key, Value string
+}
+
+// InfluxPrinter writes results of the test as a single point in
+// InfluxDB line protocol.
+type InfluxPrinter struct {
+	// Percentiles of latency to output, each of them is written as
+	// a separate field.
+	Percentiles []float64
+	// Tags to attach to the point.
+	Tags []Tag
+}
+
+// NewInfluxPrinter creates an InfluxPrinter that outputs given
+// percentiles of latency and attaches given tags.
+func NewInfluxPrinter(percentiles []float64, tags []Tag) *InfluxPrinter {
+	return &InfluxPrinter{
+		Percentiles: percentiles,
+		Tags:        tags,
+	}
+}
+
+var (
+	influxMeasurementEscaper = strings.NewReplacer(
+		",", `\,`, " ", `\ `,
+	)
+	influxKeyEscaper = strings.NewReplacer(
+		",", `\,`, "=", `\=`, " ", `\ `,
+	)
+)
+
+// WriteLine writes results from info as a line, with the given
+// timestamp, to w. Latencies are in microseconds, statistics that
+// can't be calculated are left out.
+func (p *InfluxPrinter) WriteLine(
+	w io.Writer, info TestInfo, timestamp time.Time,
+) error {
+	var sb strings.Builder
+	sb.WriteString(influxMeasurementEscaper.Replace(InfluxMeasurement))
+
+	// Tags are sorted, as recommended by InfluxDB
+	tags := append([]Tag(nil), p.Tags...)
+	sort.SliceStable(tags, func(i, j int) bool {
+		return tags[i].Key < tags[j].Key
+	})
+	for _, t := range tags {
+		sb.WriteByte(',')
+		sb.WriteString(influxKeyEscaper.Replace(t.Key))
+		sb.WriteByte('=')
+		sb.WriteString(influxKeyEscaper.Replace(t.Value))
+	}
+
+	fields := make([]string, 0, len(p.Percentiles)+17)
+	float := func(key string, v float64) {
+		fields = append(fields,
+			key+"="+strconv.FormatFloat(v, 'f', -1, 64))
+	}
+	integer := func(key string, v uint64) {
+		fields = append(fields,
+			key+"="+strconv.FormatUint(v, 10)+"i")
+	}
+	visitSummaryFields(info.Result, p.Percentiles, float, integer)
+
+	sb.WriteByte(' ')
+	sb.WriteString(strings.Join(fields, ","))
+	sb.WriteByte(' ')
+	sb.WriteString(strconv.FormatInt(timestamp.UnixNano(), 10))
+	sb.WriteByte('\n')
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
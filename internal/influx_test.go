@@ -0,0 +1,69 @@
+package internal
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	fhist "github.com/codesenberg/concurrent/float64/histogram"
+	uhist "github.com/codesenberg/concurrent/uint64/histogram"
+)
+
+func TestInfluxPrinterLine(t *testing.T) {
+	latencies := uhist.Default()
+	latencies.Increment(100)
+	latencies.Increment(100)
+	requests := fhist.Default()
+	requests.Increment(10)
+	info := TestInfo{
+		Result: Results{
+			BytesRead:    300,
+			BytesWritten: 100,
+			TimeTaken:    2 * time.Second,
+			Req2XX:       2,
+			Req5XX:       1,
+			Errors:       []ErrorWithCount{{"timeout", 3}},
+			Latencies:    latencies,
+			Requests:     requests,
+		},
+	}
+	p := NewInfluxPrinter([]float64{0.5, 0.999}, []Tag{
+		{"host", "a b"},
+		{"env", "staging,eu"},
+	})
+	b := new(bytes.Buffer)
+	if err := p.WriteLine(b, info, time.Unix(1500000000, 42)); err != nil {
+		t.Fatal(err)
+	}
+	exp := `bombardier,env=staging\,eu,host=a\ b ` +
+		"latency_mean_us=100,latency_stddev_us=0,latency_min_us=100," +
+		"latency_max_us=100,latency_p50_us=100i,latency_p99.9_us=100i," +
+		"rps_mean=10,rps_stddev=0,rps_max=10," +
+		"req1xx=0i,req2xx=2i,req3xx=0i,req4xx=0i,req5xx=1i,others=0i," +
+		"errors=3i,bytes_read=300i,bytes_written=100i," +
+		"throughput_bytes_per_sec=200,time_taken_seconds=2 " +
+		"1500000000000000042\n"
+	if act := b.String(); act != exp {
+		t.Errorf("Expected\n%q, but got\n%q", exp, act)
+	}
+}
+
+func TestInfluxPrinterLineWithoutStatistics(t *testing.T) {
+	info := TestInfo{
+		Result: Results{
+			Latencies: uhist.Default(),
+			Requests:  fhist.Default(),
+		},
+	}
+	p := NewInfluxPrinter([]float64{0.5}, nil)
+	b := new(bytes.Buffer)
+	if err := p.WriteLine(b, info, time.Unix(0, 1)); err != nil {
+		t.Fatal(err)
+	}
+	exp := "bombardier req1xx=0i,req2xx=0i,req3xx=0i,req4xx=0i,req5xx=0i," +
+		"others=0i,errors=0i,bytes_read=0i,bytes_written=0i," +
+		"time_taken_seconds=0 1\n"
+	if act := b.String(); act != exp {
+		t.Errorf("Expected\n%q, but got\n%q", exp, act)
+	}
+}
@@ -0,0 +1,64 @@
+package internal
+
+import (
+	"io"
+	"strconv"
+	"strings"
+)
+
+// LogfmtPrinter writes summary of the results as a single line of
+// space-separated key=value pairs, the way logfmt does.
+type LogfmtPrinter struct {
+	// Percentiles of latency to output, each of them is written as
+	// a separate field.
+	Percentiles []float64
+	// Tags to write before the rest of fields.
+	Tags []Tag
+}
+
+// NewLogfmtPrinter creates a LogfmtPrinter that outputs given
+// percentiles of latency and given tags.
+func NewLogfmtPrinter(percentiles []float64, tags []Tag) *LogfmtPrinter {
+	return &LogfmtPrinter{
+		Percentiles: percentiles,
+		Tags:        tags,
+	}
+}
+
+var logfmtKeyEscaper = strings.NewReplacer(" ", "_", "=", "_", `"`, "_")
+
+// logfmtValue quotes v if it can't be written as is.
+func logfmtValue(v string) string {
+	if v == "" || strings.ContainsAny(v, " =\"\\") ||
+		strings.IndexFunc(v, func(r rune) bool { return r < ' ' }) >= 0 {
+		return strconv.Quote(v)
+	}
+	return v
+}
+
+// WriteLine writes spec of the test and results from info as a line
+// to w. Besides the tags, it includes the fields written by
+// InfluxPrinter, along with the target, method and number of
+// connections.
+func (p *LogfmtPrinter) WriteLine(w io.Writer, info TestInfo) error {
+	fields := make([]string, 0, len(p.Tags)+len(p.Percentiles)+20)
+	str := func(key, v string) {
+		fields = append(fields, logfmtKeyEscaper.Replace(key)+"="+
+			logfmtValue(v))
+	}
+	float := func(key string, v float64) {
+		str(key, strconv.FormatFloat(v, 'f', -1, 64))
+	}
+	integer := func(key string, v uint64) {
+		str(key, strconv.FormatUint(v, 10))
+	}
+	for _, t := range p.Tags {
+		str(t.Key, t.Value)
+	}
+	str("url", info.Spec.URL)
+	str("method", info.Spec.Method)
+	integer("connections", info.Spec.NumberOfConnections)
+	visitSummaryFields(info.Result, p.Percentiles, float, integer)
+	_, err := io.WriteString(w, strings.Join(fields, " ")+"\n")
+	return err
+}
@@ -0,0 +1,73 @@
+package internal
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	fhist "github.com/codesenberg/concurrent/float64/histogram"
+	uhist "github.com/codesenberg/concurrent/uint64/histogram"
+)
+
+func TestLogfmtPrinterLine(t *testing.T) {
+	latencies := uhist.Default()
+	latencies.Increment(100)
+	latencies.Increment(100)
+	requests := fhist.Default()
+	requests.Increment(10)
+	info := TestInfo{
+		Spec: Spec{
+			NumberOfConnections: 2,
+			Method:              "GET",
+			URL:                 "http://localhost:8080/?a=b",
+		},
+		Result: Results{
+			BytesRead:    300,
+			BytesWritten: 100,
+			TimeTaken:    2 * time.Second,
+			Req2XX:       2,
+			Req5XX:       1,
+			Errors:       []ErrorWithCount{{"timeout", 3}},
+			Latencies:    latencies,
+			Requests:     requests,
+		},
+	}
+	p := NewLogfmtPrinter([]float64{0.5, 0.999}, []Tag{
+		{"host", "a b"},
+		{"run id", "42"},
+		{"env", ""},
+	})
+	b := new(bytes.Buffer)
+	if err := p.WriteLine(b, info); err != nil {
+		t.Fatal(err)
+	}
+	exp := `host="a b" run_id=42 env="" ` +
+		`url="http://localhost:8080/?a=b" method=GET connections=2 ` +
+		"latency_mean_us=100 latency_stddev_us=0 latency_min_us=100 " +
+		"latency_max_us=100 latency_p50_us=100 latency_p99.9_us=100 " +
+		"rps_mean=10 rps_stddev=0 rps_max=10 " +
+		"req1xx=0 req2xx=2 req3xx=0 req4xx=0 req5xx=1 others=0 " +
+		"errors=3 bytes_read=300 bytes_written=100 " +
+		"throughput_bytes_per_sec=200 time_taken_seconds=2\n"
+	if act := b.String(); act != exp {
+		t.Errorf("Expected\n%q, but got\n%q", exp, act)
+	}
+}
+
+func TestLogfmtValue(t *testing.T) {
+	expectations := []struct {
+		in, out string
+	}{
+		{"plain", "plain"},
+		{"", `""`},
+		{"with space", `"with space"`},
+		{`a"b`, `"a\"b"`},
+		{"a\nb", `"a\nb"`},
+		{"k=v", `"k=v"`},
+	}
+	for _, e := range expectations {
+		if act := logfmtValue(e.in); act != e.out {
+			t.Errorf("%q: expected %v, but got %v", e.in, e.out, act)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package internal
+
+import "strconv"
+
+// visitSummaryFields calls float or integer, depending on the type of
+// value, for every field of the summary of results that is written by
+// printers outputting results as a single line. Latencies are in
+// microseconds, statistics that can't be calculated are left out.
+func visitSummaryFields(
+	r Results, percentiles []float64,
+	float func(key string, v float64), integer func(key string, v uint64),
+) {
+	if stats := r.LatenciesStats(percentiles); stats != nil {
+		float("latency_mean_us", stats.Mean)
+		float("latency_stddev_us", stats.Stddev)
+		float("latency_min_us", stats.Min)
+		float("latency_max_us", stats.Max)
+		for _, pc := range percentiles {
+			if lat, ok := stats.Percentiles[pc]; ok {
+				integer("latency_p"+
+					strconv.FormatFloat(pc*100, 'f', -1, 64)+"_us", lat)
+			}
+		}
+	}
+	if stats := r.RequestsStats(percentiles); stats != nil {
+		float("rps_mean", stats.Mean)
+		float("rps_stddev", stats.Stddev)
+		float("rps_max", stats.Max)
+	}
+	errors := uint64(0)
+	for _, e := range r.Errors {
+		errors += e.Count
+	}
+	integer("req1xx", r.Req1XX)
+	integer("req2xx", r.Req2XX)
+	integer("req3xx", r.Req3XX)
+	integer("req4xx", r.Req4XX)
+	integer("req5xx", r.Req5XX)
+	integer("others", r.Others)
+	integer("errors", errors)
+	integer("bytes_read", uint64(r.BytesRead))
+	integer("bytes_written", uint64(r.BytesWritten))
+	if r.TimeTaken > 0 {
+		float("throughput_bytes_per_sec", r.Throughput())
+	}
+	float("time_taken_seconds", r.TimeTaken.Seconds())
+}
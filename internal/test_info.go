@@ -13,14 +13,79 @@ type TestInfo struct {
 	Result Results
 }
 
+// RateShortfall returns the fraction of the requested rate that wasn't
+// achieved, i.e. 0.25 if only 75% of requests were sent, which tells
+// that either the client or the target was saturated. It's zero if
+// the rate was met or wasn't limited.
+func (ti TestInfo) RateShortfall() float64 {
+	requested := ti.Spec.RequestedRate()
+	achieved := ti.Result.AchievedRate()
+	if requested == 0 || achieved >= requested {
+		return 0
+	}
+	return 1 - achieved/requested
+}
+
 // Header represents HTTP header.
 type Header struct {
 	Key, Value string
+	// IsTemplate tells whether Value is a template rendered for
+	// every request.
+	IsTemplate bool
+	// Probability of the header being sent with a request, zero
+	// if it's sent with every one of them.
+	Probability float64
+}
+
+// FormField is a field of multipart/form-data body.
+type FormField struct {
+	Name, Value string
+	// IsFile tells whether Value is the path of the uploaded file.
+	IsFile bool
+}
+
+// OAuth2 describes how bearer tokens were obtained. Client secret is
+// deliberately left out.
+type OAuth2 struct {
+	TokenURL, ClientID, Scope string
+}
+
+// SigV4 describes how requests were signed with AWS Signature Version
+// 4. Secret key and session token are deliberately left out.
+type SigV4 struct {
+	AccessKey, Region, Service string
+}
+
+// Replay describes the access log requests were replayed from, with
+// their paths appended to the URL.
+type Replay struct {
+	Path, Format string
+	// Speed is how many times faster than logged requests were
+	// replayed.
+	Speed float64
+}
+
+// GRPC describes unary gRPC method that was called instead of sending
+// HTTP requests.
+type GRPC struct {
+	// Method is the full name of the method, i.e. pkg.Service/Method.
+	Method string
+	// ProtoSet is the file descriptor set the method is described in.
+	ProtoSet string
+}
+
+// WeightedURL represents target's URL and its weight.
+type WeightedURL struct {
+	URL    string
+	Weight uint
 }
 
 // Spec contains information about test performed.
 type Spec struct {
 	NumberOfConnections uint64
+	// MaxConnsPerHost is the maximum number of concurrent connections
+	// to every host, zero if there was no limit.
+	MaxConnsPerHost uint64
 
 	TestType         TestType
 	NumberOfRequests uint64
@@ -28,20 +93,209 @@ type Spec struct {
 
 	Method string
 	URL    string
+	// URLs contains all targets of the test, if there were more than
+	// one of them.
+	URLs []string
+	// WeightedURLs contains all targets of the test alongside with
+	// their weights, if targets were selected randomly according to
+	// their weights.
+	WeightedURLs []WeightedURL
+	// Scenario is the path of the file with steps every connection
+	// went through in order, URLs hold their targets. It's empty
+	// unless the test followed a scenario.
+	Scenario string
+	// Replay describes the access log requests were replayed from,
+	// nil unless they were.
+	Replay *Replay
+	// GRPC describes the method that was called, with Body as its
+	// request in JSON, nil unless gRPC calls were made. Status codes
+	// are numeric gRPC ones then.
+	GRPC *GRPC
 
 	Headers []Header
 
 	Body         string
 	BodyFilePath string
+	// BodyDir is the directory bodies were picked from at random,
+	// empty if there was a single body.
+	BodyDir string
+	// BodyGenerated tells whether bodies of requests were produced
+	// by generator function.
+	BodyGenerated bool
+	// BodySize is the range sizes of randomly generated bodies were
+	// drawn from and their distribution (i.e. "1KB-1MB:lognormal"),
+	// empty if bodies weren't generated.
+	BodySize string
+	// CompressBody is the content coding body was compressed with
+	// before sending, empty if it was sent as is.
+	CompressBody string
+	// Form holds fields request body was built from, if it was
+	// multipart/form-data.
+	Form []FormField
+	// BodyTemplate tells whether body was rendered as a template
+	// for every request.
+	BodyTemplate bool
+	// Cookies tells whether every connection kept its own cookie
+	// jar.
+	Cookies bool
+	// Resolve holds overrides of addresses connections were made
+	// to, in the form of host:port:ip.
+	Resolve []string
+	// PinDNS tells whether every host was resolved only once.
+	PinDNS bool
+	// UnixSocket is the path of Unix domain socket all connections
+	// were made to, if any.
+	UnixSocket string
+	// Proxy is the URL of proxy all connections went through, if
+	// any, with password masked.
+	Proxy string
+	// LocalAddrs are IP addresses connections were made from, if
+	// they were bound to any.
+	LocalAddrs []string
+	// OAuth2 is set if Authorization header was populated with
+	// tokens obtained with OAuth2 client credentials grant.
+	OAuth2 *OAuth2
+	// BasicAuthUser is the user HTTP Basic authentication was
+	// performed as, password is deliberately left out.
+	BasicAuthUser string
+	// SigV4 is set if requests were signed with AWS Signature
+	// Version 4.
+	SigV4 *SigV4
 
 	CertPath string
 	KeyPath  string
 
+	// CertFromMemory tells whether client certificate or its key was
+	// supplied as PEM, rather than read from file. PEM itself isn't
+	// recorded.
+	CertFromMemory bool
+	// CACertPath is the path of CA bundle server certificates were
+	// verified against, empty if system roots were used.
+	CACertPath string
+	// SNI is the server name sent in TLS handshake, empty if it was
+	// the host from URL.
+	SNI string
+	// ALPN lists protocols advertised in TLS handshake, it's empty
+	// if the client's default ones were.
+	ALPN []string
+	// Insecure tells whether server certificates weren't verified.
+	Insecure bool
+
 	Stream     bool
 	Timeout    time.Duration
 	ClientType ClientType
+	// ConnectTimeout bounds establishing of connections, zero if
+	// only Timeout did.
+	ConnectTimeout time.Duration
+	// RampUp is the period over which connections were started.
+	RampUp time.Duration
+	// Warmup is the period at the start of the test during which
+	// latencies and request rates weren't recorded.
+	Warmup time.Duration
+	// ResetAfterWarmup tells whether status code and byte counters
+	// were reset once the warmup was over.
+	ResetAfterWarmup bool
+	// PrimeConnections tells whether every connection sent a request
+	// before the test, results of which weren't recorded.
+	PrimeConnections bool
 
 	Rate *uint64
+	// RatePerConn tells whether Rate limited each connection
+	// separately, rather than all of them together.
+	RatePerConn bool
+	// LoadProfile describes how rate changed over time, it's empty
+	// unless rate followed a load profile.
+	LoadProfile string
+	// Arrival is the process rate limited requests followed, empty
+	// if they were evenly spaced.
+	Arrival string
+	// CorrectedLatency tells whether latency was measured from the
+	// time request was scheduled for, rather than the time it was
+	// sent, to correct coordinated omission.
+	CorrectedLatency bool
+	// DisableKeepAlive tells whether every request was sent over
+	// a new connection, NumberOfConnections being the number of
+	// requests sent at once.
+	DisableKeepAlive bool
+	// ThinkTime is the pause between consecutive requests of every
+	// connection (either fixed or a range), empty if there was none.
+	ThinkTime string
+	// Jitter is the upper bound of random delay of every request,
+	// which starts once the rate limiter lets the request through
+	// and comes on top of ThinkTime. Neither counts towards latency,
+	// even if it's corrected, since latency is then measured from
+	// the scheduled time plus the delay.
+	Jitter time.Duration
+	// ServerTimeHeader is the response header server processing time
+	// was read from, empty if it wasn't.
+	ServerTimeHeader string
+	// InterArrival tells whether gaps between consecutive completions
+	// of requests were recorded.
+	InterArrival bool
+	// KneeLatency is the latency requirement in the form of
+	// p<percentile>:<duration>, the first step of load profile that
+	// violated it is reported as the knee. It's empty if knee wasn't
+	// being detected.
+	KneeLatency string
+
+	// LatencyDigits is the number of significant digits latencies
+	// were recorded with, zero if they were recorded exactly.
+	LatencyDigits uint64
+	// LatencyCeiling is the highest latency that was tracked, zero
+	// if there was no limit.
+	LatencyCeiling time.Duration
+
+	// ConnStats tells whether results were recorded for every
+	// connection separately.
+	ConnStats bool
+
+	// Percentiles are fractions (in [0, 1] range) for which
+	// latency and request rate percentiles were calculated.
+	Percentiles []float64
+
+	// ExpectedStatusCodes lists status codes considered successful,
+	// it's empty if any status code was acceptable.
+	ExpectedStatusCodes []int
+
+	// Retries is the maximum number of times failed request was
+	// retried.
+	Retries uint64
+	// LatencyWithRetries tells whether latency of request included
+	// all of its attempts, rather than just the last one.
+	LatencyWithRetries bool
+
+	// MaxRedirects is the maximum number of redirects followed for
+	// every request, zero if redirects weren't followed.
+	MaxRedirects uint64
+	// FinalHopLatency tells whether latency of request only included
+	// the request to the last location it was redirected to, rather
+	// than all of the hops.
+	FinalHopLatency bool
+
+	// AbortErrorRate is the fraction of 5xx responses above which
+	// the test is aborted, nil if the test is never aborted.
+	AbortErrorRate *float64
+	// MaxBytes is the number of bytes read and written the test is
+	// stopped at, nil if there is no cap.
+	MaxBytes *uint64
+
+	// Seed is what random number generators were seeded with, passing
+	// it to --seed reproduces the same random choices.
+	Seed uint64
+}
+
+// RequestedRate returns the rate (in requests per second) the test was
+// limited to in total, zero if it wasn't limited or the rate followed
+// a load profile.
+func (s Spec) RequestedRate() float64 {
+	if s.Rate == nil || s.LoadProfile != "" {
+		return 0
+	}
+	rate := float64(*s.Rate)
+	if s.RatePerConn {
+		rate *= float64(s.NumberOfConnections)
+	}
+	return rate
 }
 
 // IsTimedTest tells if the test was limited by time.
@@ -55,6 +309,12 @@ func (s Spec) IsTestWithNumberOfReqs() bool {
 	return s.TestType == ByNumberOfReqs
 }
 
+// IsTestByTimeOrNumberOfReqs tells if the test was limited by both
+// time and the number of requests, whichever was reached first.
+func (s Spec) IsTestByTimeOrNumberOfReqs() bool {
+	return s.TestType == ByTimeOrNumberOfReqs
+}
+
 // IsFastHTTP tells whether fasthttp were used as HTTP client to
 // perform the test.
 func (s Spec) IsFastHTTP() bool {
@@ -77,15 +337,209 @@ func (s Spec) IsNetHTTPV2() bool {
 type Results struct {
 	BytesRead, BytesWritten int64
 	TimeTaken               time.Duration
+	// HeaderBytesRead and BodyBytesRead are bytes of status lines and
+	// headers and of bodies of successful responses received after
+	// warmup. BytesRead is counted on connections instead, so it also
+	// takes in failed and warmup requests, TLS and framing overhead,
+	// and the two don't add up to it. Headers are counted as they're
+	// sent over HTTP/1.1 even if HPACK compressed them.
+	HeaderBytesRead, BodyBytesRead int64
 
 	Req1XX, Req2XX, Req3XX, Req4XX, Req5XX, Req502 uint64
 	Others                                         uint64
-	StatusCodes map[int]uint64
+	StatusCodes                                    map[int]uint64
 
 	Errors []ErrorWithCount
+	// ErrorsByCategory holds the numbers of errors by their broad
+	// kind, like "connection refused" or "read timeout".
+	ErrorsByCategory map[string]uint64
+	// Retries is the number of times requests were retried, these
+	// aren't counted towards status codes or errors.
+	Retries uint64
+	// Redirects is the number of redirects followed, redirect
+	// responses that were followed aren't counted towards status
+	// codes.
+	Redirects uint64
+	// Aborted tells whether the test was stopped early, because
+	// the rate of 5xx responses exceeded Spec.AbortErrorRate.
+	Aborted bool
+	// StoppedBy is the condition that ended the test limited by both
+	// time and the number of requests, either ByTime or
+	// ByNumberOfReqs. It's zero for other tests and for the ones that
+	// were aborted, interrupted or stopped by the byte cap.
+	StoppedBy TestType
+	// BytesCapReached tells whether the test was stopped early,
+	// because Spec.MaxBytes were read and written.
+	BytesCapReached bool
+	// Interrupted tells whether the test was interrupted by user,
+	// so that results are partial.
+	Interrupted bool
+
+	// NewConns and ReusedConns are the numbers of requests that got
+	// a response over a new connection and over a connection that
+	// was already used by some other request, respectively.
+	NewConns, ReusedConns uint64
+	// ConnsOpened is the number of connections that were established,
+	// including ones requests over which failed.
+	ConnsOpened uint64
+	// PrimingTime is how long priming of connections took before the
+	// test, it isn't part of TimeTaken.
+	PrimingTime time.Duration
+	// RemoteAddresses are the sorted addresses connections were
+	// made to.
+	RemoteAddresses []string
+	// MaxInFlight is the highest number of requests that were sent,
+	// but not completed yet, at once.
+	MaxInFlight uint64
+	// InFlight holds the numbers of requests in flight sampled every
+	// second, oldest first.
+	InFlight []InFlightSample
+	// TimeSeries holds results for every second of the test, oldest
+	// first. It's nil unless recording of them was requested.
+	TimeSeries []TimeSeriesPoint
+	// HostConns holds the highest numbers of connections open to
+	// every host at once, sorted by host. It's only gathered if
+	// connections per host were limited.
+	HostConns []HostConns
+	// BodyFiles holds how many times every file of Spec.BodyDir was
+	// sent, sorted by name. Files that were never sent are left out.
+	BodyFiles []BodyFileCount
+
+	// Knee is the first step of the load profile during which latency
+	// exceeded Spec.KneeLatency, nil if it never did.
+	Knee *KneeStep
+
+	Latencies ReadonlyUint64Histogram
+	// TTFB holds times to first byte of responses (in microseconds),
+	// requests that failed aren't included.
+	TTFB     ReadonlyUint64Histogram
+	Requests ReadonlyFloat64Histogram
+	// ResponseSizes holds sizes of response bodies (in bytes),
+	// requests that failed aren't included.
+	ResponseSizes ReadonlyUint64Histogram
+	// RequestSizes holds sizes of request bodies (in bytes) sent with
+	// Spec.BodySize, requests that failed aren't included. It's nil
+	// unless bodies were generated.
+	RequestSizes ReadonlyUint64Histogram
+	// Handshakes holds durations of TLS handshakes (in microseconds)
+	// of connections that served requests successfully.
+	Handshakes ReadonlyUint64Histogram
+	// ServerTimes holds processing times (in microseconds) reported
+	// by server in Spec.ServerTimeHeader, it's nil unless the header
+	// was given. ServerTimeErrors is the number of successful
+	// responses without the header or with malformed one.
+	ServerTimes      ReadonlyUint64Histogram
+	ServerTimeErrors uint64
+	// InterArrivals holds gaps (in microseconds) between consecutive
+	// completions of requests on any connection, failed ones
+	// included. It's nil unless Spec.InterArrival is set.
+	InterArrivals ReadonlyUint64Histogram
+	// LatenciesByStatusClass holds latencies (in microseconds) by the
+	// hundreds digit of status code, with 0 standing for failed
+	// requests and other status codes. It's nil unless breakdown
+	// by status class was requested.
+	LatenciesByStatusClass map[int]ReadonlyUint64Histogram
+
+	// URLs holds per-URL breakdown of the results, if there were
+	// more than one target.
+	URLs []URLResults
+	// Conns holds per-connection breakdown of the results, ordered
+	// by connection ID. It's nil unless Spec.ConnStats is set.
+	Conns []ConnResults
+
+	// ClampedLatencies is the number of latencies that exceeded
+	// Spec.LatencyCeiling and were recorded as it.
+	ClampedLatencies uint64
+
+	// InterpolatePercentiles tells whether percentiles of histograms
+	// are linearly interpolated between adjacent values, rather than
+	// taken as the nearest value of the rank.
+	InterpolatePercentiles bool
+}
+
+// KneeStep describes a step of step load profile.
+type KneeStep struct {
+	// Step is the number of the step, starting from 1.
+	Step uint64
+	// Rate is the number of requests per second during the step.
+	Rate float64
+	// Latency is the percentile of latencies (in microseconds) of
+	// requests completed during the step.
+	Latency uint64
+}
+
+// InFlightSample is the number of requests in flight at some point
+// of the test.
+type InFlightSample struct {
+	// Elapsed is the time since the test began.
+	Elapsed  time.Duration
+	Requests uint64
+}
+
+// TimeSeriesPoint holds results for a period of the test.
+type TimeSeriesPoint struct {
+	// Elapsed is the time since the test began to the end of the
+	// period.
+	Elapsed time.Duration
+	// Rps is the number of requests per second completed during
+	// the period.
+	Rps float64
+	// LatencyMean is the mean latency (in microseconds) of requests
+	// completed during the period.
+	LatencyMean float64
+	Errors      uint64
+}
+
+// HostConns describes connections made to a single host.
+type HostConns struct {
+	// Host is the host:port connections were made to.
+	Host string
+	// MaxOpen is the highest number of connections that were open
+	// at once.
+	MaxOpen uint64
+}
+
+// BodyFileCount is the number of requests a file was sent with.
+type BodyFileCount struct {
+	Name  string
+	Count uint64
+}
+
+// URLResults holds results of the test for a single URL.
+type URLResults struct {
+	URL string
+	// Name of the scenario step, empty unless the test followed
+	// a scenario.
+	Name string
+
+	Req1XX, Req2XX, Req3XX, Req4XX, Req5XX, Others uint64
+	Errors                                         uint64
 
 	Latencies ReadonlyUint64Histogram
-	Requests  ReadonlyFloat64Histogram
+	// InterpolatePercentiles has the same meaning as the field of
+	// Results.
+	InterpolatePercentiles bool
+}
+
+// ConnResults holds results of the test for a single connection.
+type ConnResults struct {
+	ConnID uint64
+	// Requests is the number of requests sent over the connection,
+	// including the failed ones counted in Errors.
+	Requests, Errors uint64
+	// MeanLatency is the mean latency of requests (in microseconds).
+	MeanLatency float64
+}
+
+// NumberOfRequests returns total number of requests sent to the URL.
+func (u URLResults) NumberOfRequests() uint64 {
+	return u.Req1XX + u.Req2XX + u.Req3XX + u.Req4XX + u.Req5XX + u.Others
+}
+
+// LatenciesStats performs various statistical calculations on
+// latencies of requests sent to the URL.
+func (u URLResults) LatenciesStats(percentiles []float64) *LatenciesStats {
+	return latenciesStats(u.Latencies, percentiles, u.InterpolatePercentiles)
 }
 
 // ReadonlyUint64Histogram is a readonly histogram with uint64 keys
@@ -108,28 +562,209 @@ func (r Results) Throughput() float64 {
 	return float64(r.BytesRead+r.BytesWritten) / r.TimeTaken.Seconds()
 }
 
+// NumberOfRequests returns total number of completed requests,
+// either with a response or an error.
+func (r Results) NumberOfRequests() uint64 {
+	return r.Req1XX + r.Req2XX + r.Req3XX + r.Req4XX + r.Req5XX + r.Others
+}
+
+// AchievedRate returns the mean rate (in requests per second) at which
+// requests were completed over the whole test.
+func (r Results) AchievedRate() float64 {
+	if r.TimeTaken <= 0 {
+		return 0
+	}
+	return float64(r.NumberOfRequests()) / r.TimeTaken.Seconds()
+}
+
+// SuccessfulRequests returns the number of completed requests that
+// didn't fail with an error or unexpected status code. Latencies of
+// failed requests are recorded too, so when there are no successful
+// ones, stats of latencies only tell how long requests took to fail.
+func (r Results) SuccessfulRequests() uint64 {
+	failed := uint64(0)
+	for _, e := range r.Errors {
+		failed += e.Count
+	}
+	total := r.NumberOfRequests()
+	if failed > total {
+		return 0
+	}
+	return total - failed
+}
+
+// StoppedByTime tells if the test limited by both time and the number
+// of requests ended because its duration elapsed.
+func (r Results) StoppedByTime() bool {
+	return r.StoppedBy == ByTime
+}
+
+// StoppedByNumberOfReqs tells if the test limited by both time and the
+// number of requests ended because all of its requests completed.
+func (r Results) StoppedByNumberOfReqs() bool {
+	return r.StoppedBy == ByNumberOfReqs
+}
+
+// ConnReusePercentage returns percentage of requests that got
+// a response over a reused connection.
+func (r Results) ConnReusePercentage() float64 {
+	total := r.NewConns + r.ReusedConns
+	if total == 0 {
+		return 0
+	}
+	return float64(r.ReusedConns) / float64(total) * 100
+}
+
 // LatenciesStats contains statistical information about latencies.
 type LatenciesStats struct {
 	// These are in microseconds
 	Mean   float64
 	Stddev float64
+	Min    float64
 	Max    float64
 
+	// CV is the coefficient of variation, i.e. Stddev / Mean.
+	CV float64
+	// MeanPercentile is the fraction of requests with latency less
+	// than or equal to the Mean.
+	MeanPercentile float64
+
+	// IQR is the interquartile range, i.e. p75 - p25.
+	IQR float64
+	// Outliers is the number of requests with latency greater than
+	// p75 + 1.5 * IQR.
+	Outliers uint64
+	// MAD is the median absolute deviation, i.e. the median of
+	// distances of latencies from their median. Unlike Stddev, it
+	// isn't blown up by a few outliers.
+	MAD float64
+
 	// This is  map[0.0 <= p <= 1.0 (percentile)]microseconds
 	Percentiles map[float64]uint64
 }
 
 // LatenciesStats performs various statistical calculations on
-// latencies.
+// latencies. Percentiles are nearest-rank ones, unless
+// InterpolatePercentiles is set, either way p0 is the minimum and
+// p100 is the maximum.
 func (r Results) LatenciesStats(percentiles []float64) *LatenciesStats {
-	h := r.Latencies
+	return latenciesStats(r.Latencies, percentiles, r.InterpolatePercentiles)
+}
+
+// TTFBStats performs the same calculations as LatenciesStats on times
+// to first byte.
+func (r Results) TTFBStats(percentiles []float64) *LatenciesStats {
+	if r.TTFB == nil {
+		return nil
+	}
+	return latenciesStats(r.TTFB, percentiles, r.InterpolatePercentiles)
+}
+
+// HandshakeStats performs various statistical calculations on
+// durations of TLS handshakes.
+func (r Results) HandshakeStats(percentiles []float64) *LatenciesStats {
+	if r.Handshakes == nil {
+		return nil
+	}
+	return latenciesStats(r.Handshakes, percentiles, r.InterpolatePercentiles)
+}
+
+// ServerTimeStats performs the same calculations as LatenciesStats on
+// processing times reported by server.
+func (r Results) ServerTimeStats(percentiles []float64) *LatenciesStats {
+	if r.ServerTimes == nil {
+		return nil
+	}
+	return latenciesStats(r.ServerTimes, percentiles, r.InterpolatePercentiles)
+}
+
+// InterArrivalStats performs the same calculations as LatenciesStats
+// on gaps between completions of requests.
+func (r Results) InterArrivalStats(percentiles []float64) *LatenciesStats {
+	if r.InterArrivals == nil {
+		return nil
+	}
+	return latenciesStats(
+		r.InterArrivals, percentiles, r.InterpolatePercentiles,
+	)
+}
+
+// SizeStats contains statistical information about sizes.
+type SizeStats struct {
+	// These are in bytes
+	Mean   float64
+	Stddev float64
+	Min    float64
+	Max    float64
+
+	// This is  map[0.0 <= p <= 1.0 (percentile)]bytes
+	Percentiles map[float64]uint64
+}
+
+// ResponseSizeStats performs various statistical calculations on
+// sizes of response bodies.
+func (r Results) ResponseSizeStats(percentiles []float64) *SizeStats {
+	return r.sizeStats(r.ResponseSizes, percentiles)
+}
+
+// RequestSizeStats performs various statistical calculations on
+// sizes of generated request bodies.
+func (r Results) RequestSizeStats(percentiles []float64) *SizeStats {
+	return r.sizeStats(r.RequestSizes, percentiles)
+}
+
+func (r Results) sizeStats(
+	sizes ReadonlyUint64Histogram, percentiles []float64,
+) *SizeStats {
+	if sizes == nil {
+		return nil
+	}
+	stats := latenciesStats(sizes, percentiles, r.InterpolatePercentiles)
+	if stats == nil {
+		return nil
+	}
+	return &SizeStats{
+		Mean:        stats.Mean,
+		Stddev:      stats.Stddev,
+		Min:         stats.Min,
+		Max:         stats.Max,
+		Percentiles: stats.Percentiles,
+	}
+}
+
+// LatenciesStatsByStatusClass performs the same calculations as
+// LatenciesStats for every status class that has any requests.
+func (r Results) LatenciesStatsByStatusClass(
+	percentiles []float64,
+) map[int]*LatenciesStats {
+	if r.LatenciesByStatusClass == nil {
+		return nil
+	}
+	stats := make(map[int]*LatenciesStats)
+	for class, h := range r.LatenciesByStatusClass {
+		if s := latenciesStats(
+			h, percentiles, r.InterpolatePercentiles,
+		); s != nil {
+			stats[class] = s
+		}
+	}
+	return stats
+}
+
+func latenciesStats(
+	h ReadonlyUint64Histogram, percentiles []float64, interpolate bool,
+) *LatenciesStats {
 	sum := uint64(0)
 	count := uint64(0)
+	min := uint64(math.MaxUint64)
 	max := uint64(0)
 	pairs := make([]struct{ k, v uint64 }, 0, h.Count())
 
 	// Gather all the data
 	h.VisitAll(func(f uint64, c uint64) bool {
+		if f < min {
+			min = f
+		}
 		if f > max {
 			max = f
 		}
@@ -146,6 +781,9 @@ func (r Results) LatenciesStats(percentiles []float64) *LatenciesStats {
 	sort.Slice(pairs, func(i, j int) bool {
 		return pairs[i].k < pairs[j].k
 	})
+	percentile := func(pc float64) uint64 {
+		return percentileOf(pairs, count, pc, interpolate)
+	}
 	percentilesMap := map[float64]uint64{}
 	for _, pc := range percentiles {
 		if _, calculated := percentilesMap[pc]; calculated {
@@ -155,37 +793,136 @@ func (r Results) LatenciesStats(percentiles []float64) *LatenciesStats {
 			// Drop percentiles outside of [0, 1] range
 			continue
 		}
-		rank := uint64(pc*float64(count) + 0.5)
-		total := uint64(0)
-		for _, p := range pairs {
-			total += p.v
-			if total >= rank {
-				percentilesMap[pc] = p.k
-				break
-			}
+		percentilesMap[pc] = percentile(pc)
+	}
+
+	// Interquartile range and outliers
+	p25 := percentile(0.25)
+	p75 := percentile(0.75)
+	iqr := float64(p75) - float64(p25)
+	outliersThreshold := float64(p75) + 1.5*iqr
+	outliers := uint64(0)
+	for _, p := range pairs {
+		if float64(p.k) > outliersThreshold {
+			outliers += p.v
 		}
 	}
 
-	// Calculate mean and standard deviation
+	// Median absolute deviation, i.e. the median of distances from
+	// the median
+	median := percentile(0.5)
+	deviations := make([]struct{ k, v uint64 }, len(pairs))
+	for i, p := range pairs {
+		deviations[i].v = p.v
+		if p.k < median {
+			deviations[i].k = median - p.k
+		} else {
+			deviations[i].k = p.k - median
+		}
+	}
+	sort.Slice(deviations, func(i, j int) bool {
+		return deviations[i].k < deviations[j].k
+	})
+	mad := percentileOf(deviations, count, 0.5, interpolate)
+
+	// Calculate mean and standard deviation, going over sorted pairs
+	// so that floating point result doesn't depend on histogram's order
 	mean := float64(sum) / float64(count)
 	sumOfSquares := float64(0)
-	h.VisitAll(func(f uint64, c uint64) bool {
-		sumOfSquares += math.Pow(float64(f)-mean, 2)
-		return true
-	})
+	atOrBelowMean := uint64(0)
+	for _, p := range pairs {
+		sumOfSquares += math.Pow(float64(p.k)-mean, 2) * float64(p.v)
+		if float64(p.k) <= mean {
+			atOrBelowMean += p.v
+		}
+	}
 	stddev := 0.0
 	if count > 2 {
 		stddev = math.Sqrt(sumOfSquares / float64(count))
 	}
+	cv := 0.0
+	if mean > 0 {
+		cv = stddev / mean
+	}
 	return &LatenciesStats{
 		Mean:   mean,
 		Stddev: stddev,
+		Min:    float64(min),
 		Max:    float64(max),
 
+		CV:             cv,
+		MeanPercentile: float64(atOrBelowMean) / float64(count),
+
+		IQR:      iqr,
+		Outliers: outliers,
+		MAD:      float64(mad),
+
 		Percentiles: percentilesMap,
 	}
 }
 
+// percentileOf finds the pc percentile of count values, that are
+// sorted and grouped into pairs of value and the number of times it
+// occurred.
+func percentileOf(
+	pairs []struct{ k, v uint64 }, count uint64, pc float64, interpolate bool,
+) uint64 {
+	if interpolate {
+		return interpolatedPercentile(pairs, count, pc)
+	}
+	rank := nearestRank(pc, count)
+	total := uint64(0)
+	for _, p := range pairs {
+		total += p.v
+		if total >= rank {
+			return p.k
+		}
+	}
+	return 0
+}
+
+// nearestRank returns the rank (numbered from 1) of the value that is
+// the pc percentile of count sorted values. Rank is rounded to the
+// nearest one and kept within [1, count], so that p0 is exactly the
+// minimum and p100 is exactly the maximum, however few values there
+// are.
+func nearestRank(pc float64, count uint64) uint64 {
+	rank := uint64(pc*float64(count) + 0.5)
+	if rank < 1 {
+		return 1
+	}
+	if rank > count {
+		return count
+	}
+	return rank
+}
+
+// interpolatedPercentile finds the value of (zero-based) fractional
+// rank pc*(count-1) among sorted values, interpolating linearly
+// between the values of the ranks around it.
+func interpolatedPercentile(
+	pairs []struct{ k, v uint64 }, count uint64, pc float64,
+) uint64 {
+	pos := pc * float64(count-1)
+	lowerRank := uint64(pos)
+	frac := pos - float64(lowerRank)
+	var lower, total uint64
+	for i, p := range pairs {
+		total += p.v
+		if total <= lowerRank {
+			continue
+		}
+		lower = p.k
+		if frac == 0 || total > lowerRank+1 || i == len(pairs)-1 {
+			// The next rank has the same value
+			return lower
+		}
+		upper := pairs[i+1].k
+		return lower + uint64(frac*float64(upper-lower)+0.5)
+	}
+	return lower
+}
+
 // RequestsStats contains statistical information about requests.
 type RequestsStats struct {
 	// These are in requests per second.
@@ -242,7 +979,7 @@ func (r Results) RequestsStats(percentiles []float64) *RequestsStats {
 			// Drop percentiles outside of [0, 1] range
 			continue
 		}
-		rank := uint64(pc*float64(count) + 0.5)
+		rank := nearestRank(pc, count)
 		total := uint64(0)
 		for _, p := range pairs {
 			total += p.v
@@ -260,7 +997,7 @@ func (r Results) RequestsStats(percentiles []float64) *RequestsStats {
 		if math.IsInf(f, 0) || math.IsNaN(f) {
 			return true
 		}
-		sumOfSquares += math.Pow(f-mean, 2)
+		sumOfSquares += math.Pow(f-mean, 2) * float64(c)
 		return true
 	})
 	stddev := 0.0
@@ -293,6 +1030,9 @@ const (
 	// ByNumberOfReqs is a test limited by number of requests
 	// performed.
 	ByNumberOfReqs
+	// ByTimeOrNumberOfReqs is a test limited by both duration and
+	// number of requests, whichever is reached first.
+	ByTimeOrNumberOfReqs
 )
 
 // ClientType is the type of HTTP client used in test
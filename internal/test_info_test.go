@@ -0,0 +1,442 @@
+package internal
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	uhist "github.com/codesenberg/concurrent/uint64/histogram"
+)
+
+func TestLatenciesStatsCVAndMeanPercentile(t *testing.T) {
+	h := uhist.Default()
+	for _, v := range []uint64{100, 100, 100, 100, 600} {
+		h.Increment(v)
+	}
+	stats := Results{Latencies: h}.LatenciesStats(nil)
+	if stats == nil {
+		t.Fatal("Expected stats to be calculated")
+	}
+	if stats.Mean != 200 {
+		t.Errorf("Expected mean to be 200, but got %v", stats.Mean)
+	}
+	if exp := stats.Stddev / stats.Mean; math.Abs(stats.CV-exp) > 1e-9 {
+		t.Errorf("Expected CV to be %v, but got %v", exp, stats.CV)
+	}
+	if stats.MeanPercentile != 0.8 {
+		t.Errorf("Expected mean percentile to be 0.8, but got %v",
+			stats.MeanPercentile)
+	}
+}
+
+// naiveStddev calculates population standard deviation going over
+// every sample.
+func naiveStddev(samples []float64) float64 {
+	sum := 0.0
+	for _, s := range samples {
+		sum += s
+	}
+	mean := sum / float64(len(samples))
+	sumOfSquares := 0.0
+	for _, s := range samples {
+		sumOfSquares += (s - mean) * (s - mean)
+	}
+	return math.Sqrt(sumOfSquares / float64(len(samples)))
+}
+
+func TestStddevIsWeightedByCounts(t *testing.T) {
+	// Buckets with very different counts
+	var samples []float64
+	for v, c := range map[uint64]int{10: 1, 20: 50, 30: 3, 500: 1} {
+		for i := 0; i < c; i++ {
+			samples = append(samples, float64(v))
+		}
+	}
+	exp := naiveStddev(samples)
+
+	h := uhist.Default()
+	fh := make(Float64Histogram)
+	for _, s := range samples {
+		h.Increment(uint64(s))
+		fh[s]++
+	}
+	res := Results{Latencies: h, Requests: fh}
+	if stats := res.LatenciesStats(nil); math.Abs(stats.Stddev-exp) > 1e-9 {
+		t.Errorf("Expected latencies stddev %v, but got %v",
+			exp, stats.Stddev)
+	}
+	if stats := res.RequestsStats(nil); math.Abs(stats.Stddev-exp) > 1e-9 {
+		t.Errorf("Expected requests stddev %v, but got %v",
+			exp, stats.Stddev)
+	}
+}
+
+func TestLatenciesStatsCVWithZeroMean(t *testing.T) {
+	h := uhist.Default()
+	h.Increment(0)
+	stats := Results{Latencies: h}.LatenciesStats(nil)
+	if stats.CV != 0 {
+		t.Errorf("Expected CV to be 0, but got %v", stats.CV)
+	}
+	if stats.MeanPercentile != 1 {
+		t.Errorf("Expected mean percentile to be 1, but got %v",
+			stats.MeanPercentile)
+	}
+}
+
+func TestLatenciesStatsMin(t *testing.T) {
+	h := uhist.Default()
+	for _, v := range []uint64{300, 42, 1000, 42} {
+		h.Increment(v)
+	}
+	stats := Results{Latencies: h}.LatenciesStats(nil)
+	if stats.Min != 42 {
+		t.Errorf("Expected min to be 42, but got %v", stats.Min)
+	}
+	if stats.Max != 1000 {
+		t.Errorf("Expected max to be 1000, but got %v", stats.Max)
+	}
+}
+
+func TestLatenciesStatsIQRAndOutliers(t *testing.T) {
+	h := uhist.Default()
+	// p25 = 100, p75 = 200, so anything above 350 is an outlier
+	for _, v := range []uint64{
+		100, 100, 100, 150, 150, 200, 200, 200, 350, 351, 5000,
+	} {
+		h.Increment(v)
+	}
+	stats := Results{Latencies: h}.LatenciesStats(nil)
+	if stats.IQR != 100 {
+		t.Errorf("Expected IQR to be 100, but got %v", stats.IQR)
+	}
+	if stats.Outliers != 2 {
+		t.Errorf("Expected 2 outliers, but got %v", stats.Outliers)
+	}
+	if len(stats.Percentiles) != 0 {
+		t.Errorf("Expected quartiles to stay internal, but got %v",
+			stats.Percentiles)
+	}
+}
+
+func TestLatenciesStatsInterpolatedPercentiles(t *testing.T) {
+	h := uhist.Default()
+	for _, v := range []uint64{100, 200, 200, 400} {
+		h.Increment(v)
+	}
+	pcs := []float64{0, 0.25, 0.5, 0.9, 1}
+	expectations := []struct {
+		interpolate bool
+		out         map[float64]uint64
+	}{
+		{false, map[float64]uint64{0: 100, 0.25: 100, 0.5: 200, 0.9: 400, 1: 400}},
+		{true, map[float64]uint64{0: 100, 0.25: 175, 0.5: 200, 0.9: 340, 1: 400}},
+	}
+	for _, e := range expectations {
+		res := Results{Latencies: h, InterpolatePercentiles: e.interpolate}
+		stats := res.LatenciesStats(pcs)
+		if !reflect.DeepEqual(stats.Percentiles, e.out) {
+			t.Errorf("Expected %v with interpolation %v, but got %v",
+				e.out, e.interpolate, stats.Percentiles)
+		}
+	}
+}
+
+func TestPercentilesAtExtremes(t *testing.T) {
+	for _, values := range [][]uint64{
+		{7},
+		{7, 9},
+		{9, 7, 8},
+		{7, 7, 8, 9, 9},
+	} {
+		h := uhist.Default()
+		fh := make(Float64Histogram)
+		min, max := values[0], values[0]
+		for _, v := range values {
+			h.Increment(v)
+			fh[float64(v)]++
+			if v < min {
+				min = v
+			}
+			if v > max {
+				max = v
+			}
+		}
+		for _, interpolate := range []bool{false, true} {
+			res := Results{
+				Latencies:              h,
+				Requests:               fh,
+				InterpolatePercentiles: interpolate,
+			}
+			lats := res.LatenciesStats([]float64{0, 1}).Percentiles
+			if lats[0] != min || lats[1] != max {
+				t.Errorf("Expected p0 = %v and p100 = %v for %v, but got %v",
+					min, max, values, lats)
+			}
+		}
+		reqs := Results{Requests: fh}.RequestsStats([]float64{0, 1})
+		if reqs.Percentiles[0] != float64(min) ||
+			reqs.Percentiles[1] != float64(max) {
+			t.Errorf("Expected p0 = %v and p100 = %v for %v, but got %v",
+				min, max, values, reqs.Percentiles)
+		}
+	}
+}
+
+func TestNearestRank(t *testing.T) {
+	expectations := []struct {
+		pc    float64
+		count uint64
+		out   uint64
+	}{
+		{0, 1, 1},
+		{1, 1, 1},
+		{0, 3, 1},
+		{0.1, 3, 1},
+		{0.5, 3, 2},
+		{0.99, 3, 3},
+		{1, 3, 3},
+		{0.5, 4, 2},
+		{0.999, 1000, 999},
+		{1, 1000, 1000},
+	}
+	for _, e := range expectations {
+		if got := nearestRank(e.pc, e.count); got != e.out {
+			t.Errorf("Expected rank of p%v among %v values to be %v, "+
+				"but got %v", e.pc, e.count, e.out, got)
+		}
+	}
+}
+
+func TestLatenciesStatsInterpolatedSingleValue(t *testing.T) {
+	h := uhist.Default()
+	h.Increment(42)
+	res := Results{Latencies: h, InterpolatePercentiles: true}
+	stats := res.LatenciesStats([]float64{0, 0.5, 0.99, 1})
+	for pc, v := range stats.Percentiles {
+		if v != 42 {
+			t.Errorf("Expected p%v to be 42, but got %v", pc, v)
+		}
+	}
+}
+
+func TestLatenciesStatsMAD(t *testing.T) {
+	h := uhist.Default()
+	for _, v := range []uint64{1, 1, 2, 2, 4, 6, 9} {
+		h.Increment(v)
+	}
+	stats := Results{Latencies: h}.LatenciesStats(nil)
+	if stats.MAD != 1 {
+		t.Errorf("Expected MAD to be 1, but got %v", stats.MAD)
+	}
+	// Unlike stddev, MAD barely notices a slow outlier
+	stddev := stats.Stddev
+	h.Increment(1000000)
+	stats = Results{Latencies: h}.LatenciesStats(nil)
+	if stats.MAD != 1 {
+		t.Errorf("Expected MAD to stay 1, but got %v", stats.MAD)
+	}
+	if stats.Stddev < 1000*stddev {
+		t.Errorf("Expected stddev to be blown up, but got %v",
+			stats.Stddev)
+	}
+}
+
+func TestTTFBStats(t *testing.T) {
+	if stats := (Results{}).TTFBStats(nil); stats != nil {
+		t.Errorf("Expected no stats without TTFB histogram, but got %+v",
+			stats)
+	}
+	h := uhist.Default()
+	for _, v := range []uint64{10, 20, 30} {
+		h.Increment(v)
+	}
+	stats := Results{TTFB: h}.TTFBStats([]float64{0.5})
+	if stats == nil {
+		t.Fatal("Expected stats to be calculated")
+	}
+	if stats.Mean != 20 || stats.Min != 10 || stats.Max != 30 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+	if p50 := stats.Percentiles[0.5]; p50 != 20 {
+		t.Errorf("Expected median to be 20, but got %v", p50)
+	}
+}
+
+func TestHandshakeStats(t *testing.T) {
+	if stats := (Results{}).HandshakeStats(nil); stats != nil {
+		t.Errorf("Expected no stats without handshakes histogram, "+
+			"but got %+v", stats)
+	}
+	if stats := (Results{Handshakes: uhist.Default()}).HandshakeStats(nil); stats != nil {
+		t.Errorf("Expected no stats without handshakes, but got %+v", stats)
+	}
+	h := uhist.Default()
+	for _, v := range []uint64{1000, 3000} {
+		h.Increment(v)
+	}
+	stats := Results{Handshakes: h}.HandshakeStats([]float64{1})
+	if stats == nil {
+		t.Fatal("Expected stats to be calculated")
+	}
+	if stats.Mean != 2000 || stats.Min != 1000 || stats.Max != 3000 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+	if p100 := stats.Percentiles[1]; p100 != 3000 {
+		t.Errorf("Expected maximum to be 3000, but got %v", p100)
+	}
+}
+
+func TestSuccessfulRequests(t *testing.T) {
+	expectations := []struct {
+		in          Results
+		total, succ uint64
+	}{
+		{Results{}, 0, 0},
+		{Results{Req2XX: 5, Req4XX: 1}, 6, 6},
+		{
+			Results{
+				Others: 3,
+				Errors: []ErrorWithCount{{Error: "refused", Count: 3}},
+			},
+			3, 0,
+		},
+		{
+			Results{
+				Req2XX: 4, Req5XX: 2, Others: 1,
+				Errors: []ErrorWithCount{
+					{Error: "unexpected status code 500", Count: 2},
+					{Error: "timeout", Count: 1},
+				},
+			},
+			7, 4,
+		},
+	}
+	for _, e := range expectations {
+		total, succ := e.in.NumberOfRequests(), e.in.SuccessfulRequests()
+		if total != e.total || succ != e.succ {
+			t.Errorf("%+v: expected %v requests, %v successful, "+
+				"but got %v and %v", e.in, e.total, e.succ, total, succ)
+		}
+	}
+}
+
+func TestRateShortfall(t *testing.T) {
+	rate := uint64(100)
+	expectations := []struct {
+		spec                Spec
+		res                 Results
+		requested, achieved float64
+		shortfall           float64
+	}{
+		{
+			Spec{},
+			Results{Req2XX: 100, TimeTaken: time.Second},
+			0, 100, 0,
+		},
+		{
+			Spec{Rate: &rate},
+			Results{Req2XX: 150, Others: 50, TimeTaken: 2 * time.Second},
+			100, 100, 0,
+		},
+		{
+			Spec{Rate: &rate},
+			Results{Req2XX: 150, TimeTaken: 2 * time.Second},
+			100, 75, 0.25,
+		},
+		{
+			Spec{Rate: &rate, RatePerConn: true, NumberOfConnections: 4},
+			Results{Req2XX: 300, TimeTaken: time.Second},
+			400, 300, 0.25,
+		},
+		{
+			Spec{Rate: &rate, LoadProfile: "ramp:from=1,to=100,over=1s"},
+			Results{Req2XX: 10, TimeTaken: time.Second},
+			0, 10, 0,
+		},
+		{
+			Spec{Rate: &rate},
+			Results{},
+			100, 0, 1,
+		},
+	}
+	for _, e := range expectations {
+		ti := TestInfo{Spec: e.spec, Result: e.res}
+		requested := ti.Spec.RequestedRate()
+		achieved := ti.Result.AchievedRate()
+		shortfall := ti.RateShortfall()
+		if requested != e.requested || achieved != e.achieved ||
+			math.Abs(shortfall-e.shortfall) > 1e-9 {
+			t.Errorf("Expected requested %v, achieved %v and shortfall %v, "+
+				"but got %v, %v and %v", e.requested, e.achieved,
+				e.shortfall, requested, achieved, shortfall)
+		}
+	}
+}
+
+func TestConnReusePercentage(t *testing.T) {
+	expectations := []struct {
+		newConns, reusedConns uint64
+		out                   float64
+	}{
+		{0, 0, 0},
+		{1, 0, 0},
+		{1, 3, 75},
+		{0, 5, 100},
+	}
+	for _, e := range expectations {
+		r := Results{NewConns: e.newConns, ReusedConns: e.reusedConns}
+		if act := r.ConnReusePercentage(); act != e.out {
+			t.Errorf("%+v: expected %v, but got %v", e, e.out, act)
+		}
+	}
+}
+
+func TestLatenciesStatsByStatusClass(t *testing.T) {
+	if stats := (Results{}).LatenciesStatsByStatusClass(nil); stats != nil {
+		t.Errorf("Expected no stats without breakdown, but got %+v", stats)
+	}
+	ok, notFound := uhist.Default(), uhist.Default()
+	for _, v := range []uint64{100, 200, 300} {
+		ok.Increment(v)
+	}
+	notFound.Increment(5)
+	stats := Results{
+		LatenciesByStatusClass: map[int]ReadonlyUint64Histogram{
+			0: uhist.Default(),
+			2: ok,
+			4: notFound,
+		},
+	}.LatenciesStatsByStatusClass([]float64{0.99})
+	if len(stats) != 2 || stats[2] == nil || stats[4] == nil {
+		t.Fatalf("Expected stats for 2xx and 4xx only, but got %+v", stats)
+	}
+	if p99 := stats[2].Percentiles[0.99]; p99 != 300 {
+		t.Errorf("Expected p99 of 2xx to be 300, but got %v", p99)
+	}
+	if p99 := stats[4].Percentiles[0.99]; p99 != 5 {
+		t.Errorf("Expected p99 of 4xx to be 5, but got %v", p99)
+	}
+}
+
+func TestResponseSizeStats(t *testing.T) {
+	if stats := (Results{}).ResponseSizeStats(nil); stats != nil {
+		t.Errorf("Expected no stats without response sizes, but got %+v",
+			stats)
+	}
+	h := uhist.Default()
+	for _, v := range []uint64{0, 1024, 2048} {
+		h.Increment(v)
+	}
+	stats := Results{ResponseSizes: h}.ResponseSizeStats([]float64{0.5})
+	if stats == nil {
+		t.Fatal("Expected stats to be calculated")
+	}
+	if stats.Mean != 1024 || stats.Min != 0 || stats.Max != 2048 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+	if p50 := stats.Percentiles[0.5]; p50 != 1024 {
+		t.Errorf("Expected median to be 1024, but got %v", p50)
+	}
+}
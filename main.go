@@ -0,0 +1,10 @@
+package main
+
+import "github.com/kostyay/bombardier/bombard"
+
+// version is set at build time, see build-all.bash
+var version = "unspecified"
+
+func main() {
+	bombard.Main(version)
+}
@@ -2,8 +2,9 @@
 Package template documents the way user-defined output templates are
 ment to be used.
 
-User-defined templates use Go's text/template package, so you might
-want to check its documentation first.
+User-defined templates are given with --format-template=<file> (or,
+equivalently, --format=path:<file>) and use Go's text/template
+package, so you might want to check its documentation first.
 There are a bunch of helper methods available inside a template
 besides those described in aforementioned documentation, namely:
 	- WithLatencies()
@@ -17,6 +18,9 @@ besides those described in aforementioned documentation, namely:
 	- FormatTimeUsUint64(us uint64) string
 		Same as above, but for uint64, since type conversions are
 		not available in templates.
+	- FormatDuration(d time.Duration) string
+		Same as above, but for durations, such as Spec.TestDuration
+		or Result.TimeTaken.
 	- FloatsToArray(ps ...float64) []float64
 		Converts a bunch of floats into array, since, again,
 		type conversions are not available in templates.
@@ -24,6 +28,21 @@ besides those described in aforementioned documentation, namely:
 		Arithmetics are not available inside of templates either.
 	- StringToBytes(s string) []byte
 		Convenience function to convert string to []byte.
+	- FormatPercentile(pc float64) string
+		Formats fraction as percents without trailing zeros,
+		i.e. 0.999 becomes "99.9".
+	- JoinInts(is []int) string
+		Joins integers into a comma-separated string.
+	- JoinStrings(ss []string) string
+		Joins strings into a comma-separated string.
+	- EscapeMarkdown(s string) string
+		Escapes pipes and line breaks, so that s fits into a single
+		cell of Markdown table.
+	- Uint64HistogramJSON(h ReadonlyUint64Histogram) string
+		Encodes histogram as JSON array of [key, count] pairs
+		sorted by key, the way it's saved in JSON output.
+	- Float64HistogramJSON(h ReadonlyFloat64Histogram) string
+		Same as above, but for histogram with float64 keys.
 	- UUIDV1() (UUID, error)
 		Generates UUID Version 1, based on timestamp and
 		MAC address (RFC 4122)